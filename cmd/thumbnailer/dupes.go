@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/giobyte8/thumbnailer/internal/config"
+	"github.com/giobyte8/thumbnailer/internal/services"
+)
+
+// Default max Hamming distance between perceptual hashes of duplicates
+const defaultDupesThreshold = 10
+
+// runDupes implements 'thumbnailer dupes' subcommand: it reports clusters
+// of near duplicate originals based on perceptual hashes stored in
+// manifests. Returns process exit code.
+func runDupes(args []string) int {
+	flags := flag.NewFlagSet("dupes", flag.ContinueOnError)
+	thumbsRoot := flags.String(
+		"root",
+		"",
+		"thumbnails root to scan (defaults to DIR_THUMBNAILS_ROOT)",
+	)
+	threshold := flags.Int(
+		"threshold",
+		defaultDupesThreshold,
+		"max Hamming distance (0-64) between hashes of duplicates",
+	)
+	asJson := flags.Bool("json", false, "print clusters as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *threshold < 0 || *threshold > 64 {
+		slog.Error("Threshold must be between 0 and 64", "threshold", *threshold)
+		return 2
+	}
+
+	if *thumbsRoot == "" {
+		*thumbsRoot = config.RootDirs().Thumbnails
+	}
+
+	clusters, err := services.FindDuplicates(
+		context.Background(),
+		*thumbsRoot,
+		*threshold,
+	)
+	if err != nil {
+		slog.Error("Failed to find duplicates", "error", err)
+		return 1
+	}
+
+	if *asJson {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(clusters); err != nil {
+			slog.Error("Failed to print duplicates", "error", err)
+			return 1
+		}
+		return 0
+	}
+
+	for idx, cluster := range clusters {
+		fmt.Printf("Cluster %d (%d files)\n", idx+1, len(cluster.Files))
+		for _, file := range cluster.Files {
+			fmt.Printf("  %s  %s\n", file.DHash, file.FilePath)
+		}
+	}
+	slog.Info("Duplicates scan completed", "clusters", len(clusters))
+
+	return 0
+}
@@ -2,13 +2,13 @@ package main
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
-	"strconv"
-	"strings"
 	"syscall"
 
+	"github.com/giobyte8/thumbnailer/internal/config"
 	"github.com/giobyte8/thumbnailer/internal/consumer"
 	"github.com/giobyte8/thumbnailer/internal/services"
 	"github.com/joho/godotenv"
@@ -17,7 +17,8 @@ import (
 	thumbsgen "github.com/giobyte8/thumbnailer/internal/thumbs_gen"
 )
 
-func setupLogging() {
+// setupLogging configures default logger to write into output
+func setupLogging(output io.Writer) {
 	var log_level slog.Level
 	switch os.Getenv("LOG_LEVEL") {
 	case "DEBUG", "debug":
@@ -44,7 +45,7 @@ func setupLogging() {
 		},
 	}
 
-	logger := slog.New(slog.NewTextHandler(os.Stdout, handlerOpts))
+	logger := slog.New(slog.NewTextHandler(output, handlerOpts))
 	slog.SetDefault(logger)
 }
 
@@ -62,60 +63,28 @@ func loadEnv() {
 }
 
 func prepareThumbsService(telemetry *telemetry.TelemetrySvc) *services.ThumbnailsService {
+	rootDirs := config.RootDirs()
 	thumbsConfig := services.ThumbnailsConfig{
-		DirOriginalsRoot:  os.Getenv("DIR_ORIGINALS_ROOT"),
-		DirThumbnailsRoot: os.Getenv("DIR_THUMBNAILS_ROOT"),
-	}
-
-	if thumbsConfig.DirOriginalsRoot == "" || thumbsConfig.DirThumbnailsRoot == "" {
-		slog.Error(
-			"Missing required environment variables for thumbnails service",
-			"DIR_ORIGINALS_ROOT", thumbsConfig.DirOriginalsRoot,
-			"DIR_THUMBNAILS_ROOT", thumbsConfig.DirThumbnailsRoot,
-		)
-		os.Exit(1)
-	}
-
-	widthsStr := os.Getenv("THUMBNAIL_WIDTHS_PX")
-
-	// Default widths if not provided
-	if widthsStr == "" {
-		slog.Warn(
-			"THUMBNAIL_WIDTHS_PX is not set. Using defaults.",
-			"default",
-			"256,512,1024",
-		)
-		widthsStr = "256,512,1024"
-	}
-
-	// Parse widths
-	widthStrs := strings.Split(widthsStr, ",")
-	for _, ws := range widthStrs {
-
-		// Trim spaces in case of "256, 512"
-		width, err := strconv.Atoi(strings.TrimSpace(ws))
-		if err != nil {
-			slog.Error(
-				"Invalid thumbnail width in THUMBNAIL_WIDTHS_PX",
-				"width",
-				ws,
-				"error",
-				err,
-			)
-			os.Exit(1)
-		}
-
-		if width <= 0 {
-			slog.Error(
-				"Thumbnail width must be a positive integer", "width", width,
-			)
-			os.Exit(1)
-		}
-
-		thumbsConfig.ThumbnailWidths = append(
-			thumbsConfig.ThumbnailWidths,
-			width,
-		)
+		DirOriginalsRoot:  rootDirs.Originals,
+		DirThumbnailsRoot: rootDirs.Thumbnails,
+		UpscalePolicy:     config.UpscalePolicy(),
+		InputLimits:       config.InputLimits(),
+		AnimationLimits:   config.AnimationLimits(),
+		VideoPreview:      config.VideoPreview(),
+		LivePhotoVideo:    config.LivePhotoVideo(),
+		FrameSamples:      config.FrameSamples(),
+		ToneMap:           config.ToneMap(),
+		Storyboard:        config.Storyboard(),
+		Document:          config.Document(),
+		Archive:           config.Archive(),
+		Placeholders:      config.Placeholders(),
+		PaletteSize:       config.PaletteSize(),
+		PerceptualHash:    config.PerceptualHash(),
+		KeepMetadata:      config.KeepMetadata(),
+		ColorProfile:      config.ColorProfile(),
+		SymlinkPolicy:     rootDirs.SymlinkPolicy,
+		Presets:           config.Presets(),
+		DefaultPresets:    config.DefaultPresets(),
 	}
 
 	thumbsGenerator := thumbsgen.NewRoutedThumbsGenerator(telemetry)
@@ -124,7 +93,15 @@ func prepareThumbsService(telemetry *telemetry.TelemetrySvc) *services.Thumbnail
 
 func main() {
 	loadEnv()
-	setupLogging()
+
+	// Subcommands run to completion instead of starting the service. Their
+	// logs go to stderr, keeping stdout for results.
+	if len(os.Args) > 1 && os.Args[1] == "dupes" {
+		setupLogging(os.Stderr)
+		os.Exit(runDupes(os.Args[2:]))
+	}
+
+	setupLogging(os.Stdout)
 
 	slog.Info("Starting Thumbnailer service...")
 	ctx, cancel := context.WithCancel(context.Background())
@@ -124,5 +124,35 @@ else
   exit 1
 fi
 
+echo "Verifying djxl availability..."
+if docker exec "$APP_CONTAINER" djxl --version >/dev/null 2>&1; then
+  echo "  djxl is available ✅"
+else
+  echo "  djxl is NOT available ❌"
+  echo "--- app container logs ---"
+  docker logs "$APP_CONTAINER" || true
+  exit 1
+fi
+
+echo "Verifying dcraw_emu availability..."
+if docker exec "$APP_CONTAINER" sh -c 'command -v dcraw_emu' >/dev/null 2>&1; then
+  echo "  dcraw_emu is available ✅"
+else
+  echo "  dcraw_emu is NOT available ❌"
+  echo "--- app container logs ---"
+  docker logs "$APP_CONTAINER" || true
+  exit 1
+fi
+
+echo "Verifying pdftoppm availability..."
+if docker exec "$APP_CONTAINER" pdftoppm -v >/dev/null 2>&1; then
+  echo "  pdftoppm is available ✅"
+else
+  echo "  pdftoppm is NOT available ❌"
+  echo "--- app container logs ---"
+  docker logs "$APP_CONTAINER" || true
+  exit 1
+fi
+
 echo
 echo "Image verification passed for ${IMAGE_REF}"
@@ -6,11 +6,16 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
 )
 
 type AppConfig struct {
@@ -18,7 +23,31 @@ type AppConfig struct {
 	Amqp            AmqpConfig
 	RootDirs        RootDirsConfig
 	ThumbnailWidths []int
+	UpscalePolicy   models.UpscalePolicy
+	ResizeSpec      models.ResizeSpec
+	InputLimits     models.InputLimits
+	AnimationLimits models.AnimationLimits
+	VideoPreview    models.VideoPreviewOptions
+	LivePhotoVideo  models.LivePhotoVideoMode
+	FrameSamples    []int
+	ToneMap         models.ToneMapOperator
+	Storyboard      models.StoryboardOptions
+	Document        models.DocumentOptions
+	Archive         models.ArchiveOptions
+	Placeholders    []models.PlaceholderKind
+	PaletteSize     int
+	PerceptualHash  bool
+	KeepMetadata    []models.MetadataField
+	ColorProfile    models.ColorProfilePolicy
 	Otel            OtelConfig
+
+	// Named thumbnail presets. Holds a single 'default' preset built from
+	// THUMBNAIL_WIDTHS_PX and THUMBNAIL_RESIZE_MODE when no presets are
+	// configured.
+	Presets []models.ThumbPreset
+
+	// Names of presets applied to requests that don't select any
+	DefaultPresets []string
 }
 
 type AmqpConfig struct {
@@ -31,6 +60,10 @@ type AmqpConfig struct {
 	ExchangeName       string
 	ThumbsGenQueueName string
 	ThumbsDelQueueName string
+
+	// Optional queue where thumbnail generation results are published.
+	// Results are not published when empty.
+	ThumbsGenResultsQueueName string
 }
 
 func (c AmqpConfig) Uri() string {
@@ -50,6 +83,9 @@ func (c AmqpConfig) Uri() string {
 type RootDirsConfig struct {
 	Originals  string
 	Thumbnails string
+
+	// How symlinks resolving outside of root dirs are handled
+	SymlinkPolicy models.SymlinkPolicy
 }
 
 type OtelConfig struct {
@@ -81,6 +117,78 @@ func ThumbWidthsPx() []int {
 	return AppCfg().ThumbnailWidths
 }
 
+func UpscalePolicy() models.UpscalePolicy {
+	return AppCfg().UpscalePolicy
+}
+
+func ResizeSpec() models.ResizeSpec {
+	return AppCfg().ResizeSpec
+}
+
+func InputLimits() models.InputLimits {
+	return AppCfg().InputLimits
+}
+
+func AnimationLimits() models.AnimationLimits {
+	return AppCfg().AnimationLimits
+}
+
+func VideoPreview() models.VideoPreviewOptions {
+	return AppCfg().VideoPreview
+}
+
+func LivePhotoVideo() models.LivePhotoVideoMode {
+	return AppCfg().LivePhotoVideo
+}
+
+func FrameSamples() []int {
+	return AppCfg().FrameSamples
+}
+
+func ToneMap() models.ToneMapOperator {
+	return AppCfg().ToneMap
+}
+
+func Storyboard() models.StoryboardOptions {
+	return AppCfg().Storyboard
+}
+
+func Document() models.DocumentOptions {
+	return AppCfg().Document
+}
+
+func Archive() models.ArchiveOptions {
+	return AppCfg().Archive
+}
+
+func Placeholders() []models.PlaceholderKind {
+	return AppCfg().Placeholders
+}
+
+func PaletteSize() int {
+	return AppCfg().PaletteSize
+}
+
+func PerceptualHash() bool {
+	return AppCfg().PerceptualHash
+}
+
+func KeepMetadata() []models.MetadataField {
+	return AppCfg().KeepMetadata
+}
+
+func ColorProfile() models.ColorProfilePolicy {
+	return AppCfg().ColorProfile
+}
+
+func Presets() []models.ThumbPreset {
+	return AppCfg().Presets
+}
+
+func DefaultPresets() []string {
+	return AppCfg().DefaultPresets
+}
+
 func Otel() OtelConfig {
 	return AppCfg().Otel
 }
@@ -112,7 +220,143 @@ func newAppConfig() (*AppConfig, error) {
 		return nil, err
 	}
 
-	thumbnailWidths, err := parseThumbnailWidths(os.Getenv("THUMBNAIL_WIDTHS_PX"))
+	rawWidths := os.Getenv("THUMBNAIL_WIDTHS_PX")
+	if rawWidths == "" {
+		slog.Warn(
+			"THUMBNAIL_WIDTHS_PX is not set. Using defaults.",
+			"default",
+			defaultThumbnailWidths,
+		)
+		rawWidths = defaultThumbnailWidths
+	}
+
+	thumbnailWidths, err := parseThumbnailWidths(rawWidths)
+	if err != nil {
+		return nil, err
+	}
+
+	upscalePolicy, err := models.ParseUpscalePolicy(
+		os.Getenv("THUMBNAIL_UPSCALE_POLICY"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid THUMBNAIL_UPSCALE_POLICY: %w", err)
+	}
+
+	resizeSpec, err := models.ParseResizeSpec(os.Getenv("THUMBNAIL_RESIZE_MODE"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid THUMBNAIL_RESIZE_MODE: %w", err)
+	}
+
+	inputLimits, err := newInputLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	animationLimits, err := newAnimationLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	videoPreview, err := newVideoPreviewOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	livePhotoVideo, err := models.ParseLivePhotoVideoMode(
+		os.Getenv("THUMBNAIL_LIVE_PHOTO_VIDEO"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid THUMBNAIL_LIVE_PHOTO_VIDEO: %w", err)
+	}
+
+	// Live Photo previews take their settings from video previews, even
+	// when these are disabled for other videos
+	if livePhotoVideo == models.LivePhotoVideoPreview &&
+		(videoPreview.Duration == 0 || videoPreview.FPS == 0 || videoPreview.Width == 0) {
+		return nil, fmt.Errorf(
+			"THUMBNAIL_VIDEO_PREVIEW_* values must be positive for live photo previews",
+		)
+	}
+
+	frameSamples, err := models.ParseFrameSamplePercents(
+		os.Getenv("THUMBNAIL_VIDEO_FRAME_SAMPLES"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid THUMBNAIL_VIDEO_FRAME_SAMPLES: %w", err)
+	}
+
+	toneMap, err := models.ParseToneMapOperator(
+		os.Getenv("THUMBNAIL_HDR_TONE_MAP"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid THUMBNAIL_HDR_TONE_MAP: %w", err)
+	}
+
+	storyboard, err := newStoryboardOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := newDocumentOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := newArchiveOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	placeholders, err := models.ParsePlaceholderKinds(
+		os.Getenv("THUMBNAIL_PLACEHOLDERS"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid THUMBNAIL_PLACEHOLDERS: %w", err)
+	}
+
+	paletteSize, err := parseLimit(
+		"THUMBNAIL_PALETTE_SIZE",
+		defaultPaletteSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if paletteSize > maxPaletteSize {
+		return nil, fmt.Errorf(
+			"THUMBNAIL_PALETTE_SIZE must not exceed %d: %d",
+			maxPaletteSize,
+			paletteSize,
+		)
+	}
+
+	perceptualHash := strings.EqualFold(
+		os.Getenv("THUMBNAIL_PERCEPTUAL_HASH"),
+		"true",
+	)
+
+	keepMetadata, err := models.ParseMetadataKeepList(
+		os.Getenv("THUMBNAIL_KEEP_METADATA"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid THUMBNAIL_KEEP_METADATA: %w", err)
+	}
+
+	colorProfile, err := models.ParseColorProfilePolicy(
+		os.Getenv("THUMBNAIL_COLOR_PROFILE"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid THUMBNAIL_COLOR_PROFILE: %w", err)
+	}
+
+	presets, err := newPresets(thumbnailWidths, resizeSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultPresets, err := parseDefaultPresets(
+		os.Getenv("THUMBNAIL_DEFAULT_PRESETS"),
+		presets,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +366,25 @@ func newAppConfig() (*AppConfig, error) {
 		Amqp:            newAmqpConfig(),
 		RootDirs:        rootDirsCfg,
 		ThumbnailWidths: thumbnailWidths,
+		UpscalePolicy:   upscalePolicy,
+		ResizeSpec:      resizeSpec,
+		InputLimits:     inputLimits,
+		AnimationLimits: animationLimits,
+		VideoPreview:    videoPreview,
+		LivePhotoVideo:  livePhotoVideo,
+		FrameSamples:    frameSamples,
+		ToneMap:         toneMap,
+		Storyboard:      storyboard,
+		Document:        document,
+		Archive:         archive,
+		Placeholders:    placeholders,
+		PaletteSize:     int(paletteSize),
+		PerceptualHash:  perceptualHash,
+		KeepMetadata:    keepMetadata,
+		ColorProfile:    colorProfile,
 		Otel:            newOtelConfig(),
+		Presets:         presets,
+		DefaultPresets:  defaultPresets,
 	}, nil
 }
 
@@ -137,6 +399,8 @@ func newAmqpConfig() AmqpConfig {
 		ExchangeName:       os.Getenv("AMQP_EXCHANGE"),
 		ThumbsGenQueueName: os.Getenv("AMQP_QUEUE_THUMB_GEN_REQUESTS"),
 		ThumbsDelQueueName: os.Getenv("AMQP_QUEUE_THUMB_DEL_REQUESTS"),
+
+		ThumbsGenResultsQueueName: os.Getenv("AMQP_QUEUE_THUMB_GEN_RESULTS"),
 	}
 }
 
@@ -154,9 +418,391 @@ func newRootDirsConfig() (RootDirsConfig, error) {
 		)
 	}
 
+	symlinkPolicy, err := models.ParseSymlinkPolicy(
+		os.Getenv("DIR_SYMLINK_POLICY"),
+	)
+	if err != nil {
+		return RootDirsConfig{}, fmt.Errorf(
+			"invalid DIR_SYMLINK_POLICY: %w",
+			err,
+		)
+	}
+	rootDirsCfg.SymlinkPolicy = symlinkPolicy
+
 	return rootDirsCfg, nil
 }
 
+// newInputLimits loads limits for originals accepted for generation.
+// Zero disables a limit.
+func newInputLimits() (models.InputLimits, error) {
+	maxFileBytes, err := parseLimit(
+		"THUMBNAIL_MAX_INPUT_BYTES",
+		defaultMaxInputBytes,
+	)
+	if err != nil {
+		return models.InputLimits{}, err
+	}
+
+	maxPixels, err := parseLimit(
+		"THUMBNAIL_MAX_INPUT_PIXELS",
+		defaultMaxInputPixels,
+	)
+	if err != nil {
+		return models.InputLimits{}, err
+	}
+
+	maxDimension, err := parseLimit(
+		"THUMBNAIL_MAX_INPUT_DIMENSION",
+		defaultMaxInputDimension,
+	)
+	if err != nil {
+		return models.InputLimits{}, err
+	}
+
+	return models.InputLimits{
+		MaxFileBytes: maxFileBytes,
+		MaxPixels:    maxPixels,
+		MaxDimension: int(maxDimension),
+	}, nil
+}
+
+func newAnimationLimits() (models.AnimationLimits, error) {
+	maxFrames, err := parseLimit(
+		"THUMBNAIL_ANIMATED_MAX_FRAMES",
+		defaultAnimatedMaxFrames,
+	)
+	if err != nil {
+		return models.AnimationLimits{}, err
+	}
+
+	maxDurationMs, err := parseLimit(
+		"THUMBNAIL_ANIMATED_MAX_DURATION_MS",
+		defaultAnimatedMaxDurationMs,
+	)
+	if err != nil {
+		return models.AnimationLimits{}, err
+	}
+
+	maxBytes, err := parseLimit(
+		"THUMBNAIL_ANIMATED_MAX_BYTES",
+		defaultAnimatedMaxBytes,
+	)
+	if err != nil {
+		return models.AnimationLimits{}, err
+	}
+
+	return models.AnimationLimits{
+		MaxFrames:   int(maxFrames),
+		MaxDuration: time.Duration(maxDurationMs) * time.Millisecond,
+		MaxBytes:    maxBytes,
+	}, nil
+}
+
+func newVideoPreviewOptions() (models.VideoPreviewOptions, error) {
+	previewFormat, err := models.ParseVideoPreviewFormat(
+		os.Getenv("THUMBNAIL_VIDEO_PREVIEW"),
+	)
+	if err != nil {
+		return models.VideoPreviewOptions{}, fmt.Errorf(
+			"invalid THUMBNAIL_VIDEO_PREVIEW: %w",
+			err,
+		)
+	}
+
+	durationMs, err := parseLimit(
+		"THUMBNAIL_VIDEO_PREVIEW_DURATION_MS",
+		defaultVideoPreviewDurationMs,
+	)
+	if err != nil {
+		return models.VideoPreviewOptions{}, err
+	}
+
+	fps, err := parseLimit(
+		"THUMBNAIL_VIDEO_PREVIEW_FPS",
+		defaultVideoPreviewFPS,
+	)
+	if err != nil {
+		return models.VideoPreviewOptions{}, err
+	}
+
+	width, err := parseLimit(
+		"THUMBNAIL_VIDEO_PREVIEW_WIDTH",
+		defaultVideoPreviewWidth,
+	)
+	if err != nil {
+		return models.VideoPreviewOptions{}, err
+	}
+
+	segments, err := parseLimit(
+		"THUMBNAIL_VIDEO_PREVIEW_SEGMENTS",
+		defaultVideoPreviewSegments,
+	)
+	if err != nil {
+		return models.VideoPreviewOptions{}, err
+	}
+
+	if previewFormat != models.VideoPreviewNone &&
+		(durationMs == 0 || fps == 0 || width == 0 || segments == 0) {
+		return models.VideoPreviewOptions{}, fmt.Errorf(
+			"THUMBNAIL_VIDEO_PREVIEW_* values must be positive for %s previews",
+			previewFormat,
+		)
+	}
+
+	return models.VideoPreviewOptions{
+		Format:   previewFormat,
+		Duration: time.Duration(durationMs) * time.Millisecond,
+		FPS:      int(fps),
+		Width:    int(width),
+		Segments: int(segments),
+	}, nil
+}
+
+func newStoryboardOptions() (models.StoryboardOptions, error) {
+	frames, err := parseLimit("THUMBNAIL_STORYBOARD_FRAMES", 0)
+	if err != nil {
+		return models.StoryboardOptions{}, err
+	}
+
+	tileWidth, err := parseLimit(
+		"THUMBNAIL_STORYBOARD_TILE_WIDTH",
+		defaultStoryboardTileWidth,
+	)
+	if err != nil {
+		return models.StoryboardOptions{}, err
+	}
+
+	columns, err := parseLimit(
+		"THUMBNAIL_STORYBOARD_COLUMNS",
+		defaultStoryboardColumns,
+	)
+	if err != nil {
+		return models.StoryboardOptions{}, err
+	}
+
+	rows, err := parseLimit(
+		"THUMBNAIL_STORYBOARD_ROWS",
+		defaultStoryboardRows,
+	)
+	if err != nil {
+		return models.StoryboardOptions{}, err
+	}
+
+	if frames > 0 && (tileWidth == 0 || columns == 0 || rows == 0) {
+		return models.StoryboardOptions{}, fmt.Errorf(
+			"THUMBNAIL_STORYBOARD_* values must be positive for %d frames storyboards",
+			frames,
+		)
+	}
+
+	return models.StoryboardOptions{
+		Frames:    int(frames),
+		TileWidth: int(tileWidth),
+		Columns:   int(columns),
+		Rows:      int(rows),
+	}, nil
+}
+
+func newDocumentOptions() (models.DocumentOptions, error) {
+	dpi, err := parseLimit("THUMBNAIL_DOCUMENT_DPI", defaultDocumentDPI)
+	if err != nil {
+		return models.DocumentOptions{}, err
+	}
+	if dpi == 0 || dpi > maxDocumentDPI {
+		return models.DocumentOptions{}, fmt.Errorf(
+			"THUMBNAIL_DOCUMENT_DPI must be between 1 and %d: %d",
+			maxDocumentDPI,
+			dpi,
+		)
+	}
+
+	timeoutMs, err := parseLimit(
+		"THUMBNAIL_DOCUMENT_TIMEOUT_MS",
+		defaultDocumentTimeoutMs,
+	)
+	if err != nil {
+		return models.DocumentOptions{}, err
+	}
+
+	maxPages, err := parseLimit(
+		"THUMBNAIL_DOCUMENT_MAX_PAGES",
+		defaultDocumentMaxPages,
+	)
+	if err != nil {
+		return models.DocumentOptions{}, err
+	}
+
+	return models.DocumentOptions{
+		DPI:      int(dpi),
+		Timeout:  time.Duration(timeoutMs) * time.Millisecond,
+		MaxPages: int(maxPages),
+	}, nil
+}
+
+func newArchiveOptions() (models.ArchiveOptions, error) {
+	maxEntryBytes, err := parseLimit(
+		"THUMBNAIL_ARCHIVE_MAX_ENTRY_BYTES",
+		defaultArchiveMaxEntryBytes,
+	)
+	if err != nil {
+		return models.ArchiveOptions{}, err
+	}
+
+	maxCompressionRatio, err := parseLimit(
+		"THUMBNAIL_ARCHIVE_MAX_COMPRESSION_RATIO",
+		defaultArchiveMaxCompressionRatio,
+	)
+	if err != nil {
+		return models.ArchiveOptions{}, err
+	}
+
+	return models.ArchiveOptions{
+		MaxEntryBytes:       maxEntryBytes,
+		MaxCompressionRatio: maxCompressionRatio,
+	}, nil
+}
+
+// newPresets loads presets listed in THUMBNAIL_PRESETS. Each preset is
+// configured through THUMBNAIL_PRESET_<NAME>_* variables, where NAME is
+// the upper cased preset name with dashes replaced by underscores.
+func newPresets(
+	defaultWidths []int,
+	defaultResize models.ResizeSpec,
+) ([]models.ThumbPreset, error) {
+	presetNames := splitList(os.Getenv("THUMBNAIL_PRESETS"))
+	if len(presetNames) == 0 {
+		return []models.ThumbPreset{{
+			Name:    DefaultPresetName,
+			Widths:  defaultWidths,
+			Resize:  defaultResize,
+			Formats: []string{".webp"},
+			Quality: defaultPresetQuality,
+		}}, nil
+	}
+
+	presets := make([]models.ThumbPreset, 0, len(presetNames))
+	for _, presetName := range presetNames {
+		preset, err := newPreset(presetName, defaultWidths, defaultResize)
+		if err != nil {
+			return nil, err
+		}
+
+		presets = append(presets, preset)
+	}
+
+	if err := validatePresets(presets); err != nil {
+		return nil, err
+	}
+
+	return presets, nil
+}
+
+func newPreset(
+	name string,
+	defaultWidths []int,
+	defaultResize models.ResizeSpec,
+) (models.ThumbPreset, error) {
+	if !presetNamePattern.MatchString(name) {
+		return models.ThumbPreset{}, fmt.Errorf(
+			"invalid preset name %q: only lowercase letters, digits and "+
+				"dashes are allowed",
+			name,
+		)
+	}
+
+	envPrefix := presetEnvPrefix(name)
+	preset := models.ThumbPreset{
+		Name:    name,
+		Widths:  defaultWidths,
+		Resize:  defaultResize,
+		Formats: []string{".webp"},
+		Quality: defaultPresetQuality,
+		Suffix:  name,
+	}
+
+	if value := os.Getenv(envPrefix + "WIDTHS"); value != "" {
+		widths, err := parseThumbnailWidths(value)
+		if err != nil {
+			return models.ThumbPreset{}, fmt.Errorf(
+				"invalid %sWIDTHS: %w", envPrefix, err,
+			)
+		}
+		preset.Widths = widths
+	}
+
+	if value := os.Getenv(envPrefix + "RESIZE_MODE"); value != "" {
+		resizeSpec, err := models.ParseResizeSpec(value)
+		if err != nil {
+			return models.ThumbPreset{}, fmt.Errorf(
+				"invalid %sRESIZE_MODE: %w", envPrefix, err,
+			)
+		}
+		preset.Resize = resizeSpec
+	}
+
+	if value := os.Getenv(envPrefix + "FORMATS"); value != "" {
+		formats, err := models.ParseThumbFormats(value)
+		if err != nil {
+			return models.ThumbPreset{}, fmt.Errorf(
+				"invalid %sFORMATS: %w", envPrefix, err,
+			)
+		}
+		preset.Formats = formats
+	}
+
+	if value := os.Getenv(envPrefix + "QUALITY"); value != "" {
+		quality, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || quality < 1 || quality > 100 {
+			return models.ThumbPreset{}, fmt.Errorf(
+				"invalid %sQUALITY %q: must be an integer from 1 to 100",
+				envPrefix,
+				value,
+			)
+		}
+		preset.Quality = quality
+	}
+
+	if value, found := os.LookupEnv(envPrefix + "SUFFIX"); found {
+		suffix := strings.TrimSpace(value)
+		if suffix != "" && !presetNamePattern.MatchString(suffix) {
+			return models.ThumbPreset{}, fmt.Errorf(
+				"invalid %sSUFFIX %q: only lowercase letters, digits and "+
+					"dashes are allowed",
+				envPrefix,
+				value,
+			)
+		}
+		preset.Suffix = suffix
+	}
+
+	return preset, nil
+}
+
+// validatePresets makes sure presets won't overwrite each other files
+func validatePresets(presets []models.ThumbPreset) error {
+	names := make(map[string]bool, len(presets))
+	suffixes := make(map[string]string, len(presets))
+
+	for _, preset := range presets {
+		if names[preset.Name] {
+			return fmt.Errorf("duplicated preset name: %q", preset.Name)
+		}
+		names[preset.Name] = true
+
+		if otherPreset, found := suffixes[preset.Suffix]; found {
+			return fmt.Errorf(
+				"presets %q and %q share file name suffix %q",
+				otherPreset,
+				preset.Name,
+				preset.Suffix,
+			)
+		}
+		suffixes[preset.Suffix] = preset.Name
+	}
+
+	return nil
+}
+
 func newOtelConfig() OtelConfig {
 	return OtelConfig{
 		Enabled:               strings.EqualFold(os.Getenv("OTEL_ENABLED"), "true"),
@@ -166,6 +812,70 @@ func newOtelConfig() OtelConfig {
 
 // --- Parsers --- --- --- --- --- --- --- --- --- --- --- --- --- --- --- ---
 
+// Name of the preset used when THUMBNAIL_PRESETS is not set
+const DefaultPresetName = "default"
+
+const defaultPresetQuality = 80
+
+// Widths generated when THUMBNAIL_WIDTHS_PX is not set
+const defaultThumbnailWidths = "256,512,1024"
+
+// Colors extracted per image by default, and max accepted
+const (
+	defaultPaletteSize = 5
+	maxPaletteSize     = 16
+)
+
+// Default input limits: 256 MiB, 100 megapixels and 32768px per side
+const (
+	defaultMaxInputBytes     = 256 * 1024 * 1024
+	defaultMaxInputPixels    = 100_000_000
+	defaultMaxInputDimension = 32768
+)
+
+// Default video preview clip: 3 seconds at 10 fps, 320px wide, sampled
+// from 3 points of the video
+const (
+	defaultVideoPreviewDurationMs = 3000
+	defaultVideoPreviewFPS        = 10
+	defaultVideoPreviewWidth      = 320
+	defaultVideoPreviewSegments   = 3
+)
+
+// Default storyboard: 160px wide frames in sheets of 10x10 frames.
+// Storyboards are disabled unless a frames count is configured.
+const (
+	defaultStoryboardTileWidth = 160
+	defaultStoryboardColumns   = 10
+	defaultStoryboardRows      = 10
+)
+
+// Documents are rendered at 150 DPI by default, enough for the largest
+// usual thumbnail widths of letter and A4 pages. Rendering time and
+// memory grow with the square of DPI, hence the upper bound.
+const (
+	defaultDocumentDPI       = 150
+	maxDocumentDPI           = 600
+	defaultDocumentTimeoutMs = 30_000
+	defaultDocumentMaxPages  = 1000
+)
+
+// Archive entries are read in memory: 64 MiB covers high resolution
+// comic pages, and images hardly compress beyond 100:1, unlike zip bombs
+const (
+	defaultArchiveMaxEntryBytes       = 64 * 1024 * 1024
+	defaultArchiveMaxCompressionRatio = 100
+)
+
+// Default animation limits: 150 frames, 10 seconds and 4 MiB
+const (
+	defaultAnimatedMaxFrames     = 150
+	defaultAnimatedMaxDurationMs = 10_000
+	defaultAnimatedMaxBytes      = 4 * 1024 * 1024
+)
+
+var presetNamePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
 func loadDotEnv() error {
 	if _, err := os.Stat(".env"); err != nil {
 		if os.IsNotExist(err) {
@@ -226,3 +936,68 @@ func parseThumbnailWidths(value string) ([]int, error) {
 
 	return widths, nil
 }
+
+// parseDefaultPresets parses names of presets applied when requests don't
+// select any. Defaults to all presets.
+func parseDefaultPresets(
+	value string,
+	presets []models.ThumbPreset,
+) ([]string, error) {
+	presetNames := make([]string, 0, len(presets))
+	for _, preset := range presets {
+		presetNames = append(presetNames, preset.Name)
+	}
+
+	defaultPresets := splitList(value)
+	if len(defaultPresets) == 0 {
+		return presetNames, nil
+	}
+
+	for _, name := range defaultPresets {
+		if !slices.Contains(presetNames, name) {
+			return nil, fmt.Errorf(
+				"unknown preset in THUMBNAIL_DEFAULT_PRESETS: %q",
+				name,
+			)
+		}
+	}
+
+	return defaultPresets, nil
+}
+
+func presetEnvPrefix(presetName string) string {
+	envName := strings.ReplaceAll(strings.ToUpper(presetName), "-", "_")
+	return "THUMBNAIL_PRESET_" + envName + "_"
+}
+
+// splitList splits a comma separated list dropping blank items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// parseLimit parses a non negative integer limit from env variable with
+// given name, returning defaultValue when variable is not set.
+func parseLimit(envName string, defaultValue int64) (int64, error) {
+	value := strings.TrimSpace(os.Getenv(envName))
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", envName, value, err)
+	}
+
+	if limit < 0 {
+		return 0, fmt.Errorf("%s must not be negative: %d", envName, limit)
+	}
+
+	return limit, nil
+}
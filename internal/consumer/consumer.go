@@ -163,11 +163,17 @@ func (consumer *AMQPConsumer) consume(ctx context.Context) {
 		if err := thGenConsumer.Start(ctx, func(message amqp.Delivery) error {
 			var thumbRequest models.ThumbRequest
 			err := json.Unmarshal(message.Body, &thumbRequest)
+			if err != nil {
+				return models.NewPermanentError(err)
+			}
+
+			result, err := consumer.thumbnailSvc.ProcessGenRequest(ctx, thumbRequest)
 			if err != nil {
 				return err
 			}
 
-			return consumer.thumbnailSvc.ProcessGenRequest(ctx, thumbRequest)
+			consumer.publishGenResult(ctx, result)
+			return nil
 		}); err != nil {
 			slog.Error("AMQP: thumbGen consumer failed", "error", err)
 		}
@@ -179,7 +185,7 @@ func (consumer *AMQPConsumer) consume(ctx context.Context) {
 			var thumbRequest models.ThumbRequest
 			err := json.Unmarshal(message.Body, &thumbRequest)
 			if err != nil {
-				return err
+				return models.NewPermanentError(err)
 			}
 
 			return consumer.thumbnailSvc.ProcessDelRequest(ctx, thumbRequest)
@@ -189,6 +195,45 @@ func (consumer *AMQPConsumer) consume(ctx context.Context) {
 	}()
 }
 
+// Publishes thumbnail generation result into results queue (if any).
+// Publish failures are logged but don't fail the processed request
+// since thumbnails were already generated.
+func (consumer *AMQPConsumer) publishGenResult(
+	ctx context.Context,
+	result *models.ThumbGenResult,
+) {
+	resultsQueueName := config.Amqp().ThumbsGenResultsQueueName
+	if resultsQueueName == "" || result == nil {
+		return
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		slog.Error("AMQP: Failed to serialize generation result", "error", err)
+		return
+	}
+
+	err = consumer.channel.PublishWithContext(
+		ctx,
+		config.Amqp().ExchangeName,
+		resultsQueueName, // Routing key
+		false,            // Mandatory
+		false,            // Immediate
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Body:         body,
+		},
+	)
+	if err != nil {
+		slog.Error(
+			"AMQP: Failed to publish generation result",
+			"filePath", result.FilePath,
+			"error", err,
+		)
+	}
+}
+
 func (consumer *AMQPConsumer) connectAndSetup() error {
 	var err error
 
@@ -235,6 +280,9 @@ func (consumer *AMQPConsumer) ensureBrokerResources(cfg config.AmqpConfig) error
 		cfg.ThumbsGenQueueName,
 		cfg.ThumbsDelQueueName,
 	}
+	if cfg.ThumbsGenResultsQueueName != "" {
+		queueNames = append(queueNames, cfg.ThumbsGenResultsQueueName)
+	}
 
 	// Declare and bind each queue, exiting on error
 	for _, queueName := range queueNames {
@@ -6,6 +6,8 @@ import (
 	"log/slog"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
 )
 
 type QueueConsumer struct {
@@ -66,14 +68,18 @@ func (c *QueueConsumer) Start(
 			// Invoke callback for message
 			err := onMessage(msg)
 			if err != nil {
+				requeue := shouldRequeue(err, msg.Redelivered)
 				slog.Error(
 					"AMQP: Error processing message",
 					"consumer", consumer_name,
+					"category", models.ErrorCategoryOf(err),
+					"requeue", requeue,
 					"error", err,
 				)
 
-				// Nacknowledge the message
-				if nackErr := msg.Nack(false, false); nackErr != nil {
+				// Nacknowledge the message, messages not requeued go to
+				// the dead letter exchange of the queue (if any)
+				if nackErr := msg.Nack(false, requeue); nackErr != nil {
 					slog.Error(
 						"AMQP: Failed to nack message",
 						"consumer", consumer_name,
@@ -94,3 +100,10 @@ func (c *QueueConsumer) Start(
 		}
 	}
 }
+
+// shouldRequeue reports whether a message that failed with err is worth
+// delivering again. Transient errors are retried once, permanent and
+// rejected path errors would fail again.
+func shouldRequeue(err error, redelivered bool) bool {
+	return models.ErrorCategoryOf(err) == models.ErrCategoryTransient && !redelivered
+}
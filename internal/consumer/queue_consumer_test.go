@@ -0,0 +1,34 @@
+package consumer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+func TestShouldRequeue(t *testing.T) {
+	transientErr := errors.New("ffmpeg exited with status 1")
+	permanentErr := models.NewPermanentError(errors.New("input too large"))
+	rejectedErr := models.NewRejectedPathError(errors.New("outside root"))
+
+	tests := []struct {
+		name        string
+		err         error
+		redelivered bool
+		want        bool
+	}{
+		{name: "transient", err: transientErr, want: true},
+		{name: "transient redelivered", err: transientErr, redelivered: true, want: false},
+		{name: "permanent", err: permanentErr, want: false},
+		{name: "rejected path", err: rejectedErr, want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := shouldRequeue(tc.err, tc.redelivered); got != tc.want {
+				t.Errorf("shouldRequeue() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package format
+
+import (
+	"archive/zip"
+	"path"
+	"strings"
+)
+
+// Media type stored in the 'mimetype' entry of EPUB files
+const epubMimeType = "application/epub+zip"
+
+// Extensions of archive entries thumbnailed without conversion
+var archiveImageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".webp": true,
+	".gif":  true,
+	".bmp":  true,
+	".avif": true,
+}
+
+// Metadata comic book archivers store next to pages
+var comicMetadataEntries = map[string]bool{
+	"comicinfo.xml": true,
+}
+
+// IsArchiveImage checks whether the archive entry with given name is an
+// image, by its extension. Directories and metadata written by archivers
+// (e.g. macOS '__MACOSX' resource forks, hidden files) are not images.
+func IsArchiveImage(name string) bool {
+	if isArchiveJunk(name) {
+		return false
+	}
+
+	return archiveImageExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// isArchiveJunk checks whether entry was added by the archiver rather
+// than by the author of the archive
+func isArchiveJunk(name string) bool {
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "__MACOSX" || strings.HasPrefix(segment, ".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// detectImageArchive tells comic books, whose files are all images (and
+// optional metadata), from other archives holding images. Archives with
+// no image are UNSUPPORTED.
+func detectImageArchive(entries []*zip.File) Format {
+	images, others := 0, 0
+	for _, entry := range entries {
+		switch {
+		case entry.FileInfo().IsDir() || isArchiveJunk(entry.Name):
+		case IsArchiveImage(entry.Name):
+			images++
+		case comicMetadataEntries[strings.ToLower(path.Base(entry.Name))]:
+		default:
+			others++
+		}
+	}
+
+	switch {
+	case images == 0:
+		return UNSUPPORTED
+	case others == 0:
+		return CBZ
+	default:
+		return ZIP
+	}
+}
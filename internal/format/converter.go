@@ -41,12 +41,18 @@ func (c *FormatConverter) Convert(
 	dstAbsPath string,
 	dstFormat Format,
 ) error {
-	err := c.isConversionSupported(srcAbsPath, dstAbsPath, dstFormat)
+	srcFormat, err := c.isConversionSupported(srcAbsPath, dstAbsPath, dstFormat)
 	if err != nil {
 		return fmt.Errorf("conversion not supported: %w", err)
 	}
 
-	return c.ConvertWithoutFormatsCheck(ctx, srcAbsPath, dstAbsPath, dstFormat)
+	return c.ConvertWithoutFormatsCheck(
+		ctx,
+		srcAbsPath,
+		srcFormat,
+		dstAbsPath,
+		dstFormat,
+	)
 }
 
 // ConvertWithoutFormatsCheck performs the format conversion without
@@ -57,34 +63,62 @@ func (c *FormatConverter) Convert(
 func (c *FormatConverter) ConvertWithoutFormatsCheck(
 	ctx context.Context,
 	srcAbsPath string,
+	srcFormat Format,
 	dstAbsPath string,
 	dstFormat Format,
 ) error {
 	startTime := time.Now()
 
-	// Prepare 'heif-convert' command to do the conversion
-	//   Use 'heif-convert --help' for usage information
-	args := []string{"-q 75", srcAbsPath, dstAbsPath}
-	command := exec.CommandContext(ctx, "heif-convert", args...)
+	var err error
+	switch srcFormat {
+	case HEIF:
+		// Use 'heif-convert --help' for usage information
+		err = runConverter(ctx, "heif-convert", srcAbsPath, "-q 75", srcAbsPath, dstAbsPath)
+	case JXL:
+		// Output format is picked from 'dstAbsPath' extension
+		err = runConverter(ctx, "djxl", srcAbsPath, srcAbsPath, dstAbsPath)
+	case TIFF:
+		err = convertTiff(srcAbsPath, dstAbsPath, dstFormat)
+	case DNG, CR2, CR3, NEF, ARW:
+		err = convertRaw(ctx, srcAbsPath, dstAbsPath, dstFormat)
+	default:
+		err = fmt.Errorf("unsupported source format: %v", srcFormat)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.telemetry.Metrics().Duration(
+		metrics.FormatConvertDuration,
+		time.Since(startTime))
+	c.telemetry.Metrics().Increment(metrics.FormatConverted)
+	return nil
+}
+
+// runConverter runs the 'binary' command line converter with given args
+func runConverter(
+	ctx context.Context,
+	binary string,
+	srcAbsPath string,
+	args ...string,
+) error {
+	command := exec.CommandContext(ctx, binary, args...)
 
 	output, err := command.CombinedOutput()
 	if err != nil {
 		if errors.Is(err, exec.ErrNotFound) {
-			return fmt.Errorf("heif-convert binary not found: %w", err)
+			return fmt.Errorf("%s binary not found: %w", binary, err)
 		}
 
 		return fmt.Errorf(
-			"heif-convert failed for %s: %w. output: %s",
+			"%s failed for %s: %w. output: %s",
+			binary,
 			srcAbsPath,
 			err,
 			strings.TrimSpace(string(output)),
 		)
 	}
 
-	c.telemetry.Metrics().Duration(
-		metrics.FormatConvertDuration,
-		time.Since(startTime))
-	c.telemetry.Metrics().Increment(metrics.FormatConverted)
 	return nil
 }
 
@@ -92,51 +126,50 @@ func (c *FormatConverter) isConversionSupported(
 	srcAbsPath string,
 	dstAbsPath string,
 	dstFormat Format,
-) error {
+) (Format, error) {
 
 	// Validate dst extension is supported
 	if !c.isDstExtensionSupported(dstAbsPath) {
-		return fmt.Errorf(
+		return UNSUPPORTED, fmt.Errorf(
 			"unsupported destination file extension: %s",
 			filepath.Ext(dstAbsPath))
 	}
 
 	// Validate dst format is supported
 	if !c.isDstFormatSupported(dstFormat) {
-		return fmt.Errorf("unsupported destination format: %v", dstFormat)
+		return UNSUPPORTED, fmt.Errorf("unsupported destination format: %v", dstFormat)
 	}
 
 	// Detect src format
 	format, err := c.formatDetector.Detect(srcAbsPath)
 	if err != nil {
-		return fmt.Errorf("failed to detect format of source file: %w", err)
+		return UNSUPPORTED, fmt.Errorf("failed to detect format of source file: %w", err)
 	}
 
 	// Validate src format is supported
 	if !c.isSrcFormatSupported(format) {
-		return fmt.Errorf("unsupported source format: %v", format)
+		return UNSUPPORTED, fmt.Errorf("unsupported source format: %v", format)
 	}
 
-	return nil
+	return format, nil
 }
 
 func (c *FormatConverter) isSrcFormatSupported(srcFormat Format) bool {
-
-	// Only HEIF is supported for now
-	return HEIF == srcFormat
+	return slices.Contains(ConvertedFormats, srcFormat) ||
+		slices.Contains(RawFormats, srcFormat)
 }
 
 func (c *FormatConverter) isDstFormatSupported(dstFormat Format) bool {
 
-	// Only JPEG is supported for now
-	return JPEG == dstFormat
+	// PNG keeps bit depth of HDR images
+	return JPEG == dstFormat || PNG == dstFormat
 }
 
 func (c *FormatConverter) isDstExtensionSupported(dstAbsPath string) bool {
 
-	// 'heif-convert' supported extensions
+	// Extensions supported by every converter
 	//   Use 'heif-convert --help' to see supported output file extensions
-	supportedExtensions := []string{".jpg", ".jpeg"}
+	supportedExtensions := []string{".jpg", ".jpeg", ".png"}
 
 	dstExt := strings.ToLower(filepath.Ext(dstAbsPath))
 	return slices.Contains(supportedExtensions, dstExt)
@@ -1,7 +1,10 @@
 package format
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"image/jpeg"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -90,6 +93,87 @@ func TestConverter_Integration_SupportedCase(t *testing.T) {
 	}
 }
 
+func TestConverter_MultiPageTiff(t *testing.T) {
+	srcPath := filepath.Join(t.TempDir(), "scan.tif")
+	dstPath := filepath.Join(t.TempDir(), "converted.jpg")
+
+	// First page is 40x20 white, second one 80x40 black
+	tiffData := mkGrayTiff([]tiffPage{
+		{width: 40, height: 20, value: 255},
+		{width: 80, height: 40, value: 0},
+	})
+	if err := os.WriteFile(srcPath, tiffData, 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	converter := NewFormatConverter(mkTestTelemetrySvc(t), NewFormatDetector())
+	if err := converter.Convert(context.Background(), srcPath, dstPath, JPEG); err != nil {
+		t.Fatalf("unexpected error converting tiff: %v", err)
+	}
+
+	file, err := os.Open(dstPath)
+	if err != nil {
+		t.Fatalf("expected output file to exist: %v", err)
+	}
+	defer file.Close()
+
+	converted, err := jpeg.Decode(file)
+	if err != nil {
+		t.Fatalf("failed to decode converted file: %v", err)
+	}
+
+	bounds := converted.Bounds()
+	if bounds.Dx() != 40 || bounds.Dy() != 20 {
+		t.Fatalf("converted size = %dx%d, want first page 40x20", bounds.Dx(), bounds.Dy())
+	}
+	if r, _, _, _ := converted.At(10, 10).RGBA(); r>>8 < 250 {
+		t.Fatalf("expected white first page, got red %d", r>>8)
+	}
+}
+
+type tiffPage struct {
+	width  int
+	height int
+	value  byte
+}
+
+// mkGrayTiff builds an uncompressed little endian TIFF with one 8 bits
+// grayscale image file directory per page
+func mkGrayTiff(pages []tiffPage) []byte {
+	data := []byte{'I', 'I', 42, 0, 0, 0, 0, 0}
+	nextIfdOffsetPos := 4
+
+	for _, page := range pages {
+		pixelsOffset := len(data)
+		data = append(data, bytes.Repeat([]byte{page.value}, page.width*page.height)...)
+
+		binary.LittleEndian.PutUint32(data[nextIfdOffsetPos:], uint32(len(data)))
+		entries := [][3]uint32{
+			{256, 4, uint32(page.width)},               // ImageWidth
+			{257, 4, uint32(page.height)},              // ImageLength
+			{258, 3, 8},                                // BitsPerSample
+			{259, 3, 1},                                // Compression: none
+			{262, 3, 1},                                // Photometric: BlackIsZero
+			{273, 4, uint32(pixelsOffset)},             // StripOffsets
+			{278, 4, uint32(page.height)},              // RowsPerStrip
+			{279, 4, uint32(page.width * page.height)}, // StripByteCounts
+		}
+
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(entries)))
+		for _, entry := range entries {
+			data = binary.LittleEndian.AppendUint16(data, uint16(entry[0]))
+			data = binary.LittleEndian.AppendUint16(data, uint16(entry[1]))
+			data = binary.LittleEndian.AppendUint32(data, 1)
+			data = binary.LittleEndian.AppendUint32(data, entry[2])
+		}
+
+		nextIfdOffsetPos = len(data)
+		data = binary.LittleEndian.AppendUint32(data, 0)
+	}
+
+	return data
+}
+
 func mkTestTelemetrySvc(t *testing.T) *telemetry.TelemetrySvc {
 	t.Helper()
 	t.Setenv("OTEL_ENABLED", "false")
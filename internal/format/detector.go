@@ -1,6 +1,8 @@
 package format
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
@@ -8,6 +10,21 @@ import (
 	"github.com/h2non/filetype"
 )
 
+// Bytes read from file start for detection. filetype needs at least 261,
+// MPEG-TS detection checks sync bytes of the first few packets.
+const headerSize = 4 * m2tsPacketSize
+
+// Transport stream packets are 188 bytes long, AVCHD (M2TS) ones are
+// prefixed with a 4 bytes timestamp
+const (
+	tsPacketSize   = 188
+	m2tsPacketSize = 192
+	tsSyncByte     = 0x47
+)
+
+// Packets whose sync byte must be found to detect a transport stream
+const minTsPackets = 3
+
 type FormatDetector struct{}
 
 func NewFormatDetector() *FormatDetector {
@@ -28,14 +45,19 @@ func (d *FormatDetector) Detect(absFilePath string) (Format, error) {
 
 func detectWithFileType(absFilePath string) (Format, error) {
 
-	// filetype needs at least 261 bytes
-	header, err := firstNBytes(absFilePath, 261)
+	header, err := firstNBytes(absFilePath, headerSize)
 	if err != nil {
 		return UNSUPPORTED, fmt.Errorf(
 			"failed to read file header for format detection: %w",
 			err)
 	}
 
+	// filetype lumps ISO BMFF images together and knows no JPEG XL nor
+	// CR3
+	if format := detectImageSignature(header); format != UNSUPPORTED {
+		return format, nil
+	}
+
 	kind, err := filetype.Match(header)
 	if err != nil {
 		return UNSUPPORTED, fmt.Errorf(
@@ -43,15 +65,33 @@ func detectWithFileType(absFilePath string) (Format, error) {
 			err)
 	}
 
+	if format := imageMimeFormat(kind.MIME.Value, header); format != UNSUPPORTED {
+		return format, nil
+	}
+
 	switch kind.MIME.Value {
-	case "image/jpeg":
-		return JPEG, nil
-	case "image/png":
-		return PNG, nil
-	case "image/webp":
-		return WEBP, nil
-	case "image/heif":
-		return HEIF, nil
+	case "image/tiff":
+		// DNG, NEF and ARW files are TIFF structured too
+		return detectTiffRaw(absFilePath)
+	case "image/x-canon-cr2":
+		return CR2, nil
+
+	case "application/pdf":
+		return PDF, nil
+	case "application/msword":
+		return DOC, nil
+	case "application/vnd.ms-excel":
+		return XLS, nil
+	case "application/vnd.ms-powerpoint":
+		return PPT, nil
+	case "application/zip",
+		"application/epub+zip",
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation":
+		// Office Open XML, OpenDocument, EPUB and comic book files are
+		// zip archives, told apart by their entries
+		return detectZipArchive(absFilePath)
 
 	case "video/quicktime":
 		return MOV, nil
@@ -59,11 +99,84 @@ func detectWithFileType(absFilePath string) (Format, error) {
 		return MP4, nil
 	case "video/x-m4v":
 		return M4V, nil
+	case "video/x-matroska":
+		return MKV, nil
+	case "video/webm":
+		return WEBM, nil
+	case "video/x-msvideo":
+		return AVI, nil
+	case "video/3gpp":
+		return THREE_GP, nil
+
+	case "audio/mpeg":
+		return MP3, nil
+	case "audio/m4a":
+		return M4A, nil
+	case "audio/x-flac":
+		return FLAC, nil
+	case "audio/ogg":
+		return OGG, nil
+	}
+
+	// filetype has no matcher for transport streams
+	if isMpegTs(header) {
+		return MPEG_TS, nil
+	}
+
+	// filetype only knows MP3 files starting with an ID3 tag or with
+	// a specific frame header
+	if isMpegAudio(header) {
+		return MP3, nil
+	}
+
+	// nor for text based formats
+	if looksLikeMarkup(header) {
+		return detectSvg(absFilePath)
+	}
 
 	// For other formats, we use UNSUPPORTED to delegate detection to next
 	// detector in the chain (e.g. vips).
+	return UNSUPPORTED, nil
+}
+
+// DetectImageBytes detects the format of an image already loaded in
+// memory (e.g. an archive entry). Only image formats told apart by their
+// header are recognized, others are UNSUPPORTED.
+func (d *FormatDetector) DetectImageBytes(data []byte) Format {
+	header := data[:min(len(data), headerSize)]
+	if format := detectImageSignature(header); format != UNSUPPORTED {
+		return format
+	}
+
+	kind, err := filetype.Match(header)
+	if err != nil {
+		return UNSUPPORTED
+	}
+
+	return imageMimeFormat(kind.MIME.Value, header)
+}
+
+// imageMimeFormat maps filetype MIME types of images needing no further
+// check of the file. Other MIME types are UNSUPPORTED.
+func imageMimeFormat(mimeType string, header []byte) Format {
+	switch mimeType {
+	case "image/jpeg":
+		return JPEG
+	case "image/png":
+		return PNG
+	case "image/webp":
+		if isAnimatedWebp(header) {
+			return ANIMATED_WEBP
+		}
+		return WEBP
+	case "image/gif":
+		return GIF
+	case "image/heif":
+		return HEIF
+	case "image/bmp":
+		return BMP
 	default:
-		return UNSUPPORTED, nil
+		return UNSUPPORTED
 	}
 }
 
@@ -88,3 +201,106 @@ func firstNBytes(absFilePath string, nBytes int) ([]byte, error) {
 
 	return header, nil
 }
+
+// isAnimatedWebp checks the animation flag of the extended header
+// ('VP8X'), which comes right after the RIFF header when present
+func isAnimatedWebp(header []byte) bool {
+	return len(header) > 20 &&
+		string(header[12:16]) == "VP8X" &&
+		header[20]&0x02 != 0
+}
+
+// isMpegTs checks sync bytes at the start of consecutive transport
+// stream packets, either plain (188 bytes) or AVCHD timestamped ones
+// (192 bytes)
+func isMpegTs(header []byte) bool {
+	layouts := []struct{ packetSize, syncOffset int }{
+		{tsPacketSize, 0},
+		{m2tsPacketSize, 4},
+	}
+
+	for _, layout := range layouts {
+		packets := 0
+		for offset := layout.syncOffset; offset < len(header); offset += layout.packetSize {
+			if header[offset] != tsSyncByte {
+				packets = 0
+				break
+			}
+			packets++
+		}
+
+		if packets >= minTsPackets {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isMpegAudio checks whether header starts with a valid MPEG audio
+// layer III frame header: frame sync, version, bitrate and sample rate
+// indexes not reserved
+func isMpegAudio(header []byte) bool {
+	if len(header) < 4 || header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return false
+	}
+
+	version := header[1] >> 3 & 0x03
+	layer := header[1] >> 1 & 0x03
+	bitrateIndex := header[2] >> 4
+	sampleRateIndex := header[2] >> 2 & 0x03
+
+	return version != 0x01 &&
+		layer == 0x01 &&
+		bitrateIndex != 0x00 && bitrateIndex != 0x0F &&
+		sampleRateIndex != 0x03
+}
+
+// JPEG XL bare codestream and ISO BMFF container signatures
+var (
+	jxlCodestreamSignature = []byte{0xFF, 0x0A}
+	jxlContainerSignature  = []byte{
+		0x00, 0x00, 0x00, 0x0C, 'J', 'X', 'L', ' ', 0x0D, 0x0A, 0x87, 0x0A,
+	}
+)
+
+// detectImageSignature detects JPEG XL files and tells AVIF and Canon
+// CR3 apart from HEIF and MP4 by brands of the 'ftyp' box
+func detectImageSignature(header []byte) Format {
+	if bytes.HasPrefix(header, jxlCodestreamSignature) ||
+		bytes.HasPrefix(header, jxlContainerSignature) {
+		return JXL
+	}
+
+	for _, brand := range ftypBrands(header) {
+		switch brand {
+		case "avif", "avis":
+			return AVIF
+		case cr3Brand:
+			return CR3
+		}
+	}
+
+	return UNSUPPORTED
+}
+
+// ftypBrands returns major and compatible brands of the 'ftyp' box that
+// ISO BMFF files start with, nil for other files
+func ftypBrands(header []byte) []string {
+	if len(header) < 16 || string(header[4:8]) != "ftyp" {
+		return nil
+	}
+
+	boxSize := int(binary.BigEndian.Uint32(header[0:4]))
+	if boxSize < 16 || boxSize > len(header) {
+		return nil
+	}
+
+	// Minor version follows the major brand
+	brands := []string{string(header[8:12])}
+	for offset := 16; offset+4 <= boxSize; offset += 4 {
+		brands = append(brands, string(header[offset:offset+4]))
+	}
+
+	return brands
+}
@@ -1,6 +1,11 @@
 package format
 
 import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/giobyte8/thumbnailer/internal/testutils"
@@ -34,6 +39,410 @@ func TestFmtDetector_DetectJpeg(t *testing.T) {
 	}
 }
 
+func TestFmtDetector_DetectVideoContainers(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []byte
+		expected Format
+	}{
+		{name: "matroska", header: ebmlHeader("matroska"), expected: MKV},
+		{name: "webm", header: ebmlHeader("webm"), expected: WEBM},
+		{
+			name:     "avi",
+			header:   []byte("RIFF\x00\x10\x00\x00AVI LIST"),
+			expected: AVI,
+		},
+		{
+			name:     "3gp",
+			header:   []byte("\x00\x00\x00\x18ftyp3gp4\x00\x00\x02\x00isom3gp4"),
+			expected: THREE_GP,
+		},
+		{name: "mpeg-ts", header: tsPackets(tsPacketSize, 0, 4), expected: MPEG_TS},
+		{name: "avchd m2ts", header: tsPackets(m2tsPacketSize, 4, 4), expected: MPEG_TS},
+		{name: "too short for ts", header: tsPackets(tsPacketSize, 0, 2), expected: UNSUPPORTED},
+		{name: "text starting as ts", header: []byte("Generic text file"), expected: UNSUPPORTED},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "video")
+			if err := os.WriteFile(filePath, tc.header, 0o644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			format, err := NewFormatDetector().Detect(filePath)
+			if err != nil {
+				t.Fatalf("failed to detect format: %v", err)
+			}
+			if format != tc.expected {
+				t.Fatalf("expected format %v, got %v", tc.expected, format)
+			}
+		})
+	}
+}
+
+func TestFmtDetector_DetectStillImages(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []byte
+		expected Format
+	}{
+		{name: "avif", header: ftypHeader("avif", "mif1", "miaf"), expected: AVIF},
+		{name: "avif sequence", header: ftypHeader("avis", "msf1", "avif"), expected: AVIF},
+		{name: "avif in mif1 brand", header: ftypHeader("mif1", "avif", "miaf"), expected: AVIF},
+		{name: "heic", header: ftypHeader("heic", "mif1", "heic"), expected: HEIF},
+		{name: "heic in mif1 brand", header: ftypHeader("mif1", "heic", "miaf"), expected: HEIF},
+		{name: "jxl codestream", header: []byte{0xFF, 0x0A, 0xFA, 0x7F}, expected: JXL},
+		{
+			name:     "jxl container",
+			header:   []byte{0, 0, 0, 0x0C, 'J', 'X', 'L', ' ', 0x0D, 0x0A, 0x87, 0x0A, 0, 0},
+			expected: JXL,
+		},
+		{name: "tiff little endian", header: []byte("II*\x00\x08\x00\x00\x00\x00\x00\x00"), expected: TIFF},
+		{name: "tiff big endian", header: []byte("MM\x00*\x00\x00\x00\x08\x00\x00\x00"), expected: TIFF},
+		{name: "bmp", header: []byte("BM\x36\x00\x00\x00\x00\x00\x00\x00\x36\x00"), expected: BMP},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "image")
+			if err := os.WriteFile(filePath, tc.header, 0o644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			format, err := NewFormatDetector().Detect(filePath)
+			if err != nil {
+				t.Fatalf("failed to detect format: %v", err)
+			}
+			if format != tc.expected {
+				t.Fatalf("expected format %v, got %v", tc.expected, format)
+			}
+		})
+	}
+}
+
+func TestFmtDetector_DetectRaw(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected Format
+	}{
+		{filename: "12 raw_nikon.nef", expected: NEF},
+		{filename: "13 raw_sony.arw", expected: ARW},
+		{filename: "14 raw_canon.cr2", expected: CR2},
+		{filename: "15 raw_adobe.dng", expected: DNG},
+		{filename: "16 raw_canon.cr3", expected: CR3},
+		{filename: "17 raw_no_preview.dng", expected: DNG},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.filename, func(t *testing.T) {
+			if format := detectFmt(t, tc.filename); format != tc.expected {
+				t.Fatalf("expected format %v, got %v", tc.expected, format)
+			}
+		})
+	}
+}
+
+func TestTiffRawFormat_PlainTiffFromCamera(t *testing.T) {
+
+	// IFD0 with a Nikon make but no sub IFDs (e.g. a scanner or an
+	// in camera TIFF)
+	tiff := []byte("MM\x00*\x00\x00\x00\x08\x00\x01")
+	tiff = append(tiff, 0x01, 0x0F, 0x00, 0x02, 0, 0, 0, 4, 'N', 'I', 'K', 0)
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	if format := tiffRawFormat(tiff); format != TIFF {
+		t.Fatalf("expected format %v, got %v", TIFF, format)
+	}
+}
+
+func TestFmtDetector_DetectDocuments(t *testing.T) {
+	odfMimeType := "application/vnd.oasis.opendocument."
+	tests := []struct {
+		name     string
+		content  []byte
+		expected Format
+	}{
+		{name: "pdf", content: []byte("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n"), expected: PDF},
+		{
+			name:     "docx",
+			content:  zipArchive(t, "[Content_Types].xml", "", "word/document.xml", ""),
+			expected: DOCX,
+		},
+		{
+			name:     "xlsx",
+			content:  zipArchive(t, "_rels/.rels", "", "xl/workbook.xml", ""),
+			expected: XLSX,
+		},
+		{
+			name:     "pptx",
+			content:  zipArchive(t, "docProps/app.xml", "", "ppt/presentation.xml", ""),
+			expected: PPTX,
+		},
+		{
+			name:     "odt",
+			content:  zipArchive(t, "mimetype", odfMimeType+"text", "content.xml", ""),
+			expected: ODT,
+		},
+		{
+			name:     "ods",
+			content:  zipArchive(t, "mimetype", odfMimeType+"spreadsheet", "content.xml", ""),
+			expected: ODS,
+		},
+		{
+			name:     "odp",
+			content:  zipArchive(t, "mimetype", odfMimeType+"presentation", "content.xml", ""),
+			expected: ODP,
+		},
+		{
+			name:     "plain zip",
+			content:  zipArchive(t, "notes.txt", "Generic text file"),
+			expected: UNSUPPORTED,
+		},
+		{
+			name:     "zip signature only",
+			content:  []byte("PK\x03\x04 Generic text file"),
+			expected: UNSUPPORTED,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "document")
+			if err := os.WriteFile(filePath, tc.content, 0o644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			format, err := NewFormatDetector().Detect(filePath)
+			if err != nil {
+				t.Fatalf("failed to detect format: %v", err)
+			}
+			if format != tc.expected {
+				t.Fatalf("expected format %v, got %v", tc.expected, format)
+			}
+		})
+	}
+}
+
+func TestFmtDetector_DetectArchives(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  []byte
+		expected Format
+	}{
+		{
+			name:     "epub",
+			content:  zipArchive(t, "mimetype", "application/epub+zip", "META-INF/container.xml", ""),
+			expected: EPUB,
+		},
+		{
+			name:     "epub mimetype not first",
+			content:  zipArchive(t, "META-INF/container.xml", "", "mimetype", "application/epub+zip"),
+			expected: EPUB,
+		},
+		{
+			name:     "comic book",
+			content:  zipArchive(t, "ComicInfo.xml", "", "001.jpg", "", "002.JPG", "", "extras/", ""),
+			expected: CBZ,
+		},
+		{
+			name:     "comic book zipped on macOS",
+			content:  zipArchive(t, "01.png", "", "__MACOSX/._01.png", "", ".DS_Store", ""),
+			expected: CBZ,
+		},
+		{
+			name:     "zip with images",
+			content:  zipArchive(t, "readme.txt", "", "photos/beach.webp", ""),
+			expected: ZIP,
+		},
+		{
+			name:     "hidden images only",
+			content:  zipArchive(t, "notes.txt", "", "__MACOSX/._cover.jpg", ""),
+			expected: UNSUPPORTED,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "archive")
+			if err := os.WriteFile(filePath, tc.content, 0o644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			format, err := NewFormatDetector().Detect(filePath)
+			if err != nil {
+				t.Fatalf("failed to detect format: %v", err)
+			}
+			if format != tc.expected {
+				t.Fatalf("expected format %v, got %v", tc.expected, format)
+			}
+		})
+	}
+}
+
+func TestFmtDetector_DetectImageBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected Format
+	}{
+		{name: "jpeg", data: []byte{0xFF, 0xD8, 0xFF, 0xE0, 0, 0x10, 'J', 'F', 'I', 'F'}, expected: JPEG},
+		{name: "png", data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), expected: PNG},
+		{name: "avif", data: ftypHeader("avif", "mif1", "miaf"), expected: AVIF},
+		{name: "tiff needs file", data: []byte("II*\x00\x08\x00\x00\x00\x00\x00\x00"), expected: UNSUPPORTED},
+		{name: "text", data: []byte("Generic text file"), expected: UNSUPPORTED},
+		{name: "empty", expected: UNSUPPORTED},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if format := NewFormatDetector().DetectImageBytes(tc.data); format != tc.expected {
+				t.Fatalf("expected format %v, got %v", tc.expected, format)
+			}
+		})
+	}
+}
+
+func TestFmtDetector_DetectSvg(t *testing.T) {
+	svgRoot := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"/>`
+	tests := []struct {
+		name     string
+		content  string
+		expected Format
+	}{
+		{name: "bare svg", content: svgRoot, expected: SVG},
+		{
+			name: "editor prolog",
+			content: `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+				"<!-- Generator: Adobe Illustrator 27.0.0 -->\n" +
+				`<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd">` + "\n" +
+				svgRoot,
+			expected: SVG,
+		},
+		{name: "byte order mark", content: "\xEF\xBB\xBF\n  " + svgRoot, expected: SVG},
+		{
+			name:     "latin1 declaration",
+			content:  `<?xml version="1.0" encoding="ISO-8859-1"?>` + svgRoot,
+			expected: SVG,
+		},
+		{
+			name:     "root past filetype header",
+			content:  "<!--" + strings.Repeat(" license text ", 100) + "-->" + svgRoot,
+			expected: SVG,
+		},
+		{name: "html", content: "<!DOCTYPE html><html><body><svg></svg></body></html>", expected: UNSUPPORTED},
+		{name: "other xml", content: `<?xml version="1.0"?><rss version="2.0"></rss>`, expected: UNSUPPORTED},
+		{name: "plain text", content: "Generic text file", expected: UNSUPPORTED},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "image")
+			if err := os.WriteFile(filePath, []byte(tc.content), 0o644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			format, err := NewFormatDetector().Detect(filePath)
+			if err != nil {
+				t.Fatalf("failed to detect format: %v", err)
+			}
+			if format != tc.expected {
+				t.Fatalf("expected format %v, got %v", tc.expected, format)
+			}
+		})
+	}
+}
+
+func TestFmtDetector_DetectAudio(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []byte
+		expected Format
+	}{
+		{name: "mp3 with id3 tag", header: []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), expected: MP3},
+		{name: "mpeg1 layer III frame", header: []byte{0xFF, 0xFB, 0x90, 0x64, 0, 0}, expected: MP3},
+		{name: "mpeg2 layer III frame", header: []byte{0xFF, 0xF3, 0x64, 0xC4, 0, 0}, expected: MP3},
+		{name: "mpeg2.5 layer III frame", header: []byte{0xFF, 0xE3, 0x18, 0xC4, 0, 0}, expected: MP3},
+		{name: "aac adts frame", header: []byte{0xFF, 0xF1, 0x50, 0x80, 0, 0}, expected: UNSUPPORTED},
+		{name: "reserved bitrate", header: []byte{0xFF, 0xF3, 0xF4, 0xC4, 0, 0}, expected: UNSUPPORTED},
+		{name: "reserved sample rate", header: []byte{0xFF, 0xF3, 0x6C, 0xC4, 0, 0}, expected: UNSUPPORTED},
+		{name: "m4a", header: ftypHeader("M4A ", "M4A ", "mp42", "isom"), expected: M4A},
+		{name: "flac", header: []byte("fLaC\x00\x00\x00\x22\x10\x00\x10\x00"), expected: FLAC},
+		{name: "ogg", header: []byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00"), expected: OGG},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "audio")
+			if err := os.WriteFile(filePath, tc.header, 0o644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			format, err := NewFormatDetector().Detect(filePath)
+			if err != nil {
+				t.Fatalf("failed to detect format: %v", err)
+			}
+			if format != tc.expected {
+				t.Fatalf("expected format %v, got %v", tc.expected, format)
+			}
+		})
+	}
+}
+
+// ftypHeader returns an 'ftyp' box with given major and compatible
+// brands
+func ftypHeader(majorBrand string, compatibleBrands ...string) []byte {
+	size := 16 + 4*len(compatibleBrands)
+	header := []byte{0, 0, 0, byte(size), 'f', 't', 'y', 'p'}
+	header = append(header, majorBrand...)
+	header = append(header, 0, 0, 0, 0)
+	for _, brand := range compatibleBrands {
+		header = append(header, brand...)
+	}
+
+	return append(header, make([]byte, 32)...)
+}
+
+// ebmlHeader returns the EBML header of a Matroska file with given
+// DocType
+func ebmlHeader(docType string) []byte {
+	docTypeElement := append([]byte{0x42, 0x82, 0x80 | byte(len(docType))}, docType...)
+	header := append([]byte{0x1A, 0x45, 0xDF, 0xA3, 0x80 | byte(len(docTypeElement))}, docTypeElement...)
+	return append(header, make([]byte, 64)...)
+}
+
+// tsPackets returns count empty transport stream packets of given size,
+// with sync byte at syncOffset
+func tsPackets(packetSize int, syncOffset int, count int) []byte {
+	packets := make([]byte, packetSize*count)
+	for idx := range count {
+		packets[idx*packetSize+syncOffset] = tsSyncByte
+	}
+
+	return packets
+}
+
+// zipArchive returns a zip archive holding given pairs of entry names
+// and contents, stored in order
+func zipArchive(t *testing.T, namesAndContents ...string) []byte {
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for idx := 0; idx+1 < len(namesAndContents); idx += 2 {
+		entry, err := writer.Create(namesAndContents[idx])
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		if _, err := entry.Write([]byte(namesAndContents[idx+1])); err != nil {
+			t.Fatalf("failed to write zip entry: %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close zip archive: %v", err)
+	}
+	return archive.Bytes()
+}
+
 func detectFmt(t *testing.T, filename string) Format {
 	testFilePath := testutils.TestFilePath(filename)
 
@@ -0,0 +1,88 @@
+package format
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Parts telling Office Open XML documents apart, which filetype can only
+// do when they come first in the archive
+var ooxmlParts = map[string]Format{
+	"word/document.xml":    DOCX,
+	"xl/workbook.xml":      XLSX,
+	"ppt/presentation.xml": PPTX,
+}
+
+// Media types stored in the 'mimetype' entry of OpenDocument files
+var odfMimeTypes = map[string]Format{
+	"application/vnd.oasis.opendocument.text":         ODT,
+	"application/vnd.oasis.opendocument.spreadsheet":  ODS,
+	"application/vnd.oasis.opendocument.presentation": ODP,
+}
+
+// Bytes read from the 'mimetype' entry, longer than any known type
+const maxZipMimeTypeSize = 128
+
+// detectZipArchive lists entries of the zip archive at 'absFilePath' to
+// find the document kind it holds. Other archives are told apart by
+// their image entries (see detectImageArchive).
+func detectZipArchive(absFilePath string) (Format, error) {
+	archive, err := zip.OpenReader(absFilePath)
+	if errors.Is(err, zip.ErrFormat) {
+		// Zip signature alone, no archive to look into
+		return UNSUPPORTED, nil
+	}
+	if err != nil {
+		return UNSUPPORTED, fmt.Errorf(
+			"failed to open zip archive for format detection: %w",
+			err)
+	}
+	defer archive.Close()
+
+	mimeType, err := zipMimeType(&archive.Reader)
+	if err != nil {
+		return UNSUPPORTED, err
+	}
+	if mimeType == epubMimeType {
+		return EPUB, nil
+	}
+	if format, found := odfMimeTypes[mimeType]; found {
+		return format, nil
+	}
+
+	for _, entry := range archive.File {
+		if format, found := ooxmlParts[entry.Name]; found {
+			return format, nil
+		}
+	}
+
+	return detectImageArchive(archive.File), nil
+}
+
+// zipMimeType returns content of the 'mimetype' entry that OpenDocument
+// and EPUB archives start with, empty when there is none
+func zipMimeType(archive *zip.Reader) (string, error) {
+	for _, entry := range archive.File {
+		if entry.Name != "mimetype" {
+			continue
+		}
+
+		reader, err := entry.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to open zip mimetype entry: %w", err)
+		}
+		defer reader.Close()
+
+		mimeType, err := io.ReadAll(io.LimitReader(reader, maxZipMimeTypeSize))
+		if err != nil {
+			return "", fmt.Errorf("failed to read zip mimetype entry: %w", err)
+		}
+
+		return strings.TrimSpace(string(mimeType)), nil
+	}
+
+	return "", nil
+}
@@ -7,10 +7,84 @@ const (
 	PNG  Format = "png"
 	WEBP Format = "webp"
 	HEIF Format = "heif"
+	GIF  Format = "gif"
+	TIFF Format = "tiff"
+	BMP  Format = "bmp"
+	AVIF Format = "avif"
+	JXL  Format = "jxl"
+	SVG  Format = "svg"
 
-	MOV Format = "mov"
-	MP4 Format = "mp4"
-	M4V Format = "m4v"
+	// Camera RAW: Adobe DNG, Canon CR2 and CR3, Nikon NEF and Sony ARW
+	DNG Format = "dng"
+	CR2 Format = "cr2"
+	CR3 Format = "cr3"
+	NEF Format = "nef"
+	ARW Format = "arw"
+
+	// PDF, and office documents converted into PDF first
+	PDF  Format = "pdf"
+	DOCX Format = "docx"
+	XLSX Format = "xlsx"
+	PPTX Format = "pptx"
+	DOC  Format = "doc"
+	XLS  Format = "xls"
+	PPT  Format = "ppt"
+	ODT  Format = "odt"
+	ODS  Format = "ods"
+	ODP  Format = "odp"
+
+	// Zip based archives: comic books (zip of images), EPUB e-books and
+	// plain zip archives holding images
+	CBZ  Format = "cbz"
+	EPUB Format = "epub"
+	ZIP  Format = "zip"
+
+	// WebP with more than one frame
+	ANIMATED_WEBP Format = "animated_webp"
+
+	MOV      Format = "mov"
+	MP4      Format = "mp4"
+	M4V      Format = "m4v"
+	MKV      Format = "mkv"
+	WEBM     Format = "webm"
+	AVI      Format = "avi"
+	THREE_GP Format = "3gp"
+
+	// MPEG transport stream, including AVCHD camcorder footage (.mts,
+	// .m2ts)
+	MPEG_TS Format = "mpegts"
+
+	// Audio: MPEG layer III, MPEG-4 audio (AAC, ALAC), FLAC and Ogg
+	// (Vorbis, Opus)
+	MP3  Format = "mp3"
+	M4A  Format = "m4a"
+	FLAC Format = "flac"
+	OGG  Format = "ogg"
 
 	UNSUPPORTED Format = "unsupported"
 )
+
+// ConvertedFormats lists still image formats lilliput can't decode, which
+// FormatConverter converts into an intermediary file first
+var ConvertedFormats = []Format{HEIF, JXL, TIFF}
+
+// RawFormats lists camera RAW formats, thumbnailed from their embedded
+// JPEG preview
+var RawFormats = []Format{DNG, CR2, CR3, NEF, ARW}
+
+// OfficeFormats lists documents converted into PDF with LibreOffice
+// before their first page is rendered
+var OfficeFormats = []Format{DOCX, XLSX, PPTX, DOC, XLS, PPT, ODT, ODS, ODP}
+
+// ArchiveFormats lists zip based archives, thumbnailed from an image
+// entry read in memory
+var ArchiveFormats = []Format{CBZ, EPUB, ZIP}
+
+// VideoFormats lists containers whose frames are extracted with ffmpeg
+var VideoFormats = []Format{
+	MOV, MP4, M4V, MKV, WEBM, AVI, THREE_GP, MPEG_TS,
+}
+
+// AudioFormats lists audio files, thumbnailed from their embedded cover
+// art or from a waveform of their samples
+var AudioFormats = []Format{MP3, M4A, FLAC, OGG}
@@ -0,0 +1,151 @@
+package format
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Bytes read from TIFF files to find IFD0 and its values, which writers
+// store right after the header
+const tiffStructureSize = 64 * 1024
+
+// IFD0 tags telling camera RAW files apart from plain TIFF
+const (
+	tagMake       = 0x010F
+	tagSubIFDs    = 0x014A
+	tagDNGVersion = 0xC612
+)
+
+const tiffTypeASCII = 2
+
+// ISO BMFF brand of Canon CR3 files
+const cr3Brand = "crx "
+
+// detectTiffRaw tells TIFF structured RAW files (DNG, NEF, ARW) apart
+// from plain TIFF ones
+func detectTiffRaw(absFilePath string) (Format, error) {
+	structure, err := firstNBytes(absFilePath, tiffStructureSize)
+	if err != nil {
+		return UNSUPPORTED, fmt.Errorf(
+			"failed to read TIFF structure for format detection: %w",
+			err)
+	}
+
+	return tiffRawFormat(structure), nil
+}
+
+// tiffRawFormat reads IFD0 of TIFF data: DNG files carry a DNGVersion
+// tag, NEF and ARW ones the camera make and sub IFDs holding the sensor
+// data. Other files are plain TIFF.
+func tiffRawFormat(data []byte) Format {
+	if len(data) < 8 {
+		return TIFF
+	}
+
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return TIFF
+	}
+
+	offset := int(order.Uint32(data[4:8]))
+	if offset < 8 || offset+2 > len(data) {
+		return TIFF
+	}
+
+	var cameraMake string
+	var hasSubIFDs bool
+	entriesCount := int(order.Uint16(data[offset:]))
+	for idx := range entriesCount {
+		entryStart := offset + 2 + idx*12
+		if entryStart+12 > len(data) {
+			break
+		}
+		entry := data[entryStart : entryStart+12]
+
+		switch order.Uint16(entry[0:2]) {
+		case tagDNGVersion:
+			return DNG
+		case tagSubIFDs:
+			hasSubIFDs = true
+		case tagMake:
+			cameraMake = tiffASCII(data, entry, order)
+		}
+	}
+
+	if !hasSubIFDs {
+		return TIFF
+	}
+
+	cameraMake = strings.ToUpper(cameraMake)
+	switch {
+	case strings.HasPrefix(cameraMake, "NIKON"):
+		return NEF
+	case strings.HasPrefix(cameraMake, "SONY"):
+		return ARW
+	default:
+		return TIFF
+	}
+}
+
+// tiffASCII returns the string value of an IFD entry, empty when it
+// isn't an ASCII one or its value lies beyond data
+func tiffASCII(data []byte, entry []byte, order binary.ByteOrder) string {
+	if order.Uint16(entry[2:4]) != tiffTypeASCII {
+		return ""
+	}
+
+	count := int(order.Uint32(entry[4:8]))
+	value := entry[8:12]
+	if count > 4 {
+		valueOffset := int(order.Uint32(entry[8:12]))
+		if valueOffset < 0 || count > len(data) || valueOffset > len(data)-count {
+			return ""
+		}
+		value = data[valueOffset : valueOffset+count]
+	} else {
+		value = value[:count]
+	}
+
+	value, _, _ = bytes.Cut(value, []byte{0})
+	return strings.TrimSpace(string(value))
+}
+
+// convertRaw demosaics the RAW file at 'srcAbsPath' with LibRaw's
+// 'dcraw_emu' into an intermediary TIFF, encoded at 'dstAbsPath' the
+// same way TIFF originals are. Only used for RAW files embedding no
+// preview, as demosaicing is orders of magnitude slower.
+func convertRaw(
+	ctx context.Context,
+	srcAbsPath string,
+	dstAbsPath string,
+	dstFormat Format,
+) error {
+	tiffAbsPath := strings.TrimSuffix(dstAbsPath, filepath.Ext(dstAbsPath)) + ".tiff"
+	defer os.Remove(tiffAbsPath)
+
+	// Use 'dcraw_emu' without arguments for usage information:
+	//   -w camera white balance, -h half size (thumbnails don't need full
+	//   sensor resolution), -T TIFF output, -Z output file name.
+	// Output is rotated according to the RAW orientation.
+	err := runConverter(
+		ctx,
+		"dcraw_emu",
+		srcAbsPath,
+		"-w", "-h", "-T", "-Z", tiffAbsPath, srcAbsPath,
+	)
+	if err != nil {
+		return err
+	}
+
+	return convertTiff(tiffAbsPath, dstAbsPath, dstFormat)
+}
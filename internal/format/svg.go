@@ -0,0 +1,60 @@
+package format
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Bytes read from markup files to find their root element, past the XML
+// declaration, comments and DOCTYPE that editors write before it
+const svgSniffSize = 8 * 1024
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// looksLikeMarkup checks whether header starts with a tag, after an
+// optional byte order mark and whitespace
+func looksLikeMarkup(header []byte) bool {
+	header = bytes.TrimPrefix(header, utf8BOM)
+	header = bytes.TrimLeft(header, " \t\r\n")
+	return len(header) > 0 && header[0] == '<'
+}
+
+// detectSvg reads the start of the markup file at 'absFilePath' to tell
+// SVG images from other XML or HTML files
+func detectSvg(absFilePath string) (Format, error) {
+	prefix, err := firstNBytes(absFilePath, svgSniffSize)
+	if err != nil {
+		return UNSUPPORTED, fmt.Errorf(
+			"failed to read markup for format detection: %w",
+			err)
+	}
+
+	if isSvg(prefix) {
+		return SVG, nil
+	}
+	return UNSUPPORTED, nil
+}
+
+// isSvg checks whether the root element of markup is 'svg'. Markup is
+// only tokenized up to the root element, entities are never resolved.
+func isSvg(markup []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(bytes.TrimPrefix(markup, utf8BOM)))
+
+	// Element names are ASCII in every encoding SVG editors use
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return false
+		}
+
+		if element, isElement := token.(xml.StartElement); isElement {
+			return element.Name.Local == "svg"
+		}
+	}
+}
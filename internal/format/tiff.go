@@ -0,0 +1,52 @@
+package format
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+
+	"golang.org/x/image/tiff"
+)
+
+// convertTiff decodes the first page of the TIFF file at 'srcAbsPath' and
+// encodes it at 'dstAbsPath'. Transparent pixels are flattened over white
+// for JPEG output.
+func convertTiff(srcAbsPath string, dstAbsPath string, dstFormat Format) error {
+	srcFile, err := os.Open(srcAbsPath)
+	if err != nil {
+		return fmt.Errorf("failed to open TIFF file: %w", err)
+	}
+	defer srcFile.Close()
+
+	// Only the first image file directory is decoded, other pages of
+	// multi-page files are ignored
+	page, err := tiff.Decode(srcFile)
+	if err != nil {
+		return fmt.Errorf("failed to decode TIFF file %s: %w", srcAbsPath, err)
+	}
+
+	dstFile, err := os.Create(dstAbsPath)
+	if err != nil {
+		return fmt.Errorf("failed to create converted file: %w", err)
+	}
+	defer dstFile.Close()
+
+	if dstFormat == PNG {
+		err = png.Encode(dstFile, page)
+	} else {
+		bounds := page.Bounds()
+		flattened := image.NewRGBA(bounds)
+		draw.Draw(flattened, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(flattened, bounds, page, bounds.Min, draw.Over)
+		err = jpeg.Encode(dstFile, flattened, &jpeg.Options{Quality: 95})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode converted TIFF file: %w", err)
+	}
+
+	return dstFile.Close()
+}
@@ -0,0 +1,144 @@
+package metadata
+
+import (
+	"fmt"
+	"os"
+)
+
+// Coding-independent code points (ISO/IEC 23091-2) of HDR transfer
+// characteristics
+const (
+	TransferPQ  = 16
+	TransferHLG = 18
+)
+
+// NclxColor holds color primaries, transfer characteristics and matrix
+// coefficients of a 'nclx' color property, as ISO/IEC 23091-2 code points
+type NclxColor struct {
+	Primaries uint16
+	Transfer  uint16
+	Matrix    uint16
+	FullRange bool
+}
+
+// IsHDR reports whether transfer characteristics are PQ or HLG
+func (c *NclxColor) IsHDR() bool {
+	return c.Transfer == TransferPQ || c.Transfer == TransferHLG
+}
+
+// HeifColorProfile reads the ICC profile of a HEIF file from its 'colr'
+// item properties. Returns nil profile and no error when file has none
+// (e.g. profile given as 'nclx' color primaries).
+//
+// Image items of a file share their profile in practice, so the first
+// one found is returned.
+func HeifColorProfile(absFilePath string) ([]byte, error) {
+	properties, err := heifColorProperties(absFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, colr := range properties {
+		switch string(colr[:4]) {
+		case "prof", "rICC":
+			return colr[4:], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// HeifNclxColor reads the first 'nclx' color property of a HEIF file.
+// Returns nil and no error when file has none.
+func HeifNclxColor(absFilePath string) (*NclxColor, error) {
+	properties, err := heifColorProperties(absFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, colr := range properties {
+		if string(colr[:4]) != "nclx" || len(colr) < 11 {
+			continue
+		}
+
+		cursor := &byteCursor{data: colr[4:]}
+		return &NclxColor{
+			Primaries: uint16(cursor.uint(2)),
+			Transfer:  uint16(cursor.uint(2)),
+			Matrix:    uint16(cursor.uint(2)),
+			FullRange: cursor.uint(1)&0x80 != 0,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// heifColorProperties returns payloads of 'colr' item properties of a
+// HEIF file, each starting with its 4 bytes color type
+func heifColorProperties(absFilePath string) ([][]byte, error) {
+	file, err := os.Open(absFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HEIF file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat HEIF file: %w", err)
+	}
+
+	topBoxes, err := readBoxes(file, 0, fileInfo.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEIF boxes: %w", err)
+	}
+
+	metaBox, found := findBox(topBoxes, "meta")
+	if !found {
+		return nil, nil
+	}
+
+	metaPayload, err := loadBox(file, metaBox)
+	if err != nil {
+		return nil, err
+	}
+
+	// meta > iprp > ipco > colr
+	payload, found, err := nestedBox(metaPayload, 4, "iprp", "ipco")
+	if err != nil || !found {
+		return nil, err
+	}
+
+	properties, err := childBoxes(payload, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEIF properties: %w", err)
+	}
+
+	var colrPayloads [][]byte
+	for _, property := range properties {
+		if property.boxType == "colr" && property.size >= 4 {
+			colrPayloads = append(colrPayloads, boxPayload(payload, property))
+		}
+	}
+
+	return colrPayloads, nil
+}
+
+// nestedBox walks down a path of box types starting at payload, whose
+// first skip bytes are not boxes
+func nestedBox(payload []byte, skip int, path ...string) ([]byte, bool, error) {
+	for _, boxType := range path {
+		children, err := childBoxes(payload, skip)
+		if err != nil {
+			return nil, false, err
+		}
+
+		child, found := findBox(children, boxType)
+		if !found {
+			return nil, false, nil
+		}
+
+		payload, skip = boxPayload(payload, child), 0
+	}
+
+	return payload, true, nil
+}
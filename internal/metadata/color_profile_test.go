@@ -0,0 +1,50 @@
+package metadata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+func TestHeifColorProfile(t *testing.T) {
+	profile, err := HeifColorProfile(testutils.TestFilePath("4 thai_no_edits.heic"))
+	if err != nil {
+		t.Fatalf("failed to read color profile: %v", err)
+	}
+
+	// ICC profiles carry 'acsp' signature at offset 36
+	if len(profile) < 128 || string(profile[36:40]) != "acsp" {
+		t.Fatalf("expected ICC profile, got %d bytes", len(profile))
+	}
+}
+
+func TestHeifNclxColor(t *testing.T) {
+
+	// ftyp + meta > iprp > ipco > colr(nclx): BT.2020, HLG, BT.2020 NCL,
+	// full range
+	nclx := append([]byte("nclx"), 0, 9, 0, 18, 0, 9, 0x80)
+	heif := append(
+		mkBox("ftyp", []byte("heic")),
+		fullBox("meta", mkBox("iprp", mkBox("ipco", mkBox("colr", nclx))))...,
+	)
+
+	filePath := filepath.Join(t.TempDir(), "hdr.heic")
+	if err := os.WriteFile(filePath, heif, 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	color, err := HeifNclxColor(filePath)
+	if err != nil {
+		t.Fatalf("failed to read nclx color: %v", err)
+	}
+
+	want := NclxColor{Primaries: 9, Transfer: TransferHLG, Matrix: 9, FullRange: true}
+	if color == nil || *color != want {
+		t.Fatalf("nclx color = %+v, want %+v", color, want)
+	}
+	if !color.IsHDR() {
+		t.Fatal("HLG transfer should be HDR")
+	}
+}
@@ -0,0 +1,434 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// EXIF tags read from IFD0, Exif and GPS IFDs
+const (
+	tagImageWidth  = 0x0100
+	tagImageLength = 0x0101
+	tagMake        = 0x010F
+	tagModel       = 0x0110
+	tagOrientation = 0x0112
+	tagDateTime    = 0x0132
+	tagExifIFD     = 0x8769
+	tagGPSIFD      = 0x8825
+
+	tagExposureTime       = 0x829A
+	tagFNumber            = 0x829D
+	tagISO                = 0x8827
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagFocalLength        = 0x920A
+	tagMakerNote          = 0x927C
+	tagPixelXDimension    = 0xA002
+	tagPixelYDimension    = 0xA003
+	tagLensModel          = 0xA434
+
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+	tagGPSAltitudeRef  = 0x0005
+	tagGPSAltitude     = 0x0006
+)
+
+// TIFF field types
+const (
+	typeByte      = 1
+	typeASCII     = 2
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeUndefined = 7
+	typeSLong     = 9
+	typeSRational = 10
+	typeIFD       = 13
+)
+
+// Apple MakerNote: a header followed by a big endian IFD, whose value
+// offsets are relative to the MakerNote start
+const (
+	appleMakerNoteIFDOffset   = 14
+	tagAppleContentIdentifier = 0x0011
+)
+
+var appleMakerNoteHeader = []byte("Apple iOS\x00")
+
+// Hostile files could declare huge IFDs, real ones stay far below
+const maxIFDEntries = 1024
+
+var errInvalidTiff = errors.New("invalid TIFF structure")
+
+// exifHeader prefixes TIFF data in JPEG APP1 segments and some HEIF and
+// WebP files
+var exifHeader = []byte("Exif\x00\x00")
+
+type ifdEntry struct {
+	fieldType uint16
+	count     uint32
+	value     []byte
+}
+
+type ifd map[uint16]ifdEntry
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// parseExif reads metadata from TIFF structured EXIF data into meta,
+// keeping values already present in meta.
+func parseExif(data []byte, meta *models.MediaMetadata) error {
+	reader, err := newTiffReader(bytes.TrimPrefix(data, exifHeader))
+	if err != nil {
+		return err
+	}
+
+	ifd0, err := reader.readIFD(reader.firstIFDOffset())
+	if err != nil {
+		return err
+	}
+
+	exifIFD := reader.subIFD(ifd0, tagExifIFD)
+	gpsIFD := reader.subIFD(ifd0, tagGPSIFD)
+
+	setIfEmpty(&meta.CameraMake, reader.str(ifd0, tagMake))
+	setIfEmpty(&meta.CameraModel, reader.str(ifd0, tagModel))
+	setIfEmpty(&meta.LensModel, reader.str(exifIFD, tagLensModel))
+	setIfEmpty(&meta.ContentIdentifier, appleContentIdentifier(exifIFD))
+
+	if meta.CaptureTime == "" {
+		captureTime := reader.str(exifIFD, tagDateTimeOriginal)
+		if captureTime == "" {
+			captureTime = reader.str(ifd0, tagDateTime)
+		}
+
+		meta.CaptureTime = formatExifTime(
+			captureTime,
+			reader.str(exifIFD, tagOffsetTimeOriginal),
+		)
+	}
+
+	if orientation, ok := reader.uint(ifd0, tagOrientation); ok && meta.Orientation == 0 {
+		meta.Orientation = int(orientation)
+	}
+
+	if meta.Width == 0 || meta.Height == 0 {
+		width, okW := reader.uint(exifIFD, tagPixelXDimension)
+		height, okH := reader.uint(exifIFD, tagPixelYDimension)
+		if !okW || !okH {
+			width, okW = reader.uint(ifd0, tagImageWidth)
+			height, okH = reader.uint(ifd0, tagImageLength)
+		}
+		if okW && okH {
+			meta.Width, meta.Height = int(width), int(height)
+		}
+	}
+
+	if meta.Exposure == nil {
+		meta.Exposure = reader.exposure(exifIFD)
+	}
+	if meta.Location == nil {
+		meta.Location = reader.location(gpsIFD)
+	}
+
+	return nil
+}
+
+// appleContentIdentifier reads the Live Photo content identifier from
+// the Apple MakerNote in exifIFD, if any
+func appleContentIdentifier(exifIFD ifd) string {
+	makerNote := exifIFD[tagMakerNote].value
+	if !bytes.HasPrefix(makerNote, appleMakerNoteHeader) {
+		return ""
+	}
+
+	reader := &tiffReader{data: makerNote, order: binary.BigEndian}
+	entries, err := reader.readIFD(appleMakerNoteIFDOffset)
+	if err != nil {
+		return ""
+	}
+
+	return reader.str(entries, tagAppleContentIdentifier)
+}
+
+// newTiffReader reads byte order from the header of TIFF data
+func newTiffReader(data []byte) (*tiffReader, error) {
+	if len(data) < 8 {
+		return nil, errInvalidTiff
+	}
+
+	reader := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		reader.order = binary.LittleEndian
+	case "MM":
+		reader.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("%w: unknown byte order", errInvalidTiff)
+	}
+
+	return reader, nil
+}
+
+func (r *tiffReader) firstIFDOffset() uint32 {
+	return r.order.Uint32(r.data[4:8])
+}
+
+func (r *tiffReader) readIFD(offset uint32) (ifd, error) {
+	if uint64(offset)+2 > uint64(len(r.data)) {
+		return nil, fmt.Errorf("%w: IFD offset out of bounds", errInvalidTiff)
+	}
+
+	entriesCount := int(r.order.Uint16(r.data[offset:]))
+	if entriesCount > maxIFDEntries {
+		return nil, fmt.Errorf("%w: too many IFD entries", errInvalidTiff)
+	}
+
+	entries := make(ifd, entriesCount)
+	for idx := range entriesCount {
+		entryStart := int(offset) + 2 + idx*12
+		if entryStart+12 > len(r.data) {
+			return nil, fmt.Errorf("%w: IFD entry out of bounds", errInvalidTiff)
+		}
+		raw := r.data[entryStart : entryStart+12]
+
+		entry := ifdEntry{
+			fieldType: r.order.Uint16(raw[2:4]),
+			count:     r.order.Uint32(raw[4:8]),
+		}
+
+		size := uint64(typeSize(entry.fieldType)) * uint64(entry.count)
+		switch {
+		case size == 0:
+			continue
+		case size <= 4:
+			entry.value = raw[8 : 8+size]
+		default:
+			valueOffset := uint64(r.order.Uint32(raw[8:12]))
+			if valueOffset+size > uint64(len(r.data)) {
+				continue
+			}
+			entry.value = r.data[valueOffset : valueOffset+size]
+		}
+
+		entries[r.order.Uint16(raw[0:2])] = entry
+	}
+
+	return entries, nil
+}
+
+// subIFD reads the IFD pointed by tag in parent. Returns an empty IFD if
+// missing or invalid.
+func (r *tiffReader) subIFD(parent ifd, tag uint16) ifd {
+	offset, ok := r.uint(parent, tag)
+	if !ok {
+		return ifd{}
+	}
+
+	subIFD, err := r.readIFD(offset)
+	if err != nil {
+		return ifd{}
+	}
+
+	return subIFD
+}
+
+func (r *tiffReader) str(entries ifd, tag uint16) string {
+	entry, found := entries[tag]
+	if !found || entry.fieldType != typeASCII {
+		return ""
+	}
+
+	value, _, _ := bytes.Cut(entry.value, []byte{0})
+	return strings.TrimSpace(string(value))
+}
+
+func (r *tiffReader) uint(entries ifd, tag uint16) (uint32, bool) {
+	entry, found := entries[tag]
+	if !found || len(entry.value) == 0 {
+		return 0, false
+	}
+
+	switch entry.fieldType {
+	case typeByte, typeUndefined:
+		return uint32(entry.value[0]), true
+	case typeShort:
+		return uint32(r.order.Uint16(entry.value)), true
+	case typeLong, typeSLong, typeIFD:
+		return r.order.Uint32(entry.value), true
+	default:
+		return 0, false
+	}
+}
+
+// rational returns numerator and denominator of idx value in entry
+func (r *tiffReader) rational(entries ifd, tag uint16, idx int) (int64, int64, bool) {
+	entry, found := entries[tag]
+	if !found || (entry.fieldType != typeRational && entry.fieldType != typeSRational) {
+		return 0, 0, false
+	}
+
+	start := idx * 8
+	if start+8 > len(entry.value) {
+		return 0, 0, false
+	}
+
+	num := r.order.Uint32(entry.value[start:])
+	den := r.order.Uint32(entry.value[start+4:])
+	if den == 0 {
+		return 0, 0, false
+	}
+
+	if entry.fieldType == typeSRational {
+		return int64(int32(num)), int64(int32(den)), true
+	}
+	return int64(num), int64(den), true
+}
+
+func (r *tiffReader) float(entries ifd, tag uint16, idx int) (float64, bool) {
+	num, den, ok := r.rational(entries, tag, idx)
+	if !ok {
+		return 0, false
+	}
+
+	return float64(num) / float64(den), true
+}
+
+func (r *tiffReader) exposure(exifIFD ifd) *models.Exposure {
+	exposure := new(models.Exposure)
+
+	if num, den, ok := r.rational(exifIFD, tagExposureTime, 0); ok {
+		exposure.ExposureTime = formatExposureTime(num, den)
+	}
+	if fNumber, ok := r.float(exifIFD, tagFNumber, 0); ok {
+		exposure.FNumber = roundTo(fNumber, 2)
+	}
+	if iso, ok := r.uint(exifIFD, tagISO); ok {
+		exposure.ISO = int(iso)
+	}
+	if focalLength, ok := r.float(exifIFD, tagFocalLength, 0); ok {
+		exposure.FocalLength = roundTo(focalLength, 2)
+	}
+
+	if *exposure == (models.Exposure{}) {
+		return nil
+	}
+	return exposure
+}
+
+func (r *tiffReader) location(gpsIFD ifd) *models.GeoLocation {
+	latitude, okLat := r.gpsCoordinate(gpsIFD, tagGPSLatitude)
+	longitude, okLon := r.gpsCoordinate(gpsIFD, tagGPSLongitude)
+	if !okLat || !okLon {
+		return nil
+	}
+
+	if r.str(gpsIFD, tagGPSLatitudeRef) == "S" {
+		latitude = -latitude
+	}
+	if r.str(gpsIFD, tagGPSLongitudeRef) == "W" {
+		longitude = -longitude
+	}
+
+	location := &models.GeoLocation{
+		Latitude:  roundTo(latitude, 6),
+		Longitude: roundTo(longitude, 6),
+	}
+
+	if altitude, ok := r.float(gpsIFD, tagGPSAltitude, 0); ok {
+		if ref, _ := r.uint(gpsIFD, tagGPSAltitudeRef); ref == 1 {
+			altitude = -altitude
+		}
+		altitude = roundTo(altitude, 2)
+		location.Altitude = &altitude
+	}
+
+	return location
+}
+
+// gpsCoordinate converts degrees, minutes and seconds rationals into
+// decimal degrees
+func (r *tiffReader) gpsCoordinate(gpsIFD ifd, tag uint16) (float64, bool) {
+	var coordinate float64
+	for idx, divisor := range []float64{1, 60, 3600} {
+		value, ok := r.float(gpsIFD, tag, idx)
+		if !ok {
+			return 0, false
+		}
+		coordinate += value / divisor
+	}
+
+	return coordinate, true
+}
+
+func typeSize(fieldType uint16) int {
+	switch fieldType {
+	case typeByte, typeASCII, typeUndefined:
+		return 1
+	case typeShort:
+		return 2
+	case typeLong, typeSLong, typeIFD:
+		return 4
+	case typeRational, typeSRational:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// formatExifTime converts EXIF date time ('2006:01:02 15:04:05') and
+// optional offset ('+02:00') into RFC 3339.
+func formatExifTime(dateTime string, offset string) string {
+	parsed, err := time.Parse("2006:01:02 15:04:05", dateTime)
+	if err != nil {
+		return ""
+	}
+
+	if offsetTime, err := time.Parse("-07:00", offset); err == nil {
+		_, offsetSecs := offsetTime.Zone()
+		return time.Date(
+			parsed.Year(), parsed.Month(), parsed.Day(),
+			parsed.Hour(), parsed.Minute(), parsed.Second(), 0,
+			time.FixedZone("", offsetSecs),
+		).Format(time.RFC3339)
+	}
+
+	return parsed.Format("2006-01-02T15:04:05")
+}
+
+func formatExposureTime(num int64, den int64) string {
+	if num <= 0 {
+		return ""
+	}
+	if num >= den {
+		return strconv.FormatFloat(float64(num)/float64(den), 'f', -1, 64)
+	}
+
+	// Normalize fractions like 10/1250 into 1/125
+	return fmt.Sprintf("1/%s", strconv.FormatFloat(
+		roundTo(float64(den)/float64(num), 1), 'f', -1, 64,
+	))
+}
+
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
+
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
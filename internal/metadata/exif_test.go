@@ -0,0 +1,32 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestAppleContentIdentifier(t *testing.T) {
+	const contentIdentifier = "1E2D6A4C-7B8F-4E3A-9C1D-0F5B2A6E8D47"
+
+	// Header, version and byte order, then an IFD with a single entry
+	// whose value follows the IFD
+	makerNote := append([]byte{}, appleMakerNoteHeader...)
+	makerNote = append(makerNote, 0x00, 0x01, 'M', 'M')
+	makerNote = binary.BigEndian.AppendUint16(makerNote, 1)
+	makerNote = binary.BigEndian.AppendUint16(makerNote, tagAppleContentIdentifier)
+	makerNote = binary.BigEndian.AppendUint16(makerNote, typeASCII)
+	makerNote = binary.BigEndian.AppendUint32(makerNote, uint32(len(contentIdentifier)+1))
+	makerNote = binary.BigEndian.AppendUint32(makerNote, uint32(len(makerNote)+8))
+	makerNote = binary.BigEndian.AppendUint32(makerNote, 0)
+	makerNote = append(append(makerNote, contentIdentifier...), 0)
+
+	exifIFD := ifd{tagMakerNote: {fieldType: typeUndefined, value: makerNote}}
+	if got := appleContentIdentifier(exifIFD); got != contentIdentifier {
+		t.Errorf("content identifier = %q, want %q", got, contentIdentifier)
+	}
+
+	otherMakerNote := ifd{tagMakerNote: {fieldType: typeUndefined, value: []byte("Nikon\x00")}}
+	if got := appleContentIdentifier(otherMakerNote); got != "" {
+		t.Errorf("expected no content identifier, got %q", got)
+	}
+}
@@ -0,0 +1,326 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// Additional tags written by EncodeExif
+const (
+	tagExifVersion  = 0x9000
+	tagGPSVersionID = 0x0000
+)
+
+// exifField is a TIFF field ready to be written
+type exifField struct {
+	tag       uint16
+	fieldType uint16
+	count     uint32
+	value     []byte
+}
+
+// EncodeExif builds TIFF structured EXIF data (as stored in JPEG APP1
+// segments after the 'Exif' header) holding the given groups of meta.
+// Orientation is never written since thumbnails are stored upright.
+// Returns nil when none of the groups has values.
+func EncodeExif(
+	meta *models.MediaMetadata,
+	fields []models.MetadataField,
+) []byte {
+	if meta == nil {
+		return nil
+	}
+
+	var ifd0, exifIFD, gpsIFD []exifField
+	for _, field := range fields {
+		switch field {
+		case models.MetadataCaptureTime:
+			exifIFD = append(exifIFD, captureTimeFields(meta.CaptureTime)...)
+		case models.MetadataCamera:
+			ifd0 = appendASCII(ifd0, tagMake, meta.CameraMake)
+			ifd0 = appendASCII(ifd0, tagModel, meta.CameraModel)
+			exifIFD = appendASCII(exifIFD, tagLensModel, meta.LensModel)
+		case models.MetadataExposure:
+			exifIFD = append(exifIFD, exposureFields(meta.Exposure)...)
+		case models.MetadataLocation:
+			gpsIFD = append(gpsIFD, locationFields(meta.Location)...)
+		}
+	}
+
+	if len(ifd0) == 0 && len(exifIFD) == 0 && len(gpsIFD) == 0 {
+		return nil
+	}
+
+	if len(exifIFD) > 0 {
+		exifIFD = append(exifIFD, exifField{
+			tag:       tagExifVersion,
+			fieldType: typeUndefined,
+			count:     4,
+			value:     []byte("0232"),
+		})
+	}
+	if len(gpsIFD) > 0 {
+		gpsIFD = append(gpsIFD, exifField{
+			tag:       tagGPSVersionID,
+			fieldType: typeByte,
+			count:     4,
+			value:     []byte{2, 3, 0, 0},
+		})
+	}
+
+	// Sub IFDs are laid out after IFD0, which points to them
+	const headerSize = 8
+	if len(exifIFD) > 0 {
+		ifd0 = append(ifd0, longField(tagExifIFD, 0))
+	}
+	if len(gpsIFD) > 0 {
+		ifd0 = append(ifd0, longField(tagGPSIFD, 0))
+	}
+
+	exifOffset := headerSize + ifdSize(ifd0)
+	gpsOffset := exifOffset + ifdSize(exifIFD)
+	for idx := range ifd0 {
+		switch ifd0[idx].tag {
+		case tagExifIFD:
+			ifd0[idx] = longField(tagExifIFD, uint32(exifOffset))
+		case tagGPSIFD:
+			ifd0[idx] = longField(tagGPSIFD, uint32(gpsOffset))
+		}
+	}
+
+	data := []byte{'M', 'M', 0, 42, 0, 0, 0, headerSize}
+	data = appendIFD(data, ifd0)
+	data = appendIFD(data, exifIFD)
+	data = appendIFD(data, gpsIFD)
+
+	return data
+}
+
+// EncodeOrientation builds TIFF structured EXIF data holding only the
+// given orientation (1 to 8), for intermediary images whose pixels are
+// rotated by the decoder
+func EncodeOrientation(orientation int) []byte {
+	ifd0 := []exifField{{
+		tag:       tagOrientation,
+		fieldType: typeShort,
+		count:     1,
+		value:     binary.BigEndian.AppendUint16(nil, uint16(orientation)),
+	}}
+
+	data := []byte{'M', 'M', 0, 42, 0, 0, 0, 8}
+	return appendIFD(data, ifd0)
+}
+
+// ifdSize returns bytes taken by an IFD and the values not fitting in
+// its entries
+func ifdSize(fields []exifField) int {
+	if len(fields) == 0 {
+		return 0
+	}
+
+	size := 2 + len(fields)*12 + 4
+	for _, field := range fields {
+		if len(field.value) > 4 {
+			size += len(field.value) + len(field.value)%2
+		}
+	}
+
+	return size
+}
+
+// appendIFD writes fields as an IFD starting at the end of data
+func appendIFD(data []byte, fields []exifField) []byte {
+	if len(fields) == 0 {
+		return data
+	}
+
+	slices.SortFunc(fields, func(a, b exifField) int {
+		return int(a.tag) - int(b.tag)
+	})
+
+	order := binary.BigEndian
+	valuesOffset := len(data) + 2 + len(fields)*12 + 4
+
+	var values []byte
+	data = order.AppendUint16(data, uint16(len(fields)))
+	for _, field := range fields {
+		data = order.AppendUint16(data, field.tag)
+		data = order.AppendUint16(data, field.fieldType)
+		data = order.AppendUint32(data, field.count)
+
+		if len(field.value) <= 4 {
+			inline := make([]byte, 4)
+			copy(inline, field.value)
+			data = append(data, inline...)
+			continue
+		}
+
+		data = order.AppendUint32(data, uint32(valuesOffset+len(values)))
+		values = append(values, field.value...)
+		if len(values)%2 == 1 {
+			values = append(values, 0)
+		}
+	}
+
+	// No next IFD
+	data = order.AppendUint32(data, 0)
+	return append(data, values...)
+}
+
+func appendASCII(fields []exifField, tag uint16, value string) []exifField {
+	if value == "" {
+		return fields
+	}
+
+	return append(fields, exifField{
+		tag:       tag,
+		fieldType: typeASCII,
+		count:     uint32(len(value) + 1),
+		value:     append([]byte(value), 0),
+	})
+}
+
+func longField(tag uint16, value uint32) exifField {
+	return exifField{
+		tag:       tag,
+		fieldType: typeLong,
+		count:     1,
+		value:     binary.BigEndian.AppendUint32(nil, value),
+	}
+}
+
+// rationalField writes each value as a fraction over denominator
+func rationalField(tag uint16, denominator uint32, values ...float64) exifField {
+	var value []byte
+	for _, v := range values {
+		value = binary.BigEndian.AppendUint32(value, uint32(math.Round(v*float64(denominator))))
+		value = binary.BigEndian.AppendUint32(value, denominator)
+	}
+
+	return exifField{
+		tag:       tag,
+		fieldType: typeRational,
+		count:     uint32(len(values)),
+		value:     value,
+	}
+}
+
+// captureTimeFields converts an RFC 3339 capture time, with or without
+// offset, into EXIF date time and offset fields
+func captureTimeFields(captureTime string) []exifField {
+	if parsed, err := time.Parse(time.RFC3339, captureTime); err == nil {
+		var fields []exifField
+		fields = appendASCII(fields, tagDateTimeOriginal, parsed.Format("2006:01:02 15:04:05"))
+		return appendASCII(fields, tagOffsetTimeOriginal, parsed.Format("-07:00"))
+	}
+
+	if parsed, err := time.Parse("2006-01-02T15:04:05", captureTime); err == nil {
+		return appendASCII(nil, tagDateTimeOriginal, parsed.Format("2006:01:02 15:04:05"))
+	}
+
+	return nil
+}
+
+func exposureFields(exposure *models.Exposure) []exifField {
+	if exposure == nil {
+		return nil
+	}
+
+	var fields []exifField
+	if exposureTime, ok := parseExposureTime(exposure.ExposureTime); ok {
+		fields = append(fields, exposureTime)
+	}
+	if exposure.FNumber > 0 {
+		fields = append(fields, rationalField(tagFNumber, 100, exposure.FNumber))
+	}
+	if exposure.ISO > 0 && exposure.ISO <= math.MaxUint16 {
+		fields = append(fields, exifField{
+			tag:       tagISO,
+			fieldType: typeShort,
+			count:     1,
+			value:     binary.BigEndian.AppendUint16(nil, uint16(exposure.ISO)),
+		})
+	}
+	if exposure.FocalLength > 0 {
+		fields = append(fields, rationalField(tagFocalLength, 100, exposure.FocalLength))
+	}
+
+	return fields
+}
+
+// parseExposureTime converts exposure times formatted by
+// formatExposureTime back into a rational field
+func parseExposureTime(value string) (exifField, bool) {
+	if denominator, found := strings.CutPrefix(value, "1/"); found {
+		den, err := strconv.ParseFloat(denominator, 64)
+		if err != nil || den <= 0 {
+			return exifField{}, false
+		}
+
+		// Tenths keep fractions like '1/2.5' exact
+		field := rationalField(tagExposureTime, uint32(math.Round(den*10)), 1/den)
+		return field, true
+	}
+
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return exifField{}, false
+	}
+
+	return rationalField(tagExposureTime, 1000, seconds), true
+}
+
+func locationFields(location *models.GeoLocation) []exifField {
+	if location == nil {
+		return nil
+	}
+
+	latitudeRef, longitudeRef := "N", "E"
+	if location.Latitude < 0 {
+		latitudeRef = "S"
+	}
+	if location.Longitude < 0 {
+		longitudeRef = "W"
+	}
+
+	var fields []exifField
+	fields = appendASCII(fields, tagGPSLatitudeRef, latitudeRef)
+	fields = append(fields, rationalField(tagGPSLatitude, 1000, dms(location.Latitude)...))
+	fields = appendASCII(fields, tagGPSLongitudeRef, longitudeRef)
+	fields = append(fields, rationalField(tagGPSLongitude, 1000, dms(location.Longitude)...))
+
+	if location.Altitude != nil {
+		altitudeRef := byte(0)
+		if *location.Altitude < 0 {
+			altitudeRef = 1
+		}
+
+		fields = append(fields,
+			exifField{
+				tag:       tagGPSAltitudeRef,
+				fieldType: typeByte,
+				count:     1,
+				value:     []byte{altitudeRef},
+			},
+			rationalField(tagGPSAltitude, 100, math.Abs(*location.Altitude)),
+		)
+	}
+
+	return fields
+}
+
+// dms splits decimal degrees into degrees, minutes and seconds
+func dms(coordinate float64) []float64 {
+	coordinate = math.Abs(coordinate)
+	degrees := math.Floor(coordinate)
+	minutes := math.Floor((coordinate - degrees) * 60)
+	seconds := (coordinate - degrees - minutes/60) * 3600
+
+	return []float64{degrees, minutes, seconds}
+}
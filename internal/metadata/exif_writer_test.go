@@ -0,0 +1,79 @@
+package metadata
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+func TestEncodeExif_RoundTrip(t *testing.T) {
+	altitude := -12.5
+	meta := &models.MediaMetadata{
+		CaptureTime: "2025-05-03T13:06:08-06:00",
+		CameraMake:  "Apple",
+		CameraModel: "iPhone 16 Pro Max",
+		LensModel:   "back triple camera 6.765mm f/1.78",
+		Exposure: &models.Exposure{
+			ExposureTime: "1/400",
+			FNumber:      1.78,
+			ISO:          250,
+			FocalLength:  6.76,
+		},
+		Location: &models.GeoLocation{
+			Latitude:  -33.856784,
+			Longitude: 151.215297,
+			Altitude:  &altitude,
+		},
+		Orientation: 6,
+	}
+
+	allFields := []models.MetadataField{
+		models.MetadataCaptureTime,
+		models.MetadataCamera,
+		models.MetadataExposure,
+		models.MetadataLocation,
+	}
+
+	decoded := new(models.MediaMetadata)
+	if err := parseExif(EncodeExif(meta, allFields), decoded); err != nil {
+		t.Fatalf("failed to parse encoded EXIF: %v", err)
+	}
+
+	// Thumbnails are stored upright
+	expected := *meta
+	expected.Orientation = 0
+	if !reflect.DeepEqual(*decoded, expected) {
+		t.Errorf("expected %+v, got %+v", expected, *decoded)
+	}
+}
+
+func TestEncodeExif_KeepsOnlySelectedFields(t *testing.T) {
+	meta := &models.MediaMetadata{
+		CaptureTime: "2019-08-13T15:20:56",
+		CameraMake:  "Canon",
+		Location:    &models.GeoLocation{Latitude: 1, Longitude: 2},
+	}
+
+	decoded := new(models.MediaMetadata)
+	exif := EncodeExif(meta, []models.MetadataField{models.MetadataCaptureTime})
+	if err := parseExif(exif, decoded); err != nil {
+		t.Fatalf("failed to parse encoded EXIF: %v", err)
+	}
+
+	expected := models.MediaMetadata{CaptureTime: "2019-08-13T15:20:56"}
+	if *decoded != expected {
+		t.Errorf("expected %+v, got %+v", expected, *decoded)
+	}
+}
+
+func TestEncodeExif_NothingToKeep(t *testing.T) {
+	meta := &models.MediaMetadata{CameraMake: "Canon"}
+
+	if exif := EncodeExif(meta, nil); exif != nil {
+		t.Errorf("expected nil EXIF for empty keep list, got %d bytes", len(exif))
+	}
+	if exif := EncodeExif(meta, []models.MetadataField{models.MetadataLocation}); exif != nil {
+		t.Errorf("expected nil EXIF for missing location, got %d bytes", len(exif))
+	}
+}
@@ -0,0 +1,63 @@
+package metadata
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// Extractor reads capture metadata from originals without decoding
+// them. Only headers and metadata containers are read from disk.
+type Extractor struct{}
+
+func NewExtractor() *Extractor {
+	return &Extractor{}
+}
+
+// Extract reads metadata of file at absFilePath. Returns nil metadata
+// and no error when format carries no metadata or none was found.
+func (e *Extractor) Extract(
+	absFilePath string,
+	fileFormat format.Format,
+) (*models.MediaMetadata, error) {
+	file, err := os.Open(absFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file for metadata: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file for metadata: %w", err)
+	}
+	fileSize := fileInfo.Size()
+
+	var meta *models.MediaMetadata
+	switch fileFormat {
+	case format.JPEG:
+		meta, err = readJpeg(file)
+	case format.WEBP, format.ANIMATED_WEBP:
+		meta, err = readWebp(file, fileSize)
+	case format.HEIF, format.AVIF:
+		meta, err = readHeif(file, fileSize)
+	case format.MOV, format.MP4, format.M4V, format.THREE_GP:
+		meta, err = readQuickTime(file, fileSize)
+	default:
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to extract %s metadata: %w",
+			fileFormat,
+			err,
+		)
+	}
+
+	if meta.IsEmpty() {
+		return nil, nil
+	}
+	return meta, nil
+}
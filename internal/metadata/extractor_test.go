@@ -0,0 +1,155 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+func TestExtractor_Images(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		format   format.Format
+		expected models.MediaMetadata
+	}{
+		{
+			name:     "jpeg without offset",
+			filename: "1 house.jpg",
+			format:   format.JPEG,
+			expected: models.MediaMetadata{
+				CaptureTime: "2019-08-13T15:20:56",
+				CameraMake:  "Canon",
+				CameraModel: "Canon EOS 5D Mark IV",
+				LensModel:   "EF16-35mm f/2.8L III USM",
+				Width:       1638,
+				Height:      2048,
+				Orientation: 1,
+			},
+		},
+		{
+			name:     "jpeg with offset",
+			filename: "2 museum.jpeg",
+			format:   format.JPEG,
+			expected: models.MediaMetadata{
+				CaptureTime: "2025-05-03T13:06:08-06:00",
+				CameraMake:  "Apple",
+				CameraModel: "iPhone 16 Pro Max",
+				LensModel:   "iPhone 16 Pro Max back triple camera 6.765mm f/1.78",
+				Width:       3520,
+				Height:      1980,
+				Orientation: 1,
+			},
+		},
+		{
+			name:     "heif",
+			filename: "4 thai_no_edits.heic",
+			format:   format.HEIF,
+			expected: models.MediaMetadata{
+				CaptureTime: "2025-11-13T12:36:24+07:00",
+				CameraMake:  "Apple",
+				CameraModel: "iPhone 16 Pro Max",
+				LensModel:   "iPhone 16 Pro Max back triple camera 2.22mm f/2.2",
+				Width:       4032,
+				Height:      3024,
+				Orientation: 6,
+
+				ContentIdentifier: "343C4976-48AF-4BDA-B67C-7C020984234A",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			meta := extract(t, tc.filename, tc.format)
+			if meta == nil {
+				t.Fatal("expected metadata, got nil")
+			}
+
+			// Exposure and location are verified separately
+			got := *meta
+			got.Exposure, got.Location = nil, nil
+			if got != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestExtractor_ExposureAndLocation(t *testing.T) {
+	meta := extract(t, "2 museum.jpeg", format.JPEG)
+
+	expectedExposure := models.Exposure{
+		ExposureTime: "1/60",
+		FNumber:      1.78,
+		ISO:          250,
+		FocalLength:  6.76,
+	}
+	if meta.Exposure == nil || *meta.Exposure != expectedExposure {
+		t.Errorf("expected exposure %+v, got %+v", expectedExposure, meta.Exposure)
+	}
+
+	location := meta.Location
+	if location == nil {
+		t.Fatal("expected location, got nil")
+	}
+	if location.Latitude != 19.314814 || location.Longitude != -99.185189 {
+		t.Errorf(
+			"unexpected coordinates: %f, %f",
+			location.Latitude,
+			location.Longitude,
+		)
+	}
+	if location.Altitude == nil || *location.Altitude != 2326.41 {
+		t.Errorf("unexpected altitude: %v", location.Altitude)
+	}
+}
+
+func TestExtractor_Video(t *testing.T) {
+	meta := extract(t, "11 whatsapp.mp4", format.MP4)
+	if meta == nil {
+		t.Fatal("expected metadata, got nil")
+	}
+
+	if meta.Width != 576 || meta.Height != 1024 {
+		t.Errorf("expected 576x1024, got %dx%d", meta.Width, meta.Height)
+	}
+}
+
+func TestExtractor_NoMetadata(t *testing.T) {
+
+	// Plain VP8 file, without EXIF or XMP chunks
+	if meta := extract(t, "7 flower.webp", format.WEBP); meta != nil {
+		t.Errorf("expected nil metadata, got %+v", meta)
+	}
+}
+
+func TestExtractor_InvalidFile(t *testing.T) {
+	_, err := NewExtractor().Extract(
+		testutils.TestFilePath("7 flower.webp"),
+		format.JPEG,
+	)
+	if err == nil {
+		t.Fatal("expected error for mismatched format, got nil")
+	}
+}
+
+func extract(
+	t *testing.T,
+	filename string,
+	fileFormat format.Format,
+) *models.MediaMetadata {
+	t.Helper()
+
+	meta, err := NewExtractor().Extract(
+		testutils.TestFilePath(filename),
+		fileFormat,
+	)
+	if err != nil {
+		t.Fatalf("failed to extract metadata: %v", err)
+	}
+
+	return meta
+}
@@ -0,0 +1,235 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// heifItem locates data of a HEIF item within the file
+type heifItem struct {
+	itemType    string
+	contentType string
+	offset      int64
+	length      int64
+}
+
+// readHeif reads EXIF and XMP items referenced from the 'meta' box of a
+// HEIF file. Image data is never loaded.
+func readHeif(reader io.ReaderAt, fileSize int64) (*models.MediaMetadata, error) {
+	topBoxes, err := readBoxes(reader, 0, fileSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEIF boxes: %w", err)
+	}
+
+	metaBox, found := findBox(topBoxes, "meta")
+	if !found {
+		return nil, errors.New("HEIF 'meta' box not found")
+	}
+
+	metaPayload, err := loadBox(reader, metaBox)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := parseHeifItems(metaPayload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HEIF items: %w", err)
+	}
+
+	meta := new(models.MediaMetadata)
+	var xmpPacket []byte
+
+	for _, item := range items {
+		isXmp := item.itemType == "mime" &&
+			item.contentType == "application/rdf+xml"
+		if item.itemType != "Exif" && !isXmp {
+			continue
+		}
+		if item.length > maxMetadataChunkSize ||
+			item.offset+item.length > fileSize {
+			continue
+		}
+
+		data := make([]byte, item.length)
+		if _, err := reader.ReadAt(data, item.offset); err != nil {
+			return nil, fmt.Errorf("failed to read HEIF item: %w", err)
+		}
+
+		if isXmp {
+			xmpPacket = data
+			continue
+		}
+
+		// Exif items start with offset to the TIFF header
+		cursor := &byteCursor{data: data}
+		tiffOffset := cursor.uint(4)
+		if cursor.err != nil || tiffOffset > uint64(len(data)-4) {
+			continue
+		}
+		if err := parseExif(data[4+tiffOffset:], meta); err != nil {
+			return nil, fmt.Errorf("failed to parse EXIF: %w", err)
+		}
+	}
+
+	if xmpPacket != nil {
+		parseXmp(xmpPacket, meta)
+	}
+
+	return meta, nil
+}
+
+// parseHeifItems joins item types from 'iinf' with file locations from
+// 'iloc'. Items not stored as a single extent in the file are skipped.
+func parseHeifItems(metaPayload []byte) ([]heifItem, error) {
+	children, err := childBoxes(metaPayload, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	iinfBox, foundIinf := findBox(children, "iinf")
+	ilocBox, foundIloc := findBox(children, "iloc")
+	if !foundIinf || !foundIloc {
+		return nil, nil
+	}
+
+	itemTypes, err := parseIinf(boxPayload(metaPayload, iinfBox))
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := parseIloc(boxPayload(metaPayload, ilocBox))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []heifItem
+	for itemID, item := range itemTypes {
+		location, found := locations[itemID]
+		if !found {
+			continue
+		}
+
+		item.offset = location.offset
+		item.length = location.length
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// parseIinf maps item IDs to their type
+func parseIinf(payload []byte) (map[uint32]heifItem, error) {
+	cursor := &byteCursor{data: payload}
+	version := cursor.uint(1)
+	cursor.take(3)
+
+	countSize := 2
+	if version > 0 {
+		countSize = 4
+	}
+	cursor.uint(countSize)
+	if cursor.err != nil {
+		return nil, cursor.err
+	}
+
+	entries, err := readBoxes(bytesReaderAt(payload), int64(cursor.pos), int64(len(payload)))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[uint32]heifItem)
+	for _, entry := range entries {
+		if entry.boxType != "infe" {
+			continue
+		}
+
+		infe := &byteCursor{data: boxPayload(payload, entry)}
+		infeVersion := infe.uint(1)
+		infe.take(3)
+
+		// Item types were introduced in version 2
+		if infeVersion < 2 {
+			continue
+		}
+
+		idSize := 2
+		if infeVersion >= 3 {
+			idSize = 4
+		}
+		itemID := uint32(infe.uint(idSize))
+		infe.take(2) // protection index
+
+		item := heifItem{itemType: string(infe.take(4))}
+		infe.cstring() // item name
+		if item.itemType == "mime" {
+			item.contentType = infe.cstring()
+		}
+
+		if infe.err == nil {
+			items[itemID] = item
+		}
+	}
+
+	return items, nil
+}
+
+// parseIloc maps item IDs to their location within the file
+func parseIloc(payload []byte) (map[uint32]heifItem, error) {
+	cursor := &byteCursor{data: payload}
+	version := cursor.uint(1)
+	cursor.take(3)
+
+	sizes := cursor.uint(2)
+	offsetSize := int(sizes >> 12 & 0xF)
+	lengthSize := int(sizes >> 8 & 0xF)
+	baseOffsetSize := int(sizes >> 4 & 0xF)
+	indexSize := 0
+	if version == 1 || version == 2 {
+		indexSize = int(sizes & 0xF)
+	}
+
+	idSize := 2
+	if version == 2 {
+		idSize = 4
+	}
+	itemsCount := int(cursor.uint(idSize))
+
+	locations := make(map[uint32]heifItem)
+	for range itemsCount {
+		itemID := uint32(cursor.uint(idSize))
+
+		constructionMethod := uint64(0)
+		if version == 1 || version == 2 {
+			constructionMethod = cursor.uint(2) & 0xF
+		}
+		cursor.uint(2) // data reference index
+		baseOffset := cursor.uint(baseOffsetSize)
+
+		extentsCount := int(cursor.uint(2))
+		var extentOffset, extentLength uint64
+		for range extentsCount {
+			cursor.uint(indexSize)
+			extentOffset = cursor.uint(offsetSize)
+			extentLength = cursor.uint(lengthSize)
+		}
+
+		if cursor.err != nil {
+			return nil, cursor.err
+		}
+
+		// Only items stored in the file as a single extent are supported
+		if constructionMethod != 0 || extentsCount != 1 {
+			continue
+		}
+
+		locations[itemID] = heifItem{
+			offset: int64(baseOffset + extentOffset),
+			length: int64(extentLength),
+		}
+	}
+
+	return locations, nil
+}
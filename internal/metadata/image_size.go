@@ -0,0 +1,267 @@
+package metadata
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+)
+
+// JPEG XL files are either a bare codestream or an ISO BMFF container
+// holding it in a 'jxlc' box, or split across 'jxlp' boxes
+var (
+	jxlCodestreamSignature = []byte{0xFF, 0x0A}
+	jxlContainerSignature  = []byte{0, 0, 0, 0x0C, 'J', 'X', 'L', ' ', 0x0D, 0x0A, 0x87, 0x0A}
+)
+
+// Width of JPEG XL images declared as a ratio of their height, indexed
+// by the 3 bits ratio field of the size header
+var jxlRatios = [8][2]int{{0, 0}, {1, 1}, {12, 10}, {4, 3}, {3, 2}, {16, 9}, {5, 4}, {2, 1}}
+
+var errNoImageSize = errors.New("image dimensions not declared")
+
+// ImageSize reads width and height an image declares in its container
+// header, without decoding pixels. Supports formats decoded by an
+// external converter (format.ConvertedFormats).
+//
+// HEIF files declare a size for each image item (tiles, thumbnails and
+// the primary image), the largest one is returned.
+func ImageSize(absFilePath string, fileFormat format.Format) (int, int, error) {
+	file, err := os.Open(absFilePath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open file for dimensions: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to stat file for dimensions: %w", err)
+	}
+	fileSize := fileInfo.Size()
+
+	var width, height int
+	switch fileFormat {
+	case format.HEIF, format.AVIF:
+		width, height, err = heifImageSize(file, fileSize)
+	case format.TIFF:
+		width, height, err = tiffImageSize(file)
+	case format.JXL:
+		width, height, err = jxlImageSize(file, fileSize)
+	default:
+		return 0, 0, fmt.Errorf("dimensions of %s files are not supported", fileFormat)
+	}
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read %s dimensions: %w", fileFormat, err)
+	}
+	return width, height, nil
+}
+
+// heifImageSize reads 'ispe' item properties (meta > iprp > ipco)
+func heifImageSize(reader io.ReaderAt, fileSize int64) (int, int, error) {
+	topBoxes, err := readBoxes(reader, 0, fileSize)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	metaBox, found := findBox(topBoxes, "meta")
+	if !found {
+		return 0, 0, errors.New("HEIF 'meta' box not found")
+	}
+
+	metaPayload, err := loadBox(reader, metaBox)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	payload, found, err := nestedBox(metaPayload, 4, "iprp", "ipco")
+	if err != nil {
+		return 0, 0, err
+	}
+	if !found {
+		return 0, 0, errNoImageSize
+	}
+
+	properties, err := childBoxes(payload, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var width, height int
+	for _, property := range properties {
+		if property.boxType != "ispe" {
+			continue
+		}
+
+		// Version and flags, then width and height
+		cursor := &byteCursor{data: boxPayload(payload, property)}
+		cursor.take(4)
+		ispeWidth := int(cursor.uint(4))
+		ispeHeight := int(cursor.uint(4))
+		if cursor.err != nil {
+			return 0, 0, cursor.err
+		}
+
+		if int64(ispeWidth)*int64(ispeHeight) > int64(width)*int64(height) {
+			width, height = ispeWidth, ispeHeight
+		}
+	}
+
+	if width == 0 || height == 0 {
+		return 0, 0, errNoImageSize
+	}
+	return width, height, nil
+}
+
+// tiffImageSize reads width and length tags of the first IFD. Only the
+// IFD itself is loaded, as both tags store their value inline.
+func tiffImageSize(reader io.ReaderAt) (int, int, error) {
+	header := make([]byte, 8)
+	if _, err := reader.ReadAt(header, 0); err != nil {
+		return 0, 0, fmt.Errorf("failed to read TIFF header: %w", err)
+	}
+
+	tiff, err := newTiffReader(header)
+	if err != nil {
+		return 0, 0, err
+	}
+	ifdOffset := int64(tiff.firstIFDOffset())
+
+	countBytes := make([]byte, 2)
+	if _, err := reader.ReadAt(countBytes, ifdOffset); err != nil {
+		return 0, 0, fmt.Errorf("failed to read TIFF IFD: %w", err)
+	}
+	entriesCount := int(tiff.order.Uint16(countBytes))
+	if entriesCount > maxIFDEntries {
+		return 0, 0, fmt.Errorf("%w: too many IFD entries", errInvalidTiff)
+	}
+
+	tiff.data = make([]byte, 2+entriesCount*12)
+	if _, err := reader.ReadAt(tiff.data, ifdOffset); err != nil {
+		return 0, 0, fmt.Errorf("failed to read TIFF IFD: %w", err)
+	}
+
+	entries, err := tiff.readIFD(0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	width, foundWidth := tiff.uint(entries, tagImageWidth)
+	height, foundHeight := tiff.uint(entries, tagImageLength)
+	if !foundWidth || !foundHeight {
+		return 0, 0, errNoImageSize
+	}
+
+	return int(width), int(height), nil
+}
+
+// jxlImageSize reads the size header following the codestream signature
+func jxlImageSize(reader io.ReaderAt, fileSize int64) (int, int, error) {
+	head := make([]byte, min(fileSize, int64(len(jxlContainerSignature))))
+	if _, err := reader.ReadAt(head, 0); err != nil {
+		return 0, 0, fmt.Errorf("failed to read JPEG XL signature: %w", err)
+	}
+
+	codestreamOffset := int64(0)
+	if bytes.Equal(head, jxlContainerSignature) {
+		var err error
+		codestreamOffset, err = jxlCodestreamOffset(reader, fileSize)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	// Signature and size header, which takes 9 bytes at most
+	codestream := make([]byte, 11)
+	read, err := reader.ReadAt(codestream, codestreamOffset)
+	if err != nil && err != io.EOF {
+		return 0, 0, fmt.Errorf("failed to read JPEG XL codestream: %w", err)
+	}
+	codestream = codestream[:read]
+	if !bytes.HasPrefix(codestream, jxlCodestreamSignature) {
+		return 0, 0, errors.New("JPEG XL codestream signature not found")
+	}
+
+	return parseJxlSizeHeader(codestream[2:])
+}
+
+// jxlCodestreamOffset locates the codestream in a JPEG XL container: the
+// payload of 'jxlc' box or, after its 4 bytes index, of first 'jxlp' box
+func jxlCodestreamOffset(reader io.ReaderAt, fileSize int64) (int64, error) {
+	boxes, err := readBoxes(reader, 0, fileSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, candidate := range boxes {
+		switch candidate.boxType {
+		case "jxlc":
+			return candidate.offset, nil
+		case "jxlp":
+			return candidate.offset + 4, nil
+		}
+	}
+
+	return 0, errors.New("JPEG XL codestream box not found")
+}
+
+// parseJxlSizeHeader decodes the SizeHeader bundle (ISO/IEC 18181-1),
+// whose fields are packed starting from least significant bits
+func parseJxlSizeHeader(data []byte) (int, int, error) {
+	bits := &bitReader{data: data}
+
+	var height, ratio, width int
+	if bits.read(1) == 1 {
+		height = (bits.read(5) + 1) * 8
+		if ratio = bits.read(3); ratio == 0 {
+			width = (bits.read(5) + 1) * 8
+		}
+	} else {
+		height = readJxlDimension(bits)
+		if ratio = bits.read(3); ratio == 0 {
+			width = readJxlDimension(bits)
+		}
+	}
+	if ratio != 0 {
+		width = height * jxlRatios[ratio][0] / jxlRatios[ratio][1]
+	}
+
+	if bits.overflow {
+		return 0, 0, errors.New("truncated JPEG XL size header")
+	}
+	return width, height, nil
+}
+
+// readJxlDimension decodes a dimension stored in 9, 13, 18 or 30 bits,
+// as told by a 2 bits selector
+func readJxlDimension(bits *bitReader) int {
+	sizes := [4]int{9, 13, 18, 30}
+	return bits.read(sizes[bits.read(2)]) + 1
+}
+
+// bitReader reads little endian bit fields, recording reads past the
+// end of data instead of failing
+type bitReader struct {
+	data     []byte
+	pos      int
+	overflow bool
+}
+
+func (r *bitReader) read(count int) int {
+	value := 0
+	for idx := range count {
+		if r.pos/8 >= len(r.data) {
+			r.overflow = true
+			return 0
+		}
+
+		bit := int(r.data[r.pos/8]>>(r.pos%8)) & 1
+		value |= bit << idx
+		r.pos++
+	}
+
+	return value
+}
@@ -0,0 +1,183 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+func TestImageSize(t *testing.T) {
+	heif := append(
+		mkBox("ftyp", []byte("heic")),
+		fullBox("meta", mkBox("iprp", mkBox("ipco",
+			fullBox("ispe", uint32Bytes(512), uint32Bytes(512)),
+			fullBox("ispe", uint32Bytes(60000), uint32Bytes(40000)),
+		)))...,
+	)
+
+	tests := []struct {
+		name       string
+		filePath   string
+		fileFormat format.Format
+		wantWidth  int
+		wantHeight int
+	}{
+		{
+			name:       "heif fixture",
+			filePath:   testutils.TestFilePath("4 thai_no_edits.heic"),
+			fileFormat: format.HEIF,
+			wantWidth:  4032,
+			wantHeight: 3024,
+		},
+		{
+			name:       "heif largest item",
+			filePath:   writeTempFile(t, heif),
+			fileFormat: format.HEIF,
+			wantWidth:  60000,
+			wantHeight: 40000,
+		},
+		{
+			name:       "tiff little endian",
+			filePath:   writeTempFile(t, mkTiffHeader(binary.LittleEndian, 70000, 50000)),
+			fileFormat: format.TIFF,
+			wantWidth:  70000,
+			wantHeight: 50000,
+		},
+		{
+			name:       "tiff big endian",
+			filePath:   writeTempFile(t, mkTiffHeader(binary.BigEndian, 640, 480)),
+			fileFormat: format.TIFF,
+			wantWidth:  640,
+			wantHeight: 480,
+		},
+		{
+			name:       "jxl small size",
+			filePath:   writeTempFile(t, mkJxlCodestream(jxlSmallSize(48, 0, 64))),
+			fileFormat: format.JXL,
+			wantWidth:  64,
+			wantHeight: 48,
+		},
+		{
+			name:       "jxl ratio",
+			filePath:   writeTempFile(t, mkJxlCodestream(jxlSmallSize(72, 5, 0))),
+			fileFormat: format.JXL,
+			wantWidth:  128,
+			wantHeight: 72,
+		},
+		{
+			name:       "jxl large size",
+			filePath:   writeTempFile(t, mkJxlCodestream(jxlLargeSize(1_000_000, 300_000))),
+			fileFormat: format.JXL,
+			wantWidth:  300_000,
+			wantHeight: 1_000_000,
+		},
+		{
+			name: "jxl container",
+			filePath: writeTempFile(t, append(
+				append(append([]byte{}, jxlContainerSignature...), mkBox("ftyp", []byte("jxl "))...),
+				mkBox("jxlp", uint32Bytes(0), mkJxlCodestream(jxlLargeSize(5000, 9000)))...,
+			)),
+			fileFormat: format.JXL,
+			wantWidth:  9000,
+			wantHeight: 5000,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			width, height, err := ImageSize(tc.filePath, tc.fileFormat)
+			if err != nil {
+				t.Fatalf("failed to read image size: %v", err)
+			}
+			if width != tc.wantWidth || height != tc.wantHeight {
+				t.Errorf(
+					"size = %dx%d, want %dx%d",
+					width, height, tc.wantWidth, tc.wantHeight,
+				)
+			}
+		})
+	}
+}
+
+func TestImageSize_NotDeclared(t *testing.T) {
+	heif := append(mkBox("ftyp", []byte("heic")), fullBox("meta")...)
+
+	_, _, err := ImageSize(writeTempFile(t, heif), format.HEIF)
+	if err == nil {
+		t.Fatal("expected error for HEIF without 'ispe' property, got nil")
+	}
+
+	_, _, err = ImageSize(writeTempFile(t, []byte{0xFF, 0x0A}), format.JXL)
+	if err == nil {
+		t.Fatal("expected error for truncated JPEG XL header, got nil")
+	}
+}
+
+// mkTiffHeader builds a TIFF header followed by an IFD declaring image
+// dimensions only
+func mkTiffHeader(order binary.AppendByteOrder, width uint32, height uint32) []byte {
+	tiff := []byte("II*\x00")
+	if order == binary.AppendByteOrder(binary.BigEndian) {
+		tiff = []byte("MM\x00*")
+	}
+	tiff = order.AppendUint32(tiff, 8)
+
+	tiff = order.AppendUint16(tiff, 2)
+	for _, entry := range [][2]uint32{{tagImageWidth, width}, {tagImageLength, height}} {
+		tiff = order.AppendUint16(tiff, uint16(entry[0]))
+		tiff = order.AppendUint16(tiff, typeLong)
+		tiff = order.AppendUint32(tiff, 1)
+		tiff = order.AppendUint32(tiff, entry[1])
+	}
+
+	return order.AppendUint32(tiff, 0)
+}
+
+func mkJxlCodestream(sizeHeader []byte) []byte {
+	return append(append([]byte{}, jxlCodestreamSignature...), sizeHeader...)
+}
+
+// jxlSmallSize encodes a size header of dimensions multiple of 8 up to
+// 256. Width is omitted when given by ratio.
+func jxlSmallSize(height int, ratio int, width int) []byte {
+	bits := &bitWriter{}
+	bits.write(1, 1)
+	bits.write(height/8-1, 5)
+	bits.write(ratio, 3)
+	if ratio == 0 {
+		bits.write(width/8-1, 5)
+	}
+
+	return bits.data
+}
+
+// jxlLargeSize encodes a size header with 30 bits dimensions
+func jxlLargeSize(height int, width int) []byte {
+	bits := &bitWriter{}
+	bits.write(0, 1)
+	bits.write(3, 2)
+	bits.write(height-1, 30)
+	bits.write(0, 3)
+	bits.write(3, 2)
+	bits.write(width-1, 30)
+
+	return bits.data
+}
+
+type bitWriter struct {
+	data []byte
+	pos  int
+}
+
+func (w *bitWriter) write(value int, count int) {
+	for idx := range count {
+		if w.pos%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+
+		w.data[w.pos/8] |= byte(value>>idx&1) << (w.pos % 8)
+		w.pos++
+	}
+}
@@ -0,0 +1,165 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Metadata boxes (HEIF 'meta', QuickTime 'moov') larger than this are
+// not loaded
+const maxMetadataBoxSize = 32 * 1024 * 1024
+
+var errInvalidBox = errors.New("invalid ISO BMFF box")
+
+// box locates the payload of an ISO BMFF box (HEIF, MP4 and QuickTime
+// share this structure)
+type box struct {
+	boxType string
+	offset  int64
+	size    int64
+}
+
+// readBoxes lists boxes stored between start and end offsets of reader
+func readBoxes(reader io.ReaderAt, start int64, end int64) ([]box, error) {
+	var boxes []box
+
+	offset := start
+	for offset+8 <= end {
+		var header [16]byte
+		if _, err := reader.ReadAt(header[:8], offset); err != nil {
+			return nil, fmt.Errorf("failed to read box header: %w", err)
+		}
+
+		headerSize := int64(8)
+		boxSize := int64(binary.BigEndian.Uint32(header[0:4]))
+		switch boxSize {
+		case 0:
+			// Box extends to end of enclosing container
+			boxSize = end - offset
+		case 1:
+			if _, err := reader.ReadAt(header[8:16], offset+8); err != nil {
+				return nil, fmt.Errorf("failed to read box header: %w", err)
+			}
+			headerSize = 16
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+		}
+
+		if boxSize < headerSize || offset+boxSize > end || offset+boxSize < offset {
+			return nil, errInvalidBox
+		}
+
+		boxes = append(boxes, box{
+			boxType: string(header[4:8]),
+			offset:  offset + headerSize,
+			size:    boxSize - headerSize,
+		})
+		offset += boxSize
+	}
+
+	return boxes, nil
+}
+
+// findBox returns first box of given type
+func findBox(boxes []box, boxType string) (box, bool) {
+	for _, candidate := range boxes {
+		if candidate.boxType == boxType {
+			return candidate, true
+		}
+	}
+
+	return box{}, false
+}
+
+// loadBox reads payload of b into memory
+func loadBox(reader io.ReaderAt, b box) ([]byte, error) {
+	if b.size > maxMetadataBoxSize {
+		return nil, fmt.Errorf("'%s' box is too large: %d bytes", b.boxType, b.size)
+	}
+
+	payload := make([]byte, b.size)
+	if _, err := reader.ReadAt(payload, b.offset); err != nil {
+		return nil, fmt.Errorf("failed to read '%s' box: %w", b.boxType, err)
+	}
+
+	return payload, nil
+}
+
+// childBoxes lists boxes nested in payload, skipping skip leading bytes
+// (e.g. version and flags of full boxes)
+func childBoxes(payload []byte, skip int) ([]box, error) {
+	if len(payload) < skip {
+		return nil, errInvalidBox
+	}
+
+	return readBoxes(bytesReaderAt(payload), int64(skip), int64(len(payload)))
+}
+
+// boxPayload slices payload of b out of its enclosing payload
+func boxPayload(parent []byte, b box) []byte {
+	return parent[b.offset : b.offset+b.size]
+}
+
+// byteCursor reads big endian integers from a buffer, recording an
+// error instead of panicking when reading past its end
+type byteCursor struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (c *byteCursor) take(count int) []byte {
+	if c.err != nil || count < 0 || c.pos+count > len(c.data) {
+		c.err = errInvalidBox
+		return nil
+	}
+
+	chunk := c.data[c.pos : c.pos+count]
+	c.pos += count
+	return chunk
+}
+
+// uint reads an unsigned integer of given size in bytes (0 to 8)
+func (c *byteCursor) uint(size int) uint64 {
+	var value uint64
+	for _, b := range c.take(size) {
+		value = value<<8 | uint64(b)
+	}
+
+	return value
+}
+
+// cstring reads a null terminated string
+func (c *byteCursor) cstring() string {
+	if c.err != nil {
+		return ""
+	}
+
+	for end := c.pos; end < len(c.data); end++ {
+		if c.data[end] == 0 {
+			value := string(c.data[c.pos:end])
+			c.pos = end + 1
+			return value
+		}
+	}
+
+	// Some writers omit the last terminator
+	value := string(c.data[c.pos:])
+	c.pos = len(c.data)
+	return value
+}
+
+type bytesReaderAt []byte
+
+func (b bytesReaderAt) ReadAt(dest []byte, offset int64) (int, error) {
+	if offset < 0 || offset >= int64(len(b)) {
+		return 0, io.EOF
+	}
+
+	count := copy(dest, b[offset:])
+	if count < len(dest) {
+		return count, io.EOF
+	}
+	return count, nil
+}
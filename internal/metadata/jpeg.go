@@ -0,0 +1,128 @@
+package metadata
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// JPEG markers relevant to metadata extraction
+const (
+	markerSOI  = 0xD8
+	markerEOI  = 0xD9
+	markerSOS  = 0xDA
+	markerAPP1 = 0xE1
+)
+
+var xmpHeader = []byte("http://ns.adobe.com/xap/1.0/\x00")
+
+// readJpeg reads EXIF and XMP metadata from APP1 segments of a JPEG
+// stream, stopping at image data so the file is never fully loaded.
+func readJpeg(reader io.Reader) (*models.MediaMetadata, error) {
+	meta, xmpPacket, err := readJpegSegments(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// XMP only fills what EXIF lacks
+	if xmpPacket != nil {
+		parseXmp(xmpPacket, meta)
+	}
+
+	return meta, nil
+}
+
+// readJpegSegments reads EXIF metadata and dimensions of a JPEG stream,
+// along with its raw XMP packet (nil if missing)
+func readJpegSegments(reader io.Reader) (*models.MediaMetadata, []byte, error) {
+	bufReader := bufio.NewReader(reader)
+
+	var soi [2]byte
+	if _, err := io.ReadFull(bufReader, soi[:]); err != nil {
+		return nil, nil, fmt.Errorf("failed to read JPEG header: %w", err)
+	}
+	if soi[0] != 0xFF || soi[1] != markerSOI {
+		return nil, nil, errors.New("not a JPEG stream")
+	}
+
+	meta := new(models.MediaMetadata)
+	var xmpPacket []byte
+
+	for {
+		marker, err := nextJpegMarker(bufReader)
+		if err != nil {
+			return nil, nil, err
+		}
+		if marker == markerSOS || marker == markerEOI {
+			break
+		}
+
+		// Standalone markers carry no segment
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			continue
+		}
+
+		var lengthBytes [2]byte
+		if _, err := io.ReadFull(bufReader, lengthBytes[:]); err != nil {
+			return nil, nil, fmt.Errorf("failed to read JPEG segment: %w", err)
+		}
+		segmentLength := int(binary.BigEndian.Uint16(lengthBytes[:])) - 2
+		if segmentLength < 0 {
+			return nil, nil, errors.New("invalid JPEG segment length")
+		}
+
+		segment := make([]byte, segmentLength)
+		if _, err := io.ReadFull(bufReader, segment); err != nil {
+			return nil, nil, fmt.Errorf("failed to read JPEG segment: %w", err)
+		}
+
+		switch {
+		case marker == markerAPP1 && bytes.HasPrefix(segment, exifHeader):
+			if err := parseExif(segment, meta); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse EXIF: %w", err)
+			}
+		case marker == markerAPP1 && bytes.HasPrefix(segment, xmpHeader):
+			xmpPacket = segment[len(xmpHeader):]
+		case isStartOfFrame(marker) && len(segment) >= 5:
+			if meta.Width == 0 || meta.Height == 0 {
+				meta.Height = int(binary.BigEndian.Uint16(segment[1:3]))
+				meta.Width = int(binary.BigEndian.Uint16(segment[3:5]))
+			}
+		}
+	}
+
+	return meta, xmpPacket, nil
+}
+
+// nextJpegMarker skips fill bytes and returns the next marker code
+func nextJpegMarker(reader *bufio.Reader) (byte, error) {
+	prefix, err := reader.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read JPEG marker: %w", err)
+	}
+	if prefix != 0xFF {
+		return 0, errors.New("invalid JPEG marker")
+	}
+
+	for {
+		marker, err := reader.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read JPEG marker: %w", err)
+		}
+		if marker != 0xFF {
+			return marker, nil
+		}
+	}
+}
+
+// isStartOfFrame reports whether marker is a SOFn marker, which holds
+// image dimensions
+func isStartOfFrame(marker byte) bool {
+	return marker >= 0xC0 && marker <= 0xCF &&
+		marker != 0xC4 && marker != 0xC8 && marker != 0xCC
+}
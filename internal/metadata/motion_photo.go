@@ -0,0 +1,187 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// XMP of Google Motion Photos declares the length of the MP4 appended to
+// the photo, either as item of a container directory or, in older
+// files, as offset of the video from the file end
+var (
+	xmpContainerItemPattern = regexp.MustCompile(`<\w*Container:Item\b[^>]*>`)
+	xmpMotionPhotoSemantic  = regexp.MustCompile(`\w*Item:Semantic\s*=\s*"MotionPhoto"`)
+	xmpItemLengthPattern    = regexp.MustCompile(`\w*Item:Length\s*=\s*"(\d+)"`)
+
+	xmpMicroVideoOffsetProps = []string{"GCamera:MicroVideoOffset"}
+)
+
+// Samsung trailers end with the size of their directory and this magic
+const samsungTrailerMagic = "SEFT"
+
+// Name of Samsung trailer blocks holding the video of Motion Photos
+const samsungMotionPhotoBlock = "MotionPhoto_Data"
+
+// Samsung trailer directories list a few blocks, bigger ones are invalid
+const maxSamsungTrailerDirSize = 64 * 1024
+
+// ErrNoMotionPhoto is returned for JPEG files without an embedded video
+var ErrNoMotionPhoto = errors.New("no embedded motion photo video found")
+
+// MotionPhotoVideo locates the MP4 video embedded in a Motion Photo JPEG
+type MotionPhotoVideo struct {
+	Offset int64
+	Length int64
+}
+
+// LocateMotionPhotoVideo finds the video appended to the JPEG file at
+// absFilePath by Google and Samsung cameras. Video bytes are not read,
+// only checked to start with an MP4 'ftyp' box.
+func LocateMotionPhotoVideo(absFilePath string) (*MotionPhotoVideo, error) {
+	file, err := os.Open(absFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file for motion photo: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file for motion photo: %w", err)
+	}
+	fileSize := fileInfo.Size()
+
+	_, xmpPacket, err := readJpegSegments(file)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []*MotionPhotoVideo{
+		xmpMotionPhotoVideo(xmpPacket, fileSize),
+		samsungMotionPhotoVideo(file, fileSize),
+	}
+	for _, candidate := range candidates {
+		if candidate != nil && startsWithFtyp(file, candidate) {
+			return candidate, nil
+		}
+	}
+
+	return nil, ErrNoMotionPhoto
+}
+
+// xmpMotionPhotoVideo locates the video from the length declared in XMP,
+// as the video is the last item of the file
+func xmpMotionPhotoVideo(xmpPacket []byte, fileSize int64) *MotionPhotoVideo {
+	if xmpPacket == nil {
+		return nil
+	}
+	xmp := string(xmpPacket)
+
+	var length string
+	for _, item := range xmpContainerItemPattern.FindAllString(xmp, -1) {
+		if !xmpMotionPhotoSemantic.MatchString(item) {
+			continue
+		}
+		if match := xmpItemLengthPattern.FindStringSubmatch(item); match != nil {
+			length = match[1]
+		}
+	}
+	if length == "" {
+		length = xmpProperty(xmp, xmpMicroVideoOffsetProps)
+	}
+
+	videoLength, err := strconv.ParseInt(length, 10, 64)
+	if err != nil || videoLength <= 0 || videoLength >= fileSize {
+		return nil
+	}
+
+	return &MotionPhotoVideo{Offset: fileSize - videoLength, Length: videoLength}
+}
+
+// samsungMotionPhotoVideo locates the video in the trailer Samsung
+// cameras append to photos. The trailer directory ('SEFH', version,
+// entries count and 12 bytes entries) locates each data block by its
+// distance backwards from the directory start. Blocks start with their
+// name, followed by data.
+func samsungMotionPhotoVideo(reader io.ReaderAt, fileSize int64) *MotionPhotoVideo {
+	var footer [8]byte
+	if fileSize < int64(len(footer)) {
+		return nil
+	}
+	if _, err := reader.ReadAt(footer[:], fileSize-8); err != nil {
+		return nil
+	}
+	if string(footer[4:]) != samsungTrailerMagic {
+		return nil
+	}
+
+	dirSize := int64(binary.LittleEndian.Uint32(footer[:4]))
+	dirStart := fileSize - 8 - dirSize
+	if dirSize < 12 || dirSize > maxSamsungTrailerDirSize || dirStart < 0 {
+		return nil
+	}
+
+	dir := make([]byte, dirSize)
+	if _, err := reader.ReadAt(dir, dirStart); err != nil {
+		return nil
+	}
+	if string(dir[:4]) != "SEFH" {
+		return nil
+	}
+
+	entriesCount := int(binary.LittleEndian.Uint32(dir[8:12]))
+	for idx := range entriesCount {
+		entryStart := 12 + idx*12
+		if entryStart+12 > len(dir) {
+			break
+		}
+		entry := dir[entryStart : entryStart+12]
+
+		blockStart := dirStart - int64(binary.LittleEndian.Uint32(entry[4:8]))
+		blockLength := int64(binary.LittleEndian.Uint32(entry[8:12]))
+		if blockStart < 0 || blockLength < 8 || blockStart+blockLength > dirStart {
+			continue
+		}
+
+		var blockHeader [8]byte
+		if _, err := reader.ReadAt(blockHeader[:], blockStart); err != nil {
+			continue
+		}
+		nameLength := int64(binary.LittleEndian.Uint32(blockHeader[4:8]))
+		if nameLength != int64(len(samsungMotionPhotoBlock)) || 8+nameLength >= blockLength {
+			continue
+		}
+
+		name := make([]byte, nameLength)
+		if _, err := reader.ReadAt(name, blockStart+8); err != nil {
+			continue
+		}
+		if string(name) != samsungMotionPhotoBlock {
+			continue
+		}
+
+		return &MotionPhotoVideo{
+			Offset: blockStart + 8 + nameLength,
+			Length: blockLength - 8 - nameLength,
+		}
+	}
+
+	return nil
+}
+
+// startsWithFtyp reports whether video starts with an MP4 'ftyp' box
+func startsWithFtyp(reader io.ReaderAt, video *MotionPhotoVideo) bool {
+	var boxHeader [8]byte
+	if video.Length < int64(len(boxHeader)) {
+		return false
+	}
+	if _, err := reader.ReadAt(boxHeader[:], video.Offset); err != nil {
+		return false
+	}
+
+	return string(boxHeader[4:]) == "ftyp"
+}
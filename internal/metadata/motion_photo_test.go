@@ -0,0 +1,138 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocateMotionPhotoVideo(t *testing.T) {
+	video := append(mkBox("ftyp", []byte("isom")), mkBox("mdat", make([]byte, 64))...)
+
+	containerXmp := fmt.Sprintf(
+		`<Container:Directory><rdf:Seq>`+
+			`<rdf:li rdf:parseType="Resource"><Container:Item Item:Mime="image/jpeg" Item:Semantic="Primary" Item:Length="0"/></rdf:li>`+
+			`<rdf:li rdf:parseType="Resource"><Container:Item Item:Mime="video/mp4" Item:Semantic="MotionPhoto" Item:Length="%d"/></rdf:li>`+
+			`</rdf:Seq></Container:Directory>`,
+		len(video),
+	)
+	microVideoXmp := fmt.Sprintf(
+		`<rdf:Description GCamera:MicroVideo="1" GCamera:MicroVideoOffset="%d"/>`,
+		len(video),
+	)
+
+	tests := []struct {
+		name    string
+		xmp     string
+		trailer []byte
+	}{
+		{name: "container directory", xmp: containerXmp, trailer: video},
+		{name: "micro video offset", xmp: microVideoXmp, trailer: video},
+		{name: "samsung trailer", trailer: samsungTrailer(video)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			jpegBytes := mkJpegWithXmp(t, tc.xmp)
+			filePath := writeTempFile(t, append(jpegBytes, tc.trailer...))
+
+			located, err := LocateMotionPhotoVideo(filePath)
+			if err != nil {
+				t.Fatalf("failed to locate video: %v", err)
+			}
+
+			fileBytes, _ := os.ReadFile(filePath)
+			embedded := fileBytes[located.Offset : located.Offset+located.Length]
+			if !bytes.Equal(embedded, video) {
+				t.Errorf(
+					"located %d bytes at %d, which aren't the embedded video",
+					located.Length,
+					located.Offset,
+				)
+			}
+		})
+	}
+}
+
+func TestLocateMotionPhotoVideo_NoVideo(t *testing.T) {
+	tests := []struct {
+		name    string
+		xmp     string
+		trailer []byte
+	}{
+		{name: "plain photo"},
+		{
+			name:    "declared length not pointing to a video",
+			xmp:     `<rdf:Description GCamera:MicroVideoOffset="16"/>`,
+			trailer: make([]byte, 16),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			jpegBytes := mkJpegWithXmp(t, tc.xmp)
+			filePath := writeTempFile(t, append(jpegBytes, tc.trailer...))
+
+			_, err := LocateMotionPhotoVideo(filePath)
+			if !errors.Is(err, ErrNoMotionPhoto) {
+				t.Fatalf("expected ErrNoMotionPhoto, got %v", err)
+			}
+		})
+	}
+}
+
+// mkJpegWithXmp encodes a small JPEG carrying xmp (if any) in an APP1
+// segment
+func mkJpegWithXmp(t *testing.T, xmp string) []byte {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatalf("failed to encode JPEG: %v", err)
+	}
+	if xmp == "" {
+		return encoded.Bytes()
+	}
+
+	segment := append(append([]byte{}, xmpHeader...), xmp...)
+	app1 := binary.BigEndian.AppendUint16([]byte{0xFF, markerAPP1}, uint16(len(segment)+2))
+
+	jpegBytes := append([]byte{0xFF, markerSOI}, app1...)
+	jpegBytes = append(jpegBytes, segment...)
+	return append(jpegBytes, encoded.Bytes()[2:]...)
+}
+
+// samsungTrailer wraps video into a Samsung trailer with a single block
+func samsungTrailer(video []byte) []byte {
+	block := binary.LittleEndian.AppendUint32(
+		[]byte{0, 0, 0x30, 0x0a},
+		uint32(len(samsungMotionPhotoBlock)),
+	)
+	block = append(append(block, samsungMotionPhotoBlock...), video...)
+
+	dir := append([]byte("SEFH"), binary.LittleEndian.AppendUint32(nil, 107)...)
+	dir = binary.LittleEndian.AppendUint32(dir, 1)
+	dir = append(dir, 0, 0, 0x30, 0x0a)
+	dir = binary.LittleEndian.AppendUint32(dir, uint32(len(block)))
+	dir = binary.LittleEndian.AppendUint32(dir, uint32(len(block)))
+
+	trailer := append(block, dir...)
+	trailer = binary.LittleEndian.AppendUint32(trailer, uint32(len(dir)))
+	return append(trailer, samsungTrailerMagic...)
+}
+
+func writeTempFile(t *testing.T, data []byte) string {
+	t.Helper()
+
+	filePath := filepath.Join(t.TempDir(), "motion.jpg")
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	return filePath
+}
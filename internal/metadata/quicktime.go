@@ -0,0 +1,281 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// QuickTime timestamps count seconds since 1904-01-01 UTC
+var quickTimeEpoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ISO 6709 locations look like '+19.4326-099.1332+2240.000/'
+var iso6709Pattern = regexp.MustCompile(
+	`^([+-]\d+(?:\.\d+)?)([+-]\d+(?:\.\d+)?)([+-]\d+(?:\.\d+)?)?`,
+)
+
+// Keys of QuickTime metadata ('mdta') written by phones and cameras
+const (
+	keyCreationDate = "com.apple.quicktime.creationdate"
+	keyLocation     = "com.apple.quicktime.location.ISO6709"
+	keyMake         = "com.apple.quicktime.make"
+	keyModel        = "com.apple.quicktime.model"
+
+	keyContentIdentifier = "com.apple.quicktime.content.identifier"
+)
+
+// readQuickTime reads creation time, location, device and dimensions
+// from the 'moov' box of MOV and MP4 files
+func readQuickTime(reader io.ReaderAt, fileSize int64) (*models.MediaMetadata, error) {
+	topBoxes, err := readBoxes(reader, 0, fileSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read QuickTime boxes: %w", err)
+	}
+
+	moovBox, found := findBox(topBoxes, "moov")
+	if !found {
+		return nil, errors.New("QuickTime 'moov' box not found")
+	}
+
+	moov, err := loadBox(reader, moovBox)
+	if err != nil {
+		return nil, err
+	}
+
+	moovChildren, err := childBoxes(moov, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read 'moov' box: %w", err)
+	}
+
+	meta := new(models.MediaMetadata)
+
+	// Keyed metadata is preferred as it records local time offset
+	if metaBox, found := findBox(moovChildren, "meta"); found {
+		readQuickTimeKeys(boxPayload(moov, metaBox), meta)
+	}
+
+	if udtaBox, found := findBox(moovChildren, "udta"); found {
+		readUserData(boxPayload(moov, udtaBox), meta)
+	}
+
+	if mvhdBox, found := findBox(moovChildren, "mvhd"); found && meta.CaptureTime == "" {
+		meta.CaptureTime = mvhdCreationTime(boxPayload(moov, mvhdBox))
+	}
+
+	for _, trak := range moovChildren {
+		if trak.boxType != "trak" || meta.Width != 0 {
+			continue
+		}
+
+		trakChildren, err := childBoxes(boxPayload(moov, trak), 0)
+		if err != nil {
+			continue
+		}
+		if tkhdBox, found := findBox(trakChildren, "tkhd"); found {
+			meta.Width, meta.Height = tkhdDimensions(
+				boxPayload(boxPayload(moov, trak), tkhdBox),
+			)
+		}
+	}
+
+	return meta, nil
+}
+
+// mvhdCreationTime returns movie creation time in UTC, or empty string
+// when not recorded
+func mvhdCreationTime(payload []byte) string {
+	cursor := &byteCursor{data: payload}
+	version := cursor.uint(1)
+	cursor.take(3)
+
+	timeSize := 4
+	if version == 1 {
+		timeSize = 8
+	}
+	seconds := cursor.uint(timeSize)
+	if cursor.err != nil || seconds == 0 {
+		return ""
+	}
+
+	return quickTimeEpoch.
+		Add(time.Duration(seconds) * time.Second).
+		Format(time.RFC3339)
+}
+
+// tkhdDimensions returns presentation size of a track. Audio tracks
+// report zero.
+func tkhdDimensions(payload []byte) (int, int) {
+	cursor := &byteCursor{data: payload}
+	version := cursor.uint(1)
+	cursor.take(3)
+
+	// Times, track ID and duration
+	if version == 1 {
+		cursor.take(32)
+	} else {
+		cursor.take(20)
+	}
+
+	// Reserved, layer, group, volume, reserved and matrix
+	cursor.take(52)
+
+	// Fixed point 16.16 values
+	width := cursor.uint(4) >> 16
+	height := cursor.uint(4) >> 16
+	if cursor.err != nil {
+		return 0, 0
+	}
+
+	return int(width), int(height)
+}
+
+// readUserData reads location from the '©xyz' entry of 'udta' box
+func readUserData(udta []byte, meta *models.MediaMetadata) {
+	children, err := childBoxes(udta, 0)
+	if err != nil {
+		return
+	}
+
+	xyzBox, found := findBox(children, "\xa9xyz")
+	if !found || meta.Location != nil {
+		return
+	}
+
+	// Size and language precede the text
+	cursor := &byteCursor{data: boxPayload(udta, xyzBox)}
+	textSize := int(cursor.uint(2))
+	cursor.take(2)
+	text := cursor.take(textSize)
+	if cursor.err == nil {
+		meta.Location = parseISO6709(string(text))
+	}
+}
+
+// readQuickTimeKeys reads 'mdta' keyed metadata ('keys' and 'ilst'
+// boxes) of a QuickTime 'meta' box
+func readQuickTimeKeys(metaPayload []byte, meta *models.MediaMetadata) {
+
+	// QuickTime 'meta' boxes have no version and flags, unlike MP4 ones
+	skip := 4
+	if len(metaPayload) >= 8 && string(metaPayload[4:8]) == "hdlr" {
+		skip = 0
+	}
+
+	children, err := childBoxes(metaPayload, skip)
+	if err != nil {
+		return
+	}
+
+	keysBox, foundKeys := findBox(children, "keys")
+	ilstBox, foundIlst := findBox(children, "ilst")
+	if !foundKeys || !foundIlst {
+		return
+	}
+
+	keys := parseKeys(boxPayload(metaPayload, keysBox))
+	values := parseIlst(boxPayload(metaPayload, ilstBox), keys)
+
+	if creationDate, found := values[keyCreationDate]; found {
+		meta.CaptureTime = normalizeQuickTimeDate(creationDate)
+	}
+	if location, found := values[keyLocation]; found {
+		meta.Location = parseISO6709(location)
+	}
+	setIfEmpty(&meta.CameraMake, values[keyMake])
+	setIfEmpty(&meta.CameraModel, values[keyModel])
+	setIfEmpty(&meta.ContentIdentifier, values[keyContentIdentifier])
+}
+
+// parseKeys returns key names indexed from 1, as referenced by 'ilst'
+func parseKeys(payload []byte) map[uint32]string {
+	cursor := &byteCursor{data: payload}
+	cursor.take(4)
+	keysCount := cursor.uint(4)
+
+	keys := make(map[uint32]string)
+	for idx := uint64(1); idx <= keysCount && cursor.err == nil; idx++ {
+		keySize := int(cursor.uint(4))
+		cursor.take(4) // namespace
+		name := cursor.take(keySize - 8)
+		if cursor.err == nil {
+			keys[uint32(idx)] = string(name)
+		}
+	}
+
+	return keys
+}
+
+// parseIlst returns text values of 'ilst' entries by key name
+func parseIlst(payload []byte, keys map[uint32]string) map[string]string {
+	values := make(map[string]string)
+
+	entries, err := childBoxes(payload, 0)
+	if err != nil {
+		return values
+	}
+
+	for _, entry := range entries {
+		cursor := &byteCursor{data: []byte(entry.boxType)}
+		keyName, found := keys[uint32(cursor.uint(4))]
+		if !found {
+			continue
+		}
+
+		entryPayload := boxPayload(payload, entry)
+		dataBoxes, err := childBoxes(entryPayload, 0)
+		if err != nil {
+			continue
+		}
+
+		dataBox, found := findBox(dataBoxes, "data")
+		if !found || dataBox.size < 8 {
+			continue
+		}
+
+		// Type indicator and locale precede the value
+		values[keyName] = string(boxPayload(entryPayload, dataBox)[8:])
+	}
+
+	return values
+}
+
+// normalizeQuickTimeDate converts ISO 8601 dates as written by phones
+// (e.g. '2025-05-03T13:06:08-0600') into RFC 3339
+func normalizeQuickTimeDate(value string) string {
+	for _, layout := range []string{"2006-01-02T15:04:05-0700", time.RFC3339} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.Format(time.RFC3339)
+		}
+	}
+
+	return normalizeXmpTime(value)
+}
+
+func parseISO6709(value string) *models.GeoLocation {
+	match := iso6709Pattern.FindStringSubmatch(value)
+	if match == nil {
+		return nil
+	}
+
+	latitude, errLat := strconv.ParseFloat(match[1], 64)
+	longitude, errLon := strconv.ParseFloat(match[2], 64)
+	if errLat != nil || errLon != nil {
+		return nil
+	}
+
+	location := &models.GeoLocation{
+		Latitude:  roundTo(latitude, 6),
+		Longitude: roundTo(longitude, 6),
+	}
+	if altitude, err := strconv.ParseFloat(match[3], 64); err == nil {
+		altitude = roundTo(altitude, 2)
+		location.Altitude = &altitude
+	}
+
+	return location
+}
@@ -0,0 +1,113 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestReadQuickTime_KeyedMetadata(t *testing.T) {
+	keys := fullBox(
+		"keys",
+		uint32Bytes(3),
+		mdtaKey(keyCreationDate),
+		mdtaKey(keyLocation),
+		mdtaKey(keyContentIdentifier),
+	)
+	ilst := mkBox(
+		"ilst",
+		ilstEntry(1, "2025-05-03T13:06:08-0600"),
+		ilstEntry(2, "+19.4326-099.1332+2240.000/"),
+		ilstEntry(3, "1E2D6A4C-7B8F-4E3A-9C1D-0F5B2A6E8D47"),
+	)
+	hdlr := fullBox("hdlr", make([]byte, 20))
+
+	// QuickTime 'meta' box, without version and flags
+	moov := mkBox("moov", mkBox("meta", hdlr, keys, ilst))
+	file := append(mkBox("ftyp", []byte("qt  ")), moov...)
+
+	meta, err := readQuickTime(bytesReaderAt(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("failed to read metadata: %v", err)
+	}
+
+	if meta.CaptureTime != "2025-05-03T13:06:08-06:00" {
+		t.Errorf("unexpected capture time: %s", meta.CaptureTime)
+	}
+	if meta.Location == nil ||
+		meta.Location.Latitude != 19.4326 ||
+		meta.Location.Longitude != -99.1332 ||
+		meta.Location.Altitude == nil ||
+		*meta.Location.Altitude != 2240 {
+		t.Errorf("unexpected location: %+v", meta.Location)
+	}
+	if meta.ContentIdentifier != "1E2D6A4C-7B8F-4E3A-9C1D-0F5B2A6E8D47" {
+		t.Errorf("unexpected content identifier: %s", meta.ContentIdentifier)
+	}
+}
+
+func TestReadQuickTime_MovieHeader(t *testing.T) {
+
+	// 2024-01-02T03:04:05Z as seconds since 1904
+	mvhd := make([]byte, 20)
+	binary.BigEndian.PutUint32(mvhd[4:8], 3787009445)
+
+	text := "+48.8584+002.2945/"
+	xyz := append([]byte{0, byte(len(text)), 0x15, 0xc7}, text...)
+
+	moov := mkBox(
+		"moov",
+		mkBox("mvhd", mvhd),
+		mkBox("udta", mkBox("\xa9xyz", xyz)),
+	)
+
+	meta, err := readQuickTime(bytesReaderAt(moov), int64(len(moov)))
+	if err != nil {
+		t.Fatalf("failed to read metadata: %v", err)
+	}
+
+	if meta.CaptureTime != "2024-01-02T03:04:05Z" {
+		t.Errorf("unexpected capture time: %s", meta.CaptureTime)
+	}
+	if meta.Location == nil ||
+		meta.Location.Latitude != 48.8584 ||
+		meta.Location.Longitude != 2.2945 ||
+		meta.Location.Altitude != nil {
+		t.Errorf("unexpected location: %+v", meta.Location)
+	}
+}
+
+func TestReadQuickTime_TruncatedBox(t *testing.T) {
+	moov := mkBox("moov", mkBox("mvhd", make([]byte, 20)))
+	truncated := moov[:len(moov)-4]
+
+	_, err := readQuickTime(bytesReaderAt(truncated), int64(len(truncated)))
+	if err == nil {
+		t.Fatal("expected error for truncated file, got nil")
+	}
+}
+
+func mkBox(boxType string, payloads ...[]byte) []byte {
+	var payload []byte
+	for _, p := range payloads {
+		payload = append(payload, p...)
+	}
+
+	return append(append(uint32Bytes(uint32(8+len(payload))), boxType...), payload...)
+}
+
+func fullBox(boxType string, payloads ...[]byte) []byte {
+	return mkBox(boxType, append([][]byte{make([]byte, 4)}, payloads...)...)
+}
+
+func mdtaKey(name string) []byte {
+	return append(append(uint32Bytes(uint32(8+len(name))), "mdta"...), name...)
+}
+
+func ilstEntry(keyIdx uint32, value string) []byte {
+	data := mkBox("data", uint32Bytes(1), make([]byte, 4), []byte(value))
+	return mkBox(string(uint32Bytes(keyIdx)), data)
+}
+
+func uint32Bytes(value uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, value)
+}
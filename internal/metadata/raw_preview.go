@@ -0,0 +1,500 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+)
+
+// TIFF tags locating images stored in IFDs of RAW files
+const (
+	tagCompression      = 0x0103
+	tagStripOffsets     = 0x0111
+	tagStripByteCounts  = 0x0117
+	tagSubIFDs          = 0x014A
+	tagJPEGInterchange  = 0x0201
+	tagJPEGInterchangeL = 0x0202
+)
+
+// TIFF compressions of JPEG strips: old style and new style JPEG
+const (
+	compressionOldJPEG = 6
+	compressionJPEG    = 7
+)
+
+// Bytes of TIFF structured RAW files loaded to walk their IFDs. Cameras
+// write IFDs and their values before image data, at the file start.
+const maxRawStructureSize = 4 * 1024 * 1024
+
+// Embedded previews larger than this are ignored
+const maxRawPreviewSize = 64 * 1024 * 1024
+
+// Bytes of each candidate read to find its JPEG frame header, enough to
+// skip the EXIF and maker note segments some previews carry
+const rawPreviewHeaderSize = 256 * 1024
+
+// Bounds for hostile files chaining IFDs in loops or deep trees
+const (
+	maxRawIFDs         = 64
+	maxSubIFDsDepth    = 2
+	maxCr3PreviewBoxes = 8
+)
+
+// Canon CR3 'uuid' boxes: metadata (CMT1 to CMT4, THMB) in 'moov' and
+// top level preview (PRVW)
+var (
+	cr3MetadataUUID = []byte{
+		0x85, 0xc0, 0xb6, 0x87, 0x82, 0x0f, 0x11, 0xe0,
+		0x81, 0x11, 0xf4, 0xce, 0x46, 0x2b, 0x6a, 0x48,
+	}
+	cr3PreviewUUID = []byte{
+		0xea, 0xf4, 0x2b, 0x5e, 0x1c, 0x98, 0x4b, 0x88,
+		0xb9, 0xfb, 0xb7, 0xdc, 0x40, 0x6e, 0x4d, 0x16,
+	}
+)
+
+// ErrNoRawPreview is returned for RAW files without a usable embedded
+// JPEG preview
+var ErrNoRawPreview = errors.New("no embedded JPEG preview found")
+
+// RawPreview is the largest JPEG preview embedded in a camera RAW file
+type RawPreview struct {
+	JPEG []byte
+
+	// Dimensions of the preview as stored, before orientation
+	Width  int
+	Height int
+
+	// EXIF orientation (1 to 8) of the RAW file. Previews are stored as
+	// captured by the sensor and carry no orientation themselves.
+	Orientation int
+}
+
+// rawPreviewCandidate locates a JPEG stream inside a RAW file
+type rawPreviewCandidate struct {
+	offset int64
+	length int64
+}
+
+// ExtractRawPreview reads the largest embedded JPEG preview of a RAW
+// file, along with the orientation of the RAW. Previews are located
+// through IFDs and sub IFDs of TIFF structured files (DNG, CR2, NEF,
+// ARW) and through the first track and 'PRVW' box of CR3 files. Lossless
+// JPEG streams (sensor data) aren't previews and are skipped.
+//
+// Returns ErrNoRawPreview when the file embeds no preview.
+func ExtractRawPreview(
+	absFilePath string,
+	fileFormat format.Format,
+) (*RawPreview, error) {
+	file, err := os.Open(absFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open RAW file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat RAW file: %w", err)
+	}
+	fileSize := fileInfo.Size()
+
+	var candidates []rawPreviewCandidate
+	var orientation int
+	switch fileFormat {
+	case format.DNG, format.CR2, format.NEF, format.ARW:
+		candidates, orientation, err = tiffRawPreviews(file, fileSize)
+	case format.CR3:
+		candidates, orientation, err = cr3Previews(file, fileSize)
+	default:
+		return nil, fmt.Errorf("not a RAW format: %s", fileFormat)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to read %s structure: %w",
+			fileFormat,
+			err,
+		)
+	}
+
+	preview, err := largestPreview(file, fileSize, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	preview.Orientation = orientation
+	if preview.Orientation < 1 || preview.Orientation > 8 {
+		preview.Orientation = 1
+	}
+	return preview, nil
+}
+
+// tiffRawPreviews lists JPEG images referenced by IFDs of a TIFF
+// structured RAW file and reads its orientation from IFD0
+func tiffRawPreviews(
+	reader io.ReaderAt,
+	fileSize int64,
+) ([]rawPreviewCandidate, int, error) {
+	structure := make([]byte, min(fileSize, maxRawStructureSize))
+	if _, err := reader.ReadAt(structure, 0); err != nil && err != io.EOF {
+		return nil, 0, fmt.Errorf("failed to read TIFF structure: %w", err)
+	}
+
+	tiff, err := newTiffReader(structure)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ifd0, err := tiff.readIFD(tiff.firstIFDOffset())
+	if err != nil {
+		return nil, 0, err
+	}
+	orientation, _ := tiff.uint(ifd0, tagOrientation)
+
+	var candidates []rawPreviewCandidate
+	visited := make(map[uint32]bool)
+
+	var walk func(offset uint32, depth int)
+	walk = func(offset uint32, depth int) {
+		for offset != 0 && !visited[offset] && len(visited) < maxRawIFDs {
+			visited[offset] = true
+
+			entries, err := tiff.readIFD(offset)
+			if err != nil {
+				return
+			}
+			candidates = append(candidates, tiff.ifdPreviews(entries)...)
+
+			if depth < maxSubIFDsDepth {
+				for _, subOffset := range tiff.uints(entries, tagSubIFDs) {
+					walk(subOffset, depth+1)
+				}
+			}
+
+			offset = tiff.nextIFDOffset(offset)
+		}
+	}
+	walk(tiff.firstIFDOffset(), 0)
+
+	return candidates, int(orientation), nil
+}
+
+// ifdPreviews returns JPEG images of an IFD, either as JPEG interchange
+// format or as a single JPEG compressed strip
+func (r *tiffReader) ifdPreviews(entries ifd) []rawPreviewCandidate {
+	var candidates []rawPreviewCandidate
+
+	offset, okOffset := r.uint(entries, tagJPEGInterchange)
+	length, okLength := r.uint(entries, tagJPEGInterchangeL)
+	if okOffset && okLength {
+		candidates = append(candidates, rawPreviewCandidate{
+			offset: int64(offset),
+			length: int64(length),
+		})
+	}
+
+	compression, _ := r.uint(entries, tagCompression)
+	if compression != compressionOldJPEG && compression != compressionJPEG {
+		return candidates
+	}
+
+	offsets := r.uints(entries, tagStripOffsets)
+	lengths := r.uints(entries, tagStripByteCounts)
+	if len(offsets) == 1 && len(lengths) == 1 {
+		candidates = append(candidates, rawPreviewCandidate{
+			offset: int64(offsets[0]),
+			length: int64(lengths[0]),
+		})
+	}
+
+	return candidates
+}
+
+// uints returns every value of a SHORT, LONG or IFD entry
+func (r *tiffReader) uints(entries ifd, tag uint16) []uint32 {
+	entry, found := entries[tag]
+	if !found {
+		return nil
+	}
+
+	size := typeSize(entry.fieldType)
+	if entry.fieldType != typeShort && size != 4 {
+		return nil
+	}
+
+	values := make([]uint32, 0, len(entry.value)/size)
+	for start := 0; start+size <= len(entry.value); start += size {
+		if size == 2 {
+			values = append(values, uint32(r.order.Uint16(entry.value[start:])))
+		} else {
+			values = append(values, r.order.Uint32(entry.value[start:]))
+		}
+	}
+
+	return values
+}
+
+// nextIFDOffset returns offset of the IFD chained after the one at
+// offset, zero when it is the last one
+func (r *tiffReader) nextIFDOffset(offset uint32) uint32 {
+	if uint64(offset)+2 > uint64(len(r.data)) {
+		return 0
+	}
+
+	entriesCount := uint64(r.order.Uint16(r.data[offset:]))
+	nextOffset := uint64(offset) + 2 + entriesCount*12
+	if nextOffset+4 > uint64(len(r.data)) {
+		return 0
+	}
+
+	return r.order.Uint32(r.data[nextOffset:])
+}
+
+// cr3Previews lists the full size JPEG of the first track and the
+// 'PRVW' JPEG of a CR3 file, and reads its orientation from 'CMT1'
+func cr3Previews(
+	reader io.ReaderAt,
+	fileSize int64,
+) ([]rawPreviewCandidate, int, error) {
+	boxes, err := readBoxes(reader, 0, fileSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	moovBox, found := findBox(boxes, "moov")
+	if !found {
+		return nil, 0, errors.New("'moov' box not found")
+	}
+
+	moov, err := loadBox(reader, moovBox)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	moovChildren, err := childBoxes(moov, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var candidates []rawPreviewCandidate
+	if trak, found := findBox(moovChildren, "trak"); found {
+		if candidate, ok := firstTrackSample(boxPayload(moov, trak)); ok {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	previewBoxes := 0
+	for _, b := range boxes {
+		if b.boxType != "uuid" || previewBoxes >= maxCr3PreviewBoxes {
+			continue
+		}
+
+		header := make([]byte, min(b.size, 64))
+		if _, err := reader.ReadAt(header, b.offset); err != nil {
+			continue
+		}
+		if !bytes.HasPrefix(header, cr3PreviewUUID) {
+			continue
+		}
+
+		previewBoxes++
+		if candidate, ok := cr3PrvwPreview(header, b); ok {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	return candidates, cr3Orientation(moov, moovChildren), nil
+}
+
+// firstTrackSample locates the first sample of a track ('trak' payload)
+// through its sample sizes ('stsz') and chunk offsets ('stco', 'co64')
+func firstTrackSample(trak []byte) (rawPreviewCandidate, bool) {
+	stbl, found, err := nestedBox(trak, 0, "mdia", "minf", "stbl")
+	if err != nil || !found {
+		return rawPreviewCandidate{}, false
+	}
+
+	children, err := childBoxes(stbl, 0)
+	if err != nil {
+		return rawPreviewCandidate{}, false
+	}
+
+	stsz, found := findBox(children, "stsz")
+	if !found {
+		return rawPreviewCandidate{}, false
+	}
+
+	// Version and flags, then a common sample size or a size per sample
+	cursor := &byteCursor{data: boxPayload(stbl, stsz)}
+	cursor.take(4)
+	length := cursor.uint(4)
+	if samplesCount := cursor.uint(4); length == 0 && samplesCount > 0 {
+		length = cursor.uint(4)
+	}
+
+	var offset uint64
+	if co64, found := findBox(children, "co64"); found {
+		cursor := &byteCursor{data: boxPayload(stbl, co64)}
+		cursor.take(8)
+		offset = cursor.uint(8)
+		if cursor.err != nil {
+			return rawPreviewCandidate{}, false
+		}
+	} else if stco, found := findBox(children, "stco"); found {
+		cursor := &byteCursor{data: boxPayload(stbl, stco)}
+		cursor.take(8)
+		offset = cursor.uint(4)
+		if cursor.err != nil {
+			return rawPreviewCandidate{}, false
+		}
+	}
+
+	if cursor.err != nil || offset == 0 || length == 0 {
+		return rawPreviewCandidate{}, false
+	}
+
+	return rawPreviewCandidate{offset: int64(offset), length: int64(length)}, true
+}
+
+// cr3PrvwPreview locates the JPEG of the 'PRVW' box, given the header of
+// its enclosing 'uuid' box b: UUID, 8 unknown bytes, then 'PRVW' with 12
+// bytes of dimensions and flags followed by JPEG size and data
+func cr3PrvwPreview(header []byte, b box) (rawPreviewCandidate, bool) {
+	const prvwStart = 16 + 8
+	cursor := &byteCursor{data: header, pos: prvwStart}
+	cursor.take(4)
+	if string(cursor.take(4)) != "PRVW" {
+		return rawPreviewCandidate{}, false
+	}
+
+	cursor.take(12)
+	length := int64(cursor.uint(4))
+	if cursor.err != nil {
+		return rawPreviewCandidate{}, false
+	}
+
+	return rawPreviewCandidate{
+		offset: b.offset + int64(cursor.pos),
+		length: length,
+	}, true
+}
+
+// cr3Orientation reads orientation from 'CMT1' (TIFF IFD0) of the Canon
+// metadata box, zero when missing
+func cr3Orientation(moov []byte, moovChildren []box) int {
+	for _, b := range moovChildren {
+		payload := boxPayload(moov, b)
+		if b.boxType != "uuid" || !bytes.HasPrefix(payload, cr3MetadataUUID) {
+			continue
+		}
+
+		cmt1, found, err := nestedBox(payload, len(cr3MetadataUUID), "CMT1")
+		if err != nil || !found {
+			return 0
+		}
+
+		tiff, err := newTiffReader(cmt1)
+		if err != nil {
+			return 0
+		}
+
+		ifd0, err := tiff.readIFD(tiff.firstIFDOffset())
+		if err != nil {
+			return 0
+		}
+
+		orientation, _ := tiff.uint(ifd0, tagOrientation)
+		return int(orientation)
+	}
+
+	return 0
+}
+
+// largestPreview loads the candidate with most pixels among those
+// holding a baseline or progressive JPEG stream
+func largestPreview(
+	reader io.ReaderAt,
+	fileSize int64,
+	candidates []rawPreviewCandidate,
+) (*RawPreview, error) {
+	var best *rawPreviewCandidate
+	var bestWidth, bestHeight int
+
+	for idx, candidate := range candidates {
+		if candidate.offset <= 0 ||
+			candidate.length <= 0 ||
+			candidate.length > maxRawPreviewSize ||
+			candidate.offset > fileSize-candidate.length {
+			continue
+		}
+
+		header := make([]byte, min(candidate.length, rawPreviewHeaderSize))
+		if _, err := reader.ReadAt(header, candidate.offset); err != nil {
+			continue
+		}
+
+		width, height, ok := jpegPreviewDimensions(header)
+		if !ok || width*height <= bestWidth*bestHeight {
+			continue
+		}
+
+		best = &candidates[idx]
+		bestWidth, bestHeight = width, height
+	}
+
+	if best == nil {
+		return nil, ErrNoRawPreview
+	}
+
+	data := make([]byte, best.length)
+	if _, err := reader.ReadAt(data, best.offset); err != nil {
+		return nil, fmt.Errorf("failed to read embedded preview: %w", err)
+	}
+
+	return &RawPreview{JPEG: data, Width: bestWidth, Height: bestHeight}, nil
+}
+
+// jpegPreviewDimensions walks segments of a JPEG stream up to its frame
+// header. Only baseline, extended and progressive (SOF0 to SOF2) frames
+// are previews, lossless ones hold sensor data.
+func jpegPreviewDimensions(data []byte) (int, int, bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != markerSOI {
+		return 0, 0, false
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 0, 0, false
+		}
+
+		marker := data[pos+1]
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		if marker == markerSOS || marker == markerEOI {
+			return 0, 0, false
+		}
+
+		segmentLength := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if isStartOfFrame(marker) {
+			if marker > 0xC2 || pos+9 > len(data) {
+				return 0, 0, false
+			}
+
+			height := int(binary.BigEndian.Uint16(data[pos+5:]))
+			width := int(binary.BigEndian.Uint16(data[pos+7:]))
+			return width, height, width > 0 && height > 0
+		}
+
+		pos += 2 + segmentLength
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,70 @@
+package metadata
+
+import (
+	"bytes"
+	"errors"
+	"image/jpeg"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+func TestExtractRawPreview(t *testing.T) {
+
+	// Each fixture embeds a 96x64 preview along with smaller thumbnails
+	// or lossless sensor data with larger dimensions
+	tests := []struct {
+		filename    string
+		format      format.Format
+		orientation int
+	}{
+		{filename: "12 raw_nikon.nef", format: format.NEF, orientation: 6},
+		{filename: "13 raw_sony.arw", format: format.ARW, orientation: 8},
+		{filename: "14 raw_canon.cr2", format: format.CR2, orientation: 3},
+		{filename: "15 raw_adobe.dng", format: format.DNG, orientation: 6},
+		{filename: "16 raw_canon.cr3", format: format.CR3, orientation: 6},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.filename, func(t *testing.T) {
+			preview, err := ExtractRawPreview(testutils.TestFilePath(tc.filename), tc.format)
+			if err != nil {
+				t.Fatalf("failed to extract preview: %v", err)
+			}
+
+			if preview.Width != 96 || preview.Height != 64 {
+				t.Errorf("preview is %dx%d, want 96x64", preview.Width, preview.Height)
+			}
+			if preview.Orientation != tc.orientation {
+				t.Errorf("orientation = %d, want %d", preview.Orientation, tc.orientation)
+			}
+
+			config, err := jpeg.DecodeConfig(bytes.NewReader(preview.JPEG))
+			if err != nil {
+				t.Fatalf("preview isn't a valid JPEG: %v", err)
+			}
+			if config.Width != 96 || config.Height != 64 {
+				t.Errorf("decoded preview is %dx%d", config.Width, config.Height)
+			}
+		})
+	}
+}
+
+func TestExtractRawPreview_NoPreview(t *testing.T) {
+	_, err := ExtractRawPreview(testutils.TestFilePath("17 raw_no_preview.dng"), format.DNG)
+	if !errors.Is(err, ErrNoRawPreview) {
+		t.Fatalf("expected ErrNoRawPreview, got %v", err)
+	}
+}
+
+func TestJpegPreviewDimensions_RejectsLossless(t *testing.T) {
+	lossless := []byte{
+		0xFF, 0xD8, 0xFF, 0xC3, 0x00, 0x0E, 0x0E,
+		0x02, 0x00, 0x02, 0x00, 0x02, 0x01, 0x11, 0x00, 0x02, 0x11, 0x00,
+	}
+
+	if _, _, ok := jpegPreviewDimensions(lossless); ok {
+		t.Fatal("lossless JPEG accepted as preview")
+	}
+}
@@ -0,0 +1,80 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// Metadata chunks larger than this are ignored
+const maxMetadataChunkSize = 4 * 1024 * 1024
+
+// readWebp reads EXIF and XMP chunks of a WebP (RIFF) file, plus canvas
+// dimensions from the VP8X chunk. Image chunks are skipped, not loaded.
+func readWebp(reader io.ReaderAt, fileSize int64) (*models.MediaMetadata, error) {
+	var header [12]byte
+	if _, err := reader.ReadAt(header[:], 0); err != nil {
+		return nil, fmt.Errorf("failed to read WebP header: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WEBP" {
+		return nil, errors.New("not a WebP file")
+	}
+
+	meta := new(models.MediaMetadata)
+	var xmpPacket []byte
+
+	offset := int64(12)
+	for offset+8 <= fileSize {
+		var chunkHeader [8]byte
+		if _, err := reader.ReadAt(chunkHeader[:], offset); err != nil {
+			return nil, fmt.Errorf("failed to read WebP chunk: %w", err)
+		}
+
+		chunkType := string(chunkHeader[0:4])
+		chunkSize := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+		dataOffset := offset + 8
+
+		switch chunkType {
+		case "EXIF", "XMP ", "VP8X":
+			if chunkSize > maxMetadataChunkSize ||
+				dataOffset+chunkSize > fileSize {
+				break
+			}
+
+			data := make([]byte, chunkSize)
+			if _, err := reader.ReadAt(data, dataOffset); err != nil {
+				return nil, fmt.Errorf("failed to read WebP chunk: %w", err)
+			}
+
+			switch chunkType {
+			case "EXIF":
+				if err := parseExif(data, meta); err != nil {
+					return nil, fmt.Errorf("failed to parse EXIF: %w", err)
+				}
+			case "XMP ":
+				xmpPacket = data
+			case "VP8X":
+				if len(data) >= 10 {
+					meta.Width = int(uint24(data[4:7])) + 1
+					meta.Height = int(uint24(data[7:10])) + 1
+				}
+			}
+		}
+
+		// Chunks are padded to even sizes
+		offset = dataOffset + chunkSize + chunkSize%2
+	}
+
+	if xmpPacket != nil {
+		parseXmp(xmpPacket, meta)
+	}
+
+	return meta, nil
+}
+
+func uint24(data []byte) uint32 {
+	return uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16
+}
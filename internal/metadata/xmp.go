@@ -0,0 +1,126 @@
+package metadata
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// XMP properties read by parseXmp, in order of preference
+var (
+	xmpCaptureTimeProps = []string{
+		"exif:DateTimeOriginal",
+		"photoshop:DateCreated",
+		"xmp:CreateDate",
+	}
+	xmpMakeProps      = []string{"tiff:Make"}
+	xmpModelProps     = []string{"tiff:Model"}
+	xmpLensProps      = []string{"exifEX:LensModel", "aux:Lens"}
+	xmpLatitudeProps  = []string{"exif:GPSLatitude"}
+	xmpLongitudeProps = []string{"exif:GPSLongitude"}
+)
+
+// XMP coordinates look like '37,46.5N' or '37,46,30N'
+var xmpCoordinatePattern = regexp.MustCompile(
+	`^(\d+),(\d+(?:\.\d+)?)(?:,(\d+(?:\.\d+)?))?([NSEW])$`,
+)
+
+// parseXmp fills fields missing in meta from an XMP packet. Properties
+// are looked up both as attributes and as elements of the RDF
+// description, which covers packets written by cameras and editors.
+func parseXmp(packet []byte, meta *models.MediaMetadata) {
+	xmp := string(packet)
+
+	if meta.CaptureTime == "" {
+		if captureTime := xmpProperty(xmp, xmpCaptureTimeProps); captureTime != "" {
+			meta.CaptureTime = normalizeXmpTime(captureTime)
+		}
+	}
+
+	setIfEmpty(&meta.CameraMake, xmpProperty(xmp, xmpMakeProps))
+	setIfEmpty(&meta.CameraModel, xmpProperty(xmp, xmpModelProps))
+	setIfEmpty(&meta.LensModel, xmpProperty(xmp, xmpLensProps))
+
+	if meta.Location == nil {
+		latitude, okLat := parseXmpCoordinate(xmpProperty(xmp, xmpLatitudeProps))
+		longitude, okLon := parseXmpCoordinate(xmpProperty(xmp, xmpLongitudeProps))
+		if okLat && okLon {
+			meta.Location = &models.GeoLocation{
+				Latitude:  roundTo(latitude, 6),
+				Longitude: roundTo(longitude, 6),
+			}
+		}
+	}
+}
+
+// xmpProperty returns value of first property found in xmp
+func xmpProperty(xmp string, names []string) string {
+	for _, name := range names {
+		quotedName := regexp.QuoteMeta(name)
+		patterns := []string{
+			quotedName + `\s*=\s*"([^"]*)"`,
+			`<` + quotedName + `>([^<]*)</` + quotedName + `>`,
+		}
+
+		for _, pattern := range patterns {
+			match := regexp.MustCompile(pattern).FindStringSubmatch(xmp)
+			if match != nil && strings.TrimSpace(match[1]) != "" {
+				return strings.TrimSpace(match[1])
+			}
+		}
+	}
+
+	return ""
+}
+
+// normalizeXmpTime converts XMP dates (ISO 8601, optionally without
+// seconds or offset) into the format of models.MediaMetadata.CaptureTime
+func normalizeXmpTime(value string) string {
+	layouts := []struct {
+		layout    string
+		hasOffset bool
+	}{
+		{time.RFC3339Nano, true},
+		{"2006-01-02T15:04Z07:00", true},
+		{"2006-01-02T15:04:05.999999999", false},
+		{"2006-01-02T15:04", false},
+	}
+
+	for _, candidate := range layouts {
+		parsed, err := time.Parse(candidate.layout, value)
+		if err != nil {
+			continue
+		}
+
+		if candidate.hasOffset {
+			return parsed.Format(time.RFC3339)
+		}
+		return parsed.Format("2006-01-02T15:04:05")
+	}
+
+	return ""
+}
+
+func parseXmpCoordinate(value string) (float64, bool) {
+	match := xmpCoordinatePattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, false
+	}
+
+	degrees, _ := strconv.ParseFloat(match[1], 64)
+	minutes, _ := strconv.ParseFloat(match[2], 64)
+	seconds := 0.0
+	if match[3] != "" {
+		seconds, _ = strconv.ParseFloat(match[3], 64)
+	}
+
+	coordinate := degrees + minutes/60 + seconds/3600
+	if match[4] == "S" || match[4] == "W" {
+		coordinate = -coordinate
+	}
+
+	return coordinate, true
+}
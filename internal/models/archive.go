@@ -0,0 +1,15 @@
+package models
+
+// ArchiveOptions bounds reading of entries from zip based originals
+// (comic books, EPUB e-books and plain zip archives), guarding against
+// zip bombs.
+type ArchiveOptions struct {
+
+	// Entries whose uncompressed size exceeds this are rejected. Zero
+	// disables the check.
+	MaxEntryBytes int64
+
+	// Entries whose uncompressed size exceeds their compressed size by
+	// more than this factor are rejected. Zero disables the check.
+	MaxCompressionRatio int64
+}
@@ -0,0 +1,17 @@
+package models
+
+// AudioInfo describes the main audio stream of an audio file, as reported
+// by ffprobe
+type AudioInfo struct {
+	DurationMs int64 `json:"durationMs"`
+
+	// Codec of the audio stream (e.g. 'mp3', 'aac', 'flac')
+	Codec string `json:"codec"`
+
+	SampleRate int `json:"sampleRate"`
+	Channels   int `json:"channels"`
+
+	// Whether file embeds cover art, which thumbnails are produced from.
+	// Otherwise thumbnails show a waveform of the audio.
+	HasCover bool `json:"hasCover"`
+}
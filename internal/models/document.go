@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// DocumentOptions describes how the first page of PDF and office
+// documents is rasterized into the original of their thumbnails.
+type DocumentOptions struct {
+
+	// Resolution pages are rendered at, in dots per inch
+	DPI int
+
+	// Time allowed for converting and rendering a document. Zero
+	// disables the timeout.
+	Timeout time.Duration
+
+	// Documents with more pages than this are rejected. Zero disables
+	// the check.
+	MaxPages int
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCategory classifies processing errors so that callers can tell
+// apart failures worth retrying from the ones that will fail again.
+type ErrorCategory string
+
+const (
+	// ErrCategoryTransient is the category of uncategorized errors
+	// (e.g. IO errors or external tools failures).
+	ErrCategoryTransient ErrorCategory = "transient"
+
+	// ErrCategoryPermanent marks requests that will never succeed as they
+	// are (e.g. inputs exceeding configured limits).
+	ErrCategoryPermanent ErrorCategory = "permanent"
+
+	// ErrCategoryRejectedPath marks requests whose file path escapes the
+	// originals or thumbnails root directories.
+	ErrCategoryRejectedPath ErrorCategory = "rejected_path"
+)
+
+// CategorizedError wraps an error together with its category
+type CategorizedError struct {
+	Category ErrorCategory
+	Err      error
+}
+
+func (e *CategorizedError) Error() string {
+	return fmt.Sprintf("%s error: %v", e.Category, e.Err)
+}
+
+func (e *CategorizedError) Unwrap() error {
+	return e.Err
+}
+
+// NewPermanentError wraps err into a permanent CategorizedError
+func NewPermanentError(err error) error {
+	return &CategorizedError{Category: ErrCategoryPermanent, Err: err}
+}
+
+// NewRejectedPathError wraps err into a rejected path CategorizedError
+func NewRejectedPathError(err error) error {
+	return &CategorizedError{Category: ErrCategoryRejectedPath, Err: err}
+}
+
+// ErrorCategoryOf returns the category of first CategorizedError in err
+// chain, or ErrCategoryTransient if there is none.
+func ErrorCategoryOf(err error) ErrorCategory {
+	var categorizedErr *CategorizedError
+	if errors.As(err, &categorizedErr) {
+		return categorizedErr.Category
+	}
+
+	return ErrCategoryTransient
+}
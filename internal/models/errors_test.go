@@ -0,0 +1,23 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCategoryOf(t *testing.T) {
+	baseErr := errors.New("image too large")
+	permanentErr := NewPermanentError(baseErr)
+	wrappedErr := fmt.Errorf("cannot generate thumbnails: %w", permanentErr)
+
+	if got := ErrorCategoryOf(wrappedErr); got != ErrCategoryPermanent {
+		t.Fatalf("ErrorCategoryOf(wrapped) = %q, want %q", got, ErrCategoryPermanent)
+	}
+	if !errors.Is(wrappedErr, baseErr) {
+		t.Fatalf("wrapped error doesn't unwrap to base error")
+	}
+	if got := ErrorCategoryOf(baseErr); got != ErrCategoryTransient {
+		t.Fatalf("ErrorCategoryOf(base) = %q, want %q", got, ErrCategoryTransient)
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultFrameSamplePercents are the points of a video, as percentages
+// of its duration, where candidate frames for thumbnails are sampled.
+var DefaultFrameSamplePercents = []int{10, 25, 50, 75}
+
+// ParseFrameSamplePercents parses a comma separated list of percentages
+// (0 to 99) of video duration. Empty value returns the default list,
+// 'none' disables sampling in favor of a fixed seek.
+func ParseFrameSamplePercents(value string) ([]int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "":
+		return DefaultFrameSamplePercents, nil
+	case "none":
+		return nil, nil
+	}
+
+	var percents []int
+	for _, rawPercent := range strings.Split(value, ",") {
+		percent, err := strconv.Atoi(strings.TrimSpace(rawPercent))
+		if err != nil {
+			return nil, fmt.Errorf("invalid sample percent %q: %w", rawPercent, err)
+		}
+		if percent < 0 || percent > 99 {
+			return nil, fmt.Errorf("sample percent out of range 0-99: %d", percent)
+		}
+
+		percents = append(percents, percent)
+	}
+
+	return percents, nil
+}
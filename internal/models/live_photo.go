@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LivePhotoVideoMode determines what is produced for the video of an
+// Apple Live Photo, which mostly shows the same scene as its photo
+type LivePhotoVideoMode string
+
+const (
+	// LivePhotoVideoStill produces still thumbnails, as for other videos
+	LivePhotoVideoStill LivePhotoVideoMode = "still"
+
+	// LivePhotoVideoPreview produces a looping preview clip instead of
+	// thumbnails duplicating the photo
+	LivePhotoVideoPreview LivePhotoVideoMode = "preview"
+)
+
+// ParseLivePhotoVideoMode parses a mode name (case insensitive). Empty
+// value defaults to LivePhotoVideoStill.
+func ParseLivePhotoVideoMode(value string) (LivePhotoVideoMode, error) {
+	switch mode := LivePhotoVideoMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return LivePhotoVideoStill, nil
+	case LivePhotoVideoStill, LivePhotoVideoPreview:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown live photo video mode: %q", value)
+	}
+}
+
+// LivePhotoRole tells which part of a Live Photo or Motion Photo an
+// original is
+type LivePhotoRole string
+
+const (
+	LivePhotoRolePhoto LivePhotoRole = "photo"
+	LivePhotoRoleVideo LivePhotoRole = "video"
+)
+
+// LivePhoto describes the pairing of a photo with the short video
+// captured along with it: separate files with the same name for Apple
+// Live Photos, a video embedded in the JPEG for Google and Samsung
+// Motion Photos.
+type LivePhoto struct {
+	Role LivePhotoRole `json:"role"`
+
+	// Path to the other file of an Apple Live Photo, relative to env
+	// variable 'DIR_ORIGINALS_ROOT'
+	PairedFilePath string `json:"pairedFilePath,omitempty"`
+
+	// Content identifier shared by both files of an Apple Live Photo.
+	// Empty when files were paired by name only.
+	ContentIdentifier string `json:"contentIdentifier,omitempty"`
+
+	// Name of the video extracted from a Motion Photo, relative to the
+	// directory where thumbnails of the original file are stored.
+	VideoFileName string `json:"videoFileName,omitempty"`
+}
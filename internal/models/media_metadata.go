@@ -0,0 +1,56 @@
+package models
+
+// MediaMetadata holds capture metadata read from originals (EXIF and XMP
+// for images, QuickTime atoms for videos). Fields missing from the
+// original are left empty.
+type MediaMetadata struct {
+
+	// Capture time in RFC 3339 format. Offset is omitted when original
+	// doesn't record it (e.g. '2019-08-13T15:20:56'), as camera local
+	// time zone is unknown.
+	CaptureTime string `json:"captureTime,omitempty"`
+
+	CameraMake  string `json:"cameraMake,omitempty"`
+	CameraModel string `json:"cameraModel,omitempty"`
+	LensModel   string `json:"lensModel,omitempty"`
+
+	Exposure *Exposure    `json:"exposure,omitempty"`
+	Location *GeoLocation `json:"location,omitempty"`
+
+	// Dimensions as stored in the original, before applying orientation
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+
+	// EXIF orientation (1 to 8)
+	Orientation int `json:"orientation,omitempty"`
+
+	// Identifier Apple devices write into both files of a Live Photo
+	// (photo MakerNote and video QuickTime keys)
+	ContentIdentifier string `json:"contentIdentifier,omitempty"`
+}
+
+type Exposure struct {
+
+	// Exposure time in seconds, as fraction for short ones (e.g. '1/125')
+	ExposureTime string `json:"exposureTime,omitempty"`
+
+	FNumber float64 `json:"fNumber,omitempty"`
+	ISO     int     `json:"iso,omitempty"`
+
+	// Focal length in millimeters
+	FocalLength float64 `json:"focalLength,omitempty"`
+}
+
+// GeoLocation is a WGS 84 position in decimal degrees
+type GeoLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+
+	// Altitude in meters above sea level, if known
+	Altitude *float64 `json:"altitude,omitempty"`
+}
+
+// IsEmpty reports whether no metadata was found
+func (m *MediaMetadata) IsEmpty() bool {
+	return *m == MediaMetadata{}
+}
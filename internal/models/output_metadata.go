@@ -0,0 +1,73 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MetadataField identifies a group of original metadata that may be
+// copied into thumbnails. Thumbnails carry no metadata by default.
+type MetadataField string
+
+const (
+	// MetadataCaptureTime keeps capture date, time and offset
+	MetadataCaptureTime MetadataField = "capture_time"
+
+	// MetadataCamera keeps camera make, model and lens
+	MetadataCamera MetadataField = "camera"
+
+	// MetadataExposure keeps exposure time, aperture, ISO and focal length
+	MetadataExposure MetadataField = "exposure"
+
+	// MetadataLocation keeps GPS coordinates and altitude
+	MetadataLocation MetadataField = "location"
+)
+
+// ParseMetadataKeepList parses a comma separated list of metadata fields
+// (case insensitive). Empty value and 'none' keep no metadata.
+func ParseMetadataKeepList(value string) ([]MetadataField, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "none" {
+		return nil, nil
+	}
+
+	var fields []MetadataField
+	for _, rawField := range strings.Split(value, ",") {
+		switch field := MetadataField(strings.TrimSpace(rawField)); field {
+		case MetadataCaptureTime, MetadataCamera, MetadataExposure, MetadataLocation:
+			fields = append(fields, field)
+		default:
+			return nil, fmt.Errorf("unknown metadata field: %q", rawField)
+		}
+	}
+
+	return fields, nil
+}
+
+// ColorProfilePolicy determines how color profiles of originals carry
+// over to thumbnails.
+type ColorProfilePolicy string
+
+const (
+	// ColorProfileSRGB converts pixels of wide gamut originals (e.g.
+	// Display P3) to sRGB. Thumbnails carry no profile, which viewers
+	// interpret as sRGB.
+	ColorProfileSRGB ColorProfilePolicy = "srgb"
+
+	// ColorProfileEmbed keeps original pixels and embeds the original
+	// profile into thumbnails.
+	ColorProfileEmbed ColorProfilePolicy = "embed"
+)
+
+// ParseColorProfilePolicy parses a policy name (case insensitive). Empty
+// value defaults to ColorProfileSRGB.
+func ParseColorProfilePolicy(value string) (ColorProfilePolicy, error) {
+	switch policy := ColorProfilePolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return ColorProfileSRGB, nil
+	case ColorProfileSRGB, ColorProfileEmbed:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown color profile policy: %q", value)
+	}
+}
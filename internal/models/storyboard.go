@@ -0,0 +1,52 @@
+package models
+
+// StoryboardOptions describes sprite sheets of evenly spaced video
+// frames, used by players to preview positions of their scrub bar.
+type StoryboardOptions struct {
+
+	// Number of frames sampled from the video. Zero disables storyboards.
+	Frames int
+
+	// Width in pixels of each frame in sheets
+	TileWidth int
+
+	// Grid of frames in each sheet. Frames beyond Columns * Rows go into
+	// further sheets.
+	Columns int
+	Rows    int
+}
+
+func (o StoryboardOptions) Enabled() bool {
+	return o.Frames > 0
+}
+
+// Storyboard describes sprite sheets produced for a video along with the
+// WebVTT file mapping time ranges to frames in them
+type Storyboard struct {
+
+	// Name of the WebVTT file, relative to the directory where
+	// thumbnails of the original file are stored.
+	VttFileName string `json:"vttFileName"`
+
+	Sheets []StoryboardSheet `json:"sheets"`
+
+	// Dimensions of each frame in sheets
+	TileWidth  int `json:"tileWidth"`
+	TileHeight int `json:"tileHeight"`
+
+	// Number of frames across all sheets
+	Frames int `json:"frames"`
+
+	// Playback time covered by each frame
+	IntervalMs int64 `json:"intervalMs"`
+}
+
+type StoryboardSheet struct {
+
+	// Name of the sheet file, relative to the directory where
+	// thumbnails of the original file are stored.
+	FileName string `json:"fileName"`
+
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
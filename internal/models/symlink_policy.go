@@ -0,0 +1,33 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SymlinkPolicy determines how symlinks resolving outside of the
+// originals or thumbnails root directories are handled.
+type SymlinkPolicy string
+
+const (
+	// SymlinkDeny rejects paths that resolve outside of their root
+	// directory through symlinks.
+	SymlinkDeny SymlinkPolicy = "deny"
+
+	// SymlinkFollow follows symlinks wherever they point to. Paths are
+	// still required to be lexically contained in their root directory.
+	SymlinkFollow SymlinkPolicy = "follow"
+)
+
+// ParseSymlinkPolicy parses a policy name (case insensitive). Empty
+// value defaults to SymlinkDeny.
+func ParseSymlinkPolicy(value string) (SymlinkPolicy, error) {
+	switch policy := SymlinkPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return SymlinkDeny, nil
+	case SymlinkDeny, SymlinkFollow:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown symlink policy: %q", value)
+	}
+}
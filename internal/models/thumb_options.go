@@ -0,0 +1,316 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UpscalePolicy determines what to do with requested thumbnail widths
+// that are larger than the width of the original file.
+type UpscalePolicy string
+
+const (
+	// UpscaleSkip discards widths larger than the original. If that
+	// leaves no width at all, a single thumbnail with the original
+	// width is generated instead.
+	UpscaleSkip UpscalePolicy = "skip"
+
+	// UpscaleClamp replaces widths larger than the original with the
+	// original width, producing an 'original size' variant.
+	UpscaleClamp UpscalePolicy = "clamp"
+
+	// UpscaleAllow generates every requested width, upscaling the
+	// original when needed.
+	UpscaleAllow UpscalePolicy = "allow"
+)
+
+// ParseUpscalePolicy parses a policy name (case insensitive). Empty
+// value defaults to UpscaleClamp.
+func ParseUpscalePolicy(value string) (UpscalePolicy, error) {
+	switch policy := UpscalePolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return UpscaleClamp, nil
+	case UpscaleSkip, UpscaleClamp, UpscaleAllow:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown upscale policy: %q", value)
+	}
+}
+
+// ResizeMode determines how originals are fit into thumbnail dimensions.
+type ResizeMode string
+
+const (
+	// ResizeFitWidth scales the original to target width keeping its
+	// aspect ratio. Height is unbounded.
+	ResizeFitWidth ResizeMode = "fit-width"
+
+	// ResizeFitBox scales the original to fit inside the spec box,
+	// keeping its aspect ratio.
+	ResizeFitBox ResizeMode = "fit-box"
+
+	// ResizeCover scales and crops the original to fill the spec box.
+	ResizeCover ResizeMode = "cover"
+
+	// ResizePad fits the original inside the spec box, filling remaining
+	// space with transparent pixels.
+	ResizePad ResizeMode = "pad"
+)
+
+// ResizeSpec describes how thumbnails are shaped. Box based modes bound
+// thumbnails by a box of explicit dimensions (in pixels) instead of the
+// configured widths.
+//
+// Zero value behaves as ResizeFitWidth.
+type ResizeSpec struct {
+	Mode      ResizeMode
+	BoxWidth  int
+	BoxHeight int
+}
+
+// ParseResizeSpec parses specs in the form '<mode>[:<w>x<h>]', e.g.
+// 'fit-width', 'fit-box:1600x400', 'cover:320x320' or 'pad:640x360'.
+// Empty value defaults to 'fit-width'.
+func ParseResizeSpec(value string) (ResizeSpec, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return ResizeSpec{Mode: ResizeFitWidth}, nil
+	}
+
+	rawMode, rawBox, hasBox := strings.Cut(value, ":")
+	mode := ResizeMode(rawMode)
+
+	switch mode {
+	case ResizeFitWidth:
+		if hasBox {
+			return ResizeSpec{}, fmt.Errorf(
+				"resize mode %q doesn't accept box dimensions: %q",
+				mode,
+				value,
+			)
+		}
+
+		return ResizeSpec{Mode: mode}, nil
+
+	case ResizeFitBox, ResizeCover, ResizePad:
+		if !hasBox {
+			return ResizeSpec{}, fmt.Errorf(
+				"resize mode %q requires box dimensions (e.g. %s:640x360)",
+				mode,
+				mode,
+			)
+		}
+
+		boxWidth, boxHeight, err := parseBoxDimensions(rawBox)
+		if err != nil {
+			return ResizeSpec{}, fmt.Errorf("invalid resize spec %q: %w", value, err)
+		}
+
+		return ResizeSpec{
+			Mode:      mode,
+			BoxWidth:  boxWidth,
+			BoxHeight: boxHeight,
+		}, nil
+
+	default:
+		return ResizeSpec{}, fmt.Errorf("unknown resize mode: %q", rawMode)
+	}
+}
+
+// IsBoxed tells whether thumbnails are bounded by a box instead of
+// just their width.
+func (s ResizeSpec) IsBoxed() bool {
+	switch s.Mode {
+	case ResizeFitBox, ResizeCover, ResizePad:
+		return s.BoxWidth > 0 && s.BoxHeight > 0
+	default:
+		return false
+	}
+}
+
+// Box returns dimensions of the box thumbnails are bounded by, shrunk
+// to maxWidth keeping its proportions when wider (e.g. when upscale
+// policy clamps the box to the width of the original). Returns zero
+// dimensions for width-bounded specs.
+func (s ResizeSpec) Box(maxWidth int) (int, int) {
+	if !s.IsBoxed() {
+		return 0, 0
+	}
+	if maxWidth >= s.BoxWidth {
+		return s.BoxWidth, s.BoxHeight
+	}
+
+	return maxWidth, max(1, maxWidth*s.BoxHeight/s.BoxWidth)
+}
+
+// Tag returns a short identifier of the spec suitable for file names
+// (e.g. 'cover320x320'). Returns empty string for width-bounded specs so
+// that their thumbnails keep the historical naming.
+func (s ResizeSpec) Tag() string {
+	if !s.IsBoxed() {
+		return ""
+	}
+
+	tagMode := string(s.Mode)
+	if s.Mode == ResizeFitBox {
+		tagMode = "box"
+	}
+
+	return fmt.Sprintf("%s%dx%d", tagMode, s.BoxWidth, s.BoxHeight)
+}
+
+func (s ResizeSpec) String() string {
+	if !s.IsBoxed() {
+		return string(ResizeFitWidth)
+	}
+
+	return fmt.Sprintf("%s:%dx%d", s.Mode, s.BoxWidth, s.BoxHeight)
+}
+
+func parseBoxDimensions(value string) (int, int, error) {
+	rawWidth, rawHeight, found := strings.Cut(value, "x")
+	if !found {
+		return 0, 0, fmt.Errorf("box dimensions must be '<w>x<h>': %q", value)
+	}
+
+	width, errW := strconv.Atoi(rawWidth)
+	height, errH := strconv.Atoi(rawHeight)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf(
+			"box dimensions must be positive integers: %q",
+			value,
+		)
+	}
+
+	return width, height, nil
+}
+
+// FocalPoint is a point of interest in the original, with coordinates
+// normalized to [0, 1] from the top left corner.
+type FocalPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+func (p FocalPoint) Validate() error {
+	if p.X < 0 || p.X > 1 || p.Y < 0 || p.Y > 1 {
+		return fmt.Errorf(
+			"focal point coordinates must be within [0, 1]: (%v, %v)",
+			p.X,
+			p.Y,
+		)
+	}
+
+	return nil
+}
+
+// ThumbPreset is a named set of thumbnail options (e.g. 'grid' or
+// 'og-image'). Requests select presets by name.
+type ThumbPreset struct {
+	Name   string
+	Widths []int
+	Resize ResizeSpec
+
+	// Extensions of output formats (e.g. '.webp', '.jpg')
+	Formats []string
+
+	// Encoding quality from 0 to 100
+	Quality int
+
+	// Appended to thumbnail file names so that outputs of presets don't
+	// collide. Empty suffix keeps the historical naming.
+	Suffix string
+}
+
+// Extensions by supported thumbnail format name
+var thumbFormatExtensions = map[string]string{
+	"webp": ".webp",
+	"jpeg": ".jpg",
+	"jpg":  ".jpg",
+	"png":  ".png",
+	"avif": ".avif",
+}
+
+// ParseThumbFormats parses a comma separated list of thumbnail format
+// names (e.g. 'webp,jpeg') into file extensions.
+func ParseThumbFormats(value string) ([]string, error) {
+	var extensions []string
+	for _, rawFormat := range strings.Split(value, ",") {
+		formatName := strings.ToLower(strings.TrimSpace(rawFormat))
+		if formatName == "" {
+			continue
+		}
+
+		extension, found := thumbFormatExtensions[formatName]
+		if !found {
+			return nil, fmt.Errorf("unsupported thumbnail format: %q", rawFormat)
+		}
+
+		extensions = append(extensions, extension)
+	}
+
+	if len(extensions) == 0 {
+		return nil, fmt.Errorf("at least one thumbnail format is required")
+	}
+
+	return extensions, nil
+}
+
+// InputLimits bounds the originals accepted for thumbnail generation, to
+// protect the service against oversized inputs and decompression bombs.
+// Zero value of each limit disables it.
+type InputLimits struct {
+	MaxFileBytes int64
+	MaxPixels    int64
+	MaxDimension int
+}
+
+// AnimationLimits bounds animated thumbnails produced from animated
+// originals (GIF, animated WebP). Zero value of each limit disables it.
+type AnimationLimits struct {
+
+	// Frames beyond this count are dropped
+	MaxFrames int
+
+	// Frames beyond this playback time are dropped
+	MaxDuration time.Duration
+
+	// Animated thumbnails larger than this are replaced by a static one
+	MaxBytes int64
+}
+
+// PlaceholderKind identifies an algorithm producing compact placeholders
+// that clients render while thumbnails load.
+type PlaceholderKind string
+
+const (
+	PlaceholderBlurHash  PlaceholderKind = "blurhash"
+	PlaceholderThumbHash PlaceholderKind = "thumbhash"
+)
+
+// ParsePlaceholderKinds parses a comma separated list of placeholder
+// kinds (case insensitive). Empty value defaults to all kinds and 'none'
+// disables placeholders.
+func ParsePlaceholderKinds(value string) ([]PlaceholderKind, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "":
+		return []PlaceholderKind{PlaceholderBlurHash, PlaceholderThumbHash}, nil
+	case "none":
+		return nil, nil
+	}
+
+	var kinds []PlaceholderKind
+	for _, rawKind := range strings.Split(value, ",") {
+		switch kind := PlaceholderKind(strings.TrimSpace(rawKind)); kind {
+		case PlaceholderBlurHash, PlaceholderThumbHash:
+			kinds = append(kinds, kind)
+		default:
+			return nil, fmt.Errorf("unknown placeholder kind: %q", rawKind)
+		}
+	}
+
+	return kinds, nil
+}
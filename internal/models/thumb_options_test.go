@@ -0,0 +1,71 @@
+package models
+
+import "testing"
+
+func TestParseResizeSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected ResizeSpec
+		tag      string
+	}{
+		{name: "empty defaults to fit width", value: "", expected: ResizeSpec{Mode: ResizeFitWidth}},
+		{name: "fit width", value: "fit-width", expected: ResizeSpec{Mode: ResizeFitWidth}},
+		{name: "fit box", value: "fit-box:1600x400", expected: ResizeSpec{ResizeFitBox, 1600, 400}, tag: "box1600x400"},
+		{name: "cover square", value: "cover:320x320", expected: ResizeSpec{ResizeCover, 320, 320}, tag: "cover320x320"},
+		{name: "pad case insensitive", value: " PAD:640X360 ", expected: ResizeSpec{ResizePad, 640, 360}, tag: "pad640x360"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := ParseResizeSpec(tc.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if spec != tc.expected {
+				t.Fatalf("unexpected spec: got %+v want %+v", spec, tc.expected)
+			}
+
+			if spec.Tag() != tc.tag {
+				t.Fatalf("unexpected tag: got %q want %q", spec.Tag(), tc.tag)
+			}
+		})
+	}
+}
+
+func TestParseResizeSpec_Invalid(t *testing.T) {
+	values := []string{
+		"stretch",
+		"cover",
+		"cover:16",
+		"pad:0x9",
+		"pad:16:9",
+		"fit-box:-4x1",
+		"fit-width:16x9",
+	}
+
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			if _, err := ParseResizeSpec(value); err == nil {
+				t.Fatalf("expected error for %q, got nil", value)
+			}
+		})
+	}
+}
+
+func TestResizeSpecBox(t *testing.T) {
+	spec := ResizeSpec{Mode: ResizeCover, BoxWidth: 640, BoxHeight: 360}
+	if width, height := spec.Box(4000); width != 640 || height != 360 {
+		t.Fatalf("unexpected box: got %dx%d want 640x360", width, height)
+	}
+
+	// Box clamped to a narrower original keeps its proportions
+	if width, height := spec.Box(320); width != 320 || height != 180 {
+		t.Fatalf("unexpected clamped box: got %dx%d want 320x180", width, height)
+	}
+
+	if width, height := (ResizeSpec{}).Box(320); width != 0 || height != 0 {
+		t.Fatalf("expected no box for fit width, got %dx%d", width, height)
+	}
+}
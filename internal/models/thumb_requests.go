@@ -10,4 +10,16 @@ type ThumbRequest struct {
 	// Path to original media file, relative to env
 	// variable 'DIR_ORIGINALS_ROOT'
 	FilePath string `json:"filePath"`
+
+	// Optional resize spec (e.g. 'cover:320x320') overriding the configured
+	// one. See ParseResizeSpec for accepted values.
+	ResizeMode string `json:"resizeMode,omitempty"`
+
+	// Optional point of interest to center crops around, for resize
+	// modes that crop the original.
+	FocalPoint *FocalPoint `json:"focalPoint,omitempty"`
+
+	// Optional names of presets to generate. Configured default presets
+	// are generated when empty.
+	Presets []string `json:"presets,omitempty"`
 }
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ThumbGenResult describes the outcome of a thumbnail generation request.
+//
+// It is persisted as a manifest file next to generated thumbnails and,
+// when a results queue is configured, published as completion event so
+// clients can pick the best available thumbnail.
+type ThumbGenResult struct {
+	ThumbRequestId uuid.UUID `json:"thumbRequestId"`
+
+	// Path to original media file, relative to env
+	// variable 'DIR_ORIGINALS_ROOT'
+	FilePath string `json:"filePath"`
+
+	GeneratedAt time.Time `json:"generatedAt"`
+
+	// Dimensions of the original file (or of the frame extracted
+	// from it for videos, of the cover art or waveform for audio files)
+	OrigWidth  int `json:"origWidth"`
+	OrigHeight int `json:"origHeight"`
+
+	// Thumbnails actually produced. Might differ from configured
+	// widths depending on the upscale policy.
+	Thumbs []Thumb `json:"thumbs"`
+
+	// Compact previews to render while thumbnails load
+	Placeholders *Placeholders `json:"placeholders,omitempty"`
+
+	// Dominant color and palette of the original
+	Colors *ColorInfo `json:"colors,omitempty"`
+
+	// Perceptual difference hash of the original as 16 hex digits.
+	// Near duplicates have hashes within a small Hamming distance.
+	DHash string `json:"dHash,omitempty"`
+
+	// Capture metadata of the original
+	Metadata *MediaMetadata `json:"metadata,omitempty"`
+
+	// Stream information of videos, as probed by ffprobe
+	Video *VideoInfo `json:"video,omitempty"`
+
+	// Stream information of audio files, as probed by ffprobe
+	Audio *AudioInfo `json:"audio,omitempty"`
+
+	// Looping preview clip of videos, when enabled
+	Preview *VideoPreview `json:"preview,omitempty"`
+
+	// Sprite sheets of video frames for scrub bar previews, when enabled
+	Storyboard *Storyboard `json:"storyboard,omitempty"`
+
+	// Pairing of Live Photos and Motion Photos, when original is part
+	// of one
+	LivePhoto *LivePhoto `json:"livePhoto,omitempty"`
+}
+
+// FileNames lists every file produced for the original: thumbnails,
+// preview clip, storyboard files and video extracted from Motion Photos,
+// if any
+func (r *ThumbGenResult) FileNames() []string {
+	fileNames := make([]string, 0, len(r.Thumbs)+1)
+	for _, thumb := range r.Thumbs {
+		fileNames = append(fileNames, thumb.FileName)
+	}
+
+	if r.Preview != nil {
+		fileNames = append(fileNames, r.Preview.FileName)
+	}
+
+	if r.Storyboard != nil {
+		fileNames = append(fileNames, r.Storyboard.VttFileName)
+		for _, sheet := range r.Storyboard.Sheets {
+			fileNames = append(fileNames, sheet.FileName)
+		}
+	}
+
+	if r.LivePhoto != nil && r.LivePhoto.VideoFileName != "" {
+		fileNames = append(fileNames, r.LivePhoto.VideoFileName)
+	}
+	return fileNames
+}
+
+// Placeholders holds compact string encodings of a blurred preview of the
+// original. Only configured kinds are populated.
+type Placeholders struct {
+	BlurHash string `json:"blurHash,omitempty"`
+
+	// Base64 (standard encoding) of ThumbHash bytes
+	ThumbHash string `json:"thumbHash,omitempty"`
+}
+
+type Thumb struct {
+
+	// Name of the thumbnail file, relative to the directory where
+	// thumbnails of the original file are stored.
+	FileName string `json:"fileName"`
+
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	// Resize spec used to produce the thumbnail (e.g. 'cover:320x320')
+	Resize string `json:"resize"`
+
+	// Name of the preset that produced the thumbnail, if any
+	Preset string `json:"preset,omitempty"`
+
+	// Area of the original included in the thumbnail, for modes that
+	// crop the original.
+	Crop *CropRect `json:"crop,omitempty"`
+
+	// Whether thumbnail keeps the animation of the original
+	Animated bool `json:"animated,omitempty"`
+
+	// Whether thumbnail is the static first frame of an animated
+	// original, shown before animation loads
+	Poster bool `json:"poster,omitempty"`
+}
+
+// ColorInfo describes the most representative colors of an image
+type ColorInfo struct {
+
+	// Hex color (e.g. '#1e90ff') covering the biggest share of the image
+	Dominant string `json:"dominant"`
+
+	// Palette colors sorted by share, dominant color first
+	Palette []PaletteColor `json:"palette"`
+}
+
+type PaletteColor struct {
+	Hex string `json:"hex"`
+
+	// Fraction of image pixels (0 to 1) represented by the color
+	Weight float64 `json:"weight"`
+}
+
+// CropRect is a rectangle in (orientation normalized) pixel coordinates
+// of the original file.
+type CropRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
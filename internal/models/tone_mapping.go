@@ -0,0 +1,51 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ToneMapOperator identifies the curve compressing HDR (PQ or HLG)
+// highlights into SDR range. Names match operators of ffmpeg 'tonemap'
+// filter.
+type ToneMapOperator string
+
+const (
+	// ToneMapNone keeps HDR pixels as decoded, which renders them grey
+	// and washed out
+	ToneMapNone ToneMapOperator = "none"
+
+	// ToneMapClip clips highlights above SDR white
+	ToneMapClip ToneMapOperator = "clip"
+
+	// ToneMapReinhard compresses highlights with a simple curve
+	ToneMapReinhard ToneMapOperator = "reinhard"
+
+	// ToneMapHable preserves details in both shadows and highlights
+	ToneMapHable ToneMapOperator = "hable"
+
+	// ToneMapMobius keeps in range values untouched and smoothly
+	// compresses highlights
+	ToneMapMobius ToneMapOperator = "mobius"
+)
+
+// DefaultToneMapOperator is used when no operator is configured
+const DefaultToneMapOperator = ToneMapHable
+
+// ParseToneMapOperator parses an operator name (case insensitive). Empty
+// value means DefaultToneMapOperator.
+func ParseToneMapOperator(value string) (ToneMapOperator, error) {
+	switch operator := ToneMapOperator(strings.ToLower(strings.TrimSpace(value))); operator {
+	case "":
+		return DefaultToneMapOperator, nil
+	case ToneMapNone, ToneMapClip, ToneMapReinhard, ToneMapHable, ToneMapMobius:
+		return operator, nil
+	default:
+		return "", fmt.Errorf("unknown tone map operator: %q", value)
+	}
+}
+
+// Enabled reports whether HDR originals are tone mapped
+func (o ToneMapOperator) Enabled() bool {
+	return o != "" && o != ToneMapNone
+}
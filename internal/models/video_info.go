@@ -0,0 +1,32 @@
+package models
+
+// VideoInfo describes the main video stream of a video file, as reported
+// by ffprobe
+type VideoInfo struct {
+	DurationMs int64 `json:"durationMs"`
+
+	// Codec of the video stream (e.g. 'h264', 'hevc')
+	Codec string `json:"codec"`
+
+	// Display dimensions, with rotation applied
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	// Average frames per second
+	FrameRate float64 `json:"frameRate"`
+
+	// Clockwise rotation in degrees (0, 90, 180 or 270) applied to
+	// decoded frames for display, from the display matrix or legacy
+	// 'rotate' tag
+	Rotation int `json:"rotation"`
+
+	// Transfer characteristics and color primaries (e.g. 'smpte2084',
+	// 'bt2020'), empty when not tagged
+	ColorTransfer  string `json:"colorTransfer,omitempty"`
+	ColorPrimaries string `json:"colorPrimaries,omitempty"`
+
+	// Whether transfer characteristics are HDR (PQ or HLG)
+	HDR bool `json:"hdr"`
+
+	HasAudio bool `json:"hasAudio"`
+}
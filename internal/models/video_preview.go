@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// VideoPreviewFormat identifies the container of looping preview clips
+// produced for videos
+type VideoPreviewFormat string
+
+const (
+	// VideoPreviewNone disables preview clips
+	VideoPreviewNone VideoPreviewFormat = ""
+
+	// VideoPreviewWebp produces an animated WebP
+	VideoPreviewWebp VideoPreviewFormat = "webp"
+
+	// VideoPreviewMp4 produces a muted H.264 MP4
+	VideoPreviewMp4 VideoPreviewFormat = "mp4"
+)
+
+// ParseVideoPreviewFormat parses a preview format name (case
+// insensitive). Empty value and 'none' disable previews.
+func ParseVideoPreviewFormat(value string) (VideoPreviewFormat, error) {
+	switch previewFormat := VideoPreviewFormat(strings.ToLower(strings.TrimSpace(value))); previewFormat {
+	case VideoPreviewNone, "none":
+		return VideoPreviewNone, nil
+	case VideoPreviewWebp, VideoPreviewMp4:
+		return previewFormat, nil
+	default:
+		return "", fmt.Errorf("unknown video preview format: %q", value)
+	}
+}
+
+// Extension returns file extension of previews in this format
+func (f VideoPreviewFormat) Extension() string {
+	if f == VideoPreviewNone {
+		return ""
+	}
+
+	return "." + string(f)
+}
+
+// VideoPreviewOptions describes the looping preview clip of a video,
+// made of short segments sampled evenly across it.
+type VideoPreviewOptions struct {
+
+	// Container of the clip. Previews are disabled when empty.
+	Format VideoPreviewFormat
+
+	// Total playback time of the clip
+	Duration time.Duration
+
+	// Frames per second of the clip
+	FPS int
+
+	// Width in pixels of the clip. Videos narrower than this keep their
+	// width.
+	Width int
+
+	// Number of points of the video the clip is sampled from
+	Segments int
+}
+
+func (o VideoPreviewOptions) Enabled() bool {
+	return o.Format != VideoPreviewNone
+}
+
+// VideoPreview describes a preview clip produced for a video
+type VideoPreview struct {
+
+	// Name of the clip file, relative to the directory where
+	// thumbnails of the original file are stored.
+	FileName string `json:"fileName"`
+
+	Format     VideoPreviewFormat `json:"format"`
+	Width      int                `json:"width"`
+	DurationMs int64              `json:"durationMs"`
+}
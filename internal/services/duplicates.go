@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math/bits"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DuplicateCluster is a group of originals whose perceptual hashes are
+// all within the Hamming distance threshold of each other.
+type DuplicateCluster struct {
+	Files []DuplicateFile `json:"files"`
+}
+
+type DuplicateFile struct {
+
+	// Path to original media file, relative to originals root
+	FilePath string `json:"filePath"`
+	DHash    string `json:"dHash"`
+}
+
+// FindDuplicates scans manifests under thumbsRoot and groups originals
+// whose perceptual hashes differ in at most maxDistance bits. Originals
+// without perceptual hash are ignored. Only clusters with two or more
+// files are returned, sorted by path of their first file. Each original
+// belongs to a single cluster, the first one (by path) it is close to
+// every member of.
+//
+// Every pair of hashes is compared, so time grows quadratically with the
+// number of originals.
+func FindDuplicates(
+	ctx context.Context,
+	thumbsRoot string,
+	maxDistance int,
+) ([]DuplicateCluster, error) {
+	files, hashes, err := loadHashes(ctx, thumbsRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	// Greedy complete linkage: files are visited by path, and each one
+	// joins the first cluster whose members are all within threshold of
+	// it, or starts a new one. Chains of files where each is close to the
+	// next one don't merge ends further apart than threshold.
+	order := make([]int, len(files))
+	for idx := range order {
+		order[idx] = idx
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return strings.Compare(files[a].FilePath, files[b].FilePath)
+	})
+
+	var members [][]int
+	for _, idx := range order {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		clusterIdx := slices.IndexFunc(members, func(cluster []int) bool {
+			return !slices.ContainsFunc(cluster, func(member int) bool {
+				return bits.OnesCount64(hashes[idx]^hashes[member]) > maxDistance
+			})
+		})
+		if clusterIdx < 0 {
+			members = append(members, []int{idx})
+		} else {
+			members[clusterIdx] = append(members[clusterIdx], idx)
+		}
+	}
+
+	clusters := []DuplicateCluster{}
+	for _, cluster := range members {
+		if len(cluster) < 2 {
+			continue
+		}
+
+		clusterFiles := make([]DuplicateFile, 0, len(cluster))
+		for _, idx := range cluster {
+			clusterFiles = append(clusterFiles, files[idx])
+		}
+		clusters = append(clusters, DuplicateCluster{Files: clusterFiles})
+	}
+	slices.SortFunc(clusters, func(a, b DuplicateCluster) int {
+		return strings.Compare(a.Files[0].FilePath, b.Files[0].FilePath)
+	})
+
+	return clusters, nil
+}
+
+// loadHashes reads perceptual hashes from every manifest under
+// thumbsRoot. Unreadable manifests are logged and skipped.
+func loadHashes(
+	ctx context.Context,
+	thumbsRoot string,
+) ([]DuplicateFile, []uint64, error) {
+	var files []DuplicateFile
+	var hashes []uint64
+
+	err := filepath.WalkDir(
+		thumbsRoot,
+		func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if entry.IsDir() || !strings.HasSuffix(path, manifestSuffix) {
+				return nil
+			}
+
+			manifest, err := readManifestFile(path)
+			if err != nil {
+				slog.Warn("Skipping unreadable manifest", "error", err)
+				return nil
+			}
+			if manifest.DHash == "" {
+				return nil
+			}
+
+			hash, err := strconv.ParseUint(manifest.DHash, 16, 64)
+			if err != nil {
+				slog.Warn(
+					"Skipping manifest with invalid perceptual hash",
+					"path", path,
+					"dHash", manifest.DHash,
+				)
+				return nil
+			}
+
+			files = append(files, DuplicateFile{
+				FilePath: manifest.FilePath,
+				DHash:    manifest.DHash,
+			})
+			hashes = append(hashes, hash)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"failed to scan manifests under %s: %w",
+			thumbsRoot,
+			err,
+		)
+	}
+
+	return files, hashes, nil
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	thumbsRoot := t.TempDir()
+
+	manifests := map[string]string{
+		"phone-a/IMG_001.jpg":  "f0f0f0f0f0f0f0f0",
+		"phone-b/IMG_9001.jpg": "f0f0f0f0f0f0f0f1", // 1 bit away
+		"phone-b/IMG_9002.jpg": "f0f0f0f0f0f0f0f3", // 1 bit away from previous, 2 from first
+		"phone-a/IMG_002.jpg":  "0f0f0f0f0f0f0f0f",
+		"phone-a/IMG_003.jpg":  "", // No perceptual hash
+	}
+	for filePath, dHash := range manifests {
+		thumbsDir := filepath.Join(thumbsRoot, filepath.Dir(filePath))
+		if err := os.MkdirAll(thumbsDir, 0o755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+
+		result := &models.ThumbGenResult{FilePath: filePath, DHash: dHash}
+		if err := writeManifest(thumbsDir, filePath, "", result); err != nil {
+			t.Fatalf("failed to write manifest: %v", err)
+		}
+	}
+
+	clusters, err := FindDuplicates(context.Background(), thumbsRoot, 1)
+	if err != nil {
+		t.Fatalf("FindDuplicates() error: %v", err)
+	}
+
+	// Last file of the chain is too far from the first one to join
+	want := []DuplicateCluster{{
+		Files: []DuplicateFile{
+			{FilePath: "phone-a/IMG_001.jpg", DHash: "f0f0f0f0f0f0f0f0"},
+			{FilePath: "phone-b/IMG_9001.jpg", DHash: "f0f0f0f0f0f0f0f1"},
+		},
+	}}
+	if !reflect.DeepEqual(clusters, want) {
+		t.Fatalf("FindDuplicates() = %+v, want %+v", clusters, want)
+	}
+
+	clusters, err = FindDuplicates(context.Background(), thumbsRoot, 2)
+	if err != nil {
+		t.Fatalf("FindDuplicates() error: %v", err)
+	}
+	if len(clusters) != 1 || len(clusters[0].Files) != 3 {
+		t.Fatalf("expected whole chain within threshold 2, got %+v", clusters)
+	}
+
+	clusters, err = FindDuplicates(context.Background(), thumbsRoot, 0)
+	if err != nil {
+		t.Fatalf("FindDuplicates() error: %v", err)
+	}
+	if len(clusters) != 0 {
+		t.Fatalf("expected no clusters with zero threshold, got %+v", clusters)
+	}
+}
+
+// Every pair of files in a cluster must be within threshold, even when
+// a chain links files further apart
+func TestFindDuplicates_Chain(t *testing.T) {
+	thumbsRoot := t.TempDir()
+
+	// Each hash is 2 bits away from the previous one
+	chain := []string{
+		"ff00000000000000",
+		"ff00000000000003",
+		"ff0000000000000f",
+		"ff0000000000003f",
+		"ff000000000000ff",
+	}
+	for idx, dHash := range chain {
+		filePath := fmt.Sprintf("burst/IMG_%03d.jpg", idx)
+		result := &models.ThumbGenResult{FilePath: filePath, DHash: dHash}
+		if err := writeManifest(thumbsRoot, filePath, "", result); err != nil {
+			t.Fatalf("failed to write manifest: %v", err)
+		}
+	}
+
+	const threshold = 4
+	clusters, err := FindDuplicates(context.Background(), thumbsRoot, threshold)
+	if err != nil {
+		t.Fatalf("FindDuplicates() error: %v", err)
+	}
+
+	var gotPaths [][]string
+	for _, cluster := range clusters {
+		var paths []string
+		for idx, file := range cluster.Files {
+			paths = append(paths, file.FilePath)
+
+			for _, other := range cluster.Files[idx+1:] {
+				if distance := hammingDistance(t, file.DHash, other.DHash); distance > threshold {
+					t.Errorf(
+						"%s and %s are %d bits apart in same cluster",
+						file.FilePath,
+						other.FilePath,
+						distance,
+					)
+				}
+			}
+		}
+		gotPaths = append(gotPaths, paths)
+	}
+
+	wantPaths := [][]string{
+		{"burst/IMG_000.jpg", "burst/IMG_001.jpg", "burst/IMG_002.jpg"},
+		{"burst/IMG_003.jpg", "burst/IMG_004.jpg"},
+	}
+	if !reflect.DeepEqual(gotPaths, wantPaths) {
+		t.Fatalf("clusters = %v, want %v", gotPaths, wantPaths)
+	}
+}
+
+func hammingDistance(t *testing.T, a string, b string) int {
+	t.Helper()
+
+	hashA, errA := strconv.ParseUint(a, 16, 64)
+	hashB, errB := strconv.ParseUint(b, 16, 64)
+	if errA != nil || errB != nil {
+		t.Fatalf("invalid hashes %q and %q", a, b)
+	}
+
+	return bits.OnesCount64(hashA ^ hashB)
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+	thumbsgen "github.com/giobyte8/thumbnailer/internal/thumbs_gen"
+)
+
+const manifestSuffix = "_manifest.json"
+
+// Name suffixes files produced for an original might have, see
+// thumbsgen.OutputNameSuffix
+var outputNameSuffixes = []string{"", thumbsgen.LivePhotoVideoSuffix}
+
+// manifestFileAbsPath returns the path of the manifest describing the
+// thumbnails of given original file (e.g. 'sample.jpg' ->
+// '<thumbsDir>/sample_manifest.json'). nameSuffix follows the name of
+// the original, as in names of files produced for it.
+func manifestFileAbsPath(
+	thumbsDir string,
+	origFileRelPath string,
+	nameSuffix string,
+) string {
+	baseName := filepath.Base(origFileRelPath)
+	fileNameNoExt := strings.TrimSuffix(baseName, filepath.Ext(baseName))
+
+	return filepath.Join(thumbsDir, fileNameNoExt+nameSuffix+manifestSuffix)
+}
+
+func writeManifest(
+	thumbsDir string,
+	origFileRelPath string,
+	nameSuffix string,
+	result *models.ThumbGenResult,
+) error {
+	manifestBytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize thumbnails manifest: %w", err)
+	}
+
+	manifestAbsPath := manifestFileAbsPath(thumbsDir, origFileRelPath, nameSuffix)
+	if err := os.WriteFile(manifestAbsPath, manifestBytes, 0644); err != nil {
+		return fmt.Errorf(
+			"failed to write thumbnails manifest %s: %w",
+			manifestAbsPath,
+			err,
+		)
+	}
+
+	return nil
+}
+
+// readManifest loads the manifest of given original file. Returns nil
+// manifest and no error if it doesn't exist.
+func readManifest(
+	thumbsDir string,
+	origFileRelPath string,
+	nameSuffix string,
+) (*models.ThumbGenResult, error) {
+	manifestAbsPath := manifestFileAbsPath(thumbsDir, origFileRelPath, nameSuffix)
+	manifest, err := readManifestFile(manifestAbsPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return manifest, err
+}
+
+// readManifestFile loads the manifest at given path
+func readManifestFile(manifestAbsPath string) (*models.ThumbGenResult, error) {
+	manifestBytes, err := os.ReadFile(manifestAbsPath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to read thumbnails manifest %s: %w",
+			manifestAbsPath,
+			err,
+		)
+	}
+
+	manifest := new(models.ThumbGenResult)
+	if err := json.Unmarshal(manifestBytes, manifest); err != nil {
+		return nil, fmt.Errorf(
+			"failed to parse thumbnails manifest %s: %w",
+			manifestAbsPath,
+			err,
+		)
+	}
+
+	return manifest, nil
+}
+
+// removeManifestFiles removes every file (thumbnails, preview clip,
+// storyboard) listed in the manifests of given original file and the
+// manifests themselves. Manifests of other originals with the same name
+// (e.g. photo of a Live Photo) are kept.
+func removeManifestFiles(
+	ctx context.Context,
+	thumbsDir string,
+	origFileRelPath string,
+) error {
+	for _, nameSuffix := range outputNameSuffixes {
+		err := removeManifestFilesWSuffix(ctx, thumbsDir, origFileRelPath, nameSuffix)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func removeManifestFilesWSuffix(
+	ctx context.Context,
+	thumbsDir string,
+	origFileRelPath string,
+	nameSuffix string,
+) error {
+	manifest, err := readManifest(thumbsDir, origFileRelPath, nameSuffix)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		return nil
+	}
+	if manifest.FilePath != "" && manifest.FilePath != origFileRelPath {
+		return nil
+	}
+
+	for _, fileName := range manifest.FileNames() {
+		if err := ctx.Err(); err != nil {
+			slog.Warn("Context cancelled during thumbnail cleanup.")
+			return err
+		}
+
+		// Manifest is trusted only to reference files in thumbs dir
+		thumbAbsPath := filepath.Join(thumbsDir, filepath.Base(fileName))
+
+		slog.Debug("Removing existing thumbnail", "path", thumbAbsPath)
+		err := os.Remove(thumbAbsPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf(
+				"failed to remove existing thumbnail %s: %w",
+				thumbAbsPath,
+				err,
+			)
+		}
+	}
+
+	manifestAbsPath := manifestFileAbsPath(thumbsDir, origFileRelPath, nameSuffix)
+	err = os.Remove(manifestAbsPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf(
+			"failed to remove thumbnails manifest %s: %w",
+			manifestAbsPath,
+			err,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+	thumbsgen "github.com/giobyte8/thumbnailer/internal/thumbs_gen"
+)
+
+func TestRemoveManifestFiles_KeepsOriginalsWithSameName(t *testing.T) {
+	thumbsDir := t.TempDir()
+
+	photo := &models.ThumbGenResult{
+		FilePath: "IMG_0001.HEIC",
+		Thumbs:   []models.Thumb{{FileName: "IMG_0001_320px.webp"}},
+	}
+	video := &models.ThumbGenResult{
+		FilePath: "IMG_0001.MOV",
+		Preview:  &models.VideoPreview{FileName: "IMG_0001_video_preview.webp"},
+	}
+
+	err := writeManifest(thumbsDir, photo.FilePath, "", photo)
+	if err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	err = writeManifest(thumbsDir, video.FilePath, thumbsgen.LivePhotoVideoSuffix, video)
+	if err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	for _, fileName := range append(photo.FileNames(), video.FileNames()...) {
+		if err := os.WriteFile(filepath.Join(thumbsDir, fileName), nil, 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", fileName, err)
+		}
+	}
+
+	if err := removeManifestFiles(context.Background(), thumbsDir, video.FilePath); err != nil {
+		t.Fatalf("failed to remove manifest files: %v", err)
+	}
+
+	removed := []string{"IMG_0001_video_preview.webp", "IMG_0001_video_manifest.json"}
+	for _, fileName := range removed {
+		if _, err := os.Stat(filepath.Join(thumbsDir, fileName)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, stat error: %v", fileName, err)
+		}
+	}
+
+	kept := []string{"IMG_0001_320px.webp", "IMG_0001_manifest.json"}
+	for _, fileName := range kept {
+		if _, err := os.Stat(filepath.Join(thumbsDir, fileName)); err != nil {
+			t.Errorf("expected %s of the photo to be kept: %v", fileName, err)
+		}
+	}
+}
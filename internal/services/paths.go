@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// ErrPathOutsideRoot is wrapped by errors of request paths escaping their
+// root directory.
+var ErrPathOutsideRoot = errors.New("path escapes root directory")
+
+// containedPath joins relPath to root making sure result stays inside
+// root. Unless policy is models.SymlinkFollow, symlinks in the existing
+// part of the path are resolved and must stay inside root as well.
+//
+// Returned errors are categorized as models.ErrCategoryRejectedPath when
+// the path escapes root.
+func containedPath(
+	root string,
+	relPath string,
+	policy models.SymlinkPolicy,
+) (string, error) {
+
+	// Rejects absolute paths, empty paths and '..' components escaping
+	// the root
+	if !filepath.IsLocal(relPath) {
+		return "", rejectPath(relPath, "not a relative path inside root")
+	}
+
+	absPath := filepath.Join(root, relPath)
+	if policy == models.SymlinkFollow {
+		return absPath, nil
+	}
+
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if errors.Is(err, os.ErrNotExist) {
+
+		// Nothing to resolve inside a root that doesn't exist yet
+		return absPath, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve root dir %s: %w", root, err)
+	}
+
+	resolvedPath, err := evalExistingSymlinks(absPath)
+	if err != nil {
+		if errors.Is(err, ErrPathOutsideRoot) {
+			return "", rejectPath(relPath, err.Error())
+		}
+
+		return "", fmt.Errorf("failed to resolve path %s: %w", absPath, err)
+	}
+
+	if !isWithinDir(resolvedRoot, resolvedPath) {
+		return "", rejectPath(relPath, "symlink resolves outside root")
+	}
+
+	return absPath, nil
+}
+
+// evalExistingSymlinks resolves symlinks in the longest existing prefix
+// of path, since files about to be created don't exist yet.
+func evalExistingSymlinks(path string) (string, error) {
+	existingPath := path
+	var missingParts []string
+
+	for {
+		resolvedPath, err := filepath.EvalSymlinks(existingPath)
+		if err == nil {
+			return filepath.Join(
+				append([]string{resolvedPath}, missingParts...)...,
+			), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		// A path that exists but can't be resolved is a dangling symlink,
+		// whose target can't be verified
+		if _, lstatErr := os.Lstat(existingPath); lstatErr == nil {
+			return "", fmt.Errorf(
+				"%w: dangling symlink %s",
+				ErrPathOutsideRoot,
+				existingPath,
+			)
+		}
+
+		parentPath := filepath.Dir(existingPath)
+		if parentPath == existingPath {
+			return path, nil
+		}
+
+		missingParts = append(
+			[]string{filepath.Base(existingPath)},
+			missingParts...,
+		)
+		existingPath = parentPath
+	}
+}
+
+// isWithinDir reports whether path is dir itself or lives inside it.
+// Both are expected to be clean and absolute (or both relative).
+func isWithinDir(dir string, path string) bool {
+	relPath, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return filepath.IsLocal(relPath)
+}
+
+func rejectPath(relPath string, reason string) error {
+	return models.NewRejectedPathError(
+		fmt.Errorf("%w: %q: %s", ErrPathOutsideRoot, relPath, reason),
+	)
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	thumbsgen "github.com/giobyte8/thumbnailer/internal/thumbs_gen"
+)
+
+func TestContainedPath_RejectsHostilePaths(t *testing.T) {
+	root := t.TempDir()
+
+	hostilePaths := []string{
+		"",
+		"../outside.jpg",
+		"../../etc/passwd",
+		"nested/../../outside.jpg",
+		"/etc/passwd",
+		"nested/../..",
+	}
+
+	for _, relPath := range hostilePaths {
+		t.Run(relPath, func(t *testing.T) {
+			_, err := containedPath(root, relPath, models.SymlinkFollow)
+			assertRejectedPath(t, err)
+		})
+	}
+}
+
+func TestContainedPath_AcceptsPathsInsideRoot(t *testing.T) {
+	root := t.TempDir()
+
+	tests := map[string]string{
+		"photo.jpg":                 filepath.Join(root, "photo.jpg"),
+		"nested/dir/photo.jpg":      filepath.Join(root, "nested", "dir", "photo.jpg"),
+		"nested/../photo.jpg":       filepath.Join(root, "photo.jpg"),
+		".":                         root,
+		"not-created-yet/photo.jpg": filepath.Join(root, "not-created-yet", "photo.jpg"),
+	}
+
+	for relPath, want := range tests {
+		t.Run(relPath, func(t *testing.T) {
+			got, err := containedPath(root, relPath, models.SymlinkDeny)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != want {
+				t.Fatalf("unexpected path: got %q want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestContainedPath_SymlinkPolicy(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	mustSymlink(t, outside, filepath.Join(root, "escaping-dir"))
+	mustSymlink(t, filepath.Join(root, "inner"), filepath.Join(root, "inner-link"))
+	mustSymlink(t, filepath.Join(root, "missing"), filepath.Join(root, "dangling"))
+	if err := os.Mkdir(filepath.Join(root, "inner"), 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		relPath    string
+		policy     models.SymlinkPolicy
+		wantReject bool
+	}{
+		{name: "deny escaping dir", relPath: "escaping-dir/photo.jpg", policy: models.SymlinkDeny, wantReject: true},
+		{name: "follow escaping dir", relPath: "escaping-dir/photo.jpg", policy: models.SymlinkFollow},
+		{name: "deny dangling link", relPath: "dangling/photo.jpg", policy: models.SymlinkDeny, wantReject: true},
+		{name: "deny link inside root", relPath: "inner-link/photo.jpg", policy: models.SymlinkDeny},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := containedPath(root, tc.relPath, tc.policy)
+			if tc.wantReject {
+				assertRejectedPath(t, err)
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestThumbnailsService_RejectsHostileRequests(t *testing.T) {
+	workDir := t.TempDir()
+	origsRoot := filepath.Join(workDir, "originals")
+	thumbsRoot := filepath.Join(workDir, "thumbs")
+	for _, dir := range []string{origsRoot, thumbsRoot} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+
+	// File that a traversal in a delete request would remove
+	victimPath := filepath.Join(workDir, "victim_256px.webp")
+	if err := os.WriteFile(victimPath, []byte("keep me"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	generator := &recordingGenerator{}
+	service := NewThumbnailsService(
+		ThumbnailsConfig{
+			DirOriginalsRoot:  origsRoot,
+			DirThumbnailsRoot: thumbsRoot,
+			SymlinkPolicy:     models.SymlinkDeny,
+		},
+		generator,
+	)
+
+	req := models.ThumbRequest{FilePath: "../victim.jpg"}
+
+	err := service.ProcessDelRequest(context.Background(), req)
+	assertRejectedPath(t, err)
+	if _, statErr := os.Stat(victimPath); statErr != nil {
+		t.Fatalf("file outside thumbnails root was removed: %v", statErr)
+	}
+
+	_, err = service.ProcessGenRequest(context.Background(), req)
+	assertRejectedPath(t, err)
+	if generator.calls != 0 {
+		t.Fatalf("generator invoked for rejected path")
+	}
+}
+
+func TestThumbnailsService_RejectsOriginalsBeforeCleanup(t *testing.T) {
+	workDir := t.TempDir()
+	origsRoot := filepath.Join(workDir, "originals")
+	thumbsDir := filepath.Join(workDir, "thumbs", "escaping-dir")
+	for _, dir := range []string{origsRoot, thumbsDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+	mustSymlink(t, t.TempDir(), filepath.Join(origsRoot, "escaping-dir"))
+
+	// Thumbnails a cleanup of the escaping original would remove
+	existing := &models.ThumbGenResult{
+		FilePath: "escaping-dir/photo.jpg",
+		Thumbs:   []models.Thumb{{FileName: "photo_320px.webp"}},
+	}
+	if err := writeManifest(thumbsDir, existing.FilePath, "", existing); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	thumbPath := filepath.Join(thumbsDir, "photo_320px.webp")
+	if err := os.WriteFile(thumbPath, []byte("keep me"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	generator := &recordingGenerator{}
+	service := NewThumbnailsService(
+		ThumbnailsConfig{
+			DirOriginalsRoot:  origsRoot,
+			DirThumbnailsRoot: filepath.Join(workDir, "thumbs"),
+			SymlinkPolicy:     models.SymlinkDeny,
+		},
+		generator,
+	)
+
+	req := models.ThumbRequest{FilePath: existing.FilePath}
+	_, err := service.ProcessGenRequest(context.Background(), req)
+	assertRejectedPath(t, err)
+
+	err = service.ProcessDelRequest(context.Background(), req)
+	assertRejectedPath(t, err)
+
+	if _, statErr := os.Stat(thumbPath); statErr != nil {
+		t.Fatalf("thumbnail removed for rejected original: %v", statErr)
+	}
+	if generator.calls != 0 {
+		t.Fatalf("generator invoked for rejected path")
+	}
+}
+
+func assertRejectedPath(t *testing.T, err error) {
+	t.Helper()
+
+	if !errors.Is(err, ErrPathOutsideRoot) {
+		t.Fatalf("expected ErrPathOutsideRoot, got %v", err)
+	}
+	if got := models.ErrorCategoryOf(err); got != models.ErrCategoryRejectedPath {
+		t.Fatalf("unexpected error category: got %q want %q", got, models.ErrCategoryRejectedPath)
+	}
+}
+
+func mustSymlink(t *testing.T, target string, link string) {
+	t.Helper()
+
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+}
+
+// recordingGenerator counts invocations without generating anything
+type recordingGenerator struct {
+	calls int
+}
+
+func (g *recordingGenerator) Generate(
+	ctx context.Context,
+	meta thumbsgen.ThumbnailMeta,
+) (*models.ThumbGenResult, error) {
+	g.calls++
+	return &models.ThumbGenResult{}, nil
+}
+
+func (g *recordingGenerator) GenerateWithoutFormatsCheck(
+	ctx context.Context,
+	meta thumbsgen.ThumbnailMeta,
+	origFileFormat format.Format,
+) (*models.ThumbGenResult, error) {
+	g.calls++
+	return &models.ThumbGenResult{}, nil
+}
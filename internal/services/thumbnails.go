@@ -9,7 +9,9 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/giobyte8/thumbnailer/internal/models"
 	thumbsgen "github.com/giobyte8/thumbnailer/internal/thumbs_gen"
@@ -18,7 +20,30 @@ import (
 type ThumbnailsConfig struct {
 	DirOriginalsRoot  string
 	DirThumbnailsRoot string
-	ThumbnailWidths   []int
+	UpscalePolicy     models.UpscalePolicy
+	InputLimits       models.InputLimits
+	AnimationLimits   models.AnimationLimits
+	VideoPreview      models.VideoPreviewOptions
+	LivePhotoVideo    models.LivePhotoVideoMode
+	FrameSamples      []int
+	ToneMap           models.ToneMapOperator
+	Storyboard        models.StoryboardOptions
+	Document          models.DocumentOptions
+	Archive           models.ArchiveOptions
+	Placeholders      []models.PlaceholderKind
+	PaletteSize       int
+	PerceptualHash    bool
+	KeepMetadata      []models.MetadataField
+	ColorProfile      models.ColorProfilePolicy
+
+	// Determines whether symlinks resolving outside of the originals and
+	// thumbnails root directories are followed
+	SymlinkPolicy models.SymlinkPolicy
+
+	// Available presets and names of the ones generated for requests
+	// that don't select any
+	Presets        []models.ThumbPreset
+	DefaultPresets []string
 }
 
 type ThumbnailsService struct {
@@ -36,32 +61,59 @@ func NewThumbnailsService(
 	}
 }
 
+// ProcessGenRequest generates thumbnails for the file in request and
+// writes a manifest describing them next to generated thumbnails.
+//
+// Returned result is nil when no thumbnails were generated because the
+// original file was discarded (e.g. unsupported format).
 func (s *ThumbnailsService) ProcessGenRequest(
 	ctx context.Context,
 	req models.ThumbRequest,
-) error {
+) (*models.ThumbGenResult, error) {
 	slog.Debug(
 		"Processing thumbnail generation request",
 		"filePath",
 		req.FilePath,
 	)
 
-	err := s.cleanupExisting(ctx, req.FilePath)
+	thumbsDir, err := s.resolvePaths(req.FilePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	thumbMeta, err := s.prepareThumbnailMeta(req.FilePath)
+	err = s.cleanupExisting(ctx, req.FilePath, thumbsDir)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = s.thumbGenerator.Generate(ctx, *thumbMeta)
+	thumbMeta, err := s.prepareThumbnailMeta(req, thumbsDir)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	result, err := s.thumbGenerator.Generate(ctx, *thumbMeta)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+
+	result.ThumbRequestId = req.ThumbRequestId
+	result.FilePath = req.FilePath
+	result.GeneratedAt = time.Now().UTC()
+
+	err = writeManifest(
+		thumbMeta.ThumbFileAbsDir,
+		req.FilePath,
+		thumbsgen.OutputNameSuffix(*thumbMeta),
+		result,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
 func (s *ThumbnailsService) ProcessDelRequest(
@@ -74,21 +126,54 @@ func (s *ThumbnailsService) ProcessDelRequest(
 		req.FilePath,
 	)
 
-	return s.cleanupExisting(ctx, req.FilePath)
+	thumbsDir, err := s.resolvePaths(req.FilePath)
+	if err != nil {
+		return err
+	}
+
+	return s.cleanupExisting(ctx, req.FilePath, thumbsDir)
+}
+
+// resolvePaths makes sure original in request lives inside originals
+// root and returns the directory of its thumbnails, inside thumbnails
+// root. Called before any file is read, written or removed.
+func (s *ThumbnailsService) resolvePaths(origFileRelPath string) (string, error) {
+	_, err := containedPath(
+		s.config.DirOriginalsRoot,
+		origFileRelPath,
+		s.config.SymlinkPolicy,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return s.thumbsDirOf(origFileRelPath)
 }
 
+// cleanupExisting removes thumbnails of original previously generated in
+// thumbsDir. Both paths must be validated with resolvePaths first.
 func (s *ThumbnailsService) cleanupExisting(
 	ctx context.Context,
 	origFileRelPath string,
+	thumbsDir string,
 ) error {
-
-	// Determine sub directory for thumbnails
-	origFileRelDir := filepath.Dir(origFileRelPath)
-	thumbsDir := filepath.Join(s.config.DirThumbnailsRoot, origFileRelDir)
 	if _, err := os.Stat(thumbsDir); os.IsNotExist(err) {
 		return nil
 	}
 
+	// Remove thumbnails listed in manifest (if any)
+	err := removeManifestFiles(ctx, thumbsDir, origFileRelPath)
+	if err != nil {
+		return err
+	}
+
+	// Names of Live Photo videos match the ones of thumbnails of their
+	// photo, which must be kept
+	if thumbsgen.FindLivePhotoPhoto(s.config.DirOriginalsRoot, origFileRelPath) != "" {
+		return nil
+	}
+
+	// Thumbnails generated before manifests existed are matched by name.
 	// Prepare wildcard patterns to match existing thumbnails with
 	// width suffixes of exactly 3 or 4 digits (e.g. 320px, 1080px).
 	baseName := filepath.Base(origFileRelPath)
@@ -160,19 +245,30 @@ func (s *ThumbnailsService) cleanupExisting(
 	return nil
 }
 
+// prepareThumbnailMeta describes generation of thumbnails of original in
+// request into thumbsDir. Both paths must be validated with resolvePaths
+// first.
 func (s *ThumbnailsService) prepareThumbnailMeta(
-	origFileRelPath string,
+	req models.ThumbRequest,
+	thumbsDir string,
 ) (*thumbsgen.ThumbnailMeta, error) {
+	origFileRelPath := req.FilePath
+
+	presets, err := s.selectPresets(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FocalPoint != nil {
+		if err := req.FocalPoint.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid focal point in request: %w", err)
+		}
+	}
+
 	thumbMeta := new(thumbsgen.ThumbnailMeta)
 	thumbMeta.OrigFilesRootDir = s.config.DirOriginalsRoot
 	thumbMeta.OrigFileRelPath = origFileRelPath
-
-	// Determine output directory for thumbnails
-	origFileRelDir := filepath.Dir(origFileRelPath)
-	thumbMeta.ThumbFileAbsDir = filepath.Join(
-		s.config.DirThumbnailsRoot,
-		origFileRelDir,
-	)
+	thumbMeta.ThumbFileAbsDir = thumbsDir
 
 	// TODO: Consider move dir creation to the moment of thumbnail saving
 	if _, err := os.Stat(thumbMeta.ThumbFileAbsDir); os.IsNotExist(err) {
@@ -185,6 +281,81 @@ func (s *ThumbnailsService) prepareThumbnailMeta(
 		}
 	}
 
-	thumbMeta.ThumbWidths = s.config.ThumbnailWidths
+	thumbMeta.Presets = presets
+	thumbMeta.UpscalePolicy = s.config.UpscalePolicy
+	thumbMeta.InputLimits = s.config.InputLimits
+	thumbMeta.AnimationLimits = s.config.AnimationLimits
+	thumbMeta.VideoPreview = s.config.VideoPreview
+	thumbMeta.LivePhotoVideo = s.config.LivePhotoVideo
+	thumbMeta.LivePhotoPhoto = thumbsgen.FindLivePhotoPhoto(
+		s.config.DirOriginalsRoot,
+		origFileRelPath,
+	)
+	thumbMeta.FrameSamples = s.config.FrameSamples
+	thumbMeta.ToneMap = s.config.ToneMap
+	thumbMeta.Storyboard = s.config.Storyboard
+	thumbMeta.Document = s.config.Document
+	thumbMeta.Archive = s.config.Archive
+	thumbMeta.Placeholders = s.config.Placeholders
+	thumbMeta.PaletteSize = s.config.PaletteSize
+	thumbMeta.PerceptualHash = s.config.PerceptualHash
+	thumbMeta.KeepMetadata = s.config.KeepMetadata
+	thumbMeta.ColorProfile = s.config.ColorProfile
+	thumbMeta.FocalPoint = req.FocalPoint
 	return thumbMeta, nil
 }
+
+// thumbsDirOf returns the directory where thumbnails of given original
+// are stored, making sure it lives inside thumbnails root.
+func (s *ThumbnailsService) thumbsDirOf(origFileRelPath string) (string, error) {
+	if !filepath.IsLocal(origFileRelPath) {
+		return "", rejectPath(origFileRelPath, "not a relative path inside root")
+	}
+
+	return containedPath(
+		s.config.DirThumbnailsRoot,
+		filepath.Dir(origFileRelPath),
+		s.config.SymlinkPolicy,
+	)
+}
+
+// selectPresets returns presets named in request, or the default ones
+// when request doesn't name any. Resize mode in request (if any) takes
+// precedence over the one in selected presets.
+func (s *ThumbnailsService) selectPresets(
+	req models.ThumbRequest,
+) ([]models.ThumbPreset, error) {
+	presetNames := req.Presets
+	if len(presetNames) == 0 {
+		presetNames = s.config.DefaultPresets
+	}
+
+	var resizeOverride *models.ResizeSpec
+	if req.ResizeMode != "" {
+		resizeSpec, err := models.ParseResizeSpec(req.ResizeMode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resize mode in request: %w", err)
+		}
+		resizeOverride = &resizeSpec
+	}
+
+	presets := make([]models.ThumbPreset, 0, len(presetNames))
+	for _, name := range presetNames {
+		idx := slices.IndexFunc(
+			s.config.Presets,
+			func(p models.ThumbPreset) bool { return p.Name == name },
+		)
+		if idx < 0 {
+			return nil, fmt.Errorf("unknown preset in request: %q", name)
+		}
+
+		preset := s.config.Presets[idx]
+		if resizeOverride != nil {
+			preset.Resize = *resizeOverride
+		}
+
+		presets = append(presets, preset)
+	}
+
+	return presets, nil
+}
@@ -14,9 +14,23 @@ const (
 	ThumbReqGenRouted   MetricName = "thumb.request.generate.routed"
 	ThumbReqDelReceived MetricName = "thumb.request.delete.received"
 	ThumbCreated        MetricName = "thumb.created"
+	ThumbInputRejected  MetricName = "thumb.input.rejected"
 
-	FormatConverted     MetricName = "format_converter.converted"
-	VideoFrameExtracted MetricName = "video_frame_extractor.extracted"
+	FormatConverted       MetricName = "format_converter.converted"
+	VideoFrameExtracted   MetricName = "video_frame_extractor.extracted"
+	VideoPreviewExtracted MetricName = "video_frame_extractor.preview_extracted"
+
+	// Pages rendered from documents ('encrypted' attribute tells the ones
+	// protected by an owner password only)
+	DocumentPageRendered MetricName = "doc_renderer.page_rendered"
+
+	// Images audio files are thumbnailed from, either embedded cover art
+	// or rendered waveform ('source' attribute)
+	AudioImageExtracted MetricName = "audio_extractor.image_extracted"
+
+	// Originals paired with a video: Apple Live Photos ('role' attribute
+	// tells photo from video) and Motion Photos ('motion_photo')
+	LivePhotoDetected MetricName = "thumb.live_photo.detected"
 
 	LPDedicatedImageOpsCreated MetricName = "lilliput.dedicated_imageops_created"
 	LPErrOutputBufferTooSmall  MetricName = "lilliput.err.output_buffer_too_small"
@@ -31,6 +45,10 @@ const (
 
 	FormatConvertDuration     MetricName = "format_converter.convert.duration"
 	VideoFrameExtractDuration MetricName = "video_frame.extract.duration"
+
+	// Duration of rendering the first page of PDF documents. Conversion
+	// of office documents into PDF is reported as a format conversion.
+	DocumentRenderDuration MetricName = "doc_renderer.render.duration"
 )
 
 type MetricsSvc interface {
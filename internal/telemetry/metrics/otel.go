@@ -32,11 +32,17 @@ type otelCounters struct {
 	thumbReqDelReceivedCounter metric.Int64Counter
 	thumbCreatedCounter        metric.Int64Counter
 
-	formatConvertedCounter     metric.Int64Counter
-	videoFrameExtractedCounter metric.Int64Counter
+	formatConvertedCounter       metric.Int64Counter
+	videoFrameExtractedCounter   metric.Int64Counter
+	videoPreviewExtractedCounter metric.Int64Counter
+	documentPageRenderedCounter  metric.Int64Counter
+	audioImageExtractedCounter   metric.Int64Counter
+	livePhotoDetectedCounter     metric.Int64Counter
 
 	lpDedicatedImageOpsCreatedCounter metric.Int64Counter
 	lpErrOutputBufferTooSmallCounter  metric.Int64Counter
+
+	thumbInputRejectedCounter metric.Int64Counter
 }
 
 type otelHistograms struct {
@@ -44,6 +50,7 @@ type otelHistograms struct {
 	lilptThumbGenDurationHistogram     metric.Int64Histogram
 	videoFrameExtractDurationHistogram metric.Int64Histogram
 	formatConvertDurationHistogram     metric.Int64Histogram
+	documentRenderDurationHistogram    metric.Int64Histogram
 }
 
 var serviceName = semconv.ServiceNameKey.String("thumbnailer")
@@ -133,6 +140,46 @@ func initCounters(meter metric.Meter) (*otelCounters, error) {
 		return nil, err
 	}
 
+	videoPreviewExtractedCounter, err := meter.Int64Counter(
+		string(VideoPreviewExtracted),
+		metric.WithDescription(
+			"Number of preview clips extracted from videos"),
+		metric.WithUnit("{clip}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	documentPageRenderedCounter, err := meter.Int64Counter(
+		string(DocumentPageRendered),
+		metric.WithDescription(
+			"Number of document pages rendered into images"),
+		metric.WithUnit("{page}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	audioImageExtractedCounter, err := meter.Int64Counter(
+		string(AudioImageExtracted),
+		metric.WithDescription(
+			"Number of cover arts and waveforms extracted from audio files"),
+		metric.WithUnit("{image}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	livePhotoDetectedCounter, err := meter.Int64Counter(
+		string(LivePhotoDetected),
+		metric.WithDescription(
+			"Number of originals paired with a video as Live or Motion Photos"),
+		metric.WithUnit("{file}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	lpDedicatedImageOpsCreatedCounter, err := meter.Int64Counter(
 		string(LPDedicatedImageOpsCreated),
 		metric.WithDescription(
@@ -153,17 +200,33 @@ func initCounters(meter metric.Meter) (*otelCounters, error) {
 		return nil, err
 	}
 
+	thumbInputRejectedCounter, err := meter.Int64Counter(
+		string(ThumbInputRejected),
+		metric.WithDescription(
+			"Number of originals rejected for exceeding input limits"),
+		metric.WithUnit("{file}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &otelCounters{
 		thumbReqGenReceivedCounter: thumbReqGenReceivedCounter,
 		thumbReqGenRoutedCounter:   thumbReqGenRoutedCounter,
 		thumbReqDelReceivedCounter: thumbReqDelReceivedCounter,
 		thumbCreatedCounter:        thumbCreatedCounter,
 
-		formatConvertedCounter:     formatConvertedCounter,
-		videoFrameExtractedCounter: videoFrameExtractedCounter,
+		formatConvertedCounter:       formatConvertedCounter,
+		videoFrameExtractedCounter:   videoFrameExtractedCounter,
+		videoPreviewExtractedCounter: videoPreviewExtractedCounter,
+		documentPageRenderedCounter:  documentPageRenderedCounter,
+		audioImageExtractedCounter:   audioImageExtractedCounter,
+		livePhotoDetectedCounter:     livePhotoDetectedCounter,
 
 		lpDedicatedImageOpsCreatedCounter: lpDedicatedImageOpsCreatedCounter,
 		lpErrOutputBufferTooSmallCounter:  lpErrOutputBufferTooSmallCounter,
+
+		thumbInputRejectedCounter: thumbInputRejectedCounter,
 	}, nil
 }
 
@@ -208,11 +271,22 @@ func initHistograms(meter metric.Meter) (*otelHistograms, error) {
 		return nil, err
 	}
 
+	documentRenderDurationHistogram, err := meter.Int64Histogram(
+		string(DocumentRenderDuration),
+		metric.WithDescription(
+			"Duration of document first page rendering operations"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &otelHistograms{
 		thumbGenerateDurationHistogram:     thumbGenerateDurationHistogram,
 		lilptThumbGenDurationHistogram:     lilptThumbGenDurationHistogram,
 		videoFrameExtractDurationHistogram: videoFrameExtractDurationHistogram,
 		formatConvertDurationHistogram:     formatConvertDurationHistogram,
+		documentRenderDurationHistogram:    documentRenderDurationHistogram,
 	}, nil
 }
 
@@ -241,12 +315,23 @@ func (s *OtelMetricsSvc) IncrementWAttrs(
 		s.counters.formatConvertedCounter.Add(ctx, 1, opts)
 	case VideoFrameExtracted:
 		s.counters.videoFrameExtractedCounter.Add(ctx, 1, opts)
+	case VideoPreviewExtracted:
+		s.counters.videoPreviewExtractedCounter.Add(ctx, 1, opts)
+	case DocumentPageRendered:
+		s.counters.documentPageRenderedCounter.Add(ctx, 1, opts)
+	case AudioImageExtracted:
+		s.counters.audioImageExtractedCounter.Add(ctx, 1, opts)
+	case LivePhotoDetected:
+		s.counters.livePhotoDetectedCounter.Add(ctx, 1, opts)
 
 	case LPDedicatedImageOpsCreated:
 		s.counters.lpDedicatedImageOpsCreatedCounter.Add(ctx, 1, opts)
 	case LPErrOutputBufferTooSmall:
 		s.counters.lpErrOutputBufferTooSmallCounter.Add(ctx, 1, opts)
 
+	case ThumbInputRejected:
+		s.counters.thumbInputRejectedCounter.Add(ctx, 1, opts)
+
 	default:
 		slog.Warn("Unknown metric name", "metricName", metricName)
 	}
@@ -294,6 +379,12 @@ func (s *OtelMetricsSvc) DurationWAttrs(
 			durationMs,
 			opts,
 		)
+	case DocumentRenderDuration:
+		s.histograms.documentRenderDurationHistogram.Record(
+			ctx,
+			durationMs,
+			opts,
+		)
 
 	default:
 		slog.Warn("Unknown duration metric name", "metricName", metricName)
@@ -0,0 +1,94 @@
+package testutils
+
+import (
+	"image"
+	_ "image/jpeg" // Register JPEG format
+	_ "image/png"  // Register PNG format
+	"math"
+	"os"
+	"testing"
+)
+
+// LoadImage decodes the image at absPath, failing the test on errors
+func LoadImage(t *testing.T, absPath string) image.Image {
+	t.Helper()
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		t.Fatalf("failed to open image: %v", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		t.Fatalf("failed to decode image %s: %v", absPath, err)
+	}
+	return img
+}
+
+// AssertPatchesMatch compares mean 8 bits RGB of square patches of got
+// and want, failing the test when a channel differs by more than
+// tolerance. Patches are inset by margin pixels, skipping edges blurred
+// by chroma subsampling and compression.
+func AssertPatchesMatch(
+	t *testing.T,
+	got image.Image,
+	want image.Image,
+	patchSize int,
+	margin int,
+	tolerance float64,
+) {
+	t.Helper()
+
+	if got.Bounds().Size() != want.Bounds().Size() {
+		t.Fatalf("image is %v, want %v", got.Bounds().Size(), want.Bounds().Size())
+	}
+
+	gotPatches := meanPatchColors(got, patchSize, margin)
+	for idx, wantPatch := range meanPatchColors(want, patchSize, margin) {
+		for channel := range wantPatch {
+			diff := math.Abs(gotPatches[idx][channel] - wantPatch[channel])
+			if diff > tolerance {
+				t.Errorf(
+					"patch %d is %.1f, want %.1f (tolerance %.1f)",
+					idx,
+					gotPatches[idx],
+					wantPatch,
+					tolerance,
+				)
+				break
+			}
+		}
+	}
+}
+
+// meanPatchColors averages 8 bits RGB of patches, left to right and top
+// to bottom
+func meanPatchColors(img image.Image, patchSize int, margin int) [][3]float64 {
+	bounds := img.Bounds()
+
+	var means [][3]float64
+	for top := bounds.Min.Y; top+patchSize <= bounds.Max.Y; top += patchSize {
+		for left := bounds.Min.X; left+patchSize <= bounds.Max.X; left += patchSize {
+			var sum [3]float64
+			count := 0
+			for y := top + margin; y < top+patchSize-margin; y++ {
+				for x := left + margin; x < left+patchSize-margin; x++ {
+					r, g, b, _ := img.At(x, y).RGBA()
+					sum[0] += float64(r >> 8)
+					sum[1] += float64(g >> 8)
+					sum[2] += float64(b >> 8)
+					count++
+				}
+			}
+
+			means = append(means, [3]float64{
+				sum[0] / float64(count),
+				sum[1] / float64(count),
+				sum[2] / float64(count),
+			})
+		}
+	}
+
+	return means
+}
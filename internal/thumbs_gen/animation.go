@@ -0,0 +1,81 @@
+package thumbsgen
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/discord/lilliput"
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// thumbVariant tells which thumbnail is produced out of an original
+type thumbVariant int
+
+const (
+	// Single frame thumbnail, the only variant of static originals
+	variantStatic thumbVariant = iota
+
+	// Animated thumbnail of an animated original
+	variantAnimated
+
+	// Single frame thumbnail accompanying an animated one
+	variantPoster
+)
+
+// Encoding many frames takes much longer than a single one
+const animatedEncodeTimeout = 30 * time.Second
+
+var errAnimationTooLarge = errors.New("animated thumbnail exceeds size limit")
+
+// canAnimate tells whether thumbnails of meta in given format keep the
+// animation of the original.
+//
+// Only WebP output is animated. Padded thumbnails go through an edited
+// canvas holding a single frame, so they are static as well.
+func canAnimate(meta ThumbnailMeta, extension string, output thumbOutput) bool {
+	return output.animation != nil &&
+		strings.ToLower(extension) == ".webp" &&
+		meta.Resize.Mode != models.ResizePad
+}
+
+// transformAnimated resizes every frame of the animated image in decoder
+// to geometry dimensions, within given limits. Cover thumbnails are
+// center cropped.
+//
+// Returns errAnimationTooLarge if encoded thumbnail exceeds size limit.
+func (g *ImageThumbsGenerator) transformAnimated(
+	imgOps *lilliput.ImageOps,
+	decoder lilliput.Decoder,
+	geometry thumbGeometry,
+	resizeMode models.ResizeMode,
+	encoding thumbEncoding,
+	limits *models.AnimationLimits,
+) ([]byte, error) {
+	resizeMethod := lilliput.ImageOpsResize
+	if resizeMode == models.ResizeCover {
+		resizeMethod = lilliput.ImageOpsFit
+	}
+
+	imgOpts := &lilliput.ImageOptions{
+		FileType:             encoding.Extension,
+		Width:                geometry.Width,
+		Height:               geometry.Height,
+		ResizeMethod:         resizeMethod,
+		NormalizeOrientation: true,
+		EncodeOptions:        g.encodeOptions(encoding),
+		MaxEncodeFrames:      limits.MaxFrames,
+		MaxEncodeDuration:    limits.MaxDuration,
+		EncodeTimeout:        animatedEncodeTimeout,
+	}
+
+	animatedBuf, err := g.runTransform(imgOps, decoder, imgOpts, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	if limits.MaxBytes > 0 && int64(len(animatedBuf)) > limits.MaxBytes {
+		return nil, errAnimationTooLarge
+	}
+	return animatedBuf, nil
+}
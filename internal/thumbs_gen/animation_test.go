@@ -0,0 +1,203 @@
+package thumbsgen
+
+import (
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+func TestImageThumbsGenerator_Integration_AnimatedGif(t *testing.T) {
+	generator := mkGenerator(t)
+
+	tests := []struct {
+		name         string
+		limits       models.AnimationLimits
+		resize       models.ResizeSpec
+		wantAnimated bool
+		wantFrames   int
+	}{
+		{
+			name:         "animated within limits",
+			limits:       models.AnimationLimits{MaxBytes: 1 << 20},
+			wantAnimated: true,
+			wantFrames:   6,
+		},
+		{
+			name:         "frames limit",
+			limits:       models.AnimationLimits{MaxFrames: 3},
+			wantAnimated: true,
+			wantFrames:   3,
+		},
+		{
+			name:         "animated cover",
+			limits:       models.AnimationLimits{},
+			resize:       models.ResizeSpec{Mode: models.ResizeCover, BoxWidth: 64, BoxHeight: 64},
+			wantAnimated: true,
+			wantFrames:   6,
+		},
+		{
+			name:   "size limit falls back to static",
+			limits: models.AnimationLimits{MaxBytes: 16},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			origDir := t.TempDir()
+			writeTestGif(t, filepath.Join(origDir, "spinner.gif"), 6)
+
+			meta := ThumbnailMeta{
+				OrigFilesRootDir: origDir,
+				OrigFileRelPath:  "spinner.gif",
+				ThumbFileAbsDir:  t.TempDir(),
+				ThumbWidths:      []int{64},
+				ThumbFormats:     []string{".webp", ".jpg"},
+				Resize:           tc.resize,
+				AnimationLimits:  tc.limits,
+			}
+
+			result, err := generator.Generate(context.Background(), meta)
+			if err != nil {
+				t.Fatalf("generate failed: %v", err)
+			}
+
+			var animated, posters, static int
+			for _, thumb := range result.Thumbs {
+				thumbBytes, err := os.ReadFile(filepath.Join(meta.ThumbFileAbsDir, thumb.FileName))
+				if err != nil {
+					t.Fatalf("failed to read thumbnail: %v", err)
+				}
+
+				switch {
+				case thumb.Animated:
+					animated++
+					if frames := webpFrameCount(thumbBytes); frames != tc.wantFrames {
+						t.Errorf("%s: frames = %d, want %d", thumb.FileName, frames, tc.wantFrames)
+					}
+					if tc.resize.Mode == models.ResizeCover && thumb.Width != thumb.Height {
+						t.Errorf("%s: cover dimensions %dx%d", thumb.FileName, thumb.Width, thumb.Height)
+					}
+				case thumb.Poster:
+					posters++
+					if filepath.Ext(thumb.FileName) != ".webp" {
+						t.Errorf("poster in unexpected format: %s", thumb.FileName)
+					}
+					if frames := webpFrameCount(thumbBytes); frames != 0 {
+						t.Errorf("%s: poster is animated", thumb.FileName)
+					}
+				default:
+					static++
+				}
+			}
+
+			wantAnimated := 0
+			if tc.wantAnimated {
+				wantAnimated = 1
+			}
+			if animated != wantAnimated || posters != wantAnimated {
+				t.Errorf("animated = %d, posters = %d, want %d each", animated, posters, wantAnimated)
+			}
+			if static != 2-wantAnimated {
+				t.Errorf("static thumbs = %d, want %d", static, 2-wantAnimated)
+			}
+		})
+	}
+}
+
+func TestImageThumbsGenerator_Integration_AnimatedOutputFormats(t *testing.T) {
+	origDir := t.TempDir()
+	gifAbsPath := filepath.Join(origDir, "spinner.gif")
+	writeTestGif(t, gifAbsPath, 4)
+
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: origDir,
+		OrigFileRelPath:  "spinner.gif",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{32},
+		ThumbFormats:     []string{".webp"},
+	}
+	result, err := mkGenerator(t).Generate(context.Background(), meta)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	detector := format.NewFormatDetector()
+	tests := []struct {
+		absPath string
+		want    format.Format
+	}{
+		{gifAbsPath, format.GIF},
+		{mkThumbFileAbsPath(meta, 32, ".webp"), format.ANIMATED_WEBP},
+		{mkPosterFileAbsPath(meta, 32, ".webp"), format.WEBP},
+	}
+
+	if len(result.Thumbs) != 2 {
+		t.Fatalf("thumbs = %d, want animated thumb and poster", len(result.Thumbs))
+	}
+
+	for _, tc := range tests {
+		got, err := detector.Detect(tc.absPath)
+		if err != nil {
+			t.Fatalf("detect %s failed: %v", tc.absPath, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: format = %s, want %s", filepath.Base(tc.absPath), got, tc.want)
+		}
+	}
+}
+
+// writeTestGif writes an animated GIF of a square moving across frames
+func writeTestGif(t *testing.T, absPath string, frames int) {
+	t.Helper()
+
+	anim := &gif.GIF{}
+	for idx := range frames {
+		frame := image.NewPaletted(image.Rect(0, 0, 120, 80), palette.Plan9)
+		for y := range 80 {
+			for x := range 120 {
+				frame.Set(x, y, color.RGBA{R: 240, G: 240, B: 240, A: 255})
+			}
+		}
+		for y := 30; y < 50; y++ {
+			for x := idx * 15; x < idx*15+20; x++ {
+				frame.Set(x, y, color.RGBA{R: 200, A: 255})
+			}
+		}
+
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+	}
+
+	file, err := os.Create(absPath)
+	if err != nil {
+		t.Fatalf("failed to create gif: %v", err)
+	}
+	defer file.Close()
+
+	if err := gif.EncodeAll(file, anim); err != nil {
+		t.Fatalf("failed to encode gif: %v", err)
+	}
+}
+
+// webpFrameCount counts animation frames ('ANMF' chunks) of a WebP file
+func webpFrameCount(data []byte) int {
+	frames := 0
+	for pos := 12; pos+8 <= len(data); {
+		chunkSize := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		if string(data[pos:pos+4]) == "ANMF" {
+			frames++
+		}
+		pos += 8 + chunkSize + chunkSize%2
+	}
+
+	return frames
+}
@@ -0,0 +1,353 @@
+package thumbsgen
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/telemetry"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrNoArchiveImage is wrapped by errors of archives holding no image to
+// thumbnail
+var ErrNoArchiveImage = errors.New("archive holds no image")
+
+// Reasons of archive entries rejection, reported as metric attribute
+const (
+	rejectReasonEntrySize        = "entry_size"
+	rejectReasonCompressionRatio = "compression_ratio"
+)
+
+// Entry pointing EPUB readers to the package (OPF) document
+const epubContainerEntry = "META-INF/container.xml"
+
+// readArchiveEntry loads entry into memory. Entries exceeding archive
+// limits of meta, either by their declared or their actual size, are
+// rejected with a permanent error before being fully inflated.
+func readArchiveEntry(
+	telemetrySvc *telemetry.TelemetrySvc,
+	meta ThumbnailMeta,
+	entry *zip.File,
+) ([]byte, error) {
+	err := checkArchiveEntry(
+		telemetrySvc,
+		meta,
+		entry,
+		entry.UncompressedSize64,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := entry.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive entry %s: %w", entry.Name, err)
+	}
+	defer reader.Close()
+
+	// Declared sizes can't be trusted, read one byte past the limit to
+	// tell entries lying about their size
+	var limited io.Reader = reader
+	if maxEntryBytes := meta.Archive.MaxEntryBytes; maxEntryBytes > 0 {
+		limited = io.LimitReader(reader, maxEntryBytes+1)
+	}
+
+	data, err := io.ReadAll(limited)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive entry %s: %w", entry.Name, err)
+	}
+
+	err = checkArchiveEntry(telemetrySvc, meta, entry, uint64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// checkArchiveEntry rejects entries whose uncompressed size exceeds
+// configured limits of meta, in bytes or in ratio to their compressed
+// size
+func checkArchiveEntry(
+	telemetrySvc *telemetry.TelemetrySvc,
+	meta ThumbnailMeta,
+	entry *zip.File,
+	uncompressedSize uint64,
+) error {
+	limits := meta.Archive
+
+	if limits.MaxEntryBytes > 0 && uncompressedSize > uint64(limits.MaxEntryBytes) {
+		return rejectInput(
+			telemetrySvc,
+			meta,
+			rejectReasonEntrySize,
+			fmt.Errorf(
+				"%w: archive entry %s size exceeds %d bytes",
+				ErrInputTooLarge,
+				entry.Name,
+				limits.MaxEntryBytes,
+			),
+		)
+	}
+
+	compressedSize := max(entry.CompressedSize64, 1)
+	if limits.MaxCompressionRatio > 0 &&
+		uncompressedSize/compressedSize > uint64(limits.MaxCompressionRatio) {
+		return rejectInput(
+			telemetrySvc,
+			meta,
+			rejectReasonCompressionRatio,
+			fmt.Errorf(
+				"%w: archive entry %s inflates %d bytes into %d, beyond %d:1",
+				ErrInputTooLarge,
+				entry.Name,
+				entry.CompressedSize64,
+				uncompressedSize,
+				limits.MaxCompressionRatio,
+			),
+		)
+	}
+
+	return nil
+}
+
+// firstImageEntry returns the image entry coming first in natural sort
+// order of names (e.g. 'page2.jpg' before 'page10.jpg'), as comic book
+// readers display pages
+func firstImageEntry(archive *zip.Reader) (*zip.File, error) {
+	var first *zip.File
+	for _, entry := range archive.File {
+		if !format.IsArchiveImage(entry.Name) {
+			continue
+		}
+
+		if first == nil || naturalLess(entry.Name, first.Name) {
+			first = entry
+		}
+	}
+
+	if first == nil {
+		return nil, ErrNoArchiveImage
+	}
+	return first, nil
+}
+
+// naturalLess compares names case insensitively, with runs of digits
+// compared by their numeric value
+func naturalLess(a string, b string) bool {
+	chunksA, chunksB := naturalChunks(a), naturalChunks(b)
+	for idx := range min(len(chunksA), len(chunksB)) {
+		chunkA, chunkB := chunksA[idx], chunksB[idx]
+		if chunkA == chunkB {
+			continue
+		}
+
+		if isDigits(chunkA) && isDigits(chunkB) {
+			numA := strings.TrimLeft(chunkA, "0")
+			numB := strings.TrimLeft(chunkB, "0")
+			if len(numA) != len(numB) {
+				return len(numA) < len(numB)
+			}
+			if numA != numB {
+				return numA < numB
+			}
+
+			// Same value, fewer leading zeros first
+			return len(chunkA) < len(chunkB)
+		}
+
+		return chunkA < chunkB
+	}
+
+	if len(chunksA) != len(chunksB) {
+		return len(chunksA) < len(chunksB)
+	}
+	return a < b
+}
+
+// naturalChunks splits lower cased name into alternating runs of digits
+// and of other characters
+func naturalChunks(name string) []string {
+	var chunks []string
+	runes := []rune(strings.ToLower(name))
+	for start := 0; start < len(runes); {
+		end := start + 1
+		for end < len(runes) &&
+			unicode.IsDigit(runes[end]) == unicode.IsDigit(runes[start]) {
+			end++
+		}
+
+		chunks = append(chunks, string(runes[start:end]))
+		start = end
+	}
+
+	return chunks
+}
+
+func isDigits(chunk string) bool {
+	return chunk != "" && unicode.IsDigit([]rune(chunk)[0])
+}
+
+// Subset of 'META-INF/container.xml' read here
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// Subset of the package (OPF) document read here
+type epubPackage struct {
+	Metas []struct {
+		Name    string `xml:"name,attr"`
+		Content string `xml:"content,attr"`
+	} `xml:"metadata>meta"`
+	Items []epubItem `xml:"manifest>item"`
+}
+
+type epubItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+// epubCoverEntry returns the entry of the cover image declared in the
+// OPF manifest of an EPUB archive: the item with 'cover-image' property
+// (EPUB 3) or the item referenced by the 'cover' meta (EPUB 2). Books
+// declaring no cover fall back to their first image entry.
+func epubCoverEntry(
+	telemetrySvc *telemetry.TelemetrySvc,
+	meta ThumbnailMeta,
+	archive *zip.Reader,
+) (*zip.File, error) {
+	entries := make(map[string]*zip.File, len(archive.File))
+	for _, entry := range archive.File {
+		entries[entry.Name] = entry
+	}
+
+	opfPath, err := epubPackagePath(telemetrySvc, meta, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg epubPackage
+	err = readArchiveXML(telemetrySvc, meta, entries, opfPath, &pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	cover := epubCoverItem(pkg)
+	if cover == nil {
+		return firstImageEntry(archive)
+	}
+
+	// Manifest references are URLs relative to the package document
+	href, err := url.PathUnescape(cover.Href)
+	if err != nil {
+		return nil, models.NewPermanentError(
+			fmt.Errorf("invalid epub cover reference %q: %w", cover.Href, err),
+		)
+	}
+	coverPath := path.Join(path.Dir(opfPath), href)
+
+	entry, found := entries[coverPath]
+	if !found {
+		return nil, fmt.Errorf("%w: epub cover %s not found", ErrNoArchiveImage, coverPath)
+	}
+	return entry, nil
+}
+
+// epubPackagePath returns path of the first package document listed by
+// the EPUB container
+func epubPackagePath(
+	telemetrySvc *telemetry.TelemetrySvc,
+	meta ThumbnailMeta,
+	entries map[string]*zip.File,
+) (string, error) {
+	var container epubContainer
+	err := readArchiveXML(
+		telemetrySvc,
+		meta,
+		entries,
+		epubContainerEntry,
+		&container,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if len(container.Rootfiles) == 0 || container.Rootfiles[0].FullPath == "" {
+		return "", models.NewPermanentError(
+			errors.New("epub container lists no package document"),
+		)
+	}
+	return container.Rootfiles[0].FullPath, nil
+}
+
+// epubCoverItem returns the manifest item declared as cover image, nil
+// when there is none
+func epubCoverItem(pkg epubPackage) *epubItem {
+	for idx, item := range pkg.Items {
+		if slices.Contains(strings.Fields(item.Properties), "cover-image") {
+			return &pkg.Items[idx]
+		}
+	}
+
+	for _, meta := range pkg.Metas {
+		if meta.Name != "cover" {
+			continue
+		}
+
+		for idx, item := range pkg.Items {
+			// Some books point the meta to their cover page, not image
+			if item.ID == meta.Content && strings.HasPrefix(item.MediaType, "image/") {
+				return &pkg.Items[idx]
+			}
+		}
+	}
+
+	return nil
+}
+
+// readArchiveXML decodes the XML entry named entryPath into v. Entities
+// besides predefined ones are never resolved. Missing or malformed
+// entries fail with a permanent error, as retrying can't fix the archive.
+func readArchiveXML(
+	telemetrySvc *telemetry.TelemetrySvc,
+	meta ThumbnailMeta,
+	entries map[string]*zip.File,
+	entryPath string,
+	v any,
+) error {
+	entry, found := entries[entryPath]
+	if !found {
+		return models.NewPermanentError(
+			fmt.Errorf("archive entry %s not found", entryPath),
+		)
+	}
+
+	data, err := readArchiveEntry(telemetrySvc, meta, entry)
+	if err != nil {
+		return err
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err := decoder.Decode(v); err != nil {
+		return models.NewPermanentError(
+			fmt.Errorf("failed to parse archive entry %s: %w", entryPath, err),
+		)
+	}
+	return nil
+}
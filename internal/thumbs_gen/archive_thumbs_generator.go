@@ -0,0 +1,130 @@
+package thumbsgen
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/telemetry"
+)
+
+// ArchiveThumbsGenerator produces thumbnails of zip based archives from
+// one of their image entries: the first page of comic books and zip
+// archives, the declared cover of EPUB e-books. Entry is read in memory,
+// no intermediary file is written.
+type ArchiveThumbsGenerator struct {
+	telemetry            *telemetry.TelemetrySvc
+	formatDetector       *format.FormatDetector
+	imageThumbsGenerator *ImageThumbsGenerator
+}
+
+// NewArchiveThumbsGenerator builds an archive thumbnail generator with
+// explicit dependencies.
+func NewArchiveThumbsGenerator(
+	telemetrySvc *telemetry.TelemetrySvc,
+	formatDetector *format.FormatDetector,
+	imageThumbsGenerator *ImageThumbsGenerator,
+) *ArchiveThumbsGenerator {
+	return &ArchiveThumbsGenerator{
+		telemetry:            telemetrySvc,
+		formatDetector:       formatDetector,
+		imageThumbsGenerator: imageThumbsGenerator,
+	}
+}
+
+// Generate implements ThumbsGenerator.
+func (g *ArchiveThumbsGenerator) Generate(
+	ctx context.Context,
+	meta ThumbnailMeta,
+) (*models.ThumbGenResult, error) {
+	origFileFormat, err := g.formatDetector.Detect(mkOriginalFileAbsPath(meta))
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to detect file format for %s: %w",
+			meta.OrigFileRelPath,
+			err)
+	}
+
+	if !slices.Contains(format.ArchiveFormats, origFileFormat) {
+		return nil, fmt.Errorf(
+			"cannot generate thumbnails: unsupported original file format: %v",
+			origFileFormat,
+		)
+	}
+
+	return g.GenerateWithoutFormatsCheck(ctx, meta, origFileFormat)
+}
+
+// GenerateWithoutFormatsCheck implements ThumbsGenerator.
+func (g *ArchiveThumbsGenerator) GenerateWithoutFormatsCheck(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	origFileFormat format.Format,
+) (*models.ThumbGenResult, error) {
+	imageBytes, err := g.readImageEntry(meta, origFileFormat)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to read image from archive %s: %w",
+			meta.OrigFileRelPath,
+			err,
+		)
+	}
+
+	// Entries are picked by extension, check their content
+	imageFormat := g.formatDetector.DetectImageBytes(imageBytes)
+	result, err := g.imageThumbsGenerator.GenerateFromBytes(
+		ctx,
+		meta,
+		imageBytes,
+		imageFormat,
+	)
+	if err != nil {
+		if imageFormat == format.UNSUPPORTED {
+			err = models.NewPermanentError(err)
+		}
+
+		return nil, fmt.Errorf(
+			"failed to generate archive thumbnails from image entry for %s: %w",
+			meta.OrigFileRelPath,
+			err,
+		)
+	}
+
+	return result, nil
+}
+
+// readImageEntry loads the image entry thumbnails of the archive are
+// produced from. Archives that can't be read, or holding no image, fail
+// with a permanent error.
+func (g *ArchiveThumbsGenerator) readImageEntry(
+	meta ThumbnailMeta,
+	origFileFormat format.Format,
+) ([]byte, error) {
+	archive, err := zip.OpenReader(mkOriginalFileAbsPath(meta))
+	if err != nil {
+		if errors.Is(err, zip.ErrFormat) {
+			return nil, models.NewPermanentError(err)
+		}
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	var entry *zip.File
+	if origFileFormat == format.EPUB {
+		entry, err = epubCoverEntry(g.telemetry, meta, &archive.Reader)
+	} else {
+		entry, err = firstImageEntry(&archive.Reader)
+	}
+	if errors.Is(err, ErrNoArchiveImage) {
+		return nil, models.NewPermanentError(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return readArchiveEntry(g.telemetry, meta, entry)
+}
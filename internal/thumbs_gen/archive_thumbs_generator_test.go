@@ -0,0 +1,307 @@
+package thumbsgen
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/telemetry"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+var (
+	testRed  = color.RGBA{R: 0xFF, A: 0xFF}
+	testBlue = color.RGBA{B: 0xFF, A: 0xFF}
+)
+
+func TestArchiveThumbsGenerator_ComicBookFirstPage(t *testing.T) {
+	meta := mkArchiveMeta(t, "comic.cbz",
+		"page10.png", solidPng(t, testBlue),
+		"__MACOSX/._page1.png", "resource fork",
+		"page9.png", solidPng(t, testRed),
+		"ComicInfo.xml", "<ComicInfo/>",
+	)
+
+	result, err := mkArchiveGenerator(t).Generate(context.Background(), meta)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if len(result.Thumbs) != 1 {
+		t.Fatalf("expected one thumbnail, got %d", len(result.Thumbs))
+	}
+
+	// 'page9' comes before 'page10' in natural order
+	assertThumbColor(t, meta, testRed)
+}
+
+func TestArchiveThumbsGenerator_EpubCover(t *testing.T) {
+	container := `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+
+	tests := map[string]string{
+		"epub 3 cover-image property": `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata/>
+  <manifest>
+    <item id="p1" href="images/a%20page.png" media-type="image/png"/>
+    <item id="c" href="images/the%20cover.png" media-type="image/png" properties="cover-image"/>
+  </manifest>
+</package>`,
+		"epub 2 cover meta": `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+  <metadata><meta name="cover" content="cover-page"/><meta name="cover" content="c"/></metadata>
+  <manifest>
+    <item id="cover-page" href="cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="p1" href="images/a%20page.png" media-type="image/png"/>
+    <item id="c" href="images/the%20cover.png" media-type="image/png"/>
+  </manifest>
+</package>`,
+	}
+
+	for name, opf := range tests {
+		t.Run(name, func(t *testing.T) {
+			meta := mkArchiveMeta(t, "book.epub",
+				"mimetype", "application/epub+zip",
+				"META-INF/container.xml", container,
+				"OEBPS/content.opf", opf,
+				"OEBPS/images/a page.png", solidPng(t, testBlue),
+				"OEBPS/images/the cover.png", solidPng(t, testRed),
+			)
+
+			if _, err := mkArchiveGenerator(t).Generate(context.Background(), meta); err != nil {
+				t.Fatalf("generate failed: %v", err)
+			}
+			assertThumbColor(t, meta, testRed)
+		})
+	}
+}
+
+func TestArchiveThumbsGenerator_ZipBombLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits models.ArchiveOptions
+	}{
+		{name: "entry size", limits: models.ArchiveOptions{MaxEntryBytes: 1024}},
+		{name: "compression ratio", limits: models.ArchiveOptions{MaxCompressionRatio: 100}},
+	}
+
+	// Zeros inflate about a thousand times
+	bomb := string(make([]byte, 1024*1024))
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			meta := mkArchiveMeta(t, "bomb.zip", "page.png", bomb)
+			meta.Archive = tc.limits
+
+			_, err := mkArchiveGenerator(t).Generate(context.Background(), meta)
+			if !errors.Is(err, ErrInputTooLarge) {
+				t.Fatalf("expected input too large error, got %v", err)
+			}
+			if category := models.ErrorCategoryOf(err); category != models.ErrCategoryPermanent {
+				t.Fatalf("expected permanent error, got %s", category)
+			}
+		})
+	}
+}
+
+func TestArchiveThumbsGenerator_EpubWithoutImage(t *testing.T) {
+	meta := mkArchiveMeta(t, "book.epub",
+		"mimetype", "application/epub+zip",
+		"META-INF/container.xml", `<container><rootfiles><rootfile full-path="content.opf"/></rootfiles></container>`,
+		"content.opf", `<package><manifest><item id="t" href="text.xhtml" media-type="application/xhtml+xml"/></manifest></package>`,
+	)
+
+	_, err := mkArchiveGenerator(t).Generate(context.Background(), meta)
+	if !errors.Is(err, ErrNoArchiveImage) {
+		t.Fatalf("expected no archive image error, got %v", err)
+	}
+	if category := models.ErrorCategoryOf(err); category != models.ErrCategoryPermanent {
+		t.Fatalf("expected permanent error, got %s", category)
+	}
+}
+
+// Broken books would fail again on retry
+func TestArchiveThumbsGenerator_MalformedEpub(t *testing.T) {
+	tests := map[string][]string{
+		"missing container": {
+			"mimetype", "application/epub+zip",
+			"content.opf", `<package/>`,
+		},
+		"malformed container": {
+			"mimetype", "application/epub+zip",
+			"META-INF/container.xml", `<container><rootfiles>`,
+		},
+		"container without package": {
+			"mimetype", "application/epub+zip",
+			"META-INF/container.xml", `<container><rootfiles/></container>`,
+		},
+		"malformed package": {
+			"mimetype", "application/epub+zip",
+			"META-INF/container.xml", `<container><rootfiles><rootfile full-path="content.opf"/></rootfiles></container>`,
+			"content.opf", `<package><manifest><item id="c"`,
+		},
+	}
+
+	for name, namesAndContents := range tests {
+		t.Run(name, func(t *testing.T) {
+			meta := mkArchiveMeta(t, "book.epub", namesAndContents...)
+
+			_, err := mkArchiveGenerator(t).Generate(context.Background(), meta)
+			if err == nil {
+				t.Fatal("expected error for malformed epub, got nil")
+			}
+			if category := models.ErrorCategoryOf(err); category != models.ErrCategoryPermanent {
+				t.Fatalf("expected permanent error, got %s: %v", category, err)
+			}
+		})
+	}
+}
+
+func TestArchiveThumbsGenerator_UnsupportedMedia(t *testing.T) {
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: testutils.TestFilesDir(),
+		OrigFileRelPath:  "1 house.jpg",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{48},
+	}
+
+	if _, err := mkArchiveGenerator(t).Generate(context.Background(), meta); err == nil {
+		t.Fatal("expected unsupported format error for JPEG original")
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	names := []string{
+		"Page10.jpg",
+		"page2.jpg",
+		"page02.jpg",
+		"cover.jpg",
+		"page1.jpg",
+		"chapter 2/01.jpg",
+		"chapter 10/01.jpg",
+		"page1a.jpg",
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		switch {
+		case naturalLess(a, b):
+			return -1
+		case naturalLess(b, a):
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	want := []string{
+		"chapter 2/01.jpg",
+		"chapter 10/01.jpg",
+		"cover.jpg",
+		"page1.jpg",
+		"page1a.jpg",
+		"page2.jpg",
+		"page02.jpg",
+		"Page10.jpg",
+	}
+	if !slices.Equal(names, want) {
+		t.Fatalf("sorted = %v, want %v", names, want)
+	}
+}
+
+// mkArchiveMeta writes a zip archive holding given pairs of entry names
+// and contents as the original of returned meta
+func mkArchiveMeta(t *testing.T, fileName string, namesAndContents ...string) ThumbnailMeta {
+	t.Helper()
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for idx := 0; idx+1 < len(namesAndContents); idx += 2 {
+		entry, err := writer.Create(namesAndContents[idx])
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		if _, err := entry.Write([]byte(namesAndContents[idx+1])); err != nil {
+			t.Fatalf("failed to write zip entry: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close zip archive: %v", err)
+	}
+
+	origDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(origDir, fileName), archive.Bytes(), 0o644); err != nil {
+		t.Fatalf("failed to write archive original: %v", err)
+	}
+
+	return ThumbnailMeta{
+		OrigFilesRootDir: origDir,
+		OrigFileRelPath:  fileName,
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{48},
+	}
+}
+
+// solidPng returns a 64x96 PNG filled with given color
+func solidPng(t *testing.T, fill color.RGBA) string {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 96))
+	draw.Draw(img, img.Bounds(), image.NewUniform(fill), image.Point{}, draw.Src)
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return encoded.String()
+}
+
+func assertThumbColor(t *testing.T, meta ThumbnailMeta, want color.RGBA) {
+	t.Helper()
+
+	thumb := decodeThumb(t, mkThumbFileAbsPath(meta, 48, ThumbsExtension))
+	bounds := thumb.Bounds()
+	if bounds.Dx() != 48 || bounds.Dy() != 72 {
+		t.Fatalf("thumbnail is %dx%d, want 48x72", bounds.Dx(), bounds.Dy())
+	}
+
+	if got := thumb.At(24, 36); !similarColor(got, want) {
+		t.Fatalf("thumbnail color = %v, want %v", got, want)
+	}
+}
+
+func mkArchiveGenerator(t *testing.T) *ArchiveThumbsGenerator {
+	t.Helper()
+	t.Setenv("OTEL_ENABLED", "false")
+
+	telemetrySvc, err := telemetry.NewTelemetrySvc(context.Background())
+	if err != nil {
+		t.Fatalf("failed to init telemetry service: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = telemetrySvc.Shutdown(context.Background())
+	})
+
+	fmtDetector := format.NewFormatDetector()
+	fmtConverter := format.NewFormatConverter(telemetrySvc, fmtDetector)
+	imageGenerator := NewImageThumbsGenerator(
+		telemetrySvc,
+		fmtConverter,
+		fmtDetector,
+	)
+
+	return NewArchiveThumbsGenerator(telemetrySvc, fmtDetector, imageGenerator)
+}
@@ -0,0 +1,77 @@
+package audioextractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/giobyte8/thumbnailer/internal/telemetry"
+	"github.com/giobyte8/thumbnailer/internal/telemetry/metrics"
+)
+
+// Sources of images audio files are thumbnailed from, reported as metric
+// attribute
+const (
+	imageSourceCover    = "cover"
+	imageSourceWaveform = "waveform"
+)
+
+// Extractor reads stream info, cover art and samples of audio files with
+// ffprobe and ffmpeg
+type Extractor struct {
+	telemetry *telemetry.TelemetrySvc
+}
+
+func NewAudioExtractor(telemetrySvc *telemetry.TelemetrySvc) *Extractor {
+	return &Extractor{telemetry: telemetrySvc}
+}
+
+// ExtractCover saves the cover art embedded in the audio file at
+// 'fromAbsPath' as a JPEG image at 'intoAbsPath'. Use Probe to check
+// whether the file has cover art.
+func (e *Extractor) ExtractCover(
+	ctx context.Context,
+	fromAbsPath string,
+	intoAbsPath string,
+) error {
+
+	// Cover art is exposed by ffmpeg as a single frame video stream
+	cmd := exec.CommandContext(
+		ctx,
+		"ffmpeg",
+		"-y",
+		"-v", "error",
+		"-i", fromAbsPath,
+		"-map", "0:v:0",
+		"-an",
+		"-frames:v", "1",
+		"-q:v", "2",
+		intoAbsPath,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("ffmpeg binary not found: %w", err)
+		}
+
+		return fmt.Errorf(
+			"ffmpeg cover extraction failed for %s: %w. output: %s",
+			fromAbsPath,
+			err,
+			strings.TrimSpace(string(output)),
+		)
+	}
+
+	e.recordExtracted(imageSourceCover)
+	return nil
+}
+
+func (e *Extractor) recordExtracted(source string) {
+	e.telemetry.Metrics().IncrementWAttrs(
+		metrics.AudioImageExtracted,
+		map[string]string{"source": source},
+	)
+}
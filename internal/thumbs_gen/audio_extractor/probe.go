@@ -0,0 +1,121 @@
+package audioextractor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// Subset of 'ffprobe -print_format json' output read here
+type ffprobeOutput struct {
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+	Streams []ffprobeStream `json:"streams"`
+}
+
+type ffprobeStream struct {
+	CodecType   string `json:"codec_type"`
+	CodecName   string `json:"codec_name"`
+	SampleRate  string `json:"sample_rate"`
+	Channels    int    `json:"channels"`
+	Duration    string `json:"duration"`
+	Disposition struct {
+		AttachedPic int `json:"attached_pic"`
+	} `json:"disposition"`
+}
+
+// Probe reads duration, codec, sample rate, channels and cover art
+// presence of the audio file at 'audioAbsPath'.
+func (e *Extractor) Probe(
+	ctx context.Context,
+	audioAbsPath string,
+) (*models.AudioInfo, error) {
+	cmd := exec.CommandContext(
+		ctx,
+		"ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		audioAbsPath,
+	)
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("ffprobe binary not found: %w", err)
+		}
+
+		return nil, fmt.Errorf(
+			"ffprobe failed for %s: %w. output: %s",
+			audioAbsPath,
+			err,
+			strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	audioInfo, err := parseProbeOutput(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output for %s: %w", audioAbsPath, err)
+	}
+	return audioInfo, nil
+}
+
+func parseProbeOutput(output []byte) (*models.AudioInfo, error) {
+	var probe ffprobeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, err
+	}
+
+	audioInfo := &models.AudioInfo{}
+	var audio *ffprobeStream
+	for idx, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "audio":
+			if audio == nil {
+				audio = &probe.Streams[idx]
+			}
+		case "video":
+
+			// Cover art (ID3 APIC frame, MP4 'covr' atom, FLAC and
+			// Vorbis picture blocks) is stored as an attached picture
+			if stream.Disposition.AttachedPic != 0 {
+				audioInfo.HasCover = true
+			}
+		}
+	}
+	if audio == nil {
+		return nil, errors.New("no audio stream found")
+	}
+
+	duration := parseSeconds(probe.Format.Duration)
+	if duration <= 0 {
+		duration = parseSeconds(audio.Duration)
+	}
+
+	audioInfo.DurationMs = duration.Milliseconds()
+	audioInfo.Codec = audio.CodecName
+	audioInfo.SampleRate, _ = strconv.Atoi(audio.SampleRate)
+	audioInfo.Channels = audio.Channels
+	return audioInfo, nil
+}
+
+// parseSeconds parses seconds as printed by ffprobe (e.g. '12.345000')
+func parseSeconds(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,84 @@
+package audioextractor
+
+import (
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+func TestParseProbeOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   models.AudioInfo
+	}{
+		{
+			name: "mp3 with cover",
+			output: `{
+				"streams": [
+					{"codec_name": "mp3", "codec_type": "audio", "sample_rate": "44100", "channels": 2},
+					{"codec_name": "mjpeg", "codec_type": "video", "disposition": {"attached_pic": 1}}
+				],
+				"format": {"duration": "215.040000"}
+			}`,
+			want: models.AudioInfo{
+				DurationMs: 215040,
+				Codec:      "mp3",
+				SampleRate: 44100,
+				Channels:   2,
+				HasCover:   true,
+			},
+		},
+		{
+			name: "voice memo",
+			output: `{
+				"streams": [
+					{"codec_name": "aac", "codec_type": "audio", "sample_rate": "48000", "channels": 1, "duration": "4.500000"}
+				],
+				"format": {}
+			}`,
+			want: models.AudioInfo{
+				DurationMs: 4500,
+				Codec:      "aac",
+				SampleRate: 48000,
+				Channels:   1,
+			},
+		},
+		{
+			name: "video stream is no cover",
+			output: `{
+				"streams": [
+					{"codec_name": "theora", "codec_type": "video"},
+					{"codec_name": "vorbis", "codec_type": "audio", "sample_rate": "44100", "channels": 2}
+				],
+				"format": {"duration": "1.000000"}
+			}`,
+			want: models.AudioInfo{
+				DurationMs: 1000,
+				Codec:      "vorbis",
+				SampleRate: 44100,
+				Channels:   2,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseProbeOutput([]byte(tc.output))
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if *got != tc.want {
+				t.Fatalf("audio info = %+v, want %+v", *got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseProbeOutput_NoAudioStream(t *testing.T) {
+	output := `{"streams": [{"codec_name": "png", "codec_type": "video"}], "format": {}}`
+
+	if _, err := parseProbeOutput([]byte(output)); err == nil {
+		t.Fatal("expected error for file without audio stream")
+	}
+}
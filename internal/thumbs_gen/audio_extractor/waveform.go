@@ -0,0 +1,164 @@
+package audioextractor
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strings"
+)
+
+// Audio is decoded as mono 16 bits samples at a low rate, plenty for a
+// waveform only a few thousand pixels wide
+const (
+	waveformSampleRate = 8000
+	bytesPerSample     = 2
+)
+
+// Samples reduced into a single peak while decoding (10ms of audio), and
+// peaks kept at most. Once reached, pairs of peaks are merged so memory
+// stays bounded for long recordings.
+const (
+	samplesPerPeak = waveformSampleRate / 100
+	maxPeaks       = 1 << 16
+)
+
+// Peaks decodes the audio file at 'audioAbsPath' and returns 'columns'
+// peak amplitudes, from 0 (silence) to 1 (full scale), evenly spread over
+// its duration. Samples are streamed from ffmpeg, never loaded at once.
+func (e *Extractor) Peaks(
+	ctx context.Context,
+	audioAbsPath string,
+	columns int,
+) ([]float64, error) {
+	cmd := exec.CommandContext(
+		ctx,
+		"ffmpeg",
+		"-v", "error",
+		"-i", audioAbsPath,
+		"-map", "0:a:0",
+		"-ac", "1",
+		"-ar", fmt.Sprint(waveformSampleRate),
+		"-f", "s16le",
+		"-acodec", "pcm_s16le",
+		"pipe:1",
+	)
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to pipe ffmpeg output: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("ffmpeg binary not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	peaks, readErr := readPeaks(stdout)
+	if readErr != nil {
+		_ = cmd.Process.Kill()
+	}
+
+	if err := cmd.Wait(); err != nil && readErr == nil {
+		return nil, fmt.Errorf(
+			"ffmpeg audio decoding failed for %s: %w. output: %s",
+			audioAbsPath,
+			err,
+			strings.TrimSpace(stderr.String()),
+		)
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read decoded audio of %s: %w", audioAbsPath, readErr)
+	}
+	if len(peaks) == 0 {
+		return nil, fmt.Errorf("no audio samples decoded from %s", audioAbsPath)
+	}
+
+	e.recordExtracted(imageSourceWaveform)
+	return resamplePeaks(peaks, columns), nil
+}
+
+// readPeaks reads signed 16 bits little endian mono samples until EOF and
+// returns peak amplitudes of consecutive runs of samples. Runs grow as
+// needed to return at most maxPeaks peaks.
+func readPeaks(samples io.Reader) ([]float64, error) {
+	peaks := make([]float64, 0, 1024)
+	runSamples := samplesPerPeak
+
+	var runPeak float64
+	var runCount int
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := io.ReadFull(samples, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+
+		// A trailing odd byte can't hold a sample
+		for offset := 0; offset+bytesPerSample <= n; offset += bytesPerSample {
+			sample := int16(binary.LittleEndian.Uint16(buf[offset:]))
+			runPeak = math.Max(runPeak, math.Abs(float64(sample))/math.MaxInt16)
+			runCount++
+
+			if runCount == runSamples {
+				peaks = append(peaks, min(runPeak, 1))
+				runPeak, runCount = 0, 0
+
+				if len(peaks) == maxPeaks {
+					peaks = mergePeakPairs(peaks)
+					runSamples *= 2
+				}
+			}
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	if runCount > 0 {
+		peaks = append(peaks, min(runPeak, 1))
+	}
+	return peaks, nil
+}
+
+// mergePeakPairs halves peaks, keeping the largest of each pair
+func mergePeakPairs(peaks []float64) []float64 {
+	merged := peaks[:0]
+	for idx := 0; idx < len(peaks); idx += 2 {
+		peak := peaks[idx]
+		if idx+1 < len(peaks) {
+			peak = math.Max(peak, peaks[idx+1])
+		}
+		merged = append(merged, peak)
+	}
+
+	return merged
+}
+
+// resamplePeaks spreads peaks over given number of columns. Each column
+// gets the largest peak among those it covers, or the nearest one when
+// there are fewer peaks than columns.
+func resamplePeaks(peaks []float64, columns int) []float64 {
+	resampled := make([]float64, columns)
+	if len(peaks) == 0 {
+		return resampled
+	}
+
+	for column := range columns {
+		from := column * len(peaks) / columns
+		to := max(from+1, (column+1)*len(peaks)/columns)
+		for _, peak := range peaks[from:to] {
+			resampled[column] = math.Max(resampled[column], peak)
+		}
+	}
+
+	return resampled
+}
@@ -0,0 +1,93 @@
+package audioextractor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestReadPeaks(t *testing.T) {
+
+	// One run at half scale, one silent and a partial one at full scale,
+	// followed by a dangling byte
+	var samples []int16
+	for range samplesPerPeak {
+		samples = append(samples, -math.MaxInt16/2)
+	}
+	samples = append(samples, make([]int16, samplesPerPeak)...)
+	samples = append(samples, 100, math.MinInt16)
+
+	peaks, err := readPeaks(bytes.NewReader(append(pcm(samples), 0x7F)))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	want := []float64{float64(math.MaxInt16/2) / math.MaxInt16, 0, 1}
+	if !slices.Equal(peaks, want) {
+		t.Fatalf("peaks = %v, want %v", peaks, want)
+	}
+}
+
+func TestReadPeaks_BoundedForLongAudio(t *testing.T) {
+	samples := make([]int16, samplesPerPeak*(maxPeaks+10))
+	samples[len(samples)-1] = math.MaxInt16
+
+	peaks, err := readPeaks(bytes.NewReader(pcm(samples)))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	if len(peaks) > maxPeaks {
+		t.Fatalf("expected at most %d peaks, got %d", maxPeaks, len(peaks))
+	}
+	if peaks[len(peaks)-1] != 1 {
+		t.Fatalf("expected last peak to be kept, got %v", peaks[len(peaks)-1])
+	}
+}
+
+func TestResamplePeaks(t *testing.T) {
+	tests := []struct {
+		name    string
+		peaks   []float64
+		columns int
+		want    []float64
+	}{
+		{
+			name:    "largest peak per column",
+			peaks:   []float64{0.1, 0.5, 0.3, 0.2, 0.9, 0.4},
+			columns: 3,
+			want:    []float64{0.5, 0.3, 0.9},
+		},
+		{
+			name:    "fewer peaks than columns",
+			peaks:   []float64{0.2, 0.8},
+			columns: 4,
+			want:    []float64{0.2, 0.2, 0.8, 0.8},
+		},
+		{
+			name:    "no peaks",
+			columns: 2,
+			want:    []float64{0, 0},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := resamplePeaks(tc.peaks, tc.columns); !slices.Equal(got, tc.want) {
+				t.Fatalf("resampled = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// pcm encodes samples as signed 16 bits little endian
+func pcm(samples []int16) []byte {
+	encoded := make([]byte, 0, len(samples)*bytesPerSample)
+	for _, sample := range samples {
+		encoded = binary.LittleEndian.AppendUint16(encoded, uint16(sample))
+	}
+
+	return encoded
+}
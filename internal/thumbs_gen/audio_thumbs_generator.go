@@ -0,0 +1,180 @@
+package thumbsgen
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	audioextractor "github.com/giobyte8/thumbnailer/internal/thumbs_gen/audio_extractor"
+)
+
+// AudioThumbsGenerator produces thumbnails of audio files from their
+// embedded cover art or, when they have none, from a waveform rendered
+// out of their samples. Both go through ImageThumbsGenerator as an
+// intermediary image.
+type AudioThumbsGenerator struct {
+	formatDetector       *format.FormatDetector
+	audioExtractor       *audioextractor.Extractor
+	imageThumbsGenerator ThumbsGenerator
+}
+
+// NewAudioThumbsGenerator builds an audio thumbnail generator with
+// explicit dependencies.
+func NewAudioThumbsGenerator(
+	formatDetector *format.FormatDetector,
+	audioExtractor *audioextractor.Extractor,
+	imageThumbsGenerator ThumbsGenerator,
+) *AudioThumbsGenerator {
+	return &AudioThumbsGenerator{
+		formatDetector:       formatDetector,
+		audioExtractor:       audioExtractor,
+		imageThumbsGenerator: imageThumbsGenerator,
+	}
+}
+
+// Generate implements ThumbsGenerator.
+func (g *AudioThumbsGenerator) Generate(
+	ctx context.Context,
+	meta ThumbnailMeta,
+) (*models.ThumbGenResult, error) {
+	origFileFormat, err := g.formatDetector.Detect(mkOriginalFileAbsPath(meta))
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to detect file format for %s: %w",
+			meta.OrigFileRelPath,
+			err)
+	}
+
+	if !slices.Contains(format.AudioFormats, origFileFormat) {
+		return nil, fmt.Errorf(
+			"cannot generate thumbnails: unsupported original file format: %v",
+			origFileFormat,
+		)
+	}
+
+	return g.GenerateWithoutFormatsCheck(ctx, meta, origFileFormat)
+}
+
+// GenerateWithoutFormatsCheck implements ThumbsGenerator.
+func (g *AudioThumbsGenerator) GenerateWithoutFormatsCheck(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	origFileFormat format.Format,
+) (*models.ThumbGenResult, error) {
+	coverAbsPath := mkIntermediaryThumbFileAbsPath(meta, ".jpg")
+	waveformAbsPath := mkIntermediaryThumbFileAbsPath(meta, ".png")
+	defer func() {
+		_ = os.Remove(coverAbsPath)
+		_ = os.Remove(waveformAbsPath)
+	}()
+
+	// Without stream info a waveform is still rendered, as long as
+	// samples can be decoded
+	audio, err := g.audioExtractor.Probe(ctx, mkOriginalFileAbsPath(meta))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		slog.Warn(
+			"Failed to probe audio file",
+			"filePath", meta.OrigFileRelPath,
+			"error", err,
+		)
+	}
+
+	imageAbsPath := coverAbsPath
+	imageFormat := format.JPEG
+	if !g.extractCover(ctx, meta, audio, coverAbsPath) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if err := g.mkWaveformFile(ctx, meta, waveformAbsPath); err != nil {
+			return nil, fmt.Errorf(
+				"failed to render waveform of audio file %s: %w",
+				meta.OrigFileRelPath,
+				err,
+			)
+		}
+		imageAbsPath = waveformAbsPath
+		imageFormat = format.PNG
+	}
+
+	// Replace original file info with intermediary image file
+	imageMeta := meta
+	imageMeta.OrigFilesRootDir = meta.ThumbFileAbsDir
+	imageMeta.OrigFileRelPath = filepath.Base(imageAbsPath)
+
+	result, err := g.imageThumbsGenerator.GenerateWithoutFormatsCheck(
+		ctx,
+		imageMeta,
+		imageFormat,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to generate audio thumbnails from image for %s: %w",
+			meta.OrigFileRelPath,
+			err,
+		)
+	}
+
+	result.Audio = audio
+	return result, nil
+}
+
+// extractCover saves cover art of the audio file into 'intoAbsPath', if
+// it has any. Returns whether cover art was extracted, failures are
+// logged so a waveform is rendered instead.
+func (g *AudioThumbsGenerator) extractCover(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	audio *models.AudioInfo,
+	intoAbsPath string,
+) bool {
+	if audio == nil || !audio.HasCover {
+		return false
+	}
+
+	err := g.audioExtractor.ExtractCover(
+		ctx,
+		mkOriginalFileAbsPath(meta),
+		intoAbsPath,
+	)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn(
+				"Failed to extract audio cover art, rendering waveform",
+				"filePath", meta.OrigFileRelPath,
+				"error", err,
+			)
+		}
+
+		audio.HasCover = false
+		return false
+	}
+
+	return true
+}
+
+// mkWaveformFile renders the waveform of the audio file into a PNG at
+// 'intoAbsPath', as large as the largest thumbnail
+func (g *AudioThumbsGenerator) mkWaveformFile(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	intoAbsPath string,
+) error {
+	width, height := vectorRasterSize(meta, waveformWidth, waveformHeight)
+
+	peaks, err := g.audioExtractor.Peaks(ctx, mkOriginalFileAbsPath(meta), width)
+	if err != nil {
+		return err
+	}
+
+	return writePNGFile(renderWaveform(peaks, width, height), intoAbsPath)
+}
@@ -0,0 +1,189 @@
+package thumbsgen
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/telemetry"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+	audioextractor "github.com/giobyte8/thumbnailer/internal/thumbs_gen/audio_extractor"
+)
+
+func TestAudioThumbsGenerator_Integration_Cover(t *testing.T) {
+	skipWithoutFFmpeg(t)
+
+	// Red cover art, attached to a FLAC file
+	origDir := t.TempDir()
+	cover := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for idx := range cover.Pix {
+		if idx%4 == 0 || idx%4 == 3 {
+			cover.Pix[idx] = 0xFF
+		}
+	}
+	coverAbsPath := filepath.Join(origDir, "cover.png")
+	if err := writePNGFile(cover, coverAbsPath); err != nil {
+		t.Fatalf("failed to write cover: %v", err)
+	}
+	runFFmpeg(
+		t,
+		"-f", "lavfi", "-i", "sine=frequency=440:duration=1",
+		"-i", coverAbsPath,
+		"-map", "0:a", "-map", "1:v",
+		"-c:a", "flac", "-c:v", "png", "-disposition:v", "attached_pic",
+		filepath.Join(origDir, "song.flac"),
+	)
+
+	meta := mkAudioMeta(t, origDir, "song.flac")
+	result, err := mkAudioGenerator(t).Generate(context.Background(), meta)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	if result.Audio == nil || !result.Audio.HasCover || result.Audio.Codec != "flac" {
+		t.Fatalf("unexpected audio info: %+v", result.Audio)
+	}
+	if result.OrigWidth != 64 || result.OrigHeight != 64 {
+		t.Fatalf("expected cover dimensions, got %dx%d", result.OrigWidth, result.OrigHeight)
+	}
+
+	thumb := decodeThumb(t, mkThumbFileAbsPath(meta, 48, ThumbsExtension))
+	r, g, b, _ := thumb.At(24, 24).RGBA()
+	if r>>8 < 0xC0 || g>>8 > 0x40 || b>>8 > 0x40 {
+		t.Fatalf("expected red cover thumbnail, got r=%d g=%d b=%d", r>>8, g>>8, b>>8)
+	}
+	assertIntermediariesRemoved(t, meta)
+}
+
+func TestAudioThumbsGenerator_Integration_Waveform(t *testing.T) {
+	skipWithoutFFmpeg(t)
+
+	origDir := t.TempDir()
+	runFFmpeg(
+		t,
+		"-f", "lavfi", "-i", "sine=frequency=440:duration=1",
+		"-c:a", "flac", "-f", "ogg",
+		filepath.Join(origDir, "memo.ogg"),
+	)
+
+	meta := mkAudioMeta(t, origDir, "memo.ogg")
+	meta.ThumbWidths = []int{256, 128}
+	result, err := mkAudioGenerator(t).Generate(context.Background(), meta)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	if result.Audio == nil || result.Audio.HasCover || result.Audio.DurationMs <= 0 {
+		t.Fatalf("unexpected audio info: %+v", result.Audio)
+	}
+
+	// Waveform is rendered at the largest thumbnail size
+	if result.OrigWidth != 256 || result.OrigHeight != 128 {
+		t.Fatalf("waveform is %dx%d, want 256x128", result.OrigWidth, result.OrigHeight)
+	}
+
+	thumb := decodeThumb(t, mkThumbFileAbsPath(meta, 256, ThumbsExtension))
+	if !similarColor(thumb.At(128, 64), waveformForeground) {
+		t.Fatalf("expected waveform bar at center, got %v", thumb.At(128, 64))
+	}
+	if !similarColor(thumb.At(128, 1), waveformBackground) {
+		t.Fatalf("expected background above waveform, got %v", thumb.At(128, 1))
+	}
+	assertIntermediariesRemoved(t, meta)
+}
+
+func TestAudioThumbsGenerator_UnsupportedMedia(t *testing.T) {
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: testutils.TestFilesDir(),
+		OrigFileRelPath:  "1 house.jpg",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{48},
+	}
+
+	if _, err := mkAudioGenerator(t).Generate(context.Background(), meta); err == nil {
+		t.Fatal("expected unsupported format error for JPEG original")
+	}
+}
+
+func mkAudioMeta(t *testing.T, origDir string, fileName string) ThumbnailMeta {
+	t.Helper()
+
+	return ThumbnailMeta{
+		OrigFilesRootDir: origDir,
+		OrigFileRelPath:  fileName,
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{48},
+	}
+}
+
+func mkAudioGenerator(t *testing.T) *AudioThumbsGenerator {
+	t.Helper()
+	t.Setenv("OTEL_ENABLED", "false")
+
+	telemetrySvc, err := telemetry.NewTelemetrySvc(context.Background())
+	if err != nil {
+		t.Fatalf("failed to init telemetry service: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = telemetrySvc.Shutdown(context.Background())
+	})
+
+	fmtDetector := format.NewFormatDetector()
+	fmtConverter := format.NewFormatConverter(telemetrySvc, fmtDetector)
+	imageGenerator := NewImageThumbsGenerator(
+		telemetrySvc,
+		fmtConverter,
+		fmtDetector,
+	)
+
+	return NewAudioThumbsGenerator(
+		fmtDetector,
+		audioextractor.NewAudioExtractor(telemetrySvc),
+		imageGenerator,
+	)
+}
+
+func skipWithoutFFmpeg(t *testing.T) {
+	t.Helper()
+
+	for _, binary := range []string{"ffmpeg", "ffprobe"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("%s not available, skipping integration test", binary)
+		}
+	}
+}
+
+func runFFmpeg(t *testing.T, args ...string) {
+	t.Helper()
+
+	args = append([]string{"-y", "-v", "error"}, args...)
+	if output, err := exec.Command("ffmpeg", args...).CombinedOutput(); err != nil {
+		t.Fatalf("ffmpeg failed: %v. output: %s", err, output)
+	}
+}
+
+func assertIntermediariesRemoved(t *testing.T, meta ThumbnailMeta) {
+	t.Helper()
+
+	for _, ext := range []string{".jpg", ".png"} {
+		if _, err := os.Stat(mkIntermediaryThumbFileAbsPath(meta, ext)); !os.IsNotExist(err) {
+			t.Fatalf("expected intermediary %s image to be removed, stat error: %v", ext, err)
+		}
+	}
+}
+
+// similarColor tells whether colors are equal, give or take lossy
+// encoding artifacts
+func similarColor(got color.Color, want color.RGBA) bool {
+	r, g, b, _ := got.RGBA()
+	diff := func(channel uint32, wanted uint8) int {
+		return max(int(channel>>8)-int(wanted), int(wanted)-int(channel>>8))
+	}
+
+	return diff(r, want.R) <= 24 && diff(g, want.G) <= 24 && diff(b, want.B) <= 24
+}
@@ -0,0 +1,153 @@
+package thumbsgen
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+// Number of BlurHash components along each axis. 4x3 suits the
+// landscape originals most libraries hold while keeping hashes short.
+const (
+	blurHashComponentsX = 4
+	blurHashComponentsY = 3
+)
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+	"abcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// encodeBlurHash computes the BlurHash (https://blurha.sh) of img. It is
+// meant to be run on small downscaled images as cost grows with pixels.
+func encodeBlurHash(img image.Image, componentsX, componentsY int) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// Linear RGB of every pixel, computed once for all components
+	linear := make([][3]float64, width*height)
+	for y := range height {
+		for x := range width {
+			r, g, b := rgb8(img, bounds.Min.X+x, bounds.Min.Y+y)
+			linear[y*width+x] = [3]float64{
+				srgbToLinear(r),
+				srgbToLinear(g),
+				srgbToLinear(b),
+			}
+		}
+	}
+
+	factors := make([][3]float64, 0, componentsX*componentsY)
+	for j := range componentsY {
+		for i := range componentsX {
+			factors = append(
+				factors,
+				blurHashFactor(linear, width, height, i, j),
+			)
+		}
+	}
+
+	var hash strings.Builder
+	sizeFlag := (componentsX - 1) + (componentsY-1)*9
+	hash.WriteString(encodeBase83(sizeFlag, 1))
+
+	dc, ac := factors[0], factors[1:]
+	maxValue := 1.0
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, factor := range ac {
+			for _, value := range factor {
+				actualMax = math.Max(actualMax, math.Abs(value))
+			}
+		}
+
+		quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantisedMax+1) / 166
+		hash.WriteString(encodeBase83(quantisedMax, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+
+	hash.WriteString(encodeBase83(encodeBlurHashDC(dc), 4))
+	for _, factor := range ac {
+		hash.WriteString(encodeBase83(encodeBlurHashAC(factor, maxValue), 2))
+	}
+
+	return hash.String()
+}
+
+// blurHashFactor computes the cosine transform coefficient of component
+// (i, j) over linear pixels.
+func blurHashFactor(
+	linear [][3]float64,
+	width int,
+	height int,
+	i int,
+	j int,
+) [3]float64 {
+	var factor [3]float64
+	for y := range height {
+		basisY := math.Cos(math.Pi * float64(j) * float64(y) / float64(height))
+		for x := range width {
+			basis := basisY *
+				math.Cos(math.Pi*float64(i)*float64(x)/float64(width))
+
+			pixel := linear[y*width+x]
+			factor[0] += basis * pixel[0]
+			factor[1] += basis * pixel[1]
+			factor[2] += basis * pixel[2]
+		}
+	}
+
+	normalisation := 2.0
+	if i == 0 && j == 0 {
+		normalisation = 1
+	}
+
+	scale := normalisation / float64(width*height)
+	return [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale}
+}
+
+func encodeBlurHashDC(value [3]float64) int {
+	return linearToSrgb(value[0])<<16 +
+		linearToSrgb(value[1])<<8 +
+		linearToSrgb(value[2])
+}
+
+func encodeBlurHashAC(value [3]float64, maxValue float64) int {
+	quantise := func(v float64) int {
+		return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maxValue, 0.5)*9+9.5))))
+	}
+
+	return quantise(value[0])*19*19 + quantise(value[1])*19 + quantise(value[2])
+}
+
+func encodeBase83(value int, length int) string {
+	encoded := make([]byte, length)
+	for i := range length {
+		digit := (value / int(math.Pow(83, float64(length-i-1)))) % 83
+		encoded[i] = base83Chars[digit]
+	}
+
+	return string(encoded)
+}
+
+func srgbToLinear(value float64) float64 {
+	v := value / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value float64, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
@@ -0,0 +1,44 @@
+package thumbsgen
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEncodeBlurHash_SolidColor(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 24))
+	for y := range 24 {
+		for x := range 32 {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	got := encodeBlurHash(img, 4, 3)
+
+	// Size flag 'L' (4x3) followed by AC max and DC with average color
+	if got[:1] != "L" {
+		t.Fatalf("unexpected size flag in %q", got)
+	}
+	if dc := got[2:6]; dc != encodeBase83(0xFF0000, 4) {
+		t.Fatalf("unexpected DC component in %q: got %q want %q", got, dc, encodeBase83(0xFF0000, 4))
+	}
+}
+
+func TestEncodeBlurHash_Length(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for x := range 16 {
+		img.Set(x, 0, color.RGBA{G: 255, A: 255})
+	}
+
+	got := encodeBlurHash(img, 4, 3)
+	if len(got) != 4+2*4*3 {
+		t.Fatalf("unexpected blurhash length: got %d (%q)", len(got), got)
+	}
+}
+
+func TestEncodeBase83(t *testing.T) {
+	if got := encodeBase83(83*83+1, 3); got != "101" {
+		t.Fatalf("unexpected base83: got %q want %q", got, "101")
+	}
+}
@@ -0,0 +1,84 @@
+package thumbsgen
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+)
+
+// decodePNG decodes PNG bytes produced by lilliput into an in memory
+// image, to apply operations lilliput doesn't support.
+func decodePNG(pngBytes []byte) (image.Image, error) {
+	img, err := png.Decode(bytes.NewReader(pngBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode intermediary png: %w", err)
+	}
+
+	return img, nil
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode intermediary png: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writePNGFile encodes img as an intermediary PNG file at 'absPath'.
+// File is decoded right away, speed is favored over size.
+func writePNGFile(img image.Image, absPath string) error {
+	file, err := os.Create(absPath)
+	if err != nil {
+		return fmt.Errorf("failed to create intermediary png: %w", err)
+	}
+	defer file.Close()
+
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(file, img); err != nil {
+		return fmt.Errorf("failed to encode intermediary png: %w", err)
+	}
+
+	return file.Close()
+}
+
+// padImage centers img in a transparent canvas of given dimensions.
+func padImage(img image.Image, width int, height int) *image.NRGBA {
+	canvas := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	imgBounds := img.Bounds()
+	offset := image.Pt(
+		(width-imgBounds.Dx())/2,
+		(height-imgBounds.Dy())/2,
+	)
+	draw.Draw(
+		canvas,
+		imgBounds.Sub(imgBounds.Min).Add(offset),
+		img,
+		imgBounds.Min,
+		draw.Src,
+	)
+
+	return canvas
+}
+
+// cropImage copies the area of img within window (relative to img
+// bounds origin) into a new image.
+func cropImage(img image.Image, window image.Rectangle) *image.NRGBA {
+	cropped := image.NewNRGBA(image.Rect(0, 0, window.Dx(), window.Dy()))
+	draw.Draw(
+		cropped,
+		cropped.Bounds(),
+		img,
+		img.Bounds().Min.Add(window.Min),
+		draw.Src,
+	)
+
+	return cropped
+}
@@ -0,0 +1,315 @@
+package thumbsgen
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+var errUnsupportedProfile = errors.New("unsupported ICC profile")
+
+// sRGB primaries adapted to D50, the ICC profile connection space white
+var srgbToXYZD50 = [3][3]float64{
+	{0.4360747, 0.3850649, 0.1430804},
+	{0.2225045, 0.7168786, 0.0606169},
+	{0.0139322, 0.0971045, 0.7141733},
+}
+
+// Resolution of the table encoding linear light into sRGB
+const srgbEncodeSteps = 4096
+
+// colorTransform converts pixels of an RGB matrix/TRC ICC profile (the
+// kind cameras and phones embed, e.g. Display P3 or Adobe RGB) into sRGB.
+// LUT based profiles are not supported.
+type colorTransform struct {
+	linearize  [3][256]float64
+	matrix     [3][3]float64
+	srgbEncode [srgbEncodeSteps + 1]uint8
+}
+
+// newColorTransform parses profile and builds its transform into sRGB
+func newColorTransform(profile []byte) (*colorTransform, error) {
+	tags, err := iccTags(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Columns of source RGB to XYZ matrix
+	var toXYZ [3][3]float64
+	for channel, tag := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		xyz, err := iccXYZ(tags[tag])
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s' tag: %w", tag, err)
+		}
+		for row := range 3 {
+			toXYZ[row][channel] = xyz[row]
+		}
+	}
+
+	transform := &colorTransform{
+		matrix: multiply(invert(srgbToXYZD50), toXYZ),
+	}
+
+	for channel, tag := range []string{"rTRC", "gTRC", "bTRC"} {
+		curve, err := iccCurve(tags[tag])
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s' tag: %w", tag, err)
+		}
+		for value := range 256 {
+			transform.linearize[channel][value] = curve(float64(value) / 255)
+		}
+	}
+
+	for step := range srgbEncodeSteps + 1 {
+		encoded := srgbEncode(float64(step) / srgbEncodeSteps)
+		transform.srgbEncode[step] = uint8(math.Round(encoded * 255))
+	}
+
+	return transform, nil
+}
+
+// isIdentity reports whether transform leaves 8 bits pixels unchanged,
+// which is the case for sRGB profiles
+func (t *colorTransform) isIdentity() bool {
+	for row := range 3 {
+		for col := range 3 {
+			expected := 0.0
+			if row == col {
+				expected = 1
+			}
+			if math.Abs(t.matrix[row][col]-expected) > 0.01 {
+				return false
+			}
+		}
+	}
+
+	for channel := range 3 {
+		for value := range 256 {
+			encoded := t.encode(t.linearize[channel][value])
+			if math.Abs(float64(encoded)-float64(value)) > 1 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// apply converts img pixels into sRGB. Colors out of sRGB gamut are
+// clipped.
+func (t *colorTransform) apply(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	converted := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(converted, converted.Bounds(), img, bounds.Min, draw.Src)
+
+	pixels := converted.Pix
+	for idx := 0; idx+3 < len(pixels); idx += 4 {
+		red := t.linearize[0][pixels[idx]]
+		green := t.linearize[1][pixels[idx+1]]
+		blue := t.linearize[2][pixels[idx+2]]
+
+		for channel := range 3 {
+			row := t.matrix[channel]
+			pixels[idx+channel] = t.encode(row[0]*red + row[1]*green + row[2]*blue)
+		}
+	}
+
+	return converted
+}
+
+func (t *colorTransform) encode(linear float64) uint8 {
+	linear = min(max(linear, 0), 1)
+	return t.srgbEncode[int(math.Round(linear*srgbEncodeSteps))]
+}
+
+// iccTags maps tag signatures of an ICC profile to their data
+func iccTags(profile []byte) (map[string][]byte, error) {
+	if len(profile) < 132 || string(profile[36:40]) != "acsp" {
+		return nil, fmt.Errorf("%w: invalid header", errUnsupportedProfile)
+	}
+	if string(profile[16:20]) != "RGB " || string(profile[20:24]) != "XYZ " {
+		return nil, fmt.Errorf("%w: not an RGB to XYZ profile", errUnsupportedProfile)
+	}
+
+	tagsCount := int(binary.BigEndian.Uint32(profile[128:132]))
+	if 132+tagsCount*12 > len(profile) {
+		return nil, fmt.Errorf("%w: truncated tags table", errUnsupportedProfile)
+	}
+
+	tags := make(map[string][]byte, tagsCount)
+	for idx := range tagsCount {
+		entry := profile[132+idx*12:]
+		offset := uint64(binary.BigEndian.Uint32(entry[4:8]))
+		size := uint64(binary.BigEndian.Uint32(entry[8:12]))
+		if offset+size > uint64(len(profile)) {
+			continue
+		}
+
+		tags[string(entry[0:4])] = profile[offset : offset+size]
+	}
+
+	return tags, nil
+}
+
+func iccXYZ(tag []byte) ([3]float64, error) {
+	if len(tag) < 20 || string(tag[0:4]) != "XYZ " {
+		return [3]float64{}, errUnsupportedProfile
+	}
+
+	return [3]float64{
+		s15Fixed16(tag[8:12]),
+		s15Fixed16(tag[12:16]),
+		s15Fixed16(tag[16:20]),
+	}, nil
+}
+
+// iccCurve returns the function decoding channel values (0 to 1) of a
+// 'curv' or 'para' tag into linear light
+func iccCurve(tag []byte) (func(float64) float64, error) {
+	if len(tag) < 12 {
+		return nil, errUnsupportedProfile
+	}
+
+	switch string(tag[0:4]) {
+	case "curv":
+		return curvCurve(tag)
+	case "para":
+		return paraCurve(tag)
+	default:
+		return nil, fmt.Errorf("%w: curve type '%s'", errUnsupportedProfile, tag[0:4])
+	}
+}
+
+func curvCurve(tag []byte) (func(float64) float64, error) {
+	entriesCount := int(binary.BigEndian.Uint32(tag[8:12]))
+	if 12+entriesCount*2 > len(tag) {
+		return nil, errUnsupportedProfile
+	}
+
+	switch entriesCount {
+	case 0:
+		return func(v float64) float64 { return v }, nil
+	case 1:
+		gamma := float64(binary.BigEndian.Uint16(tag[12:14])) / 256
+		return func(v float64) float64 { return math.Pow(v, gamma) }, nil
+	}
+
+	table := make([]float64, entriesCount)
+	for idx := range table {
+		table[idx] = float64(binary.BigEndian.Uint16(tag[12+idx*2:])) / 65535
+	}
+
+	// Linear interpolation between table samples
+	return func(v float64) float64 {
+		position := v * float64(entriesCount-1)
+		lower := int(math.Floor(position))
+		if lower >= entriesCount-1 {
+			return table[entriesCount-1]
+		}
+
+		fraction := position - float64(lower)
+		return table[lower]*(1-fraction) + table[lower+1]*fraction
+	}, nil
+}
+
+func paraCurve(tag []byte) (func(float64) float64, error) {
+	paramsCounts := []int{1, 3, 4, 5, 7}
+
+	functionType := int(binary.BigEndian.Uint16(tag[8:10]))
+	if functionType >= len(paramsCounts) ||
+		12+paramsCounts[functionType]*4 > len(tag) {
+		return nil, errUnsupportedProfile
+	}
+
+	// Parameters g, a, b, c, d, e and f as defined by ICC spec
+	var p [7]float64
+	for idx := range paramsCounts[functionType] {
+		p[idx] = s15Fixed16(tag[12+idx*4:])
+	}
+	g, a, b, c, d, e, f := p[0], p[1], p[2], p[3], p[4], p[5], p[6]
+
+	power := func(v float64) float64 { return math.Pow(max(v, 0), g) }
+	switch functionType {
+	case 0:
+		return power, nil
+	case 1:
+		return func(v float64) float64 {
+			if v >= -b/a {
+				return power(a*v + b)
+			}
+			return 0
+		}, nil
+	case 2:
+		return func(v float64) float64 {
+			if v >= -b/a {
+				return power(a*v+b) + c
+			}
+			return c
+		}, nil
+	case 3:
+		return func(v float64) float64 {
+			if v >= d {
+				return power(a*v + b)
+			}
+			return c * v
+		}, nil
+	default:
+		return func(v float64) float64 {
+			if v >= d {
+				return power(a*v+b) + e
+			}
+			return c*v + f
+		}, nil
+	}
+}
+
+func s15Fixed16(data []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(data))) / 65536
+}
+
+func srgbEncode(linear float64) float64 {
+	if linear <= 0.0031308 {
+		return linear * 12.92
+	}
+	return 1.055*math.Pow(linear, 1/2.4) - 0.055
+}
+
+func multiply(a [3][3]float64, b [3][3]float64) [3][3]float64 {
+	var product [3][3]float64
+	for row := range 3 {
+		for col := range 3 {
+			for k := range 3 {
+				product[row][col] += a[row][k] * b[k][col]
+			}
+		}
+	}
+
+	return product
+}
+
+func invert(m [3][3]float64) [3][3]float64 {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+
+	return [3][3]float64{
+		{
+			(m[1][1]*m[2][2] - m[1][2]*m[2][1]) / det,
+			(m[0][2]*m[2][1] - m[0][1]*m[2][2]) / det,
+			(m[0][1]*m[1][2] - m[0][2]*m[1][1]) / det,
+		},
+		{
+			(m[1][2]*m[2][0] - m[1][0]*m[2][2]) / det,
+			(m[0][0]*m[2][2] - m[0][2]*m[2][0]) / det,
+			(m[0][2]*m[1][0] - m[0][0]*m[1][2]) / det,
+		},
+		{
+			(m[1][0]*m[2][1] - m[1][1]*m[2][0]) / det,
+			(m[0][1]*m[2][0] - m[0][0]*m[2][1]) / det,
+			(m[0][0]*m[1][1] - m[0][1]*m[1][0]) / det,
+		},
+	}
+}
@@ -0,0 +1,197 @@
+package thumbsgen
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/metadata"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+// Display P3 to sRGB conversion of linear values, as published for
+// both spaces under D65
+var p3ToSRGB = [3][3]float64{
+	{1.2249, -0.2247, 0},
+	{-0.0420, 1.0419, 0},
+	{-0.0197, -0.0786, 1.0979},
+}
+
+func TestColorTransform_DisplayP3FromHeic(t *testing.T) {
+	for _, filename := range []string{"4 thai_no_edits.heic", "5 thai_edited.heic"} {
+		t.Run(filename, func(t *testing.T) {
+			transform := heicColorTransform(t, filename)
+			if transform.isIdentity() {
+				t.Fatal("expected Display P3 profile to need conversion")
+			}
+
+			for _, p3 := range []color.NRGBA{
+				{200, 100, 50, 255},
+				{40, 160, 90, 255},
+				{128, 128, 128, 255},
+				{30, 60, 220, 128},
+			} {
+				converted := applyToPixel(transform, p3)
+				expected := referenceP3ToSRGB(p3)
+
+				if !closeColors(converted, expected, 2) {
+					t.Errorf("P3 %v: expected sRGB %v, got %v", p3, expected, converted)
+				}
+			}
+		})
+	}
+}
+
+func TestColorTransform_SaturatesP3Colors(t *testing.T) {
+	transform := heicColorTransform(t, "4 thai_no_edits.heic")
+
+	// Same values look more saturated in P3, so sRGB needs them farther
+	// apart
+	converted := applyToPixel(transform, color.NRGBA{200, 100, 50, 255})
+	if converted.R <= 200 || converted.G >= 100 || converted.B >= 50 {
+		t.Errorf("expected more saturated color, got %v", converted)
+	}
+}
+
+func TestColorTransform_SRGBProfileIsIdentity(t *testing.T) {
+	profile := mkMatrixProfile(srgbToXYZD50, srgbParaCurve())
+
+	transform, err := newColorTransform(profile)
+	if err != nil {
+		t.Fatalf("failed to parse profile: %v", err)
+	}
+	if !transform.isIdentity() {
+		t.Fatalf("expected sRGB profile to be identity, matrix %v", transform.matrix)
+	}
+}
+
+func TestColorTransform_UnsupportedProfiles(t *testing.T) {
+	cmyk := mkMatrixProfile(srgbToXYZD50, srgbParaCurve())
+	copy(cmyk[16:20], "CMYK")
+
+	tests := map[string][]byte{
+		"empty":      nil,
+		"not icc":    []byte("not an icc profile"),
+		"cmyk":       cmyk,
+		"lut curves": mkMatrixProfile(srgbToXYZD50, []byte("mAB \x00\x00\x00\x00\x00\x00\x00\x00")),
+		"truncated":  mkMatrixProfile(srgbToXYZD50, srgbParaCurve())[:140],
+	}
+
+	for name, profile := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := newColorTransform(profile); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func heicColorTransform(t *testing.T, filename string) *colorTransform {
+	t.Helper()
+
+	profile, err := metadata.HeifColorProfile(testutils.TestFilePath(filename))
+	if err != nil || profile == nil {
+		t.Fatalf("failed to read color profile: %v", err)
+	}
+
+	transform, err := newColorTransform(profile)
+	if err != nil {
+		t.Fatalf("failed to parse color profile: %v", err)
+	}
+
+	return transform
+}
+
+func applyToPixel(transform *colorTransform, pixel color.NRGBA) color.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, pixel)
+
+	return transform.apply(img).NRGBAAt(0, 0)
+}
+
+// referenceP3ToSRGB converts a Display P3 pixel with published matrix
+// and sRGB transfer function, shared by both spaces
+func referenceP3ToSRGB(pixel color.NRGBA) color.NRGBA {
+	linear := [3]float64{
+		srgbDecode(float64(pixel.R) / 255),
+		srgbDecode(float64(pixel.G) / 255),
+		srgbDecode(float64(pixel.B) / 255),
+	}
+
+	var encoded [3]uint8
+	for channel, row := range p3ToSRGB {
+		value := row[0]*linear[0] + row[1]*linear[1] + row[2]*linear[2]
+		encoded[channel] = uint8(math.Round(srgbEncode(min(max(value, 0), 1)) * 255))
+	}
+
+	return color.NRGBA{encoded[0], encoded[1], encoded[2], pixel.A}
+}
+
+func srgbDecode(value float64) float64 {
+	if value <= 0.04045 {
+		return value / 12.92
+	}
+	return math.Pow((value+0.055)/1.055, 2.4)
+}
+
+func closeColors(a color.NRGBA, b color.NRGBA, tolerance int) bool {
+	diff := func(x, y uint8) bool {
+		return math.Abs(float64(x)-float64(y)) <= float64(tolerance)
+	}
+
+	return diff(a.R, b.R) && diff(a.G, b.G) && diff(a.B, b.B) && a.A == b.A
+}
+
+// mkMatrixProfile builds a minimal RGB matrix/TRC ICC profile sharing
+// curve across channels
+func mkMatrixProfile(toXYZ [3][3]float64, curve []byte) []byte {
+	type tag struct {
+		signature string
+		data      []byte
+	}
+
+	var tags []tag
+	for channel, name := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		data := []byte("XYZ \x00\x00\x00\x00")
+		for row := range 3 {
+			fixed := int32(math.Round(toXYZ[row][channel] * 65536))
+			data = binary.BigEndian.AppendUint32(data, uint32(fixed))
+		}
+		tags = append(tags, tag{name, data})
+	}
+	for _, name := range []string{"rTRC", "gTRC", "bTRC"} {
+		tags = append(tags, tag{name, curve})
+	}
+
+	header := make([]byte, 128)
+	copy(header[16:20], "RGB ")
+	copy(header[20:24], "XYZ ")
+	copy(header[36:40], "acsp")
+
+	profile := binary.BigEndian.AppendUint32(header, uint32(len(tags)))
+	dataOffset := len(profile) + len(tags)*12
+
+	var data []byte
+	for _, t := range tags {
+		profile = append(profile, t.signature...)
+		profile = binary.BigEndian.AppendUint32(profile, uint32(dataOffset+len(data)))
+		profile = binary.BigEndian.AppendUint32(profile, uint32(len(t.data)))
+		data = append(data, t.data...)
+	}
+
+	profile = append(profile, data...)
+	binary.BigEndian.PutUint32(profile[0:4], uint32(len(profile)))
+	return profile
+}
+
+// srgbParaCurve returns sRGB transfer function as 'para' curve tag
+func srgbParaCurve() []byte {
+	curve := []byte("para\x00\x00\x00\x00\x00\x03\x00\x00")
+	for _, param := range []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045} {
+		curve = binary.BigEndian.AppendUint32(curve, uint32(int32(math.Round(param*65536))))
+	}
+
+	return curve
+}
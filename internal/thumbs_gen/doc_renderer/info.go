@@ -0,0 +1,106 @@
+package docrenderer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DocumentInfo holds properties of a PDF document read by 'pdfinfo'
+type DocumentInfo struct {
+	Pages int
+
+	// Whether the document is encrypted. Documents protected by an owner
+	// password only are encrypted too, yet they can be rendered.
+	Encrypted bool
+
+	// Size of the first page in points (1/72 inch), zero when unknown
+	PageWidthPt  float64
+	PageHeightPt float64
+}
+
+// Info reads page count, encryption and first page size of the PDF at
+// 'pdfAbsPath'.
+func (r *Renderer) Info(
+	ctx context.Context,
+	pdfAbsPath string,
+) (*DocumentInfo, error) {
+
+	// Use 'pdfinfo -h' for usage information
+	output, err := runPoppler(ctx, "pdfinfo", pdfAbsPath, pdfAbsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := parseInfoOutput(output)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to parse pdfinfo output for %s: %w",
+			pdfAbsPath,
+			err,
+		)
+	}
+	return info, nil
+}
+
+// parseInfoOutput reads 'Key: value' lines printed by 'pdfinfo'
+func parseInfoOutput(output []byte) (*DocumentInfo, error) {
+	info := &DocumentInfo{}
+	hasPages := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "Pages":
+			pages, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pages count %q: %w", value, err)
+			}
+			info.Pages = pages
+			hasPages = true
+		case "Encrypted":
+			// e.g. 'yes (print:yes copy:no change:no addNotes:no)'
+			info.Encrypted = strings.HasPrefix(value, "yes")
+		case "Page size":
+			info.PageWidthPt, info.PageHeightPt = parsePageSize(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !hasPages {
+		return nil, errors.New("no pages count found")
+	}
+	return info, nil
+}
+
+// parsePageSize parses sizes like '612 x 792 pts (letter)'. Returns
+// zeros for unexpected values.
+func parsePageSize(value string) (float64, float64) {
+	fields := strings.Fields(value)
+	if len(fields) < 3 || fields[1] != "x" {
+		return 0, 0
+	}
+
+	width, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, 0
+	}
+	height, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return 0, 0
+	}
+
+	return width, height
+}
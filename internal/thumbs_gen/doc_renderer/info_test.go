@@ -0,0 +1,60 @@
+package docrenderer
+
+import "testing"
+
+func TestParseInfoOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   DocumentInfo
+	}{
+		{
+			name: "plain document",
+			output: "Producer:       LibreOffice 7.6\n" +
+				"CreationDate:   Sat Mar  2 10:15:00 2024 UTC\n" +
+				"Tagged:         no\n" +
+				"Pages:          12\n" +
+				"Encrypted:      no\n" +
+				"Page size:      612 x 792 pts (letter)\n" +
+				"Page rot:       0\n",
+			want: DocumentInfo{Pages: 12, PageWidthPt: 612, PageHeightPt: 792},
+		},
+		{
+			name: "owner password only",
+			output: "Pages:          1\n" +
+				"Encrypted:      yes (print:yes copy:no change:no addNotes:no algorithm:AES)\n" +
+				"Page size:      595.276 x 841.89 pts (A4)\n",
+			want: DocumentInfo{
+				Pages:        1,
+				Encrypted:    true,
+				PageWidthPt:  595.276,
+				PageHeightPt: 841.89,
+			},
+		},
+		{
+			name:   "unexpected page size",
+			output: "Pages: 3\nPage size: unknown\n",
+			want:   DocumentInfo{Pages: 3},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := parseInfoOutput([]byte(tc.output))
+			if err != nil {
+				t.Fatalf("failed to parse pdfinfo output: %v", err)
+			}
+			if *info != tc.want {
+				t.Fatalf("info = %+v, want %+v", *info, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseInfoOutput_RejectsMissingPages(t *testing.T) {
+	for _, output := range []string{"Encrypted: no\n", "Pages: many\n"} {
+		if _, err := parseInfoOutput([]byte(output)); err == nil {
+			t.Fatalf("expected error for output %q", output)
+		}
+	}
+}
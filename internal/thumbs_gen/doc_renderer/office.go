@@ -0,0 +1,114 @@
+package docrenderer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/telemetry/metrics"
+)
+
+// LibreOffice binary used for office documents conversion
+const officeBinary = "soffice"
+
+// Time waited for output of LibreOffice once its process group was
+// killed on cancellation, before giving up on it
+const officeWaitDelay = 5 * time.Second
+
+// OfficeSupported tells whether LibreOffice is installed, which office
+// documents conversion requires.
+func OfficeSupported() bool {
+	_, err := exec.LookPath(officeBinary)
+	return err == nil
+}
+
+// ConvertToPdf converts the office document at 'docAbsPath' into a PDF
+// saved at 'intoAbsPath'.
+func (r *Renderer) ConvertToPdf(
+	ctx context.Context,
+	docAbsPath string,
+	intoAbsPath string,
+) error {
+	startTime := time.Now()
+
+	// Output is named after the document, a dedicated directory next to
+	// 'intoAbsPath' avoids clashes and lets it be moved without copying
+	outDirAbsPath, err := os.MkdirTemp(filepath.Dir(intoAbsPath), ".soffice-out-")
+	if err != nil {
+		return fmt.Errorf("failed to create conversion directory: %w", err)
+	}
+	defer os.RemoveAll(outDirAbsPath)
+
+	// Concurrent LibreOffice instances sharing a user profile fail, each
+	// conversion gets its own
+	profileAbsPath, err := os.MkdirTemp("", "thumbnailer-soffice-")
+	if err != nil {
+		return fmt.Errorf("failed to create LibreOffice profile directory: %w", err)
+	}
+	defer os.RemoveAll(profileAbsPath)
+
+	profileURL := url.URL{Scheme: "file", Path: profileAbsPath}
+	cmd := exec.CommandContext(
+		ctx,
+		officeBinary,
+		"--headless",
+		"--norestore",
+		"-env:UserInstallation="+profileURL.String(),
+		"--convert-to", "pdf",
+		"--outdir", outDirAbsPath,
+		docAbsPath,
+	)
+
+	// soffice launches the actual converter (soffice.bin) as a child,
+	// which keeps running and holding output pipes when only the launcher
+	// is killed
+	killProcessGroupOnCancel(cmd)
+	cmd.WaitDelay = officeWaitDelay
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("%s binary not found: %w", officeBinary, err)
+		}
+
+		return fmt.Errorf(
+			"%s conversion failed for %s: %w. output: %s",
+			officeBinary,
+			docAbsPath,
+			err,
+			strings.TrimSpace(string(output)),
+		)
+	}
+
+	// LibreOffice exits successfully even when the document can't be
+	// loaded (e.g. password protected ones), producing no output
+	docBaseName := filepath.Base(docAbsPath)
+	pdfAbsPath := filepath.Join(
+		outDirAbsPath,
+		strings.TrimSuffix(docBaseName, filepath.Ext(docBaseName))+".pdf",
+	)
+	if _, err := os.Stat(pdfAbsPath); err != nil {
+		return fmt.Errorf(
+			"%s produced no PDF for %s. output: %s",
+			officeBinary,
+			docAbsPath,
+			strings.TrimSpace(string(output)),
+		)
+	}
+
+	if err := os.Rename(pdfAbsPath, intoAbsPath); err != nil {
+		return fmt.Errorf("failed to move converted PDF: %w", err)
+	}
+
+	r.telemetry.Metrics().Duration(
+		metrics.FormatConvertDuration,
+		time.Since(startTime))
+	r.telemetry.Metrics().Increment(metrics.FormatConverted)
+	return nil
+}
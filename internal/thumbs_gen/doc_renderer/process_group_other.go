@@ -0,0 +1,9 @@
+//go:build !unix
+
+package docrenderer
+
+import "os/exec"
+
+// killProcessGroupOnCancel keeps default cancellation, which kills the
+// started process only. WaitDelay still bounds waits for its children.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package docrenderer
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestKillProcessGroupOnCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// Child keeps output pipe open after the shell is killed
+	cmd := exec.CommandContext(ctx, "sh", "-c", "sleep 30 & sleep 30")
+	killProcessGroupOnCancel(cmd)
+
+	startTime := time.Now()
+	if _, err := cmd.CombinedOutput(); err == nil {
+		t.Fatal("expected cancelled command to fail")
+	}
+
+	if elapsed := time.Since(startTime); elapsed > 5*time.Second {
+		t.Fatalf("command outlived cancellation by %v", elapsed)
+	}
+}
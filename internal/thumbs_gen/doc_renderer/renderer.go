@@ -0,0 +1,125 @@
+package docrenderer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/telemetry"
+	"github.com/giobyte8/thumbnailer/internal/telemetry/metrics"
+)
+
+// ErrEncryptedDocument is wrapped by errors of documents that can't be
+// opened without a password.
+var ErrEncryptedDocument = errors.New("document is password protected")
+
+// Message of poppler tools for documents requiring a user password
+const popplerPasswordError = "Incorrect password"
+
+// Renderer rasterizes pages of PDF documents with poppler tools and
+// converts office documents into PDF with LibreOffice.
+type Renderer struct {
+	telemetry *telemetry.TelemetrySvc
+}
+
+func NewDocRenderer(telemetrySvc *telemetry.TelemetrySvc) *Renderer {
+	return &Renderer{telemetry: telemetrySvc}
+}
+
+// RenderFirstPage rasterizes the first page of the PDF at 'pdfAbsPath'
+// at given resolution and saves it as a JPEG image at 'intoAbsPath'.
+// Info, as returned by Info, tells rendered pages of encrypted documents
+// (protected by an owner password only) apart in metrics.
+func (r *Renderer) RenderFirstPage(
+	ctx context.Context,
+	pdfAbsPath string,
+	intoAbsPath string,
+	info *DocumentInfo,
+	dpi int,
+) error {
+	startTime := time.Now()
+
+	// 'pdftoppm' appends the extension to given output prefix, and page
+	// number too unless '-singlefile' is used.
+	// Use 'pdftoppm -h' for usage information
+	outputPrefix := strings.TrimSuffix(intoAbsPath, filepath.Ext(intoAbsPath))
+	_, err := runPoppler(
+		ctx,
+		"pdftoppm",
+		pdfAbsPath,
+		"-f", "1",
+		"-l", "1",
+		"-r", strconv.Itoa(dpi),
+		"-jpeg",
+		"-jpegopt", "quality=95",
+		"-singlefile",
+		pdfAbsPath,
+		outputPrefix,
+	)
+	if err != nil {
+		return err
+	}
+
+	if renderedAbsPath := outputPrefix + ".jpg"; renderedAbsPath != intoAbsPath {
+		if err := os.Rename(renderedAbsPath, intoAbsPath); err != nil {
+			_ = os.Remove(renderedAbsPath)
+			return fmt.Errorf("failed to move rendered page: %w", err)
+		}
+	}
+
+	r.telemetry.Metrics().Duration(
+		metrics.DocumentRenderDuration,
+		time.Since(startTime))
+	r.telemetry.Metrics().IncrementWAttrs(
+		metrics.DocumentPageRendered,
+		map[string]string{"encrypted": strconv.FormatBool(info.Encrypted)},
+	)
+	return nil
+}
+
+// runPoppler runs the 'binary' poppler tool with given args and returns
+// its standard output. Failures caused by a missing password wrap
+// ErrEncryptedDocument.
+func runPoppler(
+	ctx context.Context,
+	binary string,
+	pdfAbsPath string,
+	args ...string,
+) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, binary, args...)
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("%s binary not found: %w", binary, err)
+		}
+
+		errOutput := strings.TrimSpace(stderr.String())
+		if strings.Contains(errOutput, popplerPasswordError) {
+			return nil, fmt.Errorf(
+				"%s failed for %s: %w",
+				binary,
+				pdfAbsPath,
+				ErrEncryptedDocument,
+			)
+		}
+
+		return nil, fmt.Errorf(
+			"%s failed for %s: %w. output: %s",
+			binary,
+			pdfAbsPath,
+			err,
+			errOutput,
+		)
+	}
+
+	return output, nil
+}
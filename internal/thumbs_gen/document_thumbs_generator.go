@@ -0,0 +1,247 @@
+package thumbsgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/telemetry"
+	docrenderer "github.com/giobyte8/thumbnailer/internal/thumbs_gen/doc_renderer"
+)
+
+// Reasons of document rejection, reported as metric attribute
+const (
+	rejectReasonPages     = "pages"
+	rejectReasonEncrypted = "encrypted"
+	rejectReasonTimeout   = "timeout"
+)
+
+// Points per inch, the unit of PDF page sizes
+const pointsPerInch = 72
+
+// Resolution pages are rendered at when none is configured
+const defaultDocumentDPI = 150
+
+// DocumentThumbsGenerator produces thumbnails of PDF documents from their
+// rasterized first page. Office documents are converted into PDF first.
+type DocumentThumbsGenerator struct {
+	telemetry            *telemetry.TelemetrySvc
+	formatDetector       *format.FormatDetector
+	docRenderer          *docrenderer.Renderer
+	imageThumbsGenerator ThumbsGenerator
+	officeSupported      bool
+}
+
+// NewDocumentThumbsGenerator builds a document thumbnail generator with
+// explicit dependencies. Office documents are only supported when
+// LibreOffice is installed.
+func NewDocumentThumbsGenerator(
+	telemetrySvc *telemetry.TelemetrySvc,
+	formatDetector *format.FormatDetector,
+	docRenderer *docrenderer.Renderer,
+	imageThumbsGenerator ThumbsGenerator,
+) *DocumentThumbsGenerator {
+	return &DocumentThumbsGenerator{
+		telemetry:            telemetrySvc,
+		formatDetector:       formatDetector,
+		docRenderer:          docRenderer,
+		imageThumbsGenerator: imageThumbsGenerator,
+		officeSupported:      docrenderer.OfficeSupported(),
+	}
+}
+
+// SupportedFormats lists formats this generator can handle: PDF, and
+// office documents when LibreOffice is installed.
+func (g *DocumentThumbsGenerator) SupportedFormats() []format.Format {
+	if !g.officeSupported {
+		return []format.Format{format.PDF}
+	}
+
+	return append([]format.Format{format.PDF}, format.OfficeFormats...)
+}
+
+// Generate implements ThumbsGenerator.
+func (g *DocumentThumbsGenerator) Generate(
+	ctx context.Context,
+	meta ThumbnailMeta,
+) (*models.ThumbGenResult, error) {
+	origFileFormat, err := g.formatDetector.Detect(mkOriginalFileAbsPath(meta))
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to detect file format for %s: %w",
+			meta.OrigFileRelPath,
+			err)
+	}
+
+	if !slices.Contains(g.SupportedFormats(), origFileFormat) {
+		return nil, fmt.Errorf(
+			"cannot generate thumbnails: unsupported original file format: %v",
+			origFileFormat,
+		)
+	}
+
+	return g.GenerateWithoutFormatsCheck(ctx, meta, origFileFormat)
+}
+
+// GenerateWithoutFormatsCheck implements ThumbsGenerator.
+func (g *DocumentThumbsGenerator) GenerateWithoutFormatsCheck(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	origFileFormat format.Format,
+) (*models.ThumbGenResult, error) {
+
+	// Image pipeline only checks the rendered page, reject oversized
+	// documents before any conversion or rendering
+	err := checkFileSize(g.telemetry, meta, mkOriginalFileAbsPath(meta))
+	if err != nil {
+		return nil, err
+	}
+
+	pageAbsPath := mkIntermediaryThumbFileAbsPath(meta, ".jpg")
+	defer func() {
+		_ = os.Remove(pageAbsPath)
+	}()
+
+	renderCtx := ctx
+	if meta.Document.Timeout > 0 {
+		var cancel context.CancelFunc
+		renderCtx, cancel = context.WithTimeout(ctx, meta.Document.Timeout)
+		defer cancel()
+	}
+
+	err = g.renderFirstPage(renderCtx, meta, origFileFormat, pageAbsPath)
+	if err != nil {
+		return nil, g.renderError(ctx, renderCtx, meta, err)
+	}
+
+	// Replace original file info with intermediary page file
+	pageMeta := meta
+	pageMeta.OrigFilesRootDir = meta.ThumbFileAbsDir
+	pageMeta.OrigFileRelPath = filepath.Base(pageAbsPath)
+
+	result, err := g.imageThumbsGenerator.GenerateWithoutFormatsCheck(
+		ctx,
+		pageMeta,
+		format.JPEG,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to generate document thumbnails from first page for %s: %w",
+			meta.OrigFileRelPath,
+			err,
+		)
+	}
+
+	return result, nil
+}
+
+// renderFirstPage rasterizes the first page of the document into the
+// JPEG image at 'intoAbsPath', converting office documents into an
+// intermediary PDF first.
+func (g *DocumentThumbsGenerator) renderFirstPage(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	origFileFormat format.Format,
+	intoAbsPath string,
+) error {
+	pdfAbsPath := mkOriginalFileAbsPath(meta)
+	if origFileFormat != format.PDF {
+		pdfAbsPath = mkIntermediaryThumbFileAbsPath(meta, ".pdf")
+		defer func() {
+			_ = os.Remove(pdfAbsPath)
+		}()
+
+		err := g.docRenderer.ConvertToPdf(
+			ctx,
+			mkOriginalFileAbsPath(meta),
+			pdfAbsPath,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	info, err := g.docRenderer.Info(ctx, pdfAbsPath)
+	if err != nil {
+		return err
+	}
+
+	maxPages := meta.Document.MaxPages
+	if maxPages > 0 && info.Pages > maxPages {
+		return fmt.Errorf(
+			"%w: %d pages exceed %d pages",
+			ErrInputTooLarge,
+			info.Pages,
+			maxPages,
+		)
+	}
+	if info.Pages == 0 {
+		return models.NewPermanentError(errors.New("document has no pages"))
+	}
+
+	return g.docRenderer.RenderFirstPage(
+		ctx,
+		pdfAbsPath,
+		intoAbsPath,
+		info,
+		renderDPI(meta, info),
+	)
+}
+
+// renderError wraps err of rendering the document. Errors that would
+// happen again on retries are reported as permanent ones.
+func (g *DocumentThumbsGenerator) renderError(
+	ctx context.Context,
+	renderCtx context.Context,
+	meta ThumbnailMeta,
+	err error,
+) error {
+	err = fmt.Errorf(
+		"failed to render first page of document %s: %w",
+		meta.OrigFileRelPath,
+		err,
+	)
+
+	switch {
+	case errors.Is(err, ErrInputTooLarge):
+		return rejectInput(g.telemetry, meta, rejectReasonPages, err)
+	case errors.Is(err, docrenderer.ErrEncryptedDocument):
+		return rejectInput(g.telemetry, meta, rejectReasonEncrypted, err)
+
+	// Timeout of the request itself isn't a property of the document
+	case ctx.Err() == nil && errors.Is(renderCtx.Err(), context.DeadlineExceeded):
+		return rejectInput(
+			g.telemetry,
+			meta,
+			rejectReasonTimeout,
+			fmt.Errorf("%w: rendering exceeded %v", err, meta.Document.Timeout),
+		)
+	}
+
+	return err
+}
+
+// renderDPI returns the configured resolution, lowered when needed so
+// the rendered page fits within the max input dimension. Large pages
+// like posters or drawings would be rejected by the image pipeline
+// otherwise.
+func renderDPI(meta ThumbnailMeta, info *docrenderer.DocumentInfo) int {
+	dpi := meta.Document.DPI
+	if dpi <= 0 {
+		dpi = defaultDocumentDPI
+	}
+	maxDimension := meta.InputLimits.MaxDimension
+	longestSideInches := max(info.PageWidthPt, info.PageHeightPt) / pointsPerInch
+	if maxDimension <= 0 || longestSideInches <= 0 {
+		return dpi
+	}
+
+	maxDPI := int(math.Floor(float64(maxDimension) / longestSideInches))
+	return max(1, min(dpi, maxDPI))
+}
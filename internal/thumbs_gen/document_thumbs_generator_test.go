@@ -0,0 +1,323 @@
+package thumbsgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/telemetry"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+	docrenderer "github.com/giobyte8/thumbnailer/internal/thumbs_gen/doc_renderer"
+)
+
+func TestDocumentThumbsGenerator_Integration_Pdf(t *testing.T) {
+	skipWithoutPoppler(t)
+
+	origDir := t.TempDir()
+	writeTestPdf(t, filepath.Join(origDir, "receipt.pdf"), 1)
+
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: origDir,
+		OrigFileRelPath:  "receipt.pdf",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{48},
+		Document:         models.DocumentOptions{DPI: 72, Timeout: time.Minute},
+	}
+
+	result, err := mkDocumentGenerator(t).Generate(context.Background(), meta)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if len(result.Thumbs) != 1 {
+		t.Fatalf("expected one thumbnail, got %d", len(result.Thumbs))
+	}
+
+	// Page is 144x96 points, red on its left half and blue on its right
+	thumb := decodeThumb(t, mkThumbFileAbsPath(meta, 48, ThumbsExtension))
+	bounds := thumb.Bounds()
+	if bounds.Dx() != 48 || bounds.Dy() != 32 {
+		t.Fatalf("thumbnail is %dx%d, want 48x32", bounds.Dx(), bounds.Dy())
+	}
+
+	r, _, b, _ := thumb.At(bounds.Dx()/8, bounds.Dy()/2).RGBA()
+	if r <= b {
+		t.Fatalf("expected red on the left, got r=%d b=%d", r>>8, b>>8)
+	}
+
+	if _, err := os.Stat(mkIntermediaryThumbFileAbsPath(meta, ".jpg")); !os.IsNotExist(err) {
+		t.Fatalf("expected rendered page to be removed, stat error: %v", err)
+	}
+}
+
+func TestDocumentThumbsGenerator_Integration_MaxPages(t *testing.T) {
+	skipWithoutPoppler(t)
+
+	origDir := t.TempDir()
+	writeTestPdf(t, filepath.Join(origDir, "report.pdf"), 3)
+
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: origDir,
+		OrigFileRelPath:  "report.pdf",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{48},
+		Document:         models.DocumentOptions{DPI: 72, MaxPages: 2},
+	}
+
+	_, err := mkDocumentGenerator(t).Generate(context.Background(), meta)
+	if !errors.Is(err, ErrInputTooLarge) {
+		t.Fatalf("expected input too large error, got %v", err)
+	}
+	if category := models.ErrorCategoryOf(err); category != models.ErrCategoryPermanent {
+		t.Fatalf("expected permanent error, got %s", category)
+	}
+}
+
+func TestDocumentThumbsGenerator_UnsupportedMedia(t *testing.T) {
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: testutils.TestFilesDir(),
+		OrigFileRelPath:  "1 house.jpg",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{48},
+	}
+
+	if _, err := mkDocumentGenerator(t).Generate(context.Background(), meta); err == nil {
+		t.Fatal("expected unsupported format error for JPEG original")
+	}
+}
+
+func TestDocumentThumbsGenerator_RenderErrorCategories(t *testing.T) {
+	generator := mkDocumentGenerator(t)
+	meta := ThumbnailMeta{OrigFileRelPath: "document.pdf"}
+
+	expired, cancelExpired := context.WithDeadline(
+		context.Background(),
+		time.Now().Add(-time.Second),
+	)
+	defer cancelExpired()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelledRender, cancelRender := context.WithTimeout(cancelled, time.Minute)
+	defer cancelRender()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		renderCtx context.Context
+		err       error
+		want      models.ErrorCategory
+	}{
+		{
+			name:      "encrypted",
+			ctx:       context.Background(),
+			renderCtx: context.Background(),
+			err:       fmt.Errorf("pdfinfo failed: %w", docrenderer.ErrEncryptedDocument),
+			want:      models.ErrCategoryPermanent,
+		},
+		{
+			name:      "too many pages",
+			ctx:       context.Background(),
+			renderCtx: context.Background(),
+			err:       fmt.Errorf("%w: 5 pages exceed 2 pages", ErrInputTooLarge),
+			want:      models.ErrCategoryPermanent,
+		},
+		{
+			name:      "no pages",
+			ctx:       context.Background(),
+			renderCtx: context.Background(),
+			err:       models.NewPermanentError(errors.New("document has no pages")),
+			want:      models.ErrCategoryPermanent,
+		},
+		{
+			name:      "render timeout",
+			ctx:       context.Background(),
+			renderCtx: expired,
+			err:       errors.New("signal: killed"),
+			want:      models.ErrCategoryPermanent,
+		},
+		{
+			name:      "request cancelled",
+			ctx:       cancelled,
+			renderCtx: cancelledRender,
+			err:       errors.New("signal: killed"),
+			want:      models.ErrCategoryTransient,
+		},
+		{
+			name:      "tool failure",
+			ctx:       context.Background(),
+			renderCtx: context.Background(),
+			err:       errors.New("pdftoppm binary not found"),
+			want:      models.ErrCategoryTransient,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := generator.renderError(tc.ctx, tc.renderCtx, meta, tc.err)
+			if category := models.ErrorCategoryOf(err); category != tc.want {
+				t.Fatalf("category = %s, want %s (error: %v)", category, tc.want, err)
+			}
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v to wrap %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestRenderDPI(t *testing.T) {
+	a4 := &docrenderer.DocumentInfo{Pages: 1, PageWidthPt: 595, PageHeightPt: 842}
+	poster := &docrenderer.DocumentInfo{Pages: 1, PageWidthPt: 2592, PageHeightPt: 3456}
+
+	tests := []struct {
+		name         string
+		dpi          int
+		maxDimension int
+		info         *docrenderer.DocumentInfo
+		want         int
+	}{
+		{name: "configured dpi", dpi: 200, maxDimension: 32768, info: a4, want: 200},
+		{name: "default dpi", maxDimension: 32768, info: a4, want: defaultDocumentDPI},
+		{name: "no dimension limit", dpi: 300, info: poster, want: 300},
+		{name: "unknown page size", dpi: 300, maxDimension: 1000, info: &docrenderer.DocumentInfo{Pages: 1}, want: 300},
+
+		// 48 inches long poster fits 4800px at 100 DPI
+		{name: "lowered for large pages", dpi: 300, maxDimension: 4800, info: poster, want: 100},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			meta := ThumbnailMeta{
+				Document:    models.DocumentOptions{DPI: tc.dpi},
+				InputLimits: models.InputLimits{MaxDimension: tc.maxDimension},
+			}
+
+			if got := renderDPI(meta, tc.info); got != tc.want {
+				t.Fatalf("renderDPI = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDocumentThumbsGenerator_InputLimits_FileSize(t *testing.T) {
+	origDir := t.TempDir()
+	writeTestPdf(t, filepath.Join(origDir, "report.pdf"), 3)
+
+	thumbsDir := t.TempDir()
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: origDir,
+		OrigFileRelPath:  "report.pdf",
+		ThumbFileAbsDir:  thumbsDir,
+		ThumbWidths:      []int{48},
+		InputLimits:      models.InputLimits{MaxFileBytes: 64},
+	}
+
+	_, err := mkDocumentGenerator(t).GenerateWithoutFormatsCheck(
+		context.Background(),
+		meta,
+		format.PDF,
+	)
+	assertInputRejected(t, err)
+
+	entries, _ := os.ReadDir(thumbsDir)
+	if len(entries) != 0 {
+		t.Fatalf("expected nothing rendered for rejected document, got %d files", len(entries))
+	}
+}
+
+func mkDocumentGenerator(t *testing.T) *DocumentThumbsGenerator {
+	t.Helper()
+	t.Setenv("OTEL_ENABLED", "false")
+
+	telemetrySvc, err := telemetry.NewTelemetrySvc(context.Background())
+	if err != nil {
+		t.Fatalf("failed to init telemetry service: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = telemetrySvc.Shutdown(context.Background())
+	})
+
+	fmtDetector := format.NewFormatDetector()
+	fmtConverter := format.NewFormatConverter(telemetrySvc, fmtDetector)
+	imageGenerator := NewImageThumbsGenerator(
+		telemetrySvc,
+		fmtConverter,
+		fmtDetector,
+	)
+
+	return NewDocumentThumbsGenerator(
+		telemetrySvc,
+		fmtDetector,
+		docrenderer.NewDocRenderer(telemetrySvc),
+		imageGenerator,
+	)
+}
+
+func skipWithoutPoppler(t *testing.T) {
+	t.Helper()
+
+	for _, binary := range []string{"pdfinfo", "pdftoppm"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("%s not installed", binary)
+		}
+	}
+}
+
+// writeTestPdf writes a PDF with given number of 144x96 points pages,
+// red on their left half and blue on their right one
+func writeTestPdf(t *testing.T, absPath string, pages int) {
+	t.Helper()
+
+	content := "1 0 0 rg 0 0 72 96 re f 0 0 1 rg 72 0 72 96 re f"
+
+	// Catalog, page tree and content stream come first, pages after them
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+	var kids []string
+	for idx := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 4+idx))
+		objects = append(
+			objects,
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 144 96] /Contents 3 0 R >>",
+		)
+	}
+	objects[1] = fmt.Sprintf(
+		"<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.Join(kids, " "),
+		pages,
+	)
+
+	var pdf strings.Builder
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for idx, object := range objects {
+		offsets[idx] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", idx+1, object)
+	}
+
+	xrefOffset := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(
+		&pdf,
+		"trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1,
+		xrefOffset,
+	)
+
+	if err := os.WriteFile(absPath, []byte(pdf.String()), 0o644); err != nil {
+		t.Fatalf("failed to write test PDF: %v", err)
+	}
+}
@@ -6,32 +6,93 @@ import (
 	"strings"
 )
 
+// baseNameNoExt returns the name of the original without extension,
+// followed by its OutputNameSuffix. Files produced for the original are
+// named after it.
 func baseNameNoExt(meta ThumbnailMeta) string {
 	fileBaseName := filepath.Base(meta.OrigFileRelPath)
 
 	return strings.TrimSuffix(
 		fileBaseName,
 		filepath.Ext(fileBaseName),
-	)
+	) + OutputNameSuffix(meta)
+}
+
+// OutputNameSuffix returns the suffix following the name of the original
+// in names of files produced for it. Videos of Live Photos get
+// LivePhotoVideoSuffix so they don't overwrite files of the photo.
+func OutputNameSuffix(meta ThumbnailMeta) string {
+	if meta.LivePhotoPhoto != "" {
+		return LivePhotoVideoSuffix
+	}
+
+	return ""
 }
 
 func mkOriginalFileAbsPath(meta ThumbnailMeta) string {
 	return filepath.Join(meta.OrigFilesRootDir, meta.OrigFileRelPath)
 }
 
+// mkThumbFileAbsPath creates the absolute path of a thumbnail with given
+// width. Thumbnails of box based resize modes get the mode tag appended,
+// followed by the name suffix (if any) so that variants don't collide
+// (e.g. 'sample_320px_cover320x320_grid.webp').
 func mkThumbFileAbsPath(
 	meta ThumbnailMeta,
 	thumbWidth int,
 	thumbExtension string,
 ) string {
-	thumbFileName := fmt.Sprintf(
-		"%s_%dpx%s",
-		baseNameNoExt(meta),
-		thumbWidth,
-		thumbExtension,
+	thumbFileName := fmt.Sprintf("%s_%dpx", baseNameNoExt(meta), thumbWidth)
+
+	if resizeTag := meta.Resize.Tag(); resizeTag != "" {
+		thumbFileName += "_" + resizeTag
+	}
+
+	if meta.ThumbNameSuffix != "" {
+		thumbFileName += "_" + meta.ThumbNameSuffix
+	}
+
+	return filepath.Join(meta.ThumbFileAbsDir, thumbFileName+thumbExtension)
+}
+
+// mkPosterFileAbsPath creates the absolute path of the static poster
+// accompanying an animated thumbnail with given width
+// (e.g. 'sample_320px_poster.webp').
+func mkPosterFileAbsPath(
+	meta ThumbnailMeta,
+	thumbWidth int,
+	thumbExtension string,
+) string {
+	thumbFileAbsPath := mkThumbFileAbsPath(meta, thumbWidth, thumbExtension)
+	return strings.TrimSuffix(thumbFileAbsPath, thumbExtension) +
+		"_poster" + thumbExtension
+}
+
+// mkPreviewFileAbsPath creates the absolute path of the preview clip of
+// a video (e.g. 'sample_preview.mp4').
+func mkPreviewFileAbsPath(meta ThumbnailMeta, extension string) string {
+	return filepath.Join(
+		meta.ThumbFileAbsDir,
+		fmt.Sprintf("%s_preview%s", baseNameNoExt(meta), extension),
 	)
+}
 
-	return filepath.Join(meta.ThumbFileAbsDir, thumbFileName)
+// mkMotionPhotoVideoAbsPath creates the absolute path of the video
+// extracted from a Motion Photo (e.g. 'sample_motion.mp4').
+func mkMotionPhotoVideoAbsPath(meta ThumbnailMeta) string {
+	return filepath.Join(
+		meta.ThumbFileAbsDir,
+		fmt.Sprintf("%s_motion.mp4", baseNameNoExt(meta)),
+	)
+}
+
+// mkStoryboardVttAbsPath creates the absolute path of the WebVTT file
+// indexing storyboard sheets of a video (e.g. 'sample_storyboard.vtt').
+func mkStoryboardVttAbsPath(meta ThumbnailMeta) string {
+	return filepath.Join(
+		meta.ThumbFileAbsDir,
+		fmt.Sprintf("%s_storyboard.vtt", baseNameNoExt(meta)),
+	)
 }
 
 // mkIntermediaryThumbFileAbsPath creates an absolute path for a thumbnail file
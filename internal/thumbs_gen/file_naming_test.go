@@ -3,6 +3,8 @@ package thumbsgen
 import (
 	"path/filepath"
 	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
 )
 
 func TestBaseNameNoExt(t *testing.T) {
@@ -29,6 +31,43 @@ func TestMkThumbFileAbsPath(t *testing.T) {
 	}
 }
 
+func TestMkThumbFileAbsPath_BoxedResizeMode(t *testing.T) {
+	meta := ThumbnailMeta{
+		OrigFileRelPath: filepath.Join("nested", "folder", "sample.png"),
+		ThumbFileAbsDir: filepath.Join("/tmp", "thumbs", "nested", "folder"),
+		Resize: models.ResizeSpec{
+			Mode:      models.ResizeCover,
+			BoxWidth:  320,
+			BoxHeight: 320,
+		},
+	}
+
+	got := mkThumbFileAbsPath(meta, 320, ".webp")
+	want := filepath.Join("/tmp", "thumbs", "nested", "folder", "sample_320px_cover320x320.webp")
+	if got != want {
+		t.Fatalf("unexpected thumbnail path: got %q want %q", got, want)
+	}
+}
+
+func TestMkThumbFileAbsPath_PresetSuffix(t *testing.T) {
+	meta := ThumbnailMeta{
+		OrigFileRelPath: filepath.Join("nested", "folder", "sample.png"),
+		ThumbFileAbsDir: filepath.Join("/tmp", "thumbs", "nested", "folder"),
+		Resize: models.ResizeSpec{
+			Mode:      models.ResizeCover,
+			BoxWidth:  320,
+			BoxHeight: 320,
+		},
+		ThumbNameSuffix: "grid",
+	}
+
+	got := mkThumbFileAbsPath(meta, 320, ".avif")
+	want := filepath.Join("/tmp", "thumbs", "nested", "folder", "sample_320px_cover320x320_grid.avif")
+	if got != want {
+		t.Fatalf("unexpected thumbnail path: got %q want %q", got, want)
+	}
+}
+
 func TestMkDerivedFileAbsPath(t *testing.T) {
 	meta := ThumbnailMeta{
 		OrigFileRelPath: filepath.Join("nested", "folder", "sample.heic"),
@@ -41,3 +80,34 @@ func TestMkDerivedFileAbsPath(t *testing.T) {
 		t.Fatalf("unexpected derived file path: got %q want %q", got, want)
 	}
 }
+
+func TestMkPosterFileAbsPath(t *testing.T) {
+	meta := ThumbnailMeta{
+		OrigFileRelPath: filepath.Join("nested", "sample.gif"),
+		ThumbFileAbsDir: filepath.Join("/tmp", "thumbs", "nested"),
+		Resize: models.ResizeSpec{
+			Mode:      models.ResizeCover,
+			BoxWidth:  320,
+			BoxHeight: 320,
+		},
+	}
+
+	got := mkPosterFileAbsPath(meta, 320, ".webp")
+	want := filepath.Join("/tmp", "thumbs", "nested", "sample_320px_cover320x320_poster.webp")
+	if got != want {
+		t.Fatalf("unexpected poster path: got %q want %q", got, want)
+	}
+}
+
+func TestMkPreviewFileAbsPath(t *testing.T) {
+	meta := ThumbnailMeta{
+		OrigFileRelPath: filepath.Join("nested", "clip.mov"),
+		ThumbFileAbsDir: filepath.Join("/tmp", "thumbs", "nested"),
+	}
+
+	got := mkPreviewFileAbsPath(meta, ".mp4")
+	want := filepath.Join("/tmp", "thumbs", "nested", "clip_preview.mp4")
+	if got != want {
+		t.Fatalf("unexpected preview path: got %q want %q", got, want)
+	}
+}
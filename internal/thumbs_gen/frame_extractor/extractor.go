@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
 	"github.com/giobyte8/thumbnailer/internal/telemetry"
 	"github.com/giobyte8/thumbnailer/internal/telemetry/metrics"
 )
@@ -30,8 +31,15 @@ func NewFrameExtractor(
 	}
 }
 
-// Extract extracts a frame from the video at 'fromAbsPath' and saves it as an
-// image at 'intoAbsPath'.
+// Extract extracts a representative frame from the video at 'fromAbsPath'
+// and saves it as an image at 'intoAbsPath'.
+//
+// Candidate frames are sampled at samplePercents of video duration and
+// the best scored one is kept (see extractRepresentative). Video, as
+// returned by Probe, drives sampling and rotation of frames. It might be
+// nil when unknown, then frames are rotated by ffmpeg itself. Frames of
+// HDR videos are tone mapped into SDR with toneMap operator, unless it
+// is models.ToneMapNone.
 //
 // Note: this method checks if the formats of source and destination files are
 // supported before performing the extraction. Use ExtractWithoutFormatsCheck
@@ -40,13 +48,23 @@ func (e *Extractor) Extract(
 	ctx context.Context,
 	fromAbsPath string,
 	intoAbsPath string,
+	video *models.VideoInfo,
+	toneMap models.ToneMapOperator,
+	samplePercents []int,
 ) error {
 	err := e.isExtractionSupported(fromAbsPath, intoAbsPath)
 	if err != nil {
 		return fmt.Errorf("frame extraction not supported: %w", err)
 	}
 
-	return e.ExtractWithoutFormatsCheck(ctx, fromAbsPath, intoAbsPath)
+	return e.ExtractWithoutFormatsCheck(
+		ctx,
+		fromAbsPath,
+		intoAbsPath,
+		video,
+		toneMap,
+		samplePercents,
+	)
 }
 
 // ExtractWithoutFormatsCheck performs the frame extraction without checking
@@ -58,10 +76,41 @@ func (e *Extractor) ExtractWithoutFormatsCheck(
 	ctx context.Context,
 	fromAbsPath string,
 	intoAbsPath string,
+	video *models.VideoInfo,
+	toneMap models.ToneMapOperator,
+	samplePercents []int,
 ) error {
 	startTime := time.Now()
 
-	cmd := e.makeFFmpegCommand(ctx, fromAbsPath, intoAbsPath)
+	err := e.extractRepresentative(
+		ctx,
+		fromAbsPath,
+		intoAbsPath,
+		video,
+		toneMap,
+		samplePercents,
+	)
+	if err != nil {
+		return err
+	}
+
+	e.telemetry.Metrics().Duration(
+		metrics.VideoFrameExtractDuration,
+		time.Since(startTime))
+	e.telemetry.Metrics().Increment(metrics.VideoFrameExtracted)
+	return nil
+}
+
+// extractFrameAt extracts the frame at given offset of the video
+func (e *Extractor) extractFrameAt(
+	ctx context.Context,
+	fromAbsPath string,
+	intoAbsPath string,
+	video *models.VideoInfo,
+	toneMap models.ToneMapOperator,
+	offset time.Duration,
+) error {
+	cmd := e.makeFFmpegCommand(ctx, fromAbsPath, intoAbsPath, video, toneMap, offset)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		if errors.Is(err, exec.ErrNotFound) {
@@ -76,10 +125,6 @@ func (e *Extractor) ExtractWithoutFormatsCheck(
 		)
 	}
 
-	e.telemetry.Metrics().Duration(
-		metrics.VideoFrameExtractDuration,
-		time.Since(startTime))
-	e.telemetry.Metrics().Increment(metrics.VideoFrameExtracted)
 	return nil
 }
 
@@ -89,16 +134,19 @@ func (e *Extractor) makeFFmpegCommand(
 	ctx context.Context,
 	fromAbsPath string,
 	intoAbsPath string,
+	video *models.VideoInfo,
+	toneMap models.ToneMapOperator,
+	offset time.Duration,
 ) *exec.Cmd {
-	args := []string{
-		"-y",
-		"-ss", "00:00:01",
+	args := []string{"-y", "-ss", formatSeconds(offset)}
+	args = append(args, inputArgs(video)...)
+	args = append(args,
 		"-i", fromAbsPath,
 		"-vframes", "1",
-		"-vf", "format=yuv420p",
+		"-vf", videoFilters(video, toneMap, "format=yuv420p"),
 		"-q:v", "2",
 		intoAbsPath,
-	}
+	)
 
 	return exec.CommandContext(ctx, "ffmpeg", args...)
 }
@@ -131,9 +179,7 @@ func (e *Extractor) isExtractionSupported(
 
 func (e *Extractor) isSrcFormatSupported(fromFormat format.Format) bool {
 
-	// Only MOV, MP4 and M4V are supported for now
-	supportedFormats := []format.Format{format.MOV, format.MP4, format.M4V}
-	return slices.Contains(supportedFormats, fromFormat)
+	return slices.Contains(format.VideoFormats, fromFormat)
 }
 
 func (e *Extractor) isDstExtensionSupported(intoAbsPath string) bool {
@@ -2,6 +2,7 @@ package frameextractor
 
 import (
 	"context"
+	"fmt"
 	"image"
 	_ "image/jpeg"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
 	"github.com/giobyte8/thumbnailer/internal/telemetry"
 	"github.com/giobyte8/thumbnailer/internal/testutils"
 )
@@ -44,6 +46,9 @@ func TestExtractor_UnsupportedCases(t *testing.T) {
 				context.Background(),
 				tc.fromAbsPath,
 				tc.intoAbsPath,
+				nil,
+				models.ToneMapNone,
+				models.DefaultFrameSamplePercents,
 			)
 
 			if err == nil {
@@ -58,8 +63,10 @@ func TestExtractor_UnsupportedCases(t *testing.T) {
 }
 
 func TestExtractor_Integration_SupportedCases(t *testing.T) {
-	if _, err := exec.LookPath("ffmpeg"); err != nil {
-		t.Skip("ffmpeg not available, skipping integration test")
+	for _, binary := range []string{"ffmpeg", "ffprobe"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("%s not available, skipping integration test", binary)
+		}
 	}
 
 	tests := []struct {
@@ -86,7 +93,19 @@ func TestExtractor_Integration_SupportedCases(t *testing.T) {
 			fromAbsPath := tc.fromAbsPath
 			intoAbsPath := tc.intoAbsPath
 
-			err := extractor.Extract(context.Background(), fromAbsPath, intoAbsPath)
+			video, err := extractor.Probe(context.Background(), fromAbsPath)
+			if err != nil {
+				t.Fatalf("probe failed: %v", err)
+			}
+
+			err = extractor.Extract(
+				context.Background(),
+				fromAbsPath,
+				intoAbsPath,
+				video,
+				models.ToneMapNone,
+				models.DefaultFrameSamplePercents,
+			)
 			if err != nil {
 				t.Fatalf("extract failed: %v", err)
 			}
@@ -122,6 +141,123 @@ func TestExtractor_Integration_SupportedCases(t *testing.T) {
 	}
 }
 
+// Fixtures of each container are generated with ffmpeg test source
+func TestExtractor_Integration_Containers(t *testing.T) {
+	for _, binary := range []string{"ffmpeg", "ffprobe"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("%s not available, skipping integration test", binary)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		fileName    string
+		encoderArgs []string
+		expected    format.Format
+	}{
+		{"mkv", "clip.mkv", []string{"-c:v", "mpeg4"}, format.MKV},
+		{"webm", "clip.webm", []string{"-c:v", "libvpx"}, format.WEBM},
+		{"avi", "clip.avi", []string{"-c:v", "mpeg4"}, format.AVI},
+		{"3gp", "clip.3gp", []string{"-c:v", "h263"}, format.THREE_GP},
+		{"mpeg-ts", "clip.ts", []string{"-c:v", "mpeg2video"}, format.MPEG_TS},
+		{
+			"avchd m2ts",
+			"clip.mts",
+			[]string{"-c:v", "mpeg2video", "-f", "mpegts", "-mpegts_m2ts_mode", "1"},
+			format.MPEG_TS,
+		},
+	}
+
+	fmtDetector := format.NewFormatDetector()
+	extractor := NewFrameExtractor(mkTestTelemetrySvc(t), fmtDetector)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fromAbsPath := filepath.Join(t.TempDir(), tc.fileName)
+			args := []string{
+				"-y", "-loglevel", "error",
+				"-f", "lavfi", "-i", "testsrc=size=176x144:rate=10:duration=2",
+			}
+			args = append(args, tc.encoderArgs...)
+			output, err := exec.Command("ffmpeg", append(args, fromAbsPath)...).CombinedOutput()
+			if err != nil {
+				t.Skipf("ffmpeg can't generate fixture: %v. output: %s", err, output)
+			}
+
+			detected, err := fmtDetector.Detect(fromAbsPath)
+			if err != nil {
+				t.Fatalf("failed to detect format: %v", err)
+			}
+			if detected != tc.expected {
+				t.Fatalf("expected format %v, got %v", tc.expected, detected)
+			}
+
+			video, err := extractor.Probe(context.Background(), fromAbsPath)
+			if err != nil {
+				t.Fatalf("probe failed: %v", err)
+			}
+
+			intoAbsPath := filepath.Join(t.TempDir(), "frame.jpg")
+			err = extractor.Extract(
+				context.Background(),
+				fromAbsPath,
+				intoAbsPath,
+				video,
+				models.ToneMapNone,
+				models.DefaultFrameSamplePercents,
+			)
+			if err != nil {
+				t.Fatalf("extract failed: %v", err)
+			}
+		})
+	}
+}
+
+// Frames of HDR videos are compared against the same frame with its
+// signal truncated to 8 bits, the grey and washed out reference.
+// Tone mapped frames of testdata/hdr_fixtures.go video against reference
+// frames of its HLG patches. Tolerance covers JPEG compression and
+// rounding of zscale conversions.
+func TestExtractor_Integration_ToneMapping(t *testing.T) {
+	for _, binary := range []string{"ffmpeg", "ffprobe"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("%s not available, skipping integration test", binary)
+		}
+	}
+
+	fromAbsPath := testutils.TestFilePath("18 hdr_hlg.mov")
+	extractor := NewFrameExtractor(mkTestTelemetrySvc(t), format.NewFormatDetector())
+	video, err := extractor.Probe(context.Background(), fromAbsPath)
+	if err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if !video.HDR {
+		t.Fatalf("expected HDR video, got transfer %q", video.ColorTransfer)
+	}
+
+	operators := []models.ToneMapOperator{
+		models.ToneMapClip,
+		models.ToneMapReinhard,
+		models.ToneMapHable,
+		models.ToneMapMobius,
+	}
+	for _, operator := range operators {
+		t.Run(string(operator), func(t *testing.T) {
+			intoAbsPath := filepath.Join(t.TempDir(), "frame.jpg")
+			err := extractor.extractFrameAt(context.Background(), fromAbsPath, intoAbsPath, video, operator, 0)
+			if err != nil {
+				t.Fatalf("extract failed: %v", err)
+			}
+
+			reference := testutils.LoadImage(
+				t,
+				testutils.TestFilePath(fmt.Sprintf("18 hdr_hlg_bt709_%s.png", operator)),
+			)
+			testutils.AssertPatchesMatch(t, testutils.LoadImage(t, intoAbsPath), reference, 16, 4, 14)
+		})
+	}
+}
+
 func mkTestTelemetrySvc(t *testing.T) *telemetry.TelemetrySvc {
 	t.Helper()
 	t.Setenv("OTEL_ENABLED", "false")
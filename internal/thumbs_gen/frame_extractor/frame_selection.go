@@ -0,0 +1,182 @@
+package frameextractor
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// Clips shorter than this are represented by their first frame
+const minSampledDuration = time.Second
+
+// Offset of the frame used when video duration is unknown or no sample
+// percents are configured
+const fixedSeekOffset = time.Second
+
+// Frames are scored on a grid of up to this many pixels per side
+const scoreGridSize = 256
+
+// extractRepresentative extracts candidate frames of the video at
+// samplePercents of its duration and keeps the best scored one (see
+// scoreFrame) at 'intoAbsPath'.
+//
+// Short clips get their first frame. First frame is also the fallback
+// when no candidate could be extracted (e.g. seek past last frame).
+func (e *Extractor) extractRepresentative(
+	ctx context.Context,
+	fromAbsPath string,
+	intoAbsPath string,
+	video *models.VideoInfo,
+	toneMap models.ToneMapOperator,
+	samplePercents []int,
+) error {
+	offsets := candidateOffsets(videoDuration(video), samplePercents)
+	bestPath, bestScore := "", -1.0
+	for idx, offset := range offsets {
+		candidatePath := candidateFramePath(intoAbsPath, idx)
+		defer os.Remove(candidatePath)
+
+		err := e.extractFrameAt(ctx, fromAbsPath, candidatePath, video, toneMap, offset)
+		if err == nil {
+			var score float64
+			score, err = scoreFrameFile(candidatePath)
+			if err == nil && score > bestScore {
+				bestPath, bestScore = candidatePath, score
+			}
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			slog.Debug(
+				"Skipping candidate frame",
+				"filePath", fromAbsPath,
+				"offset", offset,
+				"error", err,
+			)
+		}
+	}
+
+	if bestPath == "" {
+		return e.extractFrameAt(ctx, fromAbsPath, intoAbsPath, video, toneMap, 0)
+	}
+
+	if err := os.Rename(bestPath, intoAbsPath); err != nil {
+		return fmt.Errorf("failed to keep selected frame: %w", err)
+	}
+	return nil
+}
+
+// candidateOffsets returns offsets of candidate frames. Empty result
+// means the first frame represents the video.
+func candidateOffsets(
+	videoDuration time.Duration,
+	samplePercents []int,
+) []time.Duration {
+	if videoDuration > 0 && videoDuration < minSampledDuration {
+		return nil
+	}
+	if videoDuration <= 0 || len(samplePercents) == 0 {
+		return []time.Duration{fixedSeekOffset}
+	}
+
+	offsets := make([]time.Duration, 0, len(samplePercents))
+	for _, percent := range samplePercents {
+		offsets = append(offsets, videoDuration*time.Duration(percent)/100)
+	}
+
+	return offsets
+}
+
+// candidateFramePath places candidates next to the selected frame, with
+// same extension so ffmpeg picks the same encoder
+// (e.g. 'clip.jpg' -> 'clip.candidate1.jpg').
+func candidateFramePath(intoAbsPath string, idx int) string {
+	ext := filepath.Ext(intoAbsPath)
+	return fmt.Sprintf("%s.candidate%d%s", strings.TrimSuffix(intoAbsPath, ext), idx, ext)
+}
+
+func scoreFrameFile(frameAbsPath string) (float64, error) {
+	file, err := os.Open(frameAbsPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open candidate frame: %w", err)
+	}
+	defer file.Close()
+
+	frame, _, err := image.Decode(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode candidate frame: %w", err)
+	}
+
+	return scoreFrame(frame), nil
+}
+
+// scoreFrame rates how well a frame represents a video, from 0 to 1.
+// Well exposed frames with high contrast and sharp details score higher;
+// black, washed out, flat (e.g. fade ins) or blurry frames score lower.
+func scoreFrame(frame image.Image) float64 {
+	luma := sampleLuma(frame)
+	rows, cols := len(luma), len(luma[0])
+
+	var sum, sumSquares float64
+	for _, row := range luma {
+		for _, value := range row {
+			sum += value
+			sumSquares += value * value
+		}
+	}
+	count := float64(rows * cols)
+	mean := sum / count
+	stdDev := math.Sqrt(max(sumSquares/count-mean*mean, 0))
+
+	// Mean absolute Laplacian responds to edges and fine details
+	var laplacian float64
+	for y := 1; y < rows-1; y++ {
+		for x := 1; x < cols-1; x++ {
+			laplacian += math.Abs(4*luma[y][x] -
+				luma[y-1][x] - luma[y+1][x] - luma[y][x-1] - luma[y][x+1])
+		}
+	}
+	if rows > 2 && cols > 2 {
+		laplacian /= float64((rows - 2) * (cols - 2))
+	}
+
+	brightness := 1 - math.Abs(mean-128)/128
+	contrast := min(stdDev/64, 1)
+	sharpness := min(laplacian/32, 1)
+
+	return 0.3*brightness + 0.35*contrast + 0.35*sharpness
+}
+
+// sampleLuma reads luma (0 to 255) of frame pixels on a grid of up to
+// scoreGridSize pixels per side
+func sampleLuma(frame image.Image) [][]float64 {
+	bounds := frame.Bounds()
+	step := max(
+		(bounds.Dx()+scoreGridSize-1)/scoreGridSize,
+		(bounds.Dy()+scoreGridSize-1)/scoreGridSize,
+		1,
+	)
+
+	var luma [][]float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		var row []float64
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, _ := frame.At(x, y).RGBA()
+			row = append(row, (0.299*float64(r)+0.587*float64(g)+0.114*float64(b))/257)
+		}
+		luma = append(luma, row)
+	}
+
+	return luma
+}
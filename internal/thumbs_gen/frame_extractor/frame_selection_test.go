@@ -0,0 +1,102 @@
+package frameextractor
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCandidateOffsets(t *testing.T) {
+	tests := []struct {
+		name          string
+		videoDuration time.Duration
+		percents      []int
+		want          []time.Duration
+	}{
+		{
+			name:          "percents of duration",
+			videoDuration: 20 * time.Second,
+			percents:      []int{0, 10, 50},
+			want:          []time.Duration{0, 2 * time.Second, 10 * time.Second},
+		},
+		{
+			name:          "short clip uses first frame",
+			videoDuration: 800 * time.Millisecond,
+			percents:      []int{10, 50},
+		},
+		{
+			name:     "unknown duration uses fixed seek",
+			percents: []int{10, 50},
+			want:     []time.Duration{fixedSeekOffset},
+		},
+		{
+			name:          "no percents uses fixed seek",
+			videoDuration: 20 * time.Second,
+			want:          []time.Duration{fixedSeekOffset},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := candidateOffsets(tc.videoDuration, tc.percents)
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("offsets = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCandidateFramePath(t *testing.T) {
+	got := candidateFramePath("/thumbs/clip.jpg", 2)
+	if got != "/thumbs/clip.candidate2.jpg" {
+		t.Fatalf("unexpected candidate path: %s", got)
+	}
+}
+
+func TestScoreFrame(t *testing.T) {
+	black := mkFrame(func(x, y int) uint8 { return 4 })
+	washedOut := mkFrame(func(x, y int) uint8 { return 250 })
+	gradient := mkFrame(func(x, y int) uint8 { return uint8(x) })
+	checkers := mkFrame(func(x, y int) uint8 {
+		if (x/4+y/4)%2 == 0 {
+			return 40
+		}
+		return 210
+	})
+
+	ordered := []struct {
+		name  string
+		frame image.Image
+	}{
+		{"black", black},
+		{"washed out", washedOut},
+		{"smooth gradient", gradient},
+		{"detailed", checkers},
+	}
+
+	for idx := 1; idx < len(ordered); idx++ {
+		prev, curr := ordered[idx-1], ordered[idx]
+		if scoreFrame(prev.frame) >= scoreFrame(curr.frame) {
+			t.Errorf(
+				"%s (%.3f) should score lower than %s (%.3f)",
+				prev.name,
+				scoreFrame(prev.frame),
+				curr.name,
+				scoreFrame(curr.frame),
+			)
+		}
+	}
+}
+
+func mkFrame(lumaAt func(x, y int) uint8) image.Image {
+	frame := image.NewGray(image.Rect(0, 0, 256, 144))
+	for y := range 144 {
+		for x := range 256 {
+			frame.SetGray(x, y, color.Gray{Y: lumaAt(x, y)})
+		}
+	}
+
+	return frame
+}
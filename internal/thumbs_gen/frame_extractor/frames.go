@@ -0,0 +1,105 @@
+package frameextractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/telemetry/metrics"
+)
+
+// ExtractFrames extracts count evenly spaced frames of the video at
+// 'fromAbsPath', scaled to given width, as JPEG files into 'intoAbsDir'.
+// Duration of video, as returned by Probe, must be known. Frames of HDR
+// videos are tone mapped with toneMap operator (see Extract).
+//
+// Returns paths of extracted frames in playback order along with the
+// playback time covered by each of them.
+func (e *Extractor) ExtractFrames(
+	ctx context.Context,
+	fromAbsPath string,
+	intoAbsDir string,
+	video *models.VideoInfo,
+	toneMap models.ToneMapOperator,
+	count int,
+	width int,
+) ([]string, time.Duration, error) {
+	startTime := time.Now()
+
+	duration := videoDuration(video)
+	if duration <= 0 {
+		return nil, 0, fmt.Errorf("unknown duration of video %s", fromAbsPath)
+	}
+
+	interval := duration / time.Duration(count)
+	args := makeFramesArgs(fromAbsPath, intoAbsDir, video, toneMap, count, width, interval)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, 0, fmt.Errorf("ffmpeg binary not found: %w", err)
+		}
+
+		return nil, 0, fmt.Errorf(
+			"ffmpeg frames extraction failed for %s: %w. output: %s",
+			fromAbsPath,
+			err,
+			strings.TrimSpace(string(output)),
+		)
+	}
+
+	framePaths, err := filepath.Glob(filepath.Join(intoAbsDir, "frame_*.jpg"))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list extracted frames: %w", err)
+	}
+	if len(framePaths) == 0 {
+		return nil, 0, fmt.Errorf("no frames extracted from %s", fromAbsPath)
+	}
+
+	// Frame numbers are zero padded, names sort in playback order
+	sort.Strings(framePaths)
+
+	e.telemetry.Metrics().Duration(
+		metrics.VideoFrameExtractDuration,
+		time.Since(startTime))
+	e.telemetry.Metrics().Increment(metrics.VideoFrameExtracted)
+	return framePaths, interval, nil
+}
+
+// makeFramesArgs prepares 'ffmpeg' arguments to sample one frame every
+// interval, starting at the beginning of the video
+func makeFramesArgs(
+	fromAbsPath string,
+	intoAbsDir string,
+	video *models.VideoInfo,
+	toneMap models.ToneMapOperator,
+	count int,
+	width int,
+	interval time.Duration,
+) []string {
+	fps := strconv.FormatFloat(1/interval.Seconds(), 'f', 6, 64)
+
+	args := []string{"-y", "-hide_banner", "-loglevel", "error"}
+	args = append(args, inputArgs(video)...)
+	return append(args,
+		"-i", fromAbsPath,
+		"-vf", videoFilters(
+			video,
+			toneMap,
+			"fps="+fps,
+			fmt.Sprintf("scale=%d:-2", width),
+			"format=yuv420p",
+		),
+		"-frames:v", strconv.Itoa(count),
+		"-q:v", "3",
+		filepath.Join(intoAbsDir, "frame_%05d.jpg"),
+	)
+}
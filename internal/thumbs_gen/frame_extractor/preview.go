@@ -0,0 +1,159 @@
+package frameextractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/telemetry/metrics"
+)
+
+// previewSegment is a part of the video included in a preview clip
+type previewSegment struct {
+	Start  time.Duration
+	Length time.Duration
+}
+
+// ExtractPreview produces a looping preview clip of the video at
+// 'fromAbsPath' into 'intoAbsPath', made of segments sampled evenly
+// across the video (as returned by Probe). Audio is dropped and frames
+// of HDR videos are tone mapped with toneMap operator (see Extract).
+//
+// Returns playback time of produced clip.
+func (e *Extractor) ExtractPreview(
+	ctx context.Context,
+	fromAbsPath string,
+	intoAbsPath string,
+	video *models.VideoInfo,
+	toneMap models.ToneMapOperator,
+	opts models.VideoPreviewOptions,
+) (time.Duration, error) {
+	segments := previewSegments(videoDuration(video), opts)
+	args := makePreviewArgs(fromAbsPath, intoAbsPath, video, toneMap, segments, opts)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return 0, fmt.Errorf("ffmpeg binary not found: %w", err)
+		}
+
+		return 0, fmt.Errorf(
+			"ffmpeg preview extraction failed for %s: %w. output: %s",
+			fromAbsPath,
+			err,
+			strings.TrimSpace(string(output)),
+		)
+	}
+
+	e.telemetry.Metrics().Increment(metrics.VideoPreviewExtracted)
+
+	var clipDuration time.Duration
+	for _, segment := range segments {
+		clipDuration += segment.Length
+	}
+	return clipDuration, nil
+}
+
+// previewSegments splits preview duration into segments centered at
+// evenly spaced points of the video. Videos shorter than the preview (or
+// of unknown duration) are taken from their start in a single segment.
+func previewSegments(
+	videoDuration time.Duration,
+	opts models.VideoPreviewOptions,
+) []previewSegment {
+	if videoDuration <= 0 {
+		return []previewSegment{{Length: opts.Duration}}
+	}
+	if videoDuration <= opts.Duration || opts.Segments <= 1 {
+		return []previewSegment{{Length: min(videoDuration, opts.Duration)}}
+	}
+
+	segmentLength := opts.Duration / time.Duration(opts.Segments)
+	segments := make([]previewSegment, opts.Segments)
+	for idx := range segments {
+		center := videoDuration * time.Duration(2*idx+1) / time.Duration(2*opts.Segments)
+		start := min(max(center-segmentLength/2, 0), videoDuration-segmentLength)
+
+		segments[idx] = previewSegment{Start: start, Length: segmentLength}
+	}
+
+	return segments
+}
+
+// makePreviewArgs prepares 'ffmpeg' arguments to concatenate segments
+// into a clip. Each segment is an input seeked on its own, so only
+// sampled parts of the video are decoded.
+func makePreviewArgs(
+	fromAbsPath string,
+	intoAbsPath string,
+	video *models.VideoInfo,
+	toneMap models.ToneMapOperator,
+	segments []previewSegment,
+	opts models.VideoPreviewOptions,
+) []string {
+	args := []string{"-y", "-hide_banner", "-loglevel", "error"}
+	for _, segment := range segments {
+		args = append(args,
+			"-ss", formatSeconds(segment.Start),
+			"-t", formatSeconds(segment.Length),
+		)
+		args = append(args, inputArgs(video)...)
+		args = append(args, "-i", fromAbsPath)
+	}
+
+	// Scale each segment to preview width (keeping narrower videos as
+	// they are) with even height, required by H.264
+	var filters, labels strings.Builder
+	for idx := range segments {
+		fmt.Fprintf(
+			&filters,
+			"[%d:v]%s[v%d];",
+			idx,
+			videoFilters(
+				video,
+				toneMap,
+				fmt.Sprintf("fps=%d", opts.FPS),
+				fmt.Sprintf("scale='min(%d,iw)':-2", opts.Width),
+				"setsar=1",
+			),
+			idx,
+		)
+		fmt.Fprintf(&labels, "[v%d]", idx)
+	}
+	filterGraph := fmt.Sprintf(
+		"%s%sconcat=n=%d:v=1:a=0[out]",
+		filters.String(),
+		labels.String(),
+		len(segments),
+	)
+
+	args = append(args, "-filter_complex", filterGraph, "-map", "[out]", "-an")
+	switch opts.Format {
+	case models.VideoPreviewMp4:
+		args = append(args,
+			"-c:v", "libx264",
+			"-pix_fmt", "yuv420p",
+			"-preset", "veryfast",
+			"-crf", "28",
+			"-movflags", "+faststart",
+		)
+	default:
+		args = append(args,
+			"-c:v", "libwebp",
+			"-quality", "60",
+			"-loop", "0",
+		)
+	}
+
+	return append(args, intoAbsPath)
+}
+
+func formatSeconds(duration time.Duration) string {
+	return strconv.FormatFloat(duration.Seconds(), 'f', 3, 64)
+}
@@ -0,0 +1,150 @@
+package frameextractor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+func TestPreviewSegments(t *testing.T) {
+	opts := models.VideoPreviewOptions{Duration: 3 * time.Second, Segments: 3}
+
+	tests := []struct {
+		name          string
+		videoDuration time.Duration
+		want          []previewSegment
+	}{
+		{
+			name:          "evenly spaced segments",
+			videoDuration: 60 * time.Second,
+			want: []previewSegment{
+				{Start: 9500 * time.Millisecond, Length: time.Second},
+				{Start: 29500 * time.Millisecond, Length: time.Second},
+				{Start: 49500 * time.Millisecond, Length: time.Second},
+			},
+		},
+		{
+			name:          "short video taken whole",
+			videoDuration: 2 * time.Second,
+			want:          []previewSegment{{Length: 2 * time.Second}},
+		},
+		{
+			name: "unknown duration taken from start",
+			want: []previewSegment{{Length: 3 * time.Second}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := previewSegments(tc.videoDuration, opts)
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("segments = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMakePreviewArgs(t *testing.T) {
+	segments := []previewSegment{
+		{Start: 0, Length: time.Second},
+		{Start: 5 * time.Second, Length: time.Second},
+	}
+	opts := models.VideoPreviewOptions{
+		Format: models.VideoPreviewMp4,
+		FPS:    12,
+		Width:  320,
+	}
+
+	args := makePreviewArgs("/in.mov", "/out.mp4", nil, models.ToneMapNone, segments, opts)
+	joined := strings.Join(args, " ")
+
+	for _, want := range []string{
+		"-ss 0.000 -t 1.000 -i /in.mov -ss 5.000 -t 1.000 -i /in.mov",
+		"[0:v]fps=12,scale='min(320,iw)':-2,setsar=1[v0];",
+		"[v0][v1]concat=n=2:v=1:a=0[out]",
+		"-an -c:v libx264",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args %q missing %q", joined, want)
+		}
+	}
+
+	if args[len(args)-1] != "/out.mp4" {
+		t.Errorf("output is not last argument: %q", args)
+	}
+}
+
+func TestExtractor_Integration_Preview(t *testing.T) {
+	for _, binary := range []string{"ffmpeg", "ffprobe"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("%s not available, skipping integration test", binary)
+		}
+	}
+
+	extractor := NewFrameExtractor(mkTestTelemetrySvc(t), format.NewFormatDetector())
+	for _, previewFormat := range []models.VideoPreviewFormat{
+		models.VideoPreviewWebp,
+		models.VideoPreviewMp4,
+	} {
+		t.Run(string(previewFormat), func(t *testing.T) {
+			fromAbsPath := testutils.TestFilePath("11 whatsapp.mp4")
+			video, err := extractor.Probe(context.Background(), fromAbsPath)
+			if err != nil {
+				t.Fatalf("probe failed: %v", err)
+			}
+
+			intoAbsPath := filepath.Join(t.TempDir(), "preview"+previewFormat.Extension())
+			clipDuration, err := extractor.ExtractPreview(
+				context.Background(),
+				fromAbsPath,
+				intoAbsPath,
+				video,
+				models.ToneMapNone,
+				models.VideoPreviewOptions{
+					Format:   previewFormat,
+					Duration: 2 * time.Second,
+					FPS:      8,
+					Width:    160,
+					Segments: 2,
+				},
+			)
+			if err != nil {
+				t.Fatalf("extract preview failed: %v", err)
+			}
+
+			if clipDuration <= 0 || clipDuration > 2*time.Second {
+				t.Errorf("unexpected clip duration: %v", clipDuration)
+			}
+
+			fileInfo, err := os.Stat(intoAbsPath)
+			if err != nil || fileInfo.Size() <= 0 {
+				t.Fatalf("expected non-empty preview file: %v", err)
+			}
+		})
+	}
+}
+
+func TestMakeFramesArgs(t *testing.T) {
+	args := makeFramesArgs("/in.mp4", "/frames", nil, models.ToneMapNone, 10, 160, 2*time.Second)
+	joined := strings.Join(args, " ")
+
+	for _, want := range []string{
+		"-i /in.mp4",
+		"fps=0.500000,scale=160:-2",
+		"-frames:v 10",
+		"/frames/frame_%05d.jpg",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args %q missing %q", joined, want)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package frameextractor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// Transfer characteristics of HDR videos: PQ (HDR10, Dolby Vision) and
+// HLG
+var hdrTransfers = map[string]bool{
+	"smpte2084":    true,
+	"arib-std-b67": true,
+}
+
+// Subset of 'ffprobe -print_format json' output read here
+type ffprobeOutput struct {
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+	Streams []ffprobeStream `json:"streams"`
+}
+
+type ffprobeStream struct {
+	CodecType      string            `json:"codec_type"`
+	CodecName      string            `json:"codec_name"`
+	Width          int               `json:"width"`
+	Height         int               `json:"height"`
+	AvgFrameRate   string            `json:"avg_frame_rate"`
+	RFrameRate     string            `json:"r_frame_rate"`
+	Duration       string            `json:"duration"`
+	ColorTransfer  string            `json:"color_transfer"`
+	ColorPrimaries string            `json:"color_primaries"`
+	Tags           map[string]string `json:"tags"`
+	SideDataList   []ffprobeSideData `json:"side_data_list"`
+	Disposition    struct {
+		AttachedPic int `json:"attached_pic"`
+	} `json:"disposition"`
+}
+
+type ffprobeSideData struct {
+	SideDataType string  `json:"side_data_type"`
+	Rotation     float64 `json:"rotation"`
+}
+
+// Probe reads duration, codec, dimensions, frame rate, rotation, color
+// characteristics and audio presence of the video at 'videoAbsPath'.
+func (e *Extractor) Probe(
+	ctx context.Context,
+	videoAbsPath string,
+) (*models.VideoInfo, error) {
+	cmd := exec.CommandContext(
+		ctx,
+		"ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		videoAbsPath,
+	)
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("ffprobe binary not found: %w", err)
+		}
+
+		return nil, fmt.Errorf(
+			"ffprobe failed for %s: %w. output: %s",
+			videoAbsPath,
+			err,
+			strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	videoInfo, err := parseProbeOutput(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output for %s: %w", videoAbsPath, err)
+	}
+	return videoInfo, nil
+}
+
+func parseProbeOutput(output []byte) (*models.VideoInfo, error) {
+	var probe ffprobeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, err
+	}
+
+	videoInfo := &models.VideoInfo{}
+	var video *ffprobeStream
+	for idx, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "audio":
+			videoInfo.HasAudio = true
+		case "video":
+
+			// Cover art is stored as a single frame video stream
+			if video == nil && stream.Disposition.AttachedPic == 0 {
+				video = &probe.Streams[idx]
+			}
+		}
+	}
+	if video == nil {
+		return nil, errors.New("no video stream found")
+	}
+
+	duration := parseSeconds(probe.Format.Duration)
+	if duration <= 0 {
+		duration = parseSeconds(video.Duration)
+	}
+
+	videoInfo.DurationMs = duration.Milliseconds()
+	videoInfo.Codec = video.CodecName
+	videoInfo.Rotation = streamRotation(video)
+	videoInfo.Width, videoInfo.Height = video.Width, video.Height
+	if videoInfo.Rotation%180 != 0 {
+		videoInfo.Width, videoInfo.Height = video.Height, video.Width
+	}
+
+	videoInfo.FrameRate = parseFrameRate(video.AvgFrameRate)
+	if videoInfo.FrameRate <= 0 {
+		videoInfo.FrameRate = parseFrameRate(video.RFrameRate)
+	}
+
+	videoInfo.ColorTransfer = video.ColorTransfer
+	videoInfo.ColorPrimaries = video.ColorPrimaries
+	videoInfo.HDR = hdrTransfers[video.ColorTransfer]
+	return videoInfo, nil
+}
+
+// streamRotation returns clockwise rotation to apply to decoded frames.
+// Display matrix rotation is counterclockwise, legacy 'rotate' tag is
+// already clockwise.
+func streamRotation(stream *ffprobeStream) int {
+	degrees := 0.0
+	if rotate, ok := stream.Tags["rotate"]; ok {
+		degrees, _ = strconv.ParseFloat(rotate, 64)
+	}
+
+	for _, sideData := range stream.SideDataList {
+		if sideData.SideDataType == "Display Matrix" {
+			degrees = -sideData.Rotation
+		}
+	}
+
+	// Snap to quarter turns, other angles aren't applied
+	quarterTurns := int(math.Round(degrees / 90))
+	return ((quarterTurns%4 + 4) % 4) * 90
+}
+
+// parseSeconds parses seconds as printed by ffprobe (e.g. '12.345000')
+func parseSeconds(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// parseFrameRate parses frame rates expressed as ratios (e.g.
+// '30000/1001'), rounded to 3 decimals
+func parseFrameRate(value string) float64 {
+	numerator, denominator, found := strings.Cut(value, "/")
+	num, err := strconv.ParseFloat(numerator, 64)
+	if err != nil {
+		return 0
+	}
+
+	den := 1.0
+	if found {
+		den, err = strconv.ParseFloat(denominator, 64)
+		if err != nil || den == 0 {
+			return 0
+		}
+	}
+
+	return math.Round(num/den*1000) / 1000
+}
+
+// rotationFilter returns the ffmpeg filter rotating frames clockwise by
+// given degrees, empty when no rotation is needed
+func rotationFilter(rotation int) string {
+	switch rotation {
+	case 90:
+		return "transpose=clock"
+	case 180:
+		return "hflip,vflip"
+	case 270:
+		return "transpose=cclock"
+	default:
+		return ""
+	}
+}
+
+// inputArgs returns ffmpeg arguments placed before an input. Frames of
+// probed videos are rotated explicitly through videoFilters, so ffmpeg
+// automatic rotation is disabled for them.
+func inputArgs(video *models.VideoInfo) []string {
+	if video == nil {
+		return nil
+	}
+
+	return []string{"-noautorotate"}
+}
+
+// videoFilters prepends rotation of probed videos, and tone mapping of
+// probed HDR ones, to given filters
+func videoFilters(
+	video *models.VideoInfo,
+	toneMap models.ToneMapOperator,
+	filters ...string,
+) string {
+	if video == nil {
+		return strings.Join(filters, ",")
+	}
+
+	var prepended []string
+	if rotation := rotationFilter(video.Rotation); rotation != "" {
+		prepended = append(prepended, rotation)
+	}
+	if video.HDR && toneMap.Enabled() {
+		prepended = append(prepended, toneMapFilters(toneMap)...)
+	}
+
+	return strings.Join(append(prepended, filters...), ",")
+}
+
+// toneMapFilters convert PQ or HLG frames into SDR BT.709 with given
+// operator. Linear light is normalized to SDR white at 100 nits and
+// converted to BT.709 primaries before highlights are compressed.
+func toneMapFilters(operator models.ToneMapOperator) []string {
+	return []string{
+		"zscale=t=linear:npl=100",
+		"format=gbrpf32le",
+		"zscale=p=bt709",
+		fmt.Sprintf("tonemap=tonemap=%s:desat=0", operator),
+		"zscale=t=bt709:m=bt709:r=tv",
+		"format=yuv420p",
+	}
+}
+
+// videoDuration returns duration of probed videos, zero when unknown
+func videoDuration(video *models.VideoInfo) time.Duration {
+	if video == nil {
+		return 0
+	}
+
+	return time.Duration(video.DurationMs) * time.Millisecond
+}
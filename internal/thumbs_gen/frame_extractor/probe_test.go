@@ -0,0 +1,151 @@
+package frameextractor
+
+import (
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// Trimmed 'ffprobe -show_format -show_streams' output of a portrait HDR
+// iPhone video
+const iphoneProbeOutput = `{
+	"streams": [
+		{
+			"codec_name": "hevc",
+			"codec_type": "video",
+			"width": 1920,
+			"height": 1080,
+			"r_frame_rate": "60/1",
+			"avg_frame_rate": "30000/1001",
+			"color_transfer": "arib-std-b67",
+			"color_primaries": "bt2020",
+			"side_data_list": [
+				{"side_data_type": "DOVI configuration record"},
+				{"side_data_type": "Display Matrix", "rotation": -90}
+			]
+		},
+		{"codec_name": "aac", "codec_type": "audio"},
+		{
+			"codec_name": "mjpeg",
+			"codec_type": "video",
+			"width": 320,
+			"height": 320,
+			"disposition": {"attached_pic": 1}
+		}
+	],
+	"format": {"duration": "12.345000"}
+}`
+
+func TestParseProbeOutput(t *testing.T) {
+	got, err := parseProbeOutput([]byte(iphoneProbeOutput))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	want := models.VideoInfo{
+		DurationMs:     12345,
+		Codec:          "hevc",
+		Width:          1080,
+		Height:         1920,
+		FrameRate:      29.97,
+		Rotation:       90,
+		ColorTransfer:  "arib-std-b67",
+		ColorPrimaries: "bt2020",
+		HDR:            true,
+		HasAudio:       true,
+	}
+	if *got != want {
+		t.Fatalf("video info = %+v, want %+v", *got, want)
+	}
+}
+
+func TestParseProbeOutput_NoVideoStream(t *testing.T) {
+	output := `{"streams": [{"codec_type": "audio"}], "format": {"duration": "3.0"}}`
+	if _, err := parseProbeOutput([]byte(output)); err == nil {
+		t.Fatal("expected error for file without video stream")
+	}
+}
+
+func TestStreamRotation(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream ffprobeStream
+		want   int
+	}{
+		{name: "no rotation", want: 0},
+		{
+			name:   "legacy rotate tag",
+			stream: ffprobeStream{Tags: map[string]string{"rotate": "270"}},
+			want:   270,
+		},
+		{
+			name:   "display matrix counterclockwise",
+			stream: ffprobeStream{SideDataList: []ffprobeSideData{{SideDataType: "Display Matrix", Rotation: 90}}},
+			want:   270,
+		},
+		{
+			name:   "display matrix upside down",
+			stream: ffprobeStream{SideDataList: []ffprobeSideData{{SideDataType: "Display Matrix", Rotation: -180}}},
+			want:   180,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := streamRotation(&tc.stream); got != tc.want {
+				t.Fatalf("rotation = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVideoFilters(t *testing.T) {
+	rotated := &models.VideoInfo{Rotation: 90}
+
+	tests := []struct {
+		name    string
+		video   *models.VideoInfo
+		toneMap models.ToneMapOperator
+		want    string
+	}{
+		{name: "unknown video", toneMap: models.ToneMapHable, want: "fps=2,format=yuv420p"},
+		{name: "upright video", video: &models.VideoInfo{}, want: "fps=2,format=yuv420p"},
+		{name: "rotated video", video: rotated, want: "transpose=clock,fps=2,format=yuv420p"},
+		{
+			name:    "sdr video",
+			video:   &models.VideoInfo{},
+			toneMap: models.ToneMapHable,
+			want:    "fps=2,format=yuv420p",
+		},
+		{
+			name:    "hdr video without tone mapping",
+			video:   &models.VideoInfo{HDR: true},
+			toneMap: models.ToneMapNone,
+			want:    "fps=2,format=yuv420p",
+		},
+		{
+			name:    "tone mapped video",
+			video:   &models.VideoInfo{Rotation: 180, HDR: true},
+			toneMap: models.ToneMapMobius,
+			want: "hflip,vflip," +
+				"zscale=t=linear:npl=100,format=gbrpf32le,zscale=p=bt709," +
+				"tonemap=tonemap=mobius:desat=0,zscale=t=bt709:m=bt709:r=tv,format=yuv420p," +
+				"fps=2,format=yuv420p",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := videoFilters(tc.video, tc.toneMap, "fps=2", "format=yuv420p"); got != tc.want {
+				t.Fatalf("filters = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	if args := inputArgs(rotated); len(args) != 1 || args[0] != "-noautorotate" {
+		t.Errorf("probed videos should disable autorotation: %v", args)
+	}
+	if args := inputArgs(nil); len(args) != 0 {
+		t.Errorf("unknown videos should keep autorotation: %v", args)
+	}
+}
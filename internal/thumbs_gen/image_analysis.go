@@ -0,0 +1,58 @@
+package thumbsgen
+
+import (
+	"image"
+	"sync"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// Width of the downscaled images used to analyze contents of originals.
+// Small enough to keep analysis cheap, big enough to preserve subjects.
+const analysisMaxWidth = 256
+
+// analysisImage decodes a downscaled version of the original into
+// memory, so its pixels can be analyzed with plain Go code.
+func (g *ImageThumbsGenerator) analysisImage(
+	origFileBytes []byte,
+	origDimensions *ImgDimensions,
+) (image.Image, error) {
+	imgOps, releaseImgOps := g.imageOpsFor(origDimensions)
+	defer releaseImgOps()
+
+	decoder, err := g.decode(origFileBytes)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+
+	geometry := computeGeometry(
+		origDimensions,
+		min(analysisMaxWidth, origDimensions.Width),
+		models.ResizeSpec{Mode: models.ResizeFitWidth},
+	)
+	analysisPng, err := g.transform(
+		imgOps,
+		decoder,
+		geometry.Width,
+		geometry.Height,
+		intermediaryPngEncoding,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodePNG(analysisPng)
+}
+
+// analysisImageLoader returns a function decoding the analysis image of
+// original on first call and returning the same image afterwards, so
+// every analysis of an original shares a single decode.
+func (g *ImageThumbsGenerator) analysisImageLoader(
+	origFileBytes []byte,
+	origDimensions *ImgDimensions,
+) func() (image.Image, error) {
+	return sync.OnceValues(func() (image.Image, error) {
+		return g.analysisImage(origFileBytes, origDimensions)
+	})
+}
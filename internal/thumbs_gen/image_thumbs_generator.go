@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -13,6 +16,8 @@ import (
 
 	"github.com/discord/lilliput"
 	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/metadata"
+	"github.com/giobyte8/thumbnailer/internal/models"
 	"github.com/giobyte8/thumbnailer/internal/telemetry"
 	"github.com/giobyte8/thumbnailer/internal/telemetry/metrics"
 )
@@ -21,6 +26,7 @@ type ImageThumbsGenerator struct {
 	telemetry       *telemetry.TelemetrySvc
 	formatConverter *format.FormatConverter
 	formatDetector  *format.FormatDetector
+	metaExtractor   *metadata.Extractor
 	imgOps4k        *lilliput.ImageOps
 	resizeBuffer    []byte
 }
@@ -36,6 +42,7 @@ func NewImageThumbsGenerator(
 		telemetry:       telemetry,
 		formatConverter: formatConverter,
 		formatDetector:  formatDetector,
+		metaExtractor:   metadata.NewExtractor(),
 
 		// --------------------------------------------------------------
 		// ImageOps instance and resizeBuffer are shared across requests.
@@ -63,13 +70,13 @@ func NewImageThumbsGenerator(
 func (g *ImageThumbsGenerator) Generate(
 	ctx context.Context,
 	meta ThumbnailMeta,
-) error {
+) (*models.ThumbGenResult, error) {
 	origFileAbsPath := mkOriginalFileAbsPath(meta)
 
 	// Detect original file format
 	origFileFormat, err := g.formatDetector.Detect(origFileAbsPath)
 	if err != nil {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"failed to detect file format for %s: %w",
 			meta.OrigFileRelPath,
 			err)
@@ -78,7 +85,7 @@ func (g *ImageThumbsGenerator) Generate(
 	// Validate original file format is supported
 	err = g.isOriginalFileFormatSupported(origFileFormat)
 	if err != nil {
-		return fmt.Errorf("cannot generate thumbnails: %w", err)
+		return nil, fmt.Errorf("cannot generate thumbnails: %w", err)
 	}
 
 	return g.GenerateWithoutFormatsCheck(ctx, meta, origFileFormat)
@@ -89,15 +96,39 @@ func (g *ImageThumbsGenerator) GenerateWithoutFormatsCheck(
 	ctx context.Context,
 	meta ThumbnailMeta,
 	origFileFormat format.Format,
-) error {
+) (*models.ThumbGenResult, error) {
 
-	// If original file is HEIF, convert it to JPEG first and use the
-	// converted file as input for thumbnail generation.
-	if format.HEIF == origFileFormat {
-		intermediaryFileAbsPath, err := g.mkIntermediaryFile(ctx, meta)
+	// Reject oversized originals before any conversion or decoding
+	err := checkFileSize(g.telemetry, meta, mkOriginalFileAbsPath(meta))
+	if err != nil {
+		return nil, err
+	}
+
+	// Read from original container, as converted originals are replaced
+	// below
+	origInfo := g.readOriginalInfo(meta, origFileFormat)
+
+	// If lilliput can't decode original file (e.g. HEIF), convert it to
+	// JPEG first and use the converted file as input for thumbnail
+	// generation.
+	if slices.Contains(format.ConvertedFormats, origFileFormat) {
+
+		// Converter fully decodes the original, reject decompression
+		// bombs from dimensions declared in its container first
+		if err := g.checkHeaderDimensions(meta, origFileFormat); err != nil {
+			return nil, err
+		}
+
+		intermediaryFileAbsPath, err := g.mkIntermediaryFile(
+			ctx,
+			meta,
+			origFileFormat,
+			origInfo.hdrColor,
+		)
 		if err != nil {
-			return fmt.Errorf(
-				"failed to create intermediary file for HEIF format: %w",
+			return nil, fmt.Errorf(
+				"failed to create intermediary file for %s format: %w",
+				origFileFormat,
 				err,
 			)
 		}
@@ -113,66 +144,244 @@ func (g *ImageThumbsGenerator) GenerateWithoutFormatsCheck(
 		meta.OrigFileRelPath = filepath.Base(intermediaryFileAbsPath)
 	}
 
-	startTime := time.Now()
 	origFileAbsPath := mkOriginalFileAbsPath(meta)
 
 	// Load original file into memory
 	origFileBytes, err := os.ReadFile(origFileAbsPath)
 	if err != nil {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"failed to read original file: %w",
 			err)
 	}
 
+	return g.generateFromBytes(ctx, meta, origFileBytes, origInfo)
+}
+
+// GenerateFromBytes produces thumbnails of an original already loaded in
+// memory (e.g. an archive entry) instead of the file meta points to,
+// which still names thumbnails and their directory. No metadata of the
+// original is kept in thumbnails.
+//
+// Formats converted into an intermediary file first
+// (format.ConvertedFormats) are not supported.
+func (g *ImageThumbsGenerator) GenerateFromBytes(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	origFileBytes []byte,
+	origFileFormat format.Format,
+) (*models.ThumbGenResult, error) {
+	err := g.isOriginalFileFormatSupported(origFileFormat)
+	if err == nil && slices.Contains(format.ConvertedFormats, origFileFormat) {
+		err = fmt.Errorf("%v originals must be read from a file", origFileFormat)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate thumbnails: %w", err)
+	}
+
+	err = checkInputSize(g.telemetry, meta, int64(len(origFileBytes)))
+	if err != nil {
+		return nil, err
+	}
+
+	return g.generateFromBytes(ctx, meta, origFileBytes, originalInfo{})
+}
+
+// generateFromBytes produces every thumbnail, placeholder and analysis
+// of the original loaded in origFileBytes
+func (g *ImageThumbsGenerator) generateFromBytes(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	origFileBytes []byte,
+	origInfo originalInfo,
+) (*models.ThumbGenResult, error) {
+	startTime := time.Now()
+
 	// Get original image dimensions
 	origDimensions, err := g.dimensions(origFileBytes)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Generate thumbnails for each target width
-	for _, targetWidth := range meta.ThumbWidths {
-		select {
-		case <-ctx.Done():
-			slog.Warn(
-				"Context cancelled during thumbs generation",
-			)
-			return ctx.Err()
-		default:
-		}
+	// Header is read without decoding pixels, reject decompression bombs
+	// before any full decode
+	if err := g.checkDimensions(meta, origDimensions); err != nil {
+		return nil, err
+	}
 
-		if err := g.generateThumb(
-			meta,
+	output, err := g.prepareOutput(meta, origFileBytes, origInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ThumbGenResult{
+		OrigWidth:  origDimensions.Width,
+		OrigHeight: origDimensions.Height,
+	}
+
+	// Downscaled original shared by every content analysis
+	analysisImage := g.analysisImageLoader(origFileBytes, origDimensions)
+
+	for _, presetMeta := range expandPresets(meta) {
+		thumbs, err := g.generatePresetThumbs(
+			ctx,
+			presetMeta,
 			origFileBytes,
 			origDimensions,
-			targetWidth,
-		); err != nil {
-			return err
+			analysisImage,
+			output,
+		)
+		if err != nil {
+			return nil, err
 		}
+
+		result.Thumbs = append(result.Thumbs, thumbs...)
+	}
+
+	result.Placeholders, err = g.computePlaceholders(meta, analysisImage)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Colors, err = g.computeColors(meta, analysisImage)
+	if err != nil {
+		return nil, err
+	}
+
+	result.DHash, err = g.computeDHash(meta, analysisImage)
+	if err != nil {
+		return nil, err
 	}
 
 	g.telemetry.Metrics().Duration(
 		metrics.LilptThumbGenDuration,
 		time.Since(startTime),
 	)
-	return nil
+	return result, nil
+}
+
+// generatePresetThumbs generates the thumbnails described by a meta
+// without presets (see expandPresets), in every requested format.
+func (g *ImageThumbsGenerator) generatePresetThumbs(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	origFileBytes []byte,
+	origDimensions *ImgDimensions,
+	analysisImage func() (image.Image, error),
+	output thumbOutput,
+) ([]models.Thumb, error) {
+
+	// Crop window is chosen once per original and shared by every width
+	var cropRect image.Rectangle
+	switch {
+	case meta.Resize.Mode == models.ResizeCover && output.animation != nil:
+
+		// Animated thumbnails are center cropped by lilliput, posters
+		// match them
+		cropRect = focalCropRect(
+			origDimensions.Width,
+			origDimensions.Height,
+			meta.Resize.BoxWidth,
+			meta.Resize.BoxHeight,
+			models.FocalPoint{X: 0.5, Y: 0.5},
+		)
+	case meta.Resize.Mode == models.ResizeCover:
+		var err error
+		cropRect, err = g.chooseCropRect(meta, analysisImage, origDimensions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Generate thumbnails for each target width allowed by upscale policy
+	targetWidths := resolveTargetWidths(
+		thumbWidths(meta),
+		origDimensions.Width,
+		meta.UpscalePolicy,
+	)
+
+	var thumbs []models.Thumb
+	for _, targetWidth := range targetWidths {
+		for _, extension := range thumbFormats(meta) {
+			select {
+			case <-ctx.Done():
+				slog.Warn(
+					"Context cancelled during thumbs generation",
+				)
+				return nil, ctx.Err()
+			default:
+			}
+
+			generate := func(variant thumbVariant) (*models.Thumb, error) {
+				return g.generateThumb(
+					meta,
+					origFileBytes,
+					origDimensions,
+					targetWidth,
+					cropRect,
+					extension,
+					output,
+					variant,
+				)
+			}
+
+			variant := variantStatic
+			if canAnimate(meta, extension, output) {
+				variant = variantAnimated
+			}
+
+			thumb, err := generate(variant)
+			if errors.Is(err, errAnimationTooLarge) {
+				slog.Info(
+					"Animated thumbnail exceeds size limit, using static one",
+					"filePath", meta.OrigFileRelPath,
+					"width", targetWidth,
+				)
+				thumb, err = generate(variantStatic)
+			}
+			if err != nil {
+				return nil, err
+			}
+			thumbs = append(thumbs, *thumb)
+
+			if thumb.Animated {
+				poster, err := generate(variantPoster)
+				if err != nil {
+					return nil, err
+				}
+				thumbs = append(thumbs, *poster)
+			}
+		}
+	}
+
+	return thumbs, nil
 }
 
-// Lilliput doesn't support HEIC format, so we convert it to JPEG first and
-// then create thumbnails from the converted file.
+// Lilliput doesn't support HEIC, JPEG XL and TIFF formats, so we convert
+// them to JPEG first and then create thumbnails from the converted file.
+//
+// HDR originals (hdrColor not nil) are tone mapped into SDR on the way, as
+// 'heif-convert' would just truncate their PQ or HLG signal.
 func (g *ImageThumbsGenerator) mkIntermediaryFile(
 	ctx context.Context,
 	meta ThumbnailMeta,
+	origFileFormat format.Format,
+	hdrColor *metadata.NclxColor,
 ) (string, error) {
 	origFileAbsPath := mkOriginalFileAbsPath(meta)
 	intermediaryFileAbsPath := mkIntermediaryThumbFileAbsPath(meta, ".jpg")
 
-	err := g.formatConverter.ConvertWithoutFormatsCheck(
-		ctx,
-		origFileAbsPath,
-		intermediaryFileAbsPath,
-		format.JPEG,
-	)
+	var err error
+	if hdrColor != nil {
+		err = g.mkToneMappedFile(ctx, meta, hdrColor, intermediaryFileAbsPath)
+	} else {
+		err = g.formatConverter.ConvertWithoutFormatsCheck(
+			ctx,
+			origFileAbsPath,
+			origFileFormat,
+			intermediaryFileAbsPath,
+			format.JPEG,
+		)
+	}
 
 	if err != nil {
 		// Clean up possible corrupted intermediary file
@@ -183,45 +392,241 @@ func (g *ImageThumbsGenerator) mkIntermediaryFile(
 	return intermediaryFileAbsPath, nil
 }
 
+// mkToneMappedFile converts the HDR HEIF original into a 16 bits PNG,
+// which keeps its signal intact, and tone maps it into the SDR JPEG at
+// 'intoAbsPath'.
+func (g *ImageThumbsGenerator) mkToneMappedFile(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	hdrColor *metadata.NclxColor,
+	intoAbsPath string,
+) error {
+	mapper, err := newToneMapper(hdrColor, meta.ToneMap)
+	if err != nil {
+		return err
+	}
+
+	pngAbsPath := mkIntermediaryThumbFileAbsPath(meta, ".png")
+	defer os.Remove(pngAbsPath)
+
+	err = g.formatConverter.ConvertWithoutFormatsCheck(
+		ctx,
+		mkOriginalFileAbsPath(meta),
+		format.HEIF,
+		pngAbsPath,
+		format.PNG,
+	)
+	if err != nil {
+		return err
+	}
+
+	pngFile, err := os.Open(pngAbsPath)
+	if err != nil {
+		return fmt.Errorf("failed to open HDR intermediary file: %w", err)
+	}
+	defer pngFile.Close()
+
+	hdrImage, err := png.Decode(pngFile)
+	if err != nil {
+		return fmt.Errorf("failed to decode HDR intermediary file: %w", err)
+	}
+
+	intoFile, err := os.Create(intoAbsPath)
+	if err != nil {
+		return fmt.Errorf("failed to create tone mapped file: %w", err)
+	}
+	defer intoFile.Close()
+
+	err = jpeg.Encode(intoFile, mapper.apply(hdrImage), &jpeg.Options{Quality: 95})
+	if err != nil {
+		return fmt.Errorf("failed to encode tone mapped file: %w", err)
+	}
+
+	return intoFile.Close()
+}
+
 func (g *ImageThumbsGenerator) generateThumb(
 	meta ThumbnailMeta,
 	origFileBytes []byte,
 	origFileDimensions *ImgDimensions,
 	targetWidth int,
-) error {
+	cropRect image.Rectangle,
+	extension string,
+	output thumbOutput,
+	variant thumbVariant,
+) (*models.Thumb, error) {
+	imgOps, releaseImgOps := g.imageOpsFor(origFileDimensions)
+	defer releaseImgOps()
 
-	// Reuse shared ImageOps if original image dimensions are within its capacity,
-	// otherwise create a new one just for this request.
-	var imgOps *lilliput.ImageOps
-	maxDimension := max(origFileDimensions.Width, origFileDimensions.Height)
-	if maxDimension <= 4096 {
-		imgOps = g.imgOps4k
-	} else {
-		imgOps = lilliput.NewImageOps(maxDimension)
-		defer imgOps.Close()
+	decoder, err := g.decode(origFileBytes)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
 
-		g.telemetry.Metrics().Increment(metrics.LPDedicatedImageOpsCreated)
+	// Compute thumbnail dimensions according to resize mode
+	geometry := computeGeometry(origFileDimensions, targetWidth, meta.Resize)
+	encoding := thumbEncoding{
+		Extension: extension,
+		Quality:   thumbQuality(meta),
+		Metadata:  output.metadata,
 	}
 
-	decoder, err := g.decode(origFileBytes)
+	var resizedImgBuf []byte
+	switch {
+	case variant == variantAnimated:
+		resizedImgBuf, err = g.transformAnimated(
+			imgOps,
+			decoder,
+			geometry,
+			meta.Resize.Mode,
+			encoding,
+			output.animation,
+		)
+	case meta.Resize.Mode == models.ResizePad:
+		resizedImgBuf, err = g.transformOnCanvas(
+			imgOps,
+			decoder,
+			geometry,
+			encoding,
+			withColorTransform(output, func(img image.Image) image.Image {
+				return padImage(img, geometry.Width, geometry.Height)
+			}),
+		)
+	case meta.Resize.Mode == models.ResizeCover:
+		window := scaleCropRect(cropRect, origFileDimensions, geometry)
+		resizedImgBuf, err = g.transformOnCanvas(
+			imgOps,
+			decoder,
+			geometry,
+			encoding,
+			withColorTransform(output, func(img image.Image) image.Image {
+				return cropImage(img, window)
+			}),
+		)
+	case output.colorTransform != nil:
+		resizedImgBuf, err = g.transformOnCanvas(
+			imgOps,
+			decoder,
+			geometry,
+			encoding,
+			withColorTransform(output, func(img image.Image) image.Image {
+				return img
+			}),
+		)
+	default:
+		resizedImgBuf, err = g.transform(
+			imgOps,
+			decoder,
+			geometry.Width,
+			geometry.Height,
+			encoding,
+		)
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer decoder.Close()
 
-	// Compute target height to maintain aspect ratio
-	targetHeight := (origFileDimensions.Height * targetWidth) / origFileDimensions.Width
+	thumbFileAbsPath := mkThumbFileAbsPath(meta, targetWidth, extension)
+	if variant == variantPoster {
+		thumbFileAbsPath = mkPosterFileAbsPath(meta, targetWidth, extension)
+	}
+	if err := os.WriteFile(thumbFileAbsPath, resizedImgBuf, 0644); err != nil {
+		return nil, fmt.Errorf(
+			"failed to write thumbnail file %s: %w",
+			thumbFileAbsPath,
+			err)
+	}
 
+	thumb := &models.Thumb{
+		FileName: filepath.Base(thumbFileAbsPath),
+		Width:    geometry.Width,
+		Height:   geometry.Height,
+		Resize:   meta.Resize.String(),
+		Preset:   meta.PresetName,
+		Animated: variant == variantAnimated,
+		Poster:   variant == variantPoster,
+	}
+	if !cropRect.Empty() {
+		thumb.Crop = &models.CropRect{
+			X:      cropRect.Min.X,
+			Y:      cropRect.Min.Y,
+			Width:  cropRect.Dx(),
+			Height: cropRect.Dy(),
+		}
+	}
+
+	g.telemetry.Metrics().Increment(metrics.ThumbCreated)
+	return thumb, nil
+}
+
+// imageOpsFor returns an ImageOps with enough capacity for an image with
+// given dimensions, along with a function to release it once done.
+//
+// Shared ImageOps is reused if dimensions are within its capacity,
+// otherwise a new one is created just for the caller.
+func (g *ImageThumbsGenerator) imageOpsFor(
+	dimensions *ImgDimensions,
+) (*lilliput.ImageOps, func()) {
+	maxDimension := max(dimensions.Width, dimensions.Height)
+	if maxDimension <= 4096 {
+
+		// Clear pixel data when done since ImageOps is shared
+		return g.imgOps4k, g.imgOps4k.Clear
+	}
+
+	g.telemetry.Metrics().Increment(metrics.LPDedicatedImageOpsCreated)
+	imgOps := lilliput.NewImageOps(maxDimension)
+	return imgOps, imgOps.Close
+}
+
+// thumbEncoding describes how a thumbnail is encoded
+type thumbEncoding struct {
+	Extension string
+	Quality   int
+
+	// Replaces any metadata written by encoders
+	Metadata outputMetadata
+}
+
+// intermediaryPngEncoding encodes images that are decoded back in memory
+// for further processing.
+var intermediaryPngEncoding = thumbEncoding{Extension: ".png"}
+
+// transform resizes the image in decoder to given dimensions and encodes
+// it as described by encoding.
+//
+// Image is stretched to exact dimensions, callers are expected to keep
+// the aspect ratio of the (orientation normalized) original.
+func (g *ImageThumbsGenerator) transform(
+	imgOps *lilliput.ImageOps,
+	decoder lilliput.Decoder,
+	width int,
+	height int,
+	encoding thumbEncoding,
+) ([]byte, error) {
 	imgOpts := &lilliput.ImageOptions{
-		FileType:              ThumbsExtension,
-		Width:                 targetWidth,
-		Height:                targetHeight,
-		ResizeMethod:          lilliput.ImageOpsFit,
+		FileType:              encoding.Extension,
+		Width:                 width,
+		Height:                height,
+		ResizeMethod:          lilliput.ImageOpsResize,
 		NormalizeOrientation:  true,
-		EncodeOptions:         g.encodeOptionsByExtension(ThumbsExtension),
+		EncodeOptions:         g.encodeOptions(encoding),
 		DisableAnimatedOutput: true,
 		EncodeTimeout:         5 * time.Second,
 	}
+
+	return g.runTransform(imgOps, decoder, imgOpts, encoding)
+}
+
+// runTransform runs a lilliput transform and replaces metadata of its
+// output as described by encoding.
+func (g *ImageThumbsGenerator) runTransform(
+	imgOps *lilliput.ImageOps,
+	decoder lilliput.Decoder,
+	imgOpts *lilliput.ImageOptions,
+	encoding thumbEncoding,
+) ([]byte, error) {
 	resizedImgBuf, err := imgOps.Transform(decoder, imgOpts, g.resizeBuffer)
 	if err != nil {
 		if errors.Is(err, lilliput.ErrBufTooSmall) {
@@ -230,37 +635,89 @@ func (g *ImageThumbsGenerator) generateThumb(
 			)
 		}
 
-		return fmt.Errorf("failed to create thumbnail: %w", err)
+		return nil, fmt.Errorf("failed to create thumbnail: %w", err)
 	}
 
-	thumbFileAbsPath := mkThumbFileAbsPath(meta, targetWidth, ThumbsExtension)
-	if err := os.WriteFile(thumbFileAbsPath, resizedImgBuf, 0644); err != nil {
-		return fmt.Errorf(
-			"failed to write thumbnail file %s: %w",
-			thumbFileAbsPath,
-			err)
+	return rewriteMetadata(resizedImgBuf, encoding.Extension, encoding.Metadata)
+}
+
+// transformOnCanvas scales the image to geometry scaled dimensions and
+// applies canvasOp to it before final encoding.
+//
+// Lilliput can't crop at arbitrary offsets nor pad images, so the scaled
+// image goes through an intermediary PNG that is edited in memory.
+func (g *ImageThumbsGenerator) transformOnCanvas(
+	imgOps *lilliput.ImageOps,
+	decoder lilliput.Decoder,
+	geometry thumbGeometry,
+	encoding thumbEncoding,
+	canvasOp func(img image.Image) image.Image,
+) ([]byte, error) {
+	scaledPng, err := g.transform(
+		imgOps,
+		decoder,
+		geometry.ScaledWidth,
+		geometry.ScaledHeight,
+		intermediaryPngEncoding,
+	)
+	if err != nil {
+		return nil, err
 	}
 
-	// Clear pixel data if reusing shared ImageOps
-	if imgOps == g.imgOps4k {
-		imgOps.Clear()
+	scaledImg, err := decodePNG(scaledPng)
+	if err != nil {
+		return nil, err
 	}
 
-	g.telemetry.Metrics().Increment(metrics.ThumbCreated)
-	return nil
+	editedPng, err := encodePNG(canvasOp(scaledImg))
+	if err != nil {
+		return nil, err
+	}
+
+	// Encoders embedding the profile of decoded image (e.g. AVIF) find
+	// it in the intermediary PNG
+	editedPng, err = rewritePngMetadata(
+		editedPng,
+		outputMetadata{ICCProfile: encoding.Metadata.ICCProfile},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	editedDecoder, err := g.decode(editedPng)
+	if err != nil {
+		return nil, err
+	}
+	defer editedDecoder.Close()
+
+	imgOps.Clear()
+	return g.transform(
+		imgOps,
+		editedDecoder,
+		geometry.Width,
+		geometry.Height,
+		encoding,
+	)
 }
 
-func (g *ImageThumbsGenerator) encodeOptionsByExtension(
-	extension string,
+func (g *ImageThumbsGenerator) encodeOptions(
+	encoding thumbEncoding,
 ) map[int]int {
+	quality := encoding.Quality
+	if quality <= 0 {
+		quality = ThumbsQuality
+	}
 
 	// Select encoder options based on output file format.
 	// Different formats expect different option keys in lilliput.
-	switch strings.ToLower(extension) {
+	switch strings.ToLower(encoding.Extension) {
 	case ".webp":
 		// WebP uses a quality value from 0-100.
 		// Higher values = better visual quality and larger file size.
-		return map[int]int{lilliput.WebpQuality: ThumbsQuality}
+		return map[int]int{lilliput.WebpQuality: quality}
+	case ".avif":
+		// AVIF uses a quality value from 0-100, same as WebP.
+		return map[int]int{lilliput.AvifQuality: quality}
 	case ".png":
 		// PNG uses compression level from 0-9 (lossless format).
 		// Higher values usually reduce size but may take more CPU time.
@@ -268,12 +725,15 @@ func (g *ImageThumbsGenerator) encodeOptionsByExtension(
 	default:
 		// Default to JPEG quality (0-100).
 		// Higher values = better quality and larger file size.
-		return map[int]int{lilliput.JpegQuality: ThumbsQuality}
+		return map[int]int{lilliput.JpegQuality: quality}
 	}
 }
 
 // dimensions retrieves the width and height in pixels by reading the
 // image header via lilliput decoder, without fully decoding the image.
+//
+// Returned dimensions are those of the image once its EXIF orientation
+// is normalized, matching the pixels lilliput produces.
 func (g *ImageThumbsGenerator) dimensions(
 	fileBytes []byte,
 ) (*ImgDimensions, error) {
@@ -296,6 +756,16 @@ func (g *ImageThumbsGenerator) dimensions(
 		Height: imgHeader.Height(),
 	}
 
+	// Orientations that rotate image by 90 degrees swap its sides
+	switch imgHeader.Orientation() {
+	case lilliput.OrientationLeftTop,
+		lilliput.OrientationRightTop,
+		lilliput.OrientationRightBottom,
+		lilliput.OrientationLeftBottom:
+		imgDimensions.Width, imgDimensions.Height =
+			imgDimensions.Height, imgDimensions.Width
+	}
+
 	return imgDimensions, nil
 }
 
@@ -319,6 +789,8 @@ func (g *ImageThumbsGenerator) isOriginalFileFormatSupported(
 ) error {
 	supportedFormats := []format.Format{
 		format.JPEG, format.PNG, format.WEBP, format.HEIF,
+		format.GIF, format.ANIMATED_WEBP, format.TIFF, format.BMP,
+		format.AVIF, format.JXL,
 	}
 
 	if !slices.Contains(supportedFormats, originalFileFormat) {
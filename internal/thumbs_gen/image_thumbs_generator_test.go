@@ -1,17 +1,27 @@
 package thumbsgen
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/discord/lilliput"
 	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
 	"github.com/giobyte8/thumbnailer/internal/telemetry"
 	"github.com/giobyte8/thumbnailer/internal/testutils"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
 	_ "golang.org/x/image/webp"
 )
 
@@ -53,7 +63,7 @@ func TestImageThumbsGenerator_Integration_SupportedCases(t *testing.T) {
 				ThumbWidths:      tc.thumbWidths,
 			}
 
-			if err := generator.Generate(context.Background(), meta); err != nil {
+			if _, err := generator.Generate(context.Background(), meta); err != nil {
 				t.Fatalf("generate failed: %v", err)
 			}
 
@@ -79,7 +89,7 @@ func TestImageThumbsGenerator_Integration_HEIF(t *testing.T) {
 		ThumbWidths:      []int{120, 240},
 	}
 
-	if err := generator.Generate(context.Background(), meta); err != nil {
+	if _, err := generator.Generate(context.Background(), meta); err != nil {
 		t.Fatalf("generate failed: %v", err)
 	}
 
@@ -94,6 +104,247 @@ func TestImageThumbsGenerator_Integration_HEIF(t *testing.T) {
 	}
 }
 
+func TestImageThumbsGenerator_Integration_UpscaleClamp(t *testing.T) {
+	generator := mkGenerator(t)
+
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: testutils.TestFilesDir(),
+		OrigFileRelPath:  "1 house.jpg",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{120, 99999},
+		UpscalePolicy:    models.UpscaleClamp,
+	}
+
+	result, err := generator.Generate(context.Background(), meta)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	if len(result.Thumbs) != 2 {
+		t.Fatalf("expected 2 thumbnails, got %d", len(result.Thumbs))
+	}
+
+	origSizeThumb := result.Thumbs[1]
+	if origSizeThumb.Width != result.OrigWidth {
+		t.Fatalf(
+			"expected original size thumbnail width %d, got %d",
+			result.OrigWidth,
+			origSizeThumb.Width,
+		)
+	}
+
+	for _, thumb := range result.Thumbs {
+		thumbAbsPath := filepath.Join(meta.ThumbFileAbsDir, thumb.FileName)
+		assertThumbnailCreated(t, thumbAbsPath, thumb.Width)
+	}
+}
+
+func TestImageThumbsGenerator_Integration_BoxedResizeModes(t *testing.T) {
+	generator := mkGenerator(t)
+
+	tests := []struct {
+		name       string
+		resizeMode string
+		width      int
+		wantHeight int
+		wantCrop   bool
+	}{
+		{name: "cover crops to square", resizeMode: "cover:160x160", width: 160, wantHeight: 160, wantCrop: true},
+		{name: "pad fills 16:9 box", resizeMode: "pad:320x180", width: 320, wantHeight: 180},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resizeSpec, err := models.ParseResizeSpec(tc.resizeMode)
+			if err != nil {
+				t.Fatalf("invalid resize mode: %v", err)
+			}
+
+			meta := ThumbnailMeta{
+				OrigFilesRootDir: testutils.TestFilesDir(),
+				OrigFileRelPath:  "7 flower.webp",
+				ThumbFileAbsDir:  t.TempDir(),
+				ThumbWidths:      []int{1024}, // Box takes precedence
+				Resize:           resizeSpec,
+			}
+
+			result, err := generator.Generate(context.Background(), meta)
+			if err != nil {
+				t.Fatalf("generate failed: %v", err)
+			}
+
+			if gotCrop := result.Thumbs[0].Crop != nil; gotCrop != tc.wantCrop {
+				t.Fatalf("unexpected crop rect presence: got %v want %v", gotCrop, tc.wantCrop)
+			}
+
+			thumbAbsPath := mkThumbFileAbsPath(meta, tc.width, ThumbsExtension)
+			assertThumbnailCreated(t, thumbAbsPath, tc.width)
+
+			fileHandle, err := os.Open(thumbAbsPath)
+			if err != nil {
+				t.Fatalf("failed to open thumbnail %s: %v", thumbAbsPath, err)
+			}
+			defer fileHandle.Close()
+
+			config, _, err := image.DecodeConfig(fileHandle)
+			if err != nil {
+				t.Fatalf("failed to decode thumbnail %s: %v", thumbAbsPath, err)
+			}
+			if config.Height != tc.wantHeight {
+				t.Fatalf("unexpected height: got %d want %d", config.Height, tc.wantHeight)
+			}
+		})
+	}
+}
+
+// Originals of each still format are generated from the same picture
+func TestImageThumbsGenerator_Integration_StillFormats(t *testing.T) {
+	generator := mkGenerator(t)
+
+	tests := []struct {
+		name     string
+		fileName string
+		write    func(t *testing.T, absPath string, picture image.Image)
+		expected format.Format
+	}{
+		{"tiff", "scan.tif", writeTestTiff, format.TIFF},
+		{"bmp", "paint.bmp", writeTestBmp, format.BMP},
+		{"avif", "photo.avif", writeTestAvif, format.AVIF},
+		{"jpeg xl", "photo.jxl", writeTestJxl, format.JXL},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			origDir := t.TempDir()
+			tc.write(t, filepath.Join(origDir, tc.fileName), mkTestPicture())
+
+			detected, err := format.NewFormatDetector().Detect(filepath.Join(origDir, tc.fileName))
+			if err != nil {
+				t.Fatalf("failed to detect format: %v", err)
+			}
+			if detected != tc.expected {
+				t.Fatalf("expected format %v, got %v", tc.expected, detected)
+			}
+
+			meta := ThumbnailMeta{
+				OrigFilesRootDir: origDir,
+				OrigFileRelPath:  tc.fileName,
+				ThumbFileAbsDir:  t.TempDir(),
+				ThumbWidths:      []int{64, 128},
+			}
+			if _, err := generator.Generate(context.Background(), meta); err != nil {
+				t.Fatalf("generate failed: %v", err)
+			}
+
+			for _, width := range meta.ThumbWidths {
+				assertThumbnailCreated(t, mkThumbFileAbsPath(meta, width, ThumbsExtension), width)
+			}
+
+			// Intermediary files of converted formats are removed
+			entries, _ := os.ReadDir(meta.ThumbFileAbsDir)
+			if len(entries) != len(meta.ThumbWidths) {
+				t.Fatalf("unexpected files in thumbnails dir: %v", entries)
+			}
+		})
+	}
+}
+
+func mkTestPicture() image.Image {
+	picture := image.NewRGBA(image.Rect(0, 0, 320, 200))
+	for y := range 200 {
+		for x := range 320 {
+			picture.Set(x, y, color.RGBA{R: uint8(x * 255 / 320), G: uint8(y), B: 120, A: 255})
+		}
+	}
+
+	return picture
+}
+
+func writeTestTiff(t *testing.T, absPath string, picture image.Image) {
+	writeTestImage(t, absPath, func(file *os.File) error {
+		return tiff.Encode(file, picture, &tiff.Options{Compression: tiff.Deflate})
+	})
+}
+
+func writeTestBmp(t *testing.T, absPath string, picture image.Image) {
+	writeTestImage(t, absPath, func(file *os.File) error {
+		return bmp.Encode(file, picture)
+	})
+}
+
+// writeTestAvif encodes picture with lilliput, which decodes AVIF too
+func writeTestAvif(t *testing.T, absPath string, picture image.Image) {
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, picture); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+
+	decoder, err := lilliput.NewDecoder(pngData.Bytes())
+	if err != nil {
+		t.Fatalf("failed to decode png: %v", err)
+	}
+	defer decoder.Close()
+
+	bounds := picture.Bounds()
+	framebuffer := lilliput.NewFramebuffer(bounds.Dx(), bounds.Dy())
+	defer framebuffer.Close()
+	if err := decoder.DecodeTo(framebuffer); err != nil {
+		t.Fatalf("failed to decode png: %v", err)
+	}
+
+	encoder, err := lilliput.NewEncoder(".avif", decoder, make([]byte, 1<<20))
+	if err != nil {
+		t.Fatalf("failed to create avif encoder: %v", err)
+	}
+	defer encoder.Close()
+
+	// AVIF encoder buffers frames until flushed with a nil framebuffer
+	if _, err := encoder.Encode(framebuffer, nil); err != nil {
+		t.Fatalf("failed to encode avif: %v", err)
+	}
+	avifData, err := encoder.Encode(nil, nil)
+	if err != nil {
+		t.Fatalf("failed to flush avif: %v", err)
+	}
+	if err := os.WriteFile(absPath, avifData, 0o644); err != nil {
+		t.Fatalf("failed to write avif: %v", err)
+	}
+}
+
+// writeTestJxl encodes picture with 'cjxl', skipping the test when
+// JPEG XL tools aren't available
+func writeTestJxl(t *testing.T, absPath string, picture image.Image) {
+	for _, binary := range []string{"cjxl", "djxl"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("%s not available, skipping integration test", binary)
+		}
+	}
+
+	pngAbsPath := filepath.Join(t.TempDir(), "picture.png")
+	writeTestImage(t, pngAbsPath, func(file *os.File) error {
+		return png.Encode(file, picture)
+	})
+
+	output, err := exec.Command("cjxl", pngAbsPath, absPath).CombinedOutput()
+	if err != nil {
+		t.Fatalf("cjxl failed: %v. output: %s", err, output)
+	}
+}
+
+func writeTestImage(t *testing.T, absPath string, encode func(*os.File) error) {
+	t.Helper()
+
+	file, err := os.Create(absPath)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", absPath, err)
+	}
+	defer file.Close()
+
+	if err := encode(file); err != nil {
+		t.Fatalf("failed to encode %s: %v", absPath, err)
+	}
+}
+
 func TestImageThumbsGenerator_UnsupportedMedia(t *testing.T) {
 	generator := mkGenerator(t)
 
@@ -104,7 +355,7 @@ func TestImageThumbsGenerator_UnsupportedMedia(t *testing.T) {
 		ThumbWidths:      []int{120, 240},
 	}
 
-	err := generator.Generate(context.Background(), meta)
+	_, err := generator.Generate(context.Background(), meta)
 	if err == nil {
 		t.Fatalf("expected unsupported media error, got nil")
 	}
@@ -178,3 +429,199 @@ func assertThumbnailCreated(t *testing.T, thumbAbsPath string, expectedWidth int
 		t.Fatalf("invalid height for %s: %d", thumbAbsPath, config.Height)
 	}
 }
+
+func TestImageThumbsGenerator_Integration_Presets(t *testing.T) {
+	generator := mkGenerator(t)
+	thumbsDir := t.TempDir()
+
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: testutils.TestFilesDir(),
+		OrigFileRelPath:  "7 flower.webp",
+		ThumbFileAbsDir:  thumbsDir,
+		Presets: []models.ThumbPreset{
+			{
+				Name:    "grid",
+				Widths:  []int{160},
+				Resize:  models.ResizeSpec{Mode: models.ResizeCover, BoxWidth: 160, BoxHeight: 160},
+				Formats: []string{".webp", ".jpg"},
+				Quality: 60,
+				Suffix:  "grid",
+			},
+			{
+				Name:    "preview",
+				Widths:  []int{320},
+				Formats: []string{".png"},
+				Quality: 80,
+				Suffix:  "preview",
+			},
+		},
+	}
+
+	result, err := generator.Generate(context.Background(), meta)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	wantFiles := []string{
+		"7 flower_160px_cover160x160_grid.webp",
+		"7 flower_160px_cover160x160_grid.jpg",
+		"7 flower_320px_preview.png",
+	}
+	if len(result.Thumbs) != len(wantFiles) {
+		t.Fatalf("unexpected thumbs count: got %d want %d", len(result.Thumbs), len(wantFiles))
+	}
+
+	for i, wantFile := range wantFiles {
+		thumb := result.Thumbs[i]
+		if thumb.FileName != wantFile {
+			t.Fatalf("unexpected thumb file: got %q want %q", thumb.FileName, wantFile)
+		}
+
+		fileHandle, err := os.Open(filepath.Join(thumbsDir, wantFile))
+		if err != nil {
+			t.Fatalf("failed to open thumbnail %s: %v", wantFile, err)
+		}
+
+		config, _, err := image.DecodeConfig(fileHandle)
+		fileHandle.Close()
+		if err != nil {
+			t.Fatalf("failed to decode thumbnail %s: %v", wantFile, err)
+		}
+		if config.Width != thumb.Width {
+			t.Fatalf("unexpected width for %s: got %d want %d", wantFile, config.Width, thumb.Width)
+		}
+	}
+
+	if result.Thumbs[0].Preset != "grid" || result.Thumbs[2].Preset != "preview" {
+		t.Fatalf("unexpected thumb presets: %+v", result.Thumbs)
+	}
+}
+
+func TestImageThumbsGenerator_Integration_PlaceholdersAndColors(t *testing.T) {
+	generator := mkGenerator(t)
+
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: testutils.TestFilesDir(),
+		OrigFileRelPath:  "7 flower.webp",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{128},
+		Placeholders: []models.PlaceholderKind{
+			models.PlaceholderBlurHash,
+			models.PlaceholderThumbHash,
+		},
+		PaletteSize: 5,
+	}
+
+	result, err := generator.Generate(context.Background(), meta)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	if result.Placeholders == nil {
+		t.Fatal("expected placeholders in result")
+	}
+	if got := len(result.Placeholders.BlurHash); got != 28 {
+		t.Fatalf("unexpected blurhash length: got %d (%q)", got, result.Placeholders.BlurHash)
+	}
+
+	thumbHash, err := base64.StdEncoding.DecodeString(result.Placeholders.ThumbHash)
+	if err != nil {
+		t.Fatalf("thumbhash is not base64: %v", err)
+	}
+	if len(thumbHash) < 5 {
+		t.Fatalf("unexpected thumbhash length: %d", len(thumbHash))
+	}
+
+	if result.Colors == nil || len(result.Colors.Palette) != 5 {
+		t.Fatalf("expected 5 palette colors, got %+v", result.Colors)
+	}
+	if result.Colors.Dominant != result.Colors.Palette[0].Hex {
+		t.Fatalf("dominant color is not first in palette: %+v", result.Colors)
+	}
+}
+
+func TestImageThumbsGenerator_Integration_OutputMetadata(t *testing.T) {
+	generator := mkGenerator(t)
+
+	// Display P3 original with EXIF (including GPS), XMP and ICC profile
+	tests := []struct {
+		name            string
+		colorProfile    models.ColorProfilePolicy
+		keepMetadata    []models.MetadataField
+		resize          models.ResizeSpec
+		wantICC         bool
+		wantCaptureTime string
+	}{
+		{
+			name:         "srgb strips everything",
+			colorProfile: models.ColorProfileSRGB,
+		},
+		{
+			name:         "srgb in cover mode",
+			colorProfile: models.ColorProfileSRGB,
+			resize:       models.ResizeSpec{Mode: models.ResizeCover, BoxWidth: 160, BoxHeight: 160},
+		},
+		{
+			name:         "embed keeps profile",
+			colorProfile: models.ColorProfileEmbed,
+			wantICC:      true,
+		},
+		{
+			name:            "keep list",
+			keepMetadata:    []models.MetadataField{models.MetadataCaptureTime},
+			wantCaptureTime: "2025-05-03T13:06:08-06:00",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			meta := ThumbnailMeta{
+				OrigFilesRootDir: testutils.TestFilesDir(),
+				OrigFileRelPath:  "2 museum.jpeg",
+				ThumbFileAbsDir:  t.TempDir(),
+				ThumbWidths:      []int{160},
+				ThumbFormats:     []string{".webp", ".jpg"},
+				Resize:           tc.resize,
+				ColorProfile:     tc.colorProfile,
+				KeepMetadata:     tc.keepMetadata,
+			}
+
+			result, err := generator.Generate(context.Background(), meta)
+			if err != nil {
+				t.Fatalf("generate failed: %v", err)
+			}
+
+			for _, thumb := range result.Thumbs {
+				thumbBytes, err := os.ReadFile(filepath.Join(meta.ThumbFileAbsDir, thumb.FileName))
+				if err != nil {
+					t.Fatalf("failed to read thumbnail: %v", err)
+				}
+
+				if hasICC := len(decodedICC(t, thumbBytes)) > 0; hasICC != tc.wantICC {
+					t.Errorf("%s: ICC profile embedded = %v, want %v", thumb.FileName, hasICC, tc.wantICC)
+				}
+
+				thumbFormat := format.WEBP
+				if filepath.Ext(thumb.FileName) == ".jpg" {
+					thumbFormat = format.JPEG
+				}
+
+				thumbMeta := extractFromBytes(t, thumbBytes, thumbFormat)
+				if thumbMeta == nil {
+					thumbMeta = &models.MediaMetadata{}
+				}
+				if thumbMeta.Location != nil || thumbMeta.CameraModel != "" {
+					t.Errorf("%s: leaked original metadata %+v", thumb.FileName, thumbMeta)
+				}
+				if thumbMeta.CaptureTime != tc.wantCaptureTime {
+					t.Errorf(
+						"%s: capture time = %q, want %q",
+						thumb.FileName,
+						thumbMeta.CaptureTime,
+						tc.wantCaptureTime,
+					)
+				}
+			}
+		})
+	}
+}
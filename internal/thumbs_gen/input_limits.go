@@ -0,0 +1,154 @@
+package thumbsgen
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/metadata"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/telemetry"
+	"github.com/giobyte8/thumbnailer/internal/telemetry/metrics"
+)
+
+// ErrInputTooLarge is wrapped by errors of originals exceeding
+// configured input limits.
+var ErrInputTooLarge = errors.New("input exceeds configured limits")
+
+// Reasons of input rejection, reported as metric attribute
+const (
+	rejectReasonFileSize  = "file_size"
+	rejectReasonDimension = "dimension"
+	rejectReasonPixels    = "pixels"
+)
+
+// checkFileSize rejects originals larger than configured limit, without
+// loading them into memory.
+func checkFileSize(
+	telemetrySvc *telemetry.TelemetrySvc,
+	meta ThumbnailMeta,
+	fileAbsPath string,
+) error {
+	if meta.InputLimits.MaxFileBytes <= 0 {
+		return nil
+	}
+
+	fileInfo, err := os.Stat(fileAbsPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat original file: %w", err)
+	}
+
+	return checkInputSize(telemetrySvc, meta, fileInfo.Size())
+}
+
+// checkInputSize rejects originals of given size in bytes when larger
+// than configured limit
+func checkInputSize(
+	telemetrySvc *telemetry.TelemetrySvc,
+	meta ThumbnailMeta,
+	size int64,
+) error {
+	maxFileBytes := meta.InputLimits.MaxFileBytes
+	if maxFileBytes <= 0 || size <= maxFileBytes {
+		return nil
+	}
+
+	return rejectInput(
+		telemetrySvc,
+		meta,
+		rejectReasonFileSize,
+		fmt.Errorf(
+			"%w: file size %d bytes exceeds %d bytes",
+			ErrInputTooLarge,
+			size,
+			maxFileBytes,
+		),
+	)
+}
+
+// checkDimensions rejects originals whose dimensions (read from image
+// header) exceed configured limits.
+func (g *ImageThumbsGenerator) checkDimensions(
+	meta ThumbnailMeta,
+	dims *ImgDimensions,
+) error {
+	limits := meta.InputLimits
+
+	if limits.MaxDimension > 0 &&
+		max(dims.Width, dims.Height) > limits.MaxDimension {
+		return rejectInput(
+			g.telemetry,
+			meta,
+			rejectReasonDimension,
+			fmt.Errorf(
+				"%w: dimensions %dx%d exceed %dpx",
+				ErrInputTooLarge,
+				dims.Width,
+				dims.Height,
+				limits.MaxDimension,
+			),
+		)
+	}
+
+	pixels := int64(dims.Width) * int64(dims.Height)
+	if limits.MaxPixels > 0 && pixels > limits.MaxPixels {
+		return rejectInput(
+			g.telemetry,
+			meta,
+			rejectReasonPixels,
+			fmt.Errorf(
+				"%w: pixel count %d exceeds %d",
+				ErrInputTooLarge,
+				pixels,
+				limits.MaxPixels,
+			),
+		)
+	}
+
+	return nil
+}
+
+// checkHeaderDimensions rejects originals converted into an intermediary
+// file (format.ConvertedFormats) whose dimensions, as declared in their
+// container header, exceed configured limits. Originals declaring no
+// readable dimensions are rejected too.
+func (g *ImageThumbsGenerator) checkHeaderDimensions(
+	meta ThumbnailMeta,
+	origFileFormat format.Format,
+) error {
+	limits := meta.InputLimits
+	if limits.MaxDimension <= 0 && limits.MaxPixels <= 0 {
+		return nil
+	}
+
+	width, height, err := metadata.ImageSize(mkOriginalFileAbsPath(meta), origFileFormat)
+	if err != nil {
+		return models.NewPermanentError(err)
+	}
+
+	return g.checkDimensions(meta, &ImgDimensions{Width: width, Height: height})
+}
+
+// rejectInput records rejection of original in meta and wraps err as
+// permanent, since retrying the same original would fail again.
+func rejectInput(
+	telemetrySvc *telemetry.TelemetrySvc,
+	meta ThumbnailMeta,
+	reason string,
+	err error,
+) error {
+	slog.Warn(
+		"Original file rejected",
+		"filePath", meta.OrigFileRelPath,
+		"reason", reason,
+		"error", err,
+	)
+	telemetrySvc.Metrics().IncrementWAttrs(
+		metrics.ThumbInputRejected,
+		map[string]string{"reason": reason},
+	)
+
+	return models.NewPermanentError(err)
+}
@@ -0,0 +1,182 @@
+package thumbsgen
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+func TestImageThumbsGenerator_InputLimits(t *testing.T) {
+	generator := mkGenerator(t)
+
+	tests := []struct {
+		name   string
+		limits models.InputLimits
+	}{
+		{name: "file size", limits: models.InputLimits{MaxFileBytes: 1024}},
+		{name: "dimension", limits: models.InputLimits{MaxDimension: 256}},
+		{name: "pixel count", limits: models.InputLimits{MaxPixels: 256 * 256}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			thumbsDir := t.TempDir()
+			meta := ThumbnailMeta{
+				OrigFilesRootDir: testutils.TestFilesDir(),
+				OrigFileRelPath:  "7 flower.webp",
+				ThumbFileAbsDir:  thumbsDir,
+				ThumbWidths:      []int{128},
+				InputLimits:      tc.limits,
+			}
+
+			_, err := generator.Generate(context.Background(), meta)
+			assertInputRejected(t, err)
+
+			entries, _ := os.ReadDir(thumbsDir)
+			if len(entries) != 0 {
+				t.Fatalf("expected no thumbnails for rejected input, got %d files", len(entries))
+			}
+		})
+	}
+}
+
+func TestImageThumbsGenerator_InputLimits_RejectsDecompressionBomb(t *testing.T) {
+	generator := mkGenerator(t)
+
+	origDir := t.TempDir()
+	bombPath := filepath.Join(origDir, "bomb.png")
+	if err := os.WriteFile(bombPath, mkPngHeaderOnly(t, 60000, 60000), 0o644); err != nil {
+		t.Fatalf("failed to write png: %v", err)
+	}
+
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: origDir,
+		OrigFileRelPath:  "bomb.png",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{128},
+		InputLimits:      models.InputLimits{MaxPixels: 100_000_000},
+	}
+
+	_, err := generator.Generate(context.Background(), meta)
+	assertInputRejected(t, err)
+}
+
+func TestImageThumbsGenerator_InputLimits_RejectsConvertedFormatsFromHeader(t *testing.T) {
+	generator := mkGenerator(t)
+
+	// Generation reaching conversion would panic
+	generator.formatConverter = nil
+
+	ispe := binary.BigEndian.AppendUint32(make([]byte, 4), 60000)
+	ispe = binary.BigEndian.AppendUint32(ispe, 60000)
+	heif := append(
+		mp4Box("ftyp", []byte("heic")),
+		mp4Box("meta", append(make([]byte, 4), mp4Box("iprp", mp4Box("ipco", mp4Box("ispe", ispe)))...))...,
+	)
+
+	// Little endian header, then an IFD declaring width and length only
+	tiff := binary.LittleEndian.AppendUint32([]byte("II*\x00"), 8)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 2)
+	for _, tag := range []uint16{0x0100, 0x0101} {
+		tiff = binary.LittleEndian.AppendUint16(tiff, tag)
+		tiff = binary.LittleEndian.AppendUint16(tiff, 4) // LONG
+		tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+		tiff = binary.LittleEndian.AppendUint32(tiff, 60000)
+	}
+	tiff = binary.LittleEndian.AppendUint32(tiff, 0)
+
+	tests := []struct {
+		fileName   string
+		fileFormat format.Format
+		data       []byte
+	}{
+		{fileName: "bomb.heic", fileFormat: format.HEIF, data: heif},
+		{fileName: "bomb.tiff", fileFormat: format.TIFF, data: tiff},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.fileFormat), func(t *testing.T) {
+			origDir := t.TempDir()
+			writeOriginal(t, origDir, tc.fileName, tc.data)
+
+			thumbsDir := t.TempDir()
+			meta := ThumbnailMeta{
+				OrigFilesRootDir: origDir,
+				OrigFileRelPath:  tc.fileName,
+				ThumbFileAbsDir:  thumbsDir,
+				ThumbWidths:      []int{128},
+				InputLimits:      models.InputLimits{MaxPixels: 100_000_000},
+			}
+
+			_, err := generator.GenerateWithoutFormatsCheck(
+				context.Background(),
+				meta,
+				tc.fileFormat,
+			)
+			assertInputRejected(t, err)
+
+			entries, _ := os.ReadDir(thumbsDir)
+			if len(entries) != 0 {
+				t.Fatalf("expected no intermediary file, got %d files", len(entries))
+			}
+		})
+	}
+}
+
+func assertInputRejected(t *testing.T, err error) {
+	t.Helper()
+
+	if !errors.Is(err, ErrInputTooLarge) {
+		t.Fatalf("expected ErrInputTooLarge, got %v", err)
+	}
+	if got := models.ErrorCategoryOf(err); got != models.ErrCategoryPermanent {
+		t.Fatalf("unexpected error category: got %q want %q", got, models.ErrCategoryPermanent)
+	}
+}
+
+// mkPngHeaderOnly builds a grayscale PNG declaring given dimensions with
+// a tiny (truncated) pixel stream, as decompression bombs do.
+func mkPngHeaderOnly(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+
+	var pngBuf bytes.Buffer
+	pngBuf.Write([]byte("\x89PNG\r\n\x1a\n"))
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:4], width)
+	binary.BigEndian.PutUint32(ihdr[4:8], height)
+	ihdr[8] = 8 // Bit depth, color type 0 (grayscale)
+	writePngChunk(&pngBuf, "IHDR", ihdr)
+
+	var idat bytes.Buffer
+	zlibWriter := zlib.NewWriter(&idat)
+	if _, err := zlibWriter.Write(make([]byte, 1024)); err != nil {
+		t.Fatalf("failed to compress png data: %v", err)
+	}
+	zlibWriter.Close()
+	writePngChunk(&pngBuf, "IDAT", idat.Bytes())
+	writePngChunk(&pngBuf, "IEND", nil)
+
+	return pngBuf.Bytes()
+}
+
+func writePngChunk(buf *bytes.Buffer, chunkType string, data []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.WriteString(chunkType)
+	buf.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(data)
+	_ = binary.Write(buf, binary.BigEndian, crc.Sum32())
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
 )
 
 const ThumbsExtension = ".webp"
@@ -29,6 +30,92 @@ type ThumbnailMeta struct {
 	// For example, if this is [100, 200, 300], then three thumbnails
 	// will be generated with widths 100px, 200px, and 300px,
 	ThumbWidths []int
+
+	// Determines how originals are fit into thumbnail dimensions.
+	// Zero value behaves as 'fit-width'.
+	Resize models.ResizeSpec
+
+	// Optional point of interest used to position crop window in 'cover'
+	// resize mode. Crop window is chosen by saliency analysis when nil.
+	FocalPoint *models.FocalPoint
+
+	// Extensions of thumbnail formats to produce (e.g. '.webp', '.jpg').
+	// Defaults to ThumbsExtension when empty.
+	ThumbFormats []string
+
+	// Encoding quality from 0 to 100. Defaults to ThumbsQuality when zero.
+	ThumbQuality int
+
+	// Optional suffix appended to thumbnail file names
+	ThumbNameSuffix string
+
+	// Name of the preset options above come from, if any.
+	PresetName string
+
+	// Presets to produce thumbnails for. When not empty, each preset
+	// overrides widths, resize, formats, quality and name suffix above.
+	Presets []models.ThumbPreset
+
+	// Determines how widths larger than the original are handled.
+	// Zero value behaves as models.UpscaleAllow.
+	UpscalePolicy models.UpscalePolicy
+
+	// Kinds of placeholders to compute for the original, if any
+	Placeholders []models.PlaceholderKind
+
+	// Number of palette colors to extract from the original. Zero
+	// disables color extraction.
+	PaletteSize int
+
+	// Whether to compute a perceptual hash (dHash) of the original, used
+	// to find near duplicates
+	PerceptualHash bool
+
+	// Groups of original metadata copied into thumbnails. Thumbnails
+	// carry no metadata when empty.
+	KeepMetadata []models.MetadataField
+
+	// Determines how color profiles of originals carry over to
+	// thumbnails. Zero value behaves as models.ColorProfileSRGB.
+	ColorProfile models.ColorProfilePolicy
+
+	// Bounds for animated thumbnails of animated originals
+	AnimationLimits models.AnimationLimits
+
+	// Points of videos, as percentages of their duration, where
+	// candidate frames for thumbnails are sampled. Frame at 1 second is
+	// used when empty.
+	FrameSamples []int
+
+	// Operator tone mapping HDR videos and HEIF photos into SDR
+	// thumbnails. HDR pixels are kept as decoded when disabled.
+	ToneMap models.ToneMapOperator
+
+	// Looping preview clip produced for videos, if enabled
+	VideoPreview models.VideoPreviewOptions
+
+	// Output produced for videos of Apple Live Photos. Zero value
+	// behaves as models.LivePhotoVideoStill.
+	LivePhotoVideo models.LivePhotoVideoMode
+
+	// Path to the photo of the Apple Live Photo the original is the video
+	// of (see FindLivePhotoPhoto), relative to OrigFilesRootDir. Empty
+	// for other originals.
+	LivePhotoPhoto string
+
+	// Storyboard sprite sheets produced for videos, if enabled
+	Storyboard models.StoryboardOptions
+
+	// Rendering of the first page of PDF and office documents. Pages
+	// are rendered at 150 DPI when DPI is zero.
+	Document models.DocumentOptions
+
+	// Bounds for entries read from comic book, EPUB and zip originals
+	Archive models.ArchiveOptions
+
+	// Bounds for originals size. Originals exceeding them are rejected
+	// with a permanent error before being fully decoded.
+	InputLimits models.InputLimits
 }
 
 type ThumbsGenerator interface {
@@ -37,7 +124,13 @@ type ThumbsGenerator interface {
 	// This method checks if the format of the original file is supported
 	// before performing the generation. Use GenerateWithoutFormatsCheck if
 	// you need to skip such checks.
-	Generate(ctx context.Context, meta ThumbnailMeta) error
+	//
+	// Returned result describes the thumbnails actually produced, it is
+	// nil when the original file was discarded (e.g. unsupported format).
+	Generate(
+		ctx context.Context,
+		meta ThumbnailMeta,
+	) (*models.ThumbGenResult, error)
 
 	// GenerateWithoutFormatsCheck generates thumbnails without checking
 	// whether the format of the original file is supported.
@@ -48,7 +141,7 @@ type ThumbsGenerator interface {
 		ctx context.Context,
 		meta ThumbnailMeta,
 		origFileFormat format.Format,
-	) error
+	) (*models.ThumbGenResult, error)
 }
 
 type ImgDimensions struct {
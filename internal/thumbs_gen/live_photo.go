@@ -0,0 +1,197 @@
+package thumbsgen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/metadata"
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// LivePhotoVideoSuffix is appended to names of files produced for videos
+// of Apple Live Photos, which share their name with the photo
+const LivePhotoVideoSuffix = "_video"
+
+// livePhotoFile is an extension of files in Apple Live Photos, along
+// with the format their metadata is read as
+type livePhotoFile struct {
+	extension string
+	format    format.Format
+}
+
+// Photos of Live Photos are HEIC as captured and JPEG once exported,
+// videos are MOV. Extensions are matched in lower and upper case.
+var (
+	livePhotoPhotoFiles = []livePhotoFile{
+		{extension: ".heic", format: format.HEIF},
+		{extension: ".heif", format: format.HEIF},
+		{extension: ".jpg", format: format.JPEG},
+		{extension: ".jpeg", format: format.JPEG},
+	}
+	livePhotoVideoFiles = []livePhotoFile{
+		{extension: ".mov", format: format.MOV},
+	}
+)
+
+// FindLivePhotoPhoto returns the path of the photo of the Apple Live
+// Photo whose video is the original at origFileRelPath, that is, a photo
+// with the same name next to it. Returns empty string when original isn't
+// a MOV or there is no such photo. Paths are relative to origFilesRootDir.
+func FindLivePhotoPhoto(origFilesRootDir string, origFileRelPath string) string {
+	if _, isVideo := livePhotoFileOf(origFileRelPath, livePhotoVideoFiles); !isVideo {
+		return ""
+	}
+
+	_, photoRelPath, _ := findLivePhotoSibling(
+		origFilesRootDir,
+		origFileRelPath,
+		livePhotoPhotoFiles,
+	)
+	return photoRelPath
+}
+
+// livePhotoFileOf returns the entry of files matching extension of
+// fileRelPath, if any
+func livePhotoFileOf(fileRelPath string, files []livePhotoFile) (livePhotoFile, bool) {
+	extension := strings.ToLower(filepath.Ext(fileRelPath))
+	idx := slices.IndexFunc(files, func(file livePhotoFile) bool {
+		return file.extension == extension
+	})
+	if idx < 0 {
+		return livePhotoFile{}, false
+	}
+
+	return files[idx], true
+}
+
+// findLivePhotoSibling returns the first regular file next to the
+// original with the same name and one of files extensions, along with
+// its path
+func findLivePhotoSibling(
+	origFilesRootDir string,
+	origFileRelPath string,
+	files []livePhotoFile,
+) (livePhotoFile, string, bool) {
+	stem := strings.TrimSuffix(origFileRelPath, filepath.Ext(origFileRelPath))
+	for _, file := range files {
+		for _, extension := range []string{file.extension, strings.ToUpper(file.extension)} {
+			siblingRelPath := stem + extension
+
+			fileInfo, err := os.Stat(filepath.Join(origFilesRootDir, siblingRelPath))
+			if err == nil && fileInfo.Mode().IsRegular() {
+				return file, siblingRelPath, true
+			}
+		}
+	}
+
+	return livePhotoFile{}, "", false
+}
+
+// pairLivePhoto describes the original as part of an Apple Live Photo
+// along with the file at pairedRelPath. Files recording different
+// content identifiers aren't paired, nil is returned for them.
+func pairLivePhoto(
+	metaExtractor *metadata.Extractor,
+	meta ThumbnailMeta,
+	role models.LivePhotoRole,
+	contentIdentifier string,
+	paired livePhotoFile,
+	pairedRelPath string,
+) *models.LivePhoto {
+	var pairedIdentifier string
+	pairedMeta, err := metaExtractor.Extract(
+		filepath.Join(meta.OrigFilesRootDir, pairedRelPath),
+		paired.format,
+	)
+	if err != nil {
+		slog.Debug(
+			"Failed to read metadata of live photo paired file",
+			"filePath", pairedRelPath,
+			"error", err,
+		)
+	} else if pairedMeta != nil {
+		pairedIdentifier = pairedMeta.ContentIdentifier
+	}
+
+	if contentIdentifier != "" &&
+		pairedIdentifier != "" &&
+		contentIdentifier != pairedIdentifier {
+		slog.Debug(
+			"Files with the same name have different content identifiers",
+			"filePath", meta.OrigFileRelPath,
+			"pairedFilePath", pairedRelPath,
+		)
+		return nil
+	}
+
+	livePhoto := &models.LivePhoto{Role: role, PairedFilePath: pairedRelPath}
+	if contentIdentifier == pairedIdentifier {
+		livePhoto.ContentIdentifier = contentIdentifier
+	}
+	return livePhoto
+}
+
+// extractMotionPhoto copies the video embedded in a Motion Photo JPEG
+// next to its thumbnails. Returns nil when original embeds no video.
+func extractMotionPhoto(meta ThumbnailMeta) (*models.LivePhoto, error) {
+	origFileAbsPath := mkOriginalFileAbsPath(meta)
+	video, err := metadata.LocateMotionPhotoVideo(origFileAbsPath)
+	if errors.Is(err, metadata.ErrNoMotionPhoto) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	videoAbsPath := mkMotionPhotoVideoAbsPath(meta)
+	if err := copyMotionPhotoVideo(origFileAbsPath, videoAbsPath, video); err != nil {
+		_ = os.Remove(videoAbsPath)
+		return nil, err
+	}
+
+	return &models.LivePhoto{
+		Role:          models.LivePhotoRolePhoto,
+		VideoFileName: filepath.Base(videoAbsPath),
+	}, nil
+}
+
+// copyMotionPhotoVideo copies the video embedded in the Motion Photo at
+// fromAbsPath into a new file at intoAbsPath
+func copyMotionPhotoVideo(
+	fromAbsPath string,
+	intoAbsPath string,
+	video *metadata.MotionPhotoVideo,
+) error {
+	fromFile, err := os.Open(fromAbsPath)
+	if err != nil {
+		return fmt.Errorf("failed to open motion photo: %w", err)
+	}
+	defer fromFile.Close()
+
+	intoFile, err := os.Create(intoAbsPath)
+	if err != nil {
+		return fmt.Errorf("failed to create motion photo video: %w", err)
+	}
+	defer intoFile.Close()
+
+	copied, err := io.Copy(intoFile, io.NewSectionReader(fromFile, video.Offset, video.Length))
+	if err != nil {
+		return fmt.Errorf("failed to copy motion photo video: %w", err)
+	}
+	if copied != video.Length {
+		return fmt.Errorf(
+			"motion photo video truncated: copied %d of %d bytes",
+			copied,
+			video.Length,
+		)
+	}
+
+	return intoFile.Close()
+}
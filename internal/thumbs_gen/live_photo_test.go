@@ -0,0 +1,291 @@
+package thumbsgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/metadata"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+func TestFindLivePhotoPhoto(t *testing.T) {
+	origDir := t.TempDir()
+	for _, fileName := range []string{
+		"IMG_0001.HEIC", "IMG_0001.MOV",
+		"IMG_0002.jpg", "IMG_0002.mov",
+		"IMG_0003.MOV",
+		"IMG_0004.HEIC", "IMG_0004.MP4",
+	} {
+		writeOriginal(t, origDir, fileName, nil)
+	}
+
+	tests := map[string]string{
+		"IMG_0001.MOV":  "IMG_0001.HEIC",
+		"IMG_0002.mov":  "IMG_0002.jpg",
+		"IMG_0003.MOV":  "",
+		"IMG_0004.MP4":  "",
+		"IMG_0001.HEIC": "",
+	}
+
+	for origFileRelPath, want := range tests {
+		if got := FindLivePhotoPhoto(origDir, origFileRelPath); got != want {
+			t.Errorf("FindLivePhotoPhoto(%q) = %q, want %q", origFileRelPath, got, want)
+		}
+	}
+}
+
+func TestPairLivePhoto(t *testing.T) {
+
+	// Photo fixture records this content identifier in its MakerNote
+	const photoIdentifier = "343C4976-48AF-4BDA-B67C-7C020984234A"
+
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: testutils.TestFilesDir(),
+		OrigFileRelPath:  "4 thai_no_edits.mov",
+	}
+	photo := livePhotoFile{extension: ".heic", format: format.HEIF}
+
+	tests := []struct {
+		name                  string
+		contentIdentifier     string
+		wantPaired            bool
+		wantContentIdentifier string
+	}{
+		{
+			name:                  "matching identifiers",
+			contentIdentifier:     photoIdentifier,
+			wantPaired:            true,
+			wantContentIdentifier: photoIdentifier,
+		},
+		{
+			name:              "video without identifier",
+			contentIdentifier: "",
+			wantPaired:        true,
+		},
+		{
+			name:              "different identifiers",
+			contentIdentifier: "5F0E1B2C-3D4A-4B5C-8D6E-7F8091A2B3C4",
+			wantPaired:        false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			livePhoto := pairLivePhoto(
+				metadata.NewExtractor(),
+				meta,
+				models.LivePhotoRoleVideo,
+				tc.contentIdentifier,
+				photo,
+				"4 thai_no_edits.heic",
+			)
+
+			if !tc.wantPaired {
+				if livePhoto != nil {
+					t.Fatalf("expected files not to be paired, got %+v", livePhoto)
+				}
+				return
+			}
+
+			want := models.LivePhoto{
+				Role:              models.LivePhotoRoleVideo,
+				PairedFilePath:    "4 thai_no_edits.heic",
+				ContentIdentifier: tc.wantContentIdentifier,
+			}
+			if livePhoto == nil || *livePhoto != want {
+				t.Fatalf("livePhoto = %+v, want %+v", livePhoto, want)
+			}
+		})
+	}
+}
+
+func TestRoutedThumbsGenerator_DetectLivePhoto(t *testing.T) {
+	origDir := t.TempDir()
+	video := append(mp4Box("ftyp", []byte("isom")), mp4Box("mdat", make([]byte, 64))...)
+
+	motionPhotoXmp := fmt.Sprintf(
+		`<rdf:Description GCamera:MicroVideo="1" GCamera:MicroVideoOffset="%d"/>`,
+		len(video),
+	)
+	writeOriginal(t, origDir, "PXL_0001.MP.jpg", append(mkXmpJpeg(t, motionPhotoXmp), video...))
+	writeOriginal(t, origDir, "IMG_0001.JPG", mkXmpJpeg(t, ""))
+	writeOriginal(t, origDir, "IMG_0001.MOV", []byte("not a quicktime file"))
+	writeOriginal(t, origDir, "IMG_0002.JPG", mkXmpJpeg(t, ""))
+
+	generator := &RoutedThumbsGenerator{metaExtractor: metadata.NewExtractor()}
+
+	t.Run("motion photo", func(t *testing.T) {
+		meta := ThumbnailMeta{
+			OrigFilesRootDir: origDir,
+			OrigFileRelPath:  "PXL_0001.MP.jpg",
+			ThumbFileAbsDir:  t.TempDir(),
+		}
+
+		livePhoto := generator.detectLivePhoto(meta, format.JPEG, nil)
+		want := models.LivePhoto{
+			Role:          models.LivePhotoRolePhoto,
+			VideoFileName: "PXL_0001.MP_motion.mp4",
+		}
+		if livePhoto == nil || *livePhoto != want {
+			t.Fatalf("livePhoto = %+v, want %+v", livePhoto, want)
+		}
+
+		extracted, err := os.ReadFile(filepath.Join(meta.ThumbFileAbsDir, want.VideoFileName))
+		if err != nil {
+			t.Fatalf("failed to read extracted video: %v", err)
+		}
+		if !bytes.Equal(extracted, video) {
+			t.Fatal("extracted video differs from the embedded one")
+		}
+
+		result := models.ThumbGenResult{LivePhoto: livePhoto}
+		if fileNames := result.FileNames(); len(fileNames) != 1 || fileNames[0] != want.VideoFileName {
+			t.Fatalf("expected extracted video in result files, got %v", fileNames)
+		}
+	})
+
+	t.Run("live photo", func(t *testing.T) {
+		meta := ThumbnailMeta{
+			OrigFilesRootDir: origDir,
+			OrigFileRelPath:  "IMG_0001.JPG",
+			ThumbFileAbsDir:  t.TempDir(),
+		}
+
+		livePhoto := generator.detectLivePhoto(meta, format.JPEG, nil)
+		want := models.LivePhoto{
+			Role:           models.LivePhotoRolePhoto,
+			PairedFilePath: "IMG_0001.MOV",
+		}
+		if livePhoto == nil || *livePhoto != want {
+			t.Fatalf("livePhoto = %+v, want %+v", livePhoto, want)
+		}
+	})
+
+	t.Run("unpaired photo", func(t *testing.T) {
+		meta := ThumbnailMeta{
+			OrigFilesRootDir: origDir,
+			OrigFileRelPath:  "IMG_0002.JPG",
+			ThumbFileAbsDir:  t.TempDir(),
+		}
+
+		if livePhoto := generator.detectLivePhoto(meta, format.JPEG, nil); livePhoto != nil {
+			t.Fatalf("expected no live photo, got %+v", livePhoto)
+		}
+		if entries, _ := os.ReadDir(meta.ThumbFileAbsDir); len(entries) != 0 {
+			t.Fatalf("expected no files extracted, got %d", len(entries))
+		}
+	})
+}
+
+func TestVideoThumbsGenerator_Integration_LivePhotoPreview(t *testing.T) {
+	skipWithoutFFmpeg(t)
+
+	origDir := t.TempDir()
+	runFFmpeg(t,
+		"-f", "lavfi", "-i", "testsrc=duration=2:size=320x240:rate=15",
+		"-pix_fmt", "yuv420p",
+		filepath.Join(origDir, "IMG_0001.MOV"),
+	)
+	writeOriginal(t, origDir, "IMG_0001.HEIC", nil)
+
+	generator := mkVideoGenerator(t)
+	for _, mode := range []models.LivePhotoVideoMode{
+		models.LivePhotoVideoStill,
+		models.LivePhotoVideoPreview,
+	} {
+		t.Run(string(mode), func(t *testing.T) {
+			meta := ThumbnailMeta{
+				OrigFilesRootDir: origDir,
+				OrigFileRelPath:  "IMG_0001.MOV",
+				ThumbFileAbsDir:  t.TempDir(),
+				ThumbWidths:      []int{160},
+				LivePhotoVideo:   mode,
+				LivePhotoPhoto:   FindLivePhotoPhoto(origDir, "IMG_0001.MOV"),
+				VideoPreview: models.VideoPreviewOptions{
+					Duration: 3 * time.Second,
+					FPS:      10,
+					Width:    160,
+					Segments: 3,
+				},
+			}
+
+			result, err := generator.Generate(context.Background(), meta)
+			if err != nil {
+				t.Fatalf("generate failed: %v", err)
+			}
+
+			if result.LivePhoto == nil ||
+				result.LivePhoto.Role != models.LivePhotoRoleVideo ||
+				result.LivePhoto.PairedFilePath != "IMG_0001.HEIC" {
+				t.Fatalf("unexpected live photo: %+v", result.LivePhoto)
+			}
+
+			for _, fileName := range result.FileNames() {
+				if !strings.HasPrefix(fileName, "IMG_0001"+LivePhotoVideoSuffix) {
+					t.Errorf("file %s might overwrite files of the photo", fileName)
+				}
+			}
+
+			switch mode {
+			case models.LivePhotoVideoStill:
+				if len(result.Thumbs) != 1 || result.Preview != nil {
+					t.Fatalf("expected a still thumbnail only, got %+v", result)
+				}
+			case models.LivePhotoVideoPreview:
+				if len(result.Thumbs) != 0 || result.Preview == nil {
+					t.Fatalf("expected a preview clip only, got %+v", result)
+				}
+				if result.Preview.Format != models.VideoPreviewWebp {
+					t.Errorf("preview format = %s, want webp", result.Preview.Format)
+				}
+				if result.OrigWidth != 320 || result.OrigHeight != 240 {
+					t.Errorf("orig dimensions = %dx%d, want 320x240", result.OrigWidth, result.OrigHeight)
+				}
+			}
+		})
+	}
+}
+
+// mkXmpJpeg encodes a small JPEG carrying xmp (if any) in an APP1 segment
+func mkXmpJpeg(t *testing.T, xmp string) []byte {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, image.NewGray(image.Rect(0, 0, 16, 16)), nil); err != nil {
+		t.Fatalf("failed to encode JPEG: %v", err)
+	}
+	if xmp == "" {
+		return encoded.Bytes()
+	}
+
+	segment := append([]byte("http://ns.adobe.com/xap/1.0/\x00"), xmp...)
+	app1 := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(segment)+2))
+
+	jpegBytes := append([]byte{0xFF, 0xD8}, app1...)
+	jpegBytes = append(jpegBytes, segment...)
+	return append(jpegBytes, encoded.Bytes()[2:]...)
+}
+
+func mp4Box(boxType string, payload []byte) []byte {
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(payload)))
+	return append(append(box, boxType...), payload...)
+}
+
+func writeOriginal(t *testing.T, origDir string, fileName string, data []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(origDir, fileName), data, 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", fileName, err)
+	}
+}
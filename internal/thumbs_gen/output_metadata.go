@@ -0,0 +1,411 @@
+package thumbsgen
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+)
+
+// outputMetadata lists the only metadata written into thumbnails. Any
+// other metadata carried by encoded images (EXIF, XMP, text chunks,
+// color profiles) is stripped.
+type outputMetadata struct {
+
+	// ICC profile to embed, if any
+	ICCProfile []byte
+
+	// TIFF structured EXIF data to embed, if any
+	Exif []byte
+}
+
+var errInvalidContainer = errors.New("invalid image container")
+
+// rewriteMetadata replaces metadata of an encoded image with the one in
+// meta. Images in formats without metadata support here (e.g. AVIF,
+// whose encoder only writes the profile of the decoded image) are
+// returned as they are.
+func rewriteMetadata(
+	encoded []byte,
+	extension string,
+	meta outputMetadata,
+) ([]byte, error) {
+	var rewritten []byte
+	var err error
+
+	switch strings.ToLower(extension) {
+	case ".jpg", ".jpeg":
+		rewritten, err = rewriteJpegMetadata(encoded, meta)
+	case ".png":
+		rewritten, err = rewritePngMetadata(encoded, meta)
+	case ".webp":
+		rewritten, err = rewriteWebpMetadata(encoded, meta)
+	default:
+		return encoded, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to rewrite %s metadata: %w",
+			extension,
+			err,
+		)
+	}
+	return rewritten, nil
+}
+
+// JPEG segments holding an ICC profile chunk start with this header,
+// followed by chunk sequence number and chunks count
+var jpegICCHeader = []byte("ICC_PROFILE\x00")
+
+const (
+	jpegAPP0  = 0xE0
+	jpegAPP1  = 0xE1
+	jpegAPP2  = 0xE2
+	jpegAPP14 = 0xEE
+	jpegAPP15 = 0xEF
+	jpegCOM   = 0xFE
+	jpegSOS   = 0xDA
+
+	// Max payload of a JPEG segment, excluding its length field
+	jpegMaxSegment = 65533
+)
+
+// rewriteJpegMetadata drops APPn segments other than JFIF (APP0) and
+// Adobe (APP14, needed to interpret colors) along with comments, then
+// inserts EXIF and ICC segments after JFIF.
+func rewriteJpegMetadata(data []byte, meta outputMetadata) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errInvalidContainer
+	}
+
+	var leading, kept [][]byte
+	pos := 2
+	for {
+		if pos+4 > len(data) || data[pos] != 0xFF {
+			return nil, errInvalidContainer
+		}
+
+		marker := data[pos+1]
+		if marker == jpegSOS {
+			break
+		}
+
+		segmentEnd := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if segmentEnd > len(data) {
+			return nil, errInvalidContainer
+		}
+		segment := data[pos:segmentEnd]
+		pos = segmentEnd
+
+		switch {
+		case marker == jpegAPP0 && len(kept) == 0:
+			leading = append(leading, segment)
+		case marker == jpegAPP14:
+			kept = append(kept, segment)
+		case marker >= jpegAPP0 && marker <= jpegAPP15, marker == jpegCOM:
+			continue
+		default:
+			kept = append(kept, segment)
+		}
+	}
+
+	rewritten := append([]byte{}, data[:2]...)
+	for _, segment := range leading {
+		rewritten = append(rewritten, segment...)
+	}
+
+	if len(meta.Exif) > 0 {
+		payload := append([]byte("Exif\x00\x00"), meta.Exif...)
+		if len(payload) > jpegMaxSegment {
+			return nil, errors.New("EXIF data exceeds JPEG segment size")
+		}
+		rewritten = appendJpegSegment(rewritten, jpegAPP1, payload)
+	}
+
+	if len(meta.ICCProfile) > 0 {
+		chunkSize := jpegMaxSegment - len(jpegICCHeader) - 2
+		chunksCount := (len(meta.ICCProfile) + chunkSize - 1) / chunkSize
+		if chunksCount > 255 {
+			return nil, errors.New("ICC profile exceeds JPEG limits")
+		}
+
+		for idx := range chunksCount {
+			chunk := meta.ICCProfile[idx*chunkSize : min((idx+1)*chunkSize, len(meta.ICCProfile))]
+			payload := append([]byte{}, jpegICCHeader...)
+			payload = append(payload, byte(idx+1), byte(chunksCount))
+			rewritten = appendJpegSegment(rewritten, jpegAPP2, append(payload, chunk...))
+		}
+	}
+
+	for _, segment := range kept {
+		rewritten = append(rewritten, segment...)
+	}
+	return append(rewritten, data[pos:]...), nil
+}
+
+// replaceJpegExif swaps EXIF segments of a JPEG for one holding exif,
+// inserted after JFIF. Other segments (e.g. ICC profile) are kept.
+func replaceJpegExif(data []byte, exif []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errInvalidContainer
+	}
+
+	payload := append([]byte("Exif\x00\x00"), exif...)
+	if len(payload) > jpegMaxSegment {
+		return nil, errors.New("EXIF data exceeds JPEG segment size")
+	}
+
+	var leading, kept [][]byte
+	pos := 2
+	for {
+		if pos+4 > len(data) || data[pos] != 0xFF {
+			return nil, errInvalidContainer
+		}
+
+		marker := data[pos+1]
+		if marker == jpegSOS {
+			break
+		}
+
+		segmentEnd := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if segmentEnd > len(data) {
+			return nil, errInvalidContainer
+		}
+		segment := data[pos:segmentEnd]
+		pos = segmentEnd
+
+		switch {
+		case marker == jpegAPP0 && len(kept) == 0:
+			leading = append(leading, segment)
+		case marker == jpegAPP1 && bytes.HasPrefix(segment[4:], []byte("Exif\x00\x00")):
+			continue
+		default:
+			kept = append(kept, segment)
+		}
+	}
+
+	replaced := append([]byte{}, data[:2]...)
+	for _, segment := range leading {
+		replaced = append(replaced, segment...)
+	}
+
+	replaced = appendJpegSegment(replaced, jpegAPP1, payload)
+	for _, segment := range kept {
+		replaced = append(replaced, segment...)
+	}
+	return append(replaced, data[pos:]...), nil
+}
+
+func appendJpegSegment(data []byte, marker byte, payload []byte) []byte {
+	data = append(data, 0xFF, marker)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(payload)+2))
+	return append(data, payload...)
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// PNG chunks describing colors or holding metadata, dropped from
+// thumbnails
+var pngMetadataChunks = map[string]bool{
+	"iCCP": true,
+	"sRGB": true,
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// rewritePngMetadata drops metadata chunks and inserts ICC ('iCCP') and
+// EXIF ('eXIf') chunks right after the header.
+func rewritePngMetadata(data []byte, meta outputMetadata) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errInvalidContainer
+	}
+
+	rewritten := append([]byte{}, pngSignature...)
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+12 > len(data) {
+			return nil, errInvalidContainer
+		}
+
+		chunkEnd := pos + 12 + int(binary.BigEndian.Uint32(data[pos:]))
+		if chunkEnd > len(data) || chunkEnd < pos {
+			return nil, errInvalidContainer
+		}
+
+		chunkType := string(data[pos+4 : pos+8])
+		if !pngMetadataChunks[chunkType] {
+			rewritten = append(rewritten, data[pos:chunkEnd]...)
+		}
+		pos = chunkEnd
+
+		if chunkType != "IHDR" {
+			continue
+		}
+
+		if len(meta.ICCProfile) > 0 {
+			var compressed bytes.Buffer
+			writer := zlib.NewWriter(&compressed)
+			writer.Write(meta.ICCProfile)
+			writer.Close()
+
+			// Profile name, null separator and compression method
+			payload := append([]byte("ICC profile\x00\x00"), compressed.Bytes()...)
+			rewritten = appendPngChunk(rewritten, "iCCP", payload)
+		}
+		if len(meta.Exif) > 0 {
+			rewritten = appendPngChunk(rewritten, "eXIf", meta.Exif)
+		}
+	}
+
+	return rewritten, nil
+}
+
+func appendPngChunk(data []byte, chunkType string, payload []byte) []byte {
+	data = binary.BigEndian.AppendUint32(data, uint32(len(payload)))
+	data = append(data, chunkType...)
+	data = append(data, payload...)
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(payload)
+	return binary.BigEndian.AppendUint32(data, crc.Sum32())
+}
+
+// VP8X flags of extended WebP files
+const (
+	webpFlagICC  = 0x20
+	webpFlagExif = 0x08
+	webpFlagXmp  = 0x04
+)
+
+// rewriteWebpMetadata drops 'ICCP', 'EXIF' and 'XMP ' chunks and adds
+// the ones in meta. Simple WebP files are converted to the extended
+// format ('VP8X') when metadata is added.
+func rewriteWebpMetadata(data []byte, meta outputMetadata) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errInvalidContainer
+	}
+
+	var vp8x []byte
+	var imageChunks [][]byte
+	pos := 12
+	for pos+8 <= len(data) {
+		chunkSize := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		chunkEnd := pos + 8 + chunkSize + chunkSize%2
+		if chunkEnd > len(data) || chunkEnd < pos {
+			return nil, errInvalidContainer
+		}
+
+		switch chunkType := string(data[pos : pos+4]); chunkType {
+		case "VP8X":
+			vp8x = append([]byte{}, data[pos+8:pos+8+chunkSize]...)
+		case "ICCP", "EXIF", "XMP ":
+		default:
+			imageChunks = append(imageChunks, data[pos:chunkEnd])
+		}
+		pos = chunkEnd
+	}
+
+	if vp8x == nil && len(meta.ICCProfile) == 0 && len(meta.Exif) == 0 {
+		return data[:pos], nil
+	}
+
+	if vp8x == nil {
+		var err error
+		if vp8x, err = newVP8X(imageChunks); err != nil {
+			return nil, err
+		}
+	}
+	if len(vp8x) < 10 {
+		return nil, errInvalidContainer
+	}
+
+	vp8x[0] &^= webpFlagICC | webpFlagExif | webpFlagXmp
+	if len(meta.ICCProfile) > 0 {
+		vp8x[0] |= webpFlagICC
+	}
+	if len(meta.Exif) > 0 {
+		vp8x[0] |= webpFlagExif
+	}
+
+	// Chunks order is fixed: VP8X, ICCP, image (and animation), EXIF
+	rewritten := []byte("RIFF\x00\x00\x00\x00WEBP")
+	rewritten = appendWebpChunk(rewritten, "VP8X", vp8x)
+	if len(meta.ICCProfile) > 0 {
+		rewritten = appendWebpChunk(rewritten, "ICCP", meta.ICCProfile)
+	}
+	for _, chunk := range imageChunks {
+		rewritten = append(rewritten, chunk...)
+	}
+	if len(meta.Exif) > 0 {
+		rewritten = appendWebpChunk(rewritten, "EXIF", meta.Exif)
+	}
+
+	binary.LittleEndian.PutUint32(rewritten[4:8], uint32(len(rewritten)-8))
+	return rewritten, nil
+}
+
+// newVP8X builds the extended header of a simple (single 'VP8 ' or
+// 'VP8L' chunk) WebP file from its bitstream header
+func newVP8X(imageChunks [][]byte) ([]byte, error) {
+	if len(imageChunks) != 1 || len(imageChunks[0]) < 18 {
+		return nil, errInvalidContainer
+	}
+
+	chunk := imageChunks[0]
+	bitstream := chunk[8:]
+
+	var width, height uint32
+	var flags byte
+	switch string(chunk[0:4]) {
+	case "VP8 ":
+		// Frame tag and start code precede 14 bits dimensions
+		if len(bitstream) < 10 || !bytes.Equal(bitstream[3:6], []byte{0x9D, 0x01, 0x2A}) {
+			return nil, errInvalidContainer
+		}
+		width = uint32(binary.LittleEndian.Uint16(bitstream[6:8]) & 0x3FFF)
+		height = uint32(binary.LittleEndian.Uint16(bitstream[8:10]) & 0x3FFF)
+	case "VP8L":
+		if bitstream[0] != 0x2F {
+			return nil, errInvalidContainer
+		}
+		bits := binary.LittleEndian.Uint32(bitstream[1:5])
+		width = bits&0x3FFF + 1
+		height = bits>>14&0x3FFF + 1
+		if bits>>28&1 == 1 {
+			flags |= 0x10 // alpha
+		}
+	default:
+		return nil, errInvalidContainer
+	}
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = flags
+	putUint24(vp8x[4:7], width-1)
+	putUint24(vp8x[7:10], height-1)
+	return vp8x, nil
+}
+
+func appendWebpChunk(data []byte, chunkType string, payload []byte) []byte {
+	data = append(data, chunkType...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(payload)))
+	data = append(data, payload...)
+	if len(payload)%2 == 1 {
+		data = append(data, 0)
+	}
+
+	return data
+}
+
+func putUint24(data []byte, value uint32) {
+	data[0] = byte(value)
+	data[1] = byte(value >> 8)
+	data[2] = byte(value >> 16)
+}
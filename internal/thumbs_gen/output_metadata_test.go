@@ -0,0 +1,165 @@
+package thumbsgen
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/discord/lilliput"
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/metadata"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+func TestRewriteMetadata_StripsEverything(t *testing.T) {
+	tests := []struct {
+		name      string
+		filename  string
+		extension string
+		format    format.Format
+	}{
+		{name: "jpeg with exif, xmp and icc", filename: "2 museum.jpeg", extension: ".jpg", format: format.JPEG},
+		{name: "simple webp", filename: "7 flower.webp", extension: ".webp", format: format.WEBP},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			original := readTestFile(t, tc.filename)
+
+			stripped, err := rewriteMetadata(original, tc.extension, outputMetadata{})
+			if err != nil {
+				t.Fatalf("failed to rewrite metadata: %v", err)
+			}
+
+			if meta := extractFromBytes(t, stripped, tc.format); meta != nil &&
+				(meta.CaptureTime != "" || meta.Location != nil) {
+				t.Errorf("expected no metadata, got %+v", meta)
+			}
+			if icc := decodedICC(t, stripped); len(icc) != 0 {
+				t.Errorf("expected no ICC profile, got %d bytes", len(icc))
+			}
+			if _, _, err := image.Decode(bytes.NewReader(stripped)); err != nil {
+				t.Errorf("stripped image doesn't decode: %v", err)
+			}
+		})
+	}
+}
+
+func TestRewriteMetadata_EmbedsProfileAndExif(t *testing.T) {
+	profile, err := metadata.HeifColorProfile(testutils.TestFilePath("4 thai_no_edits.heic"))
+	if err != nil {
+		t.Fatalf("failed to read color profile: %v", err)
+	}
+
+	exif := metadata.EncodeExif(
+		&models.MediaMetadata{CaptureTime: "2025-11-13T12:36:24+07:00"},
+		[]models.MetadataField{models.MetadataCaptureTime},
+	)
+
+	pngBytes, err := encodePNG(image.NewNRGBA(image.Rect(0, 0, 8, 4)))
+	if err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		original  []byte
+		extension string
+		format    format.Format
+	}{
+		{name: "jpeg", original: readTestFile(t, "1 house.jpg"), extension: ".jpg", format: format.JPEG},
+		{name: "webp", original: readTestFile(t, "7 flower.webp"), extension: ".webp", format: format.WEBP},
+		{name: "png", original: pngBytes, extension: ".png"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rewritten, err := rewriteMetadata(
+				tc.original,
+				tc.extension,
+				outputMetadata{ICCProfile: profile, Exif: exif},
+			)
+			if err != nil {
+				t.Fatalf("failed to rewrite metadata: %v", err)
+			}
+
+			if !bytes.Equal(decodedICC(t, rewritten), profile) {
+				t.Error("embedded ICC profile doesn't match")
+			}
+
+			origConfig, _, _ := image.DecodeConfig(bytes.NewReader(tc.original))
+			config, _, err := image.DecodeConfig(bytes.NewReader(rewritten))
+			if err != nil || config.Width != origConfig.Width || config.Height != origConfig.Height {
+				t.Errorf("rewritten image doesn't decode as original: %v", err)
+			}
+
+			// PNG EXIF is not read by metadata extractor
+			if tc.format == "" {
+				return
+			}
+			meta := extractFromBytes(t, rewritten, tc.format)
+			if meta == nil || meta.CaptureTime != "2025-11-13T12:36:24+07:00" {
+				t.Errorf("expected kept capture time, got %+v", meta)
+			}
+			if meta != nil && meta.CameraModel != "" {
+				t.Errorf("expected original camera to be stripped, got %q", meta.CameraModel)
+			}
+		})
+	}
+}
+
+func TestRewriteMetadata_InvalidContainer(t *testing.T) {
+	for _, extension := range []string{".jpg", ".png", ".webp"} {
+		t.Run(extension, func(t *testing.T) {
+			if _, err := rewriteMetadata([]byte("garbage"), extension, outputMetadata{}); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func readTestFile(t *testing.T, filename string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(testutils.TestFilePath(filename))
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+
+	return data
+}
+
+func decodedICC(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	decoder, err := lilliput.NewDecoder(data)
+	if err != nil {
+		t.Fatalf("failed to decode image: %v", err)
+	}
+	defer decoder.Close()
+
+	return decoder.ICC()
+}
+
+func extractFromBytes(
+	t *testing.T,
+	data []byte,
+	fileFormat format.Format,
+) *models.MediaMetadata {
+	t.Helper()
+
+	filePath := filepath.Join(t.TempDir(), "image")
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+
+	meta, err := metadata.NewExtractor().Extract(filePath, fileFormat)
+	if err != nil {
+		t.Fatalf("failed to extract metadata: %v", err)
+	}
+
+	return meta
+}
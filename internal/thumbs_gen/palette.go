@@ -0,0 +1,184 @@
+package thumbsgen
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"slices"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// Max side of the pixel grid sampled for palette extraction
+const paletteSampleSide = 64
+
+// Pixels with lower alpha barely contribute to how the image looks
+const paletteMinAlpha = 0x80
+
+// colorBox is a set of pixels in median cut quantization
+type colorBox struct {
+	pixels [][3]uint8
+}
+
+// computeColors extracts colors of the analysis image of original.
+// Returns nil when meta disables palette extraction.
+func (g *ImageThumbsGenerator) computeColors(
+	meta ThumbnailMeta,
+	analysisImage func() (image.Image, error),
+) (*models.ColorInfo, error) {
+	if meta.PaletteSize <= 0 {
+		return nil, nil
+	}
+
+	analysisImg, err := analysisImage()
+	if err != nil {
+		return nil, err
+	}
+
+	return extractColors(analysisImg, meta.PaletteSize), nil
+}
+
+// extractColors computes the dominant color and a palette of up to
+// paletteSize colors of img through median cut quantization over a
+// sampled grid of its pixels. Returns nil when img has no opaque pixels.
+func extractColors(img image.Image, paletteSize int) *models.ColorInfo {
+	pixels := samplePixels(img)
+	if len(pixels) == 0 || paletteSize <= 0 {
+		return nil
+	}
+
+	boxes := []colorBox{{pixels: pixels}}
+	for len(boxes) < paletteSize {
+
+		// Split the box with widest channel range among splittable ones
+		splitIdx, widestRange := -1, 0
+		for idx, box := range boxes {
+			if len(box.pixels) < 2 {
+				continue
+			}
+
+			if _, channelRange := box.widestChannel(); channelRange > widestRange {
+				splitIdx, widestRange = idx, channelRange
+			}
+		}
+		if splitIdx < 0 {
+			break
+		}
+
+		lower, upper := boxes[splitIdx].split()
+		boxes[splitIdx] = lower
+		boxes = append(boxes, upper)
+	}
+
+	palette := make([]models.PaletteColor, 0, len(boxes))
+	for _, box := range boxes {
+		palette = append(palette, models.PaletteColor{
+			Hex:    box.averageHex(),
+			Weight: roundWeight(float64(len(box.pixels)) / float64(len(pixels))),
+		})
+	}
+	slices.SortStableFunc(palette, func(a, b models.PaletteColor) int {
+		switch {
+		case a.Weight > b.Weight:
+			return -1
+		case a.Weight < b.Weight:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return &models.ColorInfo{
+		Dominant: palette[0].Hex,
+		Palette:  palette,
+	}
+}
+
+// samplePixels returns the colors of a grid of at most
+// paletteSampleSide x paletteSampleSide pixels of img, skipping mostly
+// transparent ones.
+func samplePixels(img image.Image) [][3]uint8 {
+	bounds := img.Bounds()
+	step := max(
+		1,
+		int(math.Ceil(float64(max(bounds.Dx(), bounds.Dy()))/paletteSampleSide)),
+	)
+
+	var pixels [][3]uint8
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			if _, _, _, a := img.At(x, y).RGBA(); a>>8 < paletteMinAlpha {
+				continue
+			}
+
+			r, g, b := rgb8(img, x, y)
+			pixels = append(pixels, [3]uint8{uint8(r), uint8(g), uint8(b)})
+		}
+	}
+
+	return pixels
+}
+
+// widestChannel returns index and value range of the channel with the
+// widest range of values in box.
+func (box colorBox) widestChannel() (int, int) {
+	widestIdx, widestRange := 0, -1
+	for channel := range 3 {
+		minValue, maxValue := uint8(255), uint8(0)
+		for _, pixel := range box.pixels {
+			minValue = min(minValue, pixel[channel])
+			maxValue = max(maxValue, pixel[channel])
+		}
+
+		if channelRange := int(maxValue) - int(minValue); channelRange > widestRange {
+			widestIdx, widestRange = channel, channelRange
+		}
+	}
+
+	return widestIdx, widestRange
+}
+
+// split divides box at the median value of its widest channel. Pixels
+// sharing the median value stay together so that a color is never
+// split across boxes.
+func (box colorBox) split() (colorBox, colorBox) {
+	channel, _ := box.widestChannel()
+	slices.SortFunc(box.pixels, func(a, b [3]uint8) int {
+		return int(a[channel]) - int(b[channel])
+	})
+
+	medianValue := box.pixels[len(box.pixels)/2][channel]
+	cut := slices.IndexFunc(box.pixels, func(pixel [3]uint8) bool {
+		return pixel[channel] >= medianValue
+	})
+	if cut == 0 {
+		cut = slices.IndexFunc(box.pixels, func(pixel [3]uint8) bool {
+			return pixel[channel] > medianValue
+		})
+	}
+
+	return colorBox{pixels: box.pixels[:cut]},
+		colorBox{pixels: box.pixels[cut:]}
+}
+
+func (box colorBox) averageHex() string {
+	var sums [3]int
+	for _, pixel := range box.pixels {
+		sums[0] += int(pixel[0])
+		sums[1] += int(pixel[1])
+		sums[2] += int(pixel[2])
+	}
+
+	count := len(box.pixels)
+	return fmt.Sprintf(
+		"#%02x%02x%02x",
+		(sums[0]+count/2)/count,
+		(sums[1]+count/2)/count,
+		(sums[2]+count/2)/count,
+	)
+}
+
+// roundWeight keeps manifests readable by rounding weights to 3 decimals
+func roundWeight(weight float64) float64 {
+	return math.Round(weight*1000) / 1000
+}
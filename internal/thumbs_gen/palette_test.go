@@ -0,0 +1,51 @@
+package thumbsgen
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestExtractColors_TwoColors(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	for y := range 40 {
+		for x := range 40 {
+			pixelColor := color.RGBA{R: 255, A: 255}
+			if x >= 30 {
+				pixelColor = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, pixelColor)
+		}
+	}
+
+	colors := extractColors(img, 4)
+	if colors == nil {
+		t.Fatal("expected colors")
+	}
+
+	if colors.Dominant != "#ff0000" {
+		t.Fatalf("unexpected dominant color: got %q want %q", colors.Dominant, "#ff0000")
+	}
+
+	// Uniform boxes can't be split further
+	if len(colors.Palette) != 2 {
+		t.Fatalf("unexpected palette size: got %d want 2 (%+v)", len(colors.Palette), colors.Palette)
+	}
+	if colors.Palette[0].Weight != 0.75 || colors.Palette[1].Hex != "#0000ff" {
+		t.Fatalf("unexpected palette: %+v", colors.Palette)
+	}
+}
+
+func TestExtractColors_SkipsTransparentPixels(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	img.Set(5, 5, color.NRGBA{G: 255, A: 255})
+
+	colors := extractColors(img, 3)
+	if colors == nil || colors.Dominant != "#00ff00" || len(colors.Palette) != 1 {
+		t.Fatalf("unexpected colors: %+v", colors)
+	}
+
+	if got := extractColors(image.NewNRGBA(image.Rect(0, 0, 10, 10)), 3); got != nil {
+		t.Fatalf("expected no colors for transparent image, got %+v", got)
+	}
+}
@@ -0,0 +1,83 @@
+package thumbsgen
+
+import (
+	"fmt"
+	"image"
+)
+
+// dHash compares brightness of adjacent cells in a 9x8 grid, producing
+// 8 bits per row
+const (
+	dHashGridWidth  = 9
+	dHashGridHeight = 8
+)
+
+// computeDHash computes the difference hash of the analysis image of
+// original. Returns empty hash when meta disables perceptual hashing.
+func (g *ImageThumbsGenerator) computeDHash(
+	meta ThumbnailMeta,
+	analysisImage func() (image.Image, error),
+) (string, error) {
+	if !meta.PerceptualHash {
+		return "", nil
+	}
+
+	analysisImg, err := analysisImage()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%016x", dHash(analysisImg)), nil
+}
+
+// dHash computes the 64 bits difference hash of img: image is reduced to
+// a 9x8 grid of average luminance and each bit tells whether a cell is
+// brighter than its right neighbor. Similar images get hashes with small
+// Hamming distance.
+func dHash(img image.Image) uint64 {
+	grid := luminanceGrid(img, dHashGridWidth, dHashGridHeight)
+
+	var hash uint64
+	for y := range dHashGridHeight {
+		for x := range dHashGridWidth - 1 {
+			hash <<= 1
+			if grid[y][x] > grid[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+// luminanceGrid averages luminance of img over a grid of given size
+func luminanceGrid(img image.Image, width int, height int) [][]float64 {
+	bounds := img.Bounds()
+	sums := make([][]float64, height)
+	counts := make([][]int, height)
+	for y := range height {
+		sums[y] = make([]float64, width)
+		counts[y] = make([]int, width)
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		cellY := (y - bounds.Min.Y) * height / bounds.Dy()
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			cellX := (x - bounds.Min.X) * width / bounds.Dx()
+
+			r, g, b := rgb8(img, x, y)
+			sums[cellY][cellX] += 0.299*r + 0.587*g + 0.114*b
+			counts[cellY][cellX]++
+		}
+	}
+
+	for y := range height {
+		for x := range width {
+			if counts[y][x] > 0 {
+				sums[y][x] /= float64(counts[y][x])
+			}
+		}
+	}
+
+	return sums
+}
@@ -0,0 +1,52 @@
+package thumbsgen
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/bits"
+	"testing"
+)
+
+func TestDHash_Gradients(t *testing.T) {
+	brighterToRight := mkGradient(90, 80, func(x, y int) uint8 { return uint8(x * 2) })
+	if got := dHash(brighterToRight); got != 0 {
+		t.Fatalf("unexpected hash for left-to-right gradient: %016x", got)
+	}
+
+	darkerToRight := mkGradient(90, 80, func(x, y int) uint8 { return uint8(255 - x*2) })
+	if got := dHash(darkerToRight); got != ^uint64(0) {
+		t.Fatalf("unexpected hash for right-to-left gradient: %016x", got)
+	}
+}
+
+func TestDHash_SimilarImagesAreClose(t *testing.T) {
+	pattern := func(x, y int) uint8 {
+		return uint8(120 + 100*math.Sin(float64(x)/20)*math.Cos(float64(y)/15))
+	}
+
+	original := mkGradient(180, 160, pattern)
+	downscaled := mkGradient(90, 80, func(x, y int) uint8 { return pattern(x*2, y*2) })
+	brighter := mkGradient(180, 160, func(x, y int) uint8 {
+		return uint8(min(255, int(pattern(x, y))+10))
+	})
+
+	originalHash := dHash(original)
+	if distance := bits.OnesCount64(originalHash ^ dHash(downscaled)); distance > 10 {
+		t.Fatalf("downscaled copy too far from original: distance %d", distance)
+	}
+	if distance := bits.OnesCount64(originalHash ^ dHash(brighter)); distance > 10 {
+		t.Fatalf("brighter copy too far from original: distance %d", distance)
+	}
+}
+
+func mkGradient(width int, height int, value func(x, y int) uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.SetGray(x, y, color.Gray{Y: value(x, y)})
+		}
+	}
+
+	return img
+}
@@ -0,0 +1,88 @@
+package thumbsgen
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// ThumbHash algorithm works on images up to 100x100 pixels
+const thumbHashMaxSide = 100
+
+// computePlaceholders computes placeholders of kinds listed in meta from
+// the analysis image of original. Returns nil when meta lists none.
+func (g *ImageThumbsGenerator) computePlaceholders(
+	meta ThumbnailMeta,
+	analysisImage func() (image.Image, error),
+) (*models.Placeholders, error) {
+	if len(meta.Placeholders) == 0 {
+		return nil, nil
+	}
+
+	analysisImg, err := analysisImage()
+	if err != nil {
+		return nil, err
+	}
+
+	placeholders := new(models.Placeholders)
+	for _, kind := range meta.Placeholders {
+		switch kind {
+		case models.PlaceholderBlurHash:
+			placeholders.BlurHash = encodeBlurHash(
+				analysisImg,
+				blurHashComponentsX,
+				blurHashComponentsY,
+			)
+		case models.PlaceholderThumbHash:
+			placeholders.ThumbHash, err = g.thumbHash(analysisImg)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return placeholders, nil
+}
+
+// thumbHash computes the ThumbHash (https://evanw.github.io/thumbhash)
+// of img through lilliput, encoded as base64.
+func (g *ImageThumbsGenerator) thumbHash(img image.Image) (string, error) {
+	imgPng, err := encodePNG(img)
+	if err != nil {
+		return "", err
+	}
+
+	decoder, err := g.decode(imgPng)
+	if err != nil {
+		return "", err
+	}
+	defer decoder.Close()
+
+	imgDimensions := &ImgDimensions{
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+	imgOps, releaseImgOps := g.imageOpsFor(imgDimensions)
+	defer releaseImgOps()
+
+	// Fit image in the max side keeping its aspect ratio
+	scale := float64(thumbHashMaxSide) /
+		float64(max(imgDimensions.Width, imgDimensions.Height))
+	width := max(1, int(float64(imgDimensions.Width)*min(scale, 1)))
+	height := max(1, int(float64(imgDimensions.Height)*min(scale, 1)))
+
+	hashBytes, err := g.transform(
+		imgOps,
+		decoder,
+		width,
+		height,
+		thumbEncoding{Extension: ".thumbhash"},
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute thumbhash: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(hashBytes), nil
+}
@@ -0,0 +1,54 @@
+package thumbsgen
+
+// expandPresets returns one meta per preset in given meta, with preset
+// options in place of meta ones. Meta is returned as is when it has no
+// presets.
+func expandPresets(meta ThumbnailMeta) []ThumbnailMeta {
+	if len(meta.Presets) == 0 {
+		return []ThumbnailMeta{meta}
+	}
+
+	presetMetas := make([]ThumbnailMeta, 0, len(meta.Presets))
+	for _, preset := range meta.Presets {
+		presetMeta := meta
+		presetMeta.Presets = nil
+		presetMeta.PresetName = preset.Name
+		presetMeta.ThumbWidths = preset.Widths
+		presetMeta.Resize = preset.Resize
+		presetMeta.ThumbFormats = preset.Formats
+		presetMeta.ThumbQuality = preset.Quality
+		presetMeta.ThumbNameSuffix = preset.Suffix
+
+		presetMetas = append(presetMetas, presetMeta)
+	}
+
+	return presetMetas
+}
+
+// thumbWidths returns target widths to produce for meta. Box based
+// resize specs produce a single thumbnail as wide as their box.
+func thumbWidths(meta ThumbnailMeta) []int {
+	if meta.Resize.IsBoxed() {
+		return []int{meta.Resize.BoxWidth}
+	}
+
+	return meta.ThumbWidths
+}
+
+// thumbFormats returns extensions of formats to produce for meta
+func thumbFormats(meta ThumbnailMeta) []string {
+	if len(meta.ThumbFormats) == 0 {
+		return []string{ThumbsExtension}
+	}
+
+	return meta.ThumbFormats
+}
+
+// thumbQuality returns encoding quality to use for meta
+func thumbQuality(meta ThumbnailMeta) int {
+	if meta.ThumbQuality <= 0 {
+		return ThumbsQuality
+	}
+
+	return min(meta.ThumbQuality, 100)
+}
@@ -0,0 +1,80 @@
+package thumbsgen
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+func TestExpandPresets_WithoutPresets(t *testing.T) {
+	meta := ThumbnailMeta{ThumbWidths: []int{256}}
+
+	got := expandPresets(meta)
+	if len(got) != 1 || !reflect.DeepEqual(got[0], meta) {
+		t.Fatalf("expandPresets() = %+v, want meta as is", got)
+	}
+	if formats := thumbFormats(got[0]); !reflect.DeepEqual(formats, []string{ThumbsExtension}) {
+		t.Fatalf("thumbFormats() = %v, want [%s]", formats, ThumbsExtension)
+	}
+	if quality := thumbQuality(got[0]); quality != ThumbsQuality {
+		t.Fatalf("thumbQuality() = %d, want %d", quality, ThumbsQuality)
+	}
+}
+
+func TestExpandPresets_AppliesPresetOptions(t *testing.T) {
+	meta := ThumbnailMeta{
+		OrigFileRelPath: "sample.jpg",
+		UpscalePolicy:   models.UpscaleSkip,
+		Presets: []models.ThumbPreset{
+			{
+				Name:    "grid",
+				Widths:  []int{160, 320},
+				Resize:  models.ResizeSpec{Mode: models.ResizeCover, BoxWidth: 160, BoxHeight: 160},
+				Formats: []string{".webp", ".avif"},
+				Quality: 70,
+				Suffix:  "grid",
+			},
+			{
+				Name:    "og-image",
+				Widths:  []int{1200},
+				Formats: []string{".jpg"},
+				Quality: 90,
+				Suffix:  "og",
+			},
+		},
+	}
+
+	got := expandPresets(meta)
+	if len(got) != 2 {
+		t.Fatalf("expandPresets() returned %d metas, want 2", len(got))
+	}
+
+	grid := got[0]
+	if grid.PresetName != "grid" || grid.ThumbNameSuffix != "grid" {
+		t.Fatalf("grid meta = %+v, want grid name and suffix", grid)
+	}
+	if !reflect.DeepEqual(grid.ThumbWidths, []int{160, 320}) {
+		t.Fatalf("grid widths = %v, want [160 320]", grid.ThumbWidths)
+	}
+	if grid.Resize.Mode != models.ResizeCover {
+		t.Fatalf("grid resize = %+v, want cover", grid.Resize)
+	}
+	if widths := thumbWidths(grid); !reflect.DeepEqual(widths, []int{160}) {
+		t.Fatalf("grid target widths = %v, want box width [160]", widths)
+	}
+	if !reflect.DeepEqual(thumbFormats(grid), []string{".webp", ".avif"}) {
+		t.Fatalf("grid formats = %v, want [.webp .avif]", thumbFormats(grid))
+	}
+	if grid.Presets != nil {
+		t.Fatalf("expanded meta still has presets: %+v", grid.Presets)
+	}
+	if grid.OrigFileRelPath != "sample.jpg" || grid.UpscalePolicy != models.UpscaleSkip {
+		t.Fatalf("grid meta lost shared options: %+v", grid)
+	}
+
+	og := got[1]
+	if og.PresetName != "og-image" || thumbQuality(og) != 90 {
+		t.Fatalf("og meta = %+v, want og-image with quality 90", og)
+	}
+}
@@ -0,0 +1,148 @@
+package thumbsgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/metadata"
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// RawThumbsGenerator produces thumbnails of camera RAW files from their
+// embedded JPEG preview, which is what cameras and viewers display.
+// RAW files without preview are demosaiced by FormatConverter.
+type RawThumbsGenerator struct {
+	formatDetector       *format.FormatDetector
+	formatConverter      *format.FormatConverter
+	imageThumbsGenerator ThumbsGenerator
+}
+
+// NewRawThumbsGenerator builds a RAW thumbnail generator with explicit
+// dependencies.
+func NewRawThumbsGenerator(
+	formatDetector *format.FormatDetector,
+	formatConverter *format.FormatConverter,
+	imageThumbsGenerator ThumbsGenerator,
+) *RawThumbsGenerator {
+	return &RawThumbsGenerator{
+		formatDetector:       formatDetector,
+		formatConverter:      formatConverter,
+		imageThumbsGenerator: imageThumbsGenerator,
+	}
+}
+
+// Generate implements ThumbsGenerator.
+func (g *RawThumbsGenerator) Generate(
+	ctx context.Context,
+	meta ThumbnailMeta,
+) (*models.ThumbGenResult, error) {
+	origFileFormat, err := g.formatDetector.Detect(mkOriginalFileAbsPath(meta))
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to detect file format for %s: %w",
+			meta.OrigFileRelPath,
+			err)
+	}
+
+	if !slices.Contains(format.RawFormats, origFileFormat) {
+		return nil, fmt.Errorf(
+			"cannot generate thumbnails: unsupported original file format: %v",
+			origFileFormat,
+		)
+	}
+
+	return g.GenerateWithoutFormatsCheck(ctx, meta, origFileFormat)
+}
+
+// GenerateWithoutFormatsCheck implements ThumbsGenerator.
+func (g *RawThumbsGenerator) GenerateWithoutFormatsCheck(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	origFileFormat format.Format,
+) (*models.ThumbGenResult, error) {
+	previewAbsPath := mkIntermediaryThumbFileAbsPath(meta, ".jpg")
+	defer func() {
+		_ = os.Remove(previewAbsPath)
+	}()
+
+	err := g.mkPreviewFile(ctx, meta, origFileFormat, previewAbsPath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to extract preview from RAW file %s: %w",
+			meta.OrigFileRelPath,
+			err,
+		)
+	}
+
+	// Replace original file info with intermediary preview file
+	previewMeta := meta
+	previewMeta.OrigFilesRootDir = meta.ThumbFileAbsDir
+	previewMeta.OrigFileRelPath = filepath.Base(previewAbsPath)
+
+	result, err := g.imageThumbsGenerator.GenerateWithoutFormatsCheck(
+		ctx,
+		previewMeta,
+		format.JPEG,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to generate RAW thumbnails from preview for %s: %w",
+			meta.OrigFileRelPath,
+			err,
+		)
+	}
+
+	return result, nil
+}
+
+// mkPreviewFile writes the largest embedded preview of the RAW original
+// at 'intoAbsPath', tagged with the RAW orientation so it is rotated
+// upright when decoded. Falls back to demosaicing the sensor data when
+// the original embeds no preview.
+func (g *RawThumbsGenerator) mkPreviewFile(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	origFileFormat format.Format,
+	intoAbsPath string,
+) error {
+	origFileAbsPath := mkOriginalFileAbsPath(meta)
+
+	preview, err := metadata.ExtractRawPreview(origFileAbsPath, origFileFormat)
+	if errors.Is(err, metadata.ErrNoRawPreview) {
+		slog.Info(
+			"RAW file embeds no preview, demosaicing it",
+			"filePath", meta.OrigFileRelPath,
+			"format", origFileFormat,
+		)
+
+		return g.formatConverter.ConvertWithoutFormatsCheck(
+			ctx,
+			origFileAbsPath,
+			origFileFormat,
+			intoAbsPath,
+			format.JPEG,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	oriented, err := replaceJpegExif(
+		preview.JPEG,
+		metadata.EncodeOrientation(preview.Orientation),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to apply preview orientation: %w", err)
+	}
+
+	if err := os.WriteFile(intoAbsPath, oriented, 0o644); err != nil {
+		return fmt.Errorf("failed to write preview file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package thumbsgen
+
+import (
+	"context"
+	"image"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/telemetry"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+	_ "golang.org/x/image/webp"
+)
+
+func TestRawThumbsGenerator_Integration_EmbeddedPreview(t *testing.T) {
+	generator := mkRawGenerator(t)
+
+	// Previews are 96x64, red on their left half and blue on their right
+	// one. Orientation decides where red ends up.
+	tests := []struct {
+		name         string
+		originalFile string
+		height       int
+		redAtTop     bool
+		redAtLeft    bool
+	}{
+		{name: "nef rotated clockwise", originalFile: "12 raw_nikon.nef", height: 72, redAtTop: true},
+		{name: "arw rotated counterclockwise", originalFile: "13 raw_sony.arw", height: 72},
+		{name: "cr2 upside down", originalFile: "14 raw_canon.cr2", height: 32},
+		{name: "dng rotated clockwise", originalFile: "15 raw_adobe.dng", height: 72, redAtTop: true},
+		{name: "cr3 rotated clockwise", originalFile: "16 raw_canon.cr3", height: 72, redAtTop: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			meta := ThumbnailMeta{
+				OrigFilesRootDir: testutils.TestFilesDir(),
+				OrigFileRelPath:  tc.originalFile,
+				ThumbFileAbsDir:  t.TempDir(),
+				ThumbWidths:      []int{48},
+			}
+
+			result, err := generator.Generate(context.Background(), meta)
+			if err != nil {
+				t.Fatalf("generate failed: %v", err)
+			}
+			if len(result.Thumbs) != 1 {
+				t.Fatalf("expected one thumbnail, got %d", len(result.Thumbs))
+			}
+
+			thumb := decodeThumb(t, mkThumbFileAbsPath(meta, 48, ThumbsExtension))
+			bounds := thumb.Bounds()
+			if bounds.Dx() != 48 || bounds.Dy() != tc.height {
+				t.Fatalf("thumbnail is %dx%d, want 48x%d", bounds.Dx(), bounds.Dy(), tc.height)
+			}
+
+			// Sample the first quarter of the side red should be on
+			var x, y int
+			if tc.height > 48 {
+				x, y = bounds.Dx()/2, bounds.Dy()/8
+				if !tc.redAtTop {
+					y = bounds.Dy() - 1 - y
+				}
+			} else {
+				x, y = bounds.Dx()/8, bounds.Dy()/2
+				if !tc.redAtLeft {
+					x = bounds.Dx() - 1 - x
+				}
+			}
+
+			r, _, b, _ := thumb.At(x, y).RGBA()
+			if r <= b {
+				t.Fatalf("expected red at (%d, %d), got r=%d b=%d", x, y, r>>8, b>>8)
+			}
+		})
+	}
+}
+
+func TestRawThumbsGenerator_Integration_Demosaic(t *testing.T) {
+	if _, err := exec.LookPath("dcraw_emu"); err != nil {
+		t.Skip("dcraw_emu not available, skipping integration test")
+	}
+
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: testutils.TestFilesDir(),
+		OrigFileRelPath:  "17 raw_no_preview.dng",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{8},
+	}
+
+	_, err := mkRawGenerator(t).GenerateWithoutFormatsCheck(
+		context.Background(),
+		meta,
+		format.DNG,
+	)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	assertThumbnailCreated(t, mkThumbFileAbsPath(meta, 8, ThumbsExtension), 8)
+}
+
+func TestRawThumbsGenerator_UnsupportedMedia(t *testing.T) {
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: testutils.TestFilesDir(),
+		OrigFileRelPath:  "1 house.jpg",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{48},
+	}
+
+	if _, err := mkRawGenerator(t).Generate(context.Background(), meta); err == nil {
+		t.Fatal("expected unsupported format error for JPEG original")
+	}
+}
+
+func mkRawGenerator(t *testing.T) *RawThumbsGenerator {
+	t.Helper()
+	t.Setenv("OTEL_ENABLED", "false")
+
+	telemetrySvc, err := telemetry.NewTelemetrySvc(context.Background())
+	if err != nil {
+		t.Fatalf("failed to init telemetry service: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = telemetrySvc.Shutdown(context.Background())
+	})
+
+	fmtDetector := format.NewFormatDetector()
+	fmtConverter := format.NewFormatConverter(telemetrySvc, fmtDetector)
+	imageGenerator := NewImageThumbsGenerator(
+		telemetrySvc,
+		fmtConverter,
+		fmtDetector,
+	)
+
+	return NewRawThumbsGenerator(fmtDetector, fmtConverter, imageGenerator)
+}
+
+func decodeThumb(t *testing.T, thumbAbsPath string) image.Image {
+	t.Helper()
+
+	fileHandle, err := os.Open(thumbAbsPath)
+	if err != nil {
+		t.Fatalf("failed to open thumbnail %s: %v", thumbAbsPath, err)
+	}
+	defer fileHandle.Close()
+
+	thumb, _, err := image.Decode(fileHandle)
+	if err != nil {
+		t.Fatalf("failed to decode thumbnail %s: %v", thumbAbsPath, err)
+	}
+	return thumb
+}
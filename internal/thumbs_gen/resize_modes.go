@@ -0,0 +1,131 @@
+package thumbsgen
+
+import (
+	"math"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// Bounds for rasters of vector originals (e.g. SVG, waveforms) when no
+// input limits are configured. Vector dimensions are unbounded, raster
+// size must not be.
+const (
+	maxVectorRasterDimension = 16384
+	maxVectorRasterPixels    = 64_000_000
+)
+
+// thumbGeometry describes how an original is transformed into a single
+// thumbnail.
+type thumbGeometry struct {
+
+	// Dimensions the original is scaled to (before cropping or padding)
+	ScaledWidth  int
+	ScaledHeight int
+
+	// Dimensions of the resulting thumbnail
+	Width  int
+	Height int
+}
+
+// computeGeometry determines scaled and final dimensions of a thumbnail
+// with given target width, according to resize spec. Box based specs
+// derive dimensions from their box, target width only shrinks it (see
+// models.ResizeSpec.Box).
+func computeGeometry(
+	orig *ImgDimensions,
+	targetWidth int,
+	spec models.ResizeSpec,
+) thumbGeometry {
+	if !spec.IsBoxed() {
+		height := max(1, orig.Height*targetWidth/orig.Width)
+		return thumbGeometry{
+			ScaledWidth:  targetWidth,
+			ScaledHeight: height,
+			Width:        targetWidth,
+			Height:       height,
+		}
+	}
+
+	boxWidth, boxHeight := spec.Box(targetWidth)
+	scaleX := float64(boxWidth) / float64(orig.Width)
+	scaleY := float64(boxHeight) / float64(orig.Height)
+
+	switch spec.Mode {
+	case models.ResizeCover:
+		scale := math.Max(scaleX, scaleY)
+		return thumbGeometry{
+			ScaledWidth:  max(boxWidth, scaledSide(orig.Width, scale)),
+			ScaledHeight: max(boxHeight, scaledSide(orig.Height, scale)),
+			Width:        boxWidth,
+			Height:       boxHeight,
+		}
+
+	case models.ResizePad:
+		scale := math.Min(scaleX, scaleY)
+		return thumbGeometry{
+			ScaledWidth:  min(boxWidth, scaledSide(orig.Width, scale)),
+			ScaledHeight: min(boxHeight, scaledSide(orig.Height, scale)),
+			Width:        boxWidth,
+			Height:       boxHeight,
+		}
+
+	default: // models.ResizeFitBox
+		scale := math.Min(scaleX, scaleY)
+		width := min(boxWidth, scaledSide(orig.Width, scale))
+		height := min(boxHeight, scaledSide(orig.Height, scale))
+		return thumbGeometry{
+			ScaledWidth:  width,
+			ScaledHeight: height,
+			Width:        width,
+			Height:       height,
+		}
+	}
+}
+
+func scaledSide(side int, scale float64) int {
+	return max(1, int(math.Round(float64(side)*scale)))
+}
+
+// vectorRasterSize returns dimensions a vector original of given
+// intrinsic size is rasterized at: the largest size thumbnails of meta
+// scale the original to, so none of them is upscaled from a smaller
+// raster. Size is bounded by input limits.
+func vectorRasterSize(meta ThumbnailMeta, intrinsicWidth float64, intrinsicHeight float64) (int, int) {
+	aspect := intrinsicWidth / intrinsicHeight
+
+	var width float64
+	for _, presetMeta := range expandPresets(meta) {
+		spec := presetMeta.Resize
+		for _, targetWidth := range thumbWidths(presetMeta) {
+			scaledWidth := float64(targetWidth)
+			if spec.IsBoxed() {
+				boxFitWidth := float64(spec.BoxHeight) * aspect
+				if spec.Mode == models.ResizeCover {
+					scaledWidth = math.Max(scaledWidth, boxFitWidth)
+				} else {
+					scaledWidth = math.Min(scaledWidth, boxFitWidth)
+				}
+			}
+
+			width = math.Max(width, scaledWidth)
+		}
+	}
+	if width <= 0 {
+		width = intrinsicWidth
+	}
+	height := width / aspect
+
+	maxDimension := float64(maxVectorRasterDimension)
+	if meta.InputLimits.MaxDimension > 0 {
+		maxDimension = float64(meta.InputLimits.MaxDimension)
+	}
+	maxPixels := float64(maxVectorRasterPixels)
+	if meta.InputLimits.MaxPixels > 0 {
+		maxPixels = float64(meta.InputLimits.MaxPixels)
+	}
+
+	scale := math.Min(1, maxDimension/math.Max(width, height))
+	scale = math.Min(scale, math.Sqrt(maxPixels/(width*height)))
+
+	return max(1, int(math.Floor(width*scale))), max(1, int(math.Floor(height*scale)))
+}
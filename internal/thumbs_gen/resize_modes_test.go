@@ -0,0 +1,178 @@
+package thumbsgen
+
+import (
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+func TestComputeGeometry(t *testing.T) {
+	landscape := &ImgDimensions{Width: 4000, Height: 3000}
+	panorama := &ImgDimensions{Width: 8000, Height: 1000}
+
+	tests := []struct {
+		name     string
+		orig     *ImgDimensions
+		width    int
+		spec     models.ResizeSpec
+		expected thumbGeometry
+	}{
+		{
+			name:     "fit width keeps aspect ratio",
+			orig:     landscape,
+			width:    400,
+			spec:     models.ResizeSpec{Mode: models.ResizeFitWidth},
+			expected: thumbGeometry{400, 300, 400, 300},
+		},
+		{
+			name:     "zero value spec behaves as fit width",
+			orig:     landscape,
+			width:    200,
+			expected: thumbGeometry{200, 150, 200, 150},
+		},
+		{
+			name:     "fit box bounds height of panoramas",
+			orig:     panorama,
+			width:    1024,
+			spec:     models.ResizeSpec{Mode: models.ResizeFitBox, BoxWidth: 1024, BoxHeight: 576},
+			expected: thumbGeometry{1024, 128, 1024, 128},
+		},
+		{
+			name:     "fit box bounds height",
+			orig:     &ImgDimensions{Width: 1000, Height: 2000},
+			width:    400,
+			spec:     models.ResizeSpec{Mode: models.ResizeFitBox, BoxWidth: 400, BoxHeight: 400},
+			expected: thumbGeometry{200, 400, 200, 400},
+		},
+		{
+			name:     "cover scales to fill box",
+			orig:     landscape,
+			width:    300,
+			spec:     models.ResizeSpec{Mode: models.ResizeCover, BoxWidth: 300, BoxHeight: 300},
+			expected: thumbGeometry{400, 300, 300, 300},
+		},
+		{
+			name:     "cover box clamped to narrower width",
+			orig:     landscape,
+			width:    320,
+			spec:     models.ResizeSpec{Mode: models.ResizeCover, BoxWidth: 640, BoxHeight: 360},
+			expected: thumbGeometry{320, 240, 320, 180},
+		},
+		{
+			name:     "pad scales to fit box",
+			orig:     landscape,
+			width:    320,
+			spec:     models.ResizeSpec{Mode: models.ResizePad, BoxWidth: 320, BoxHeight: 180},
+			expected: thumbGeometry{240, 180, 320, 180},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := computeGeometry(tc.orig, tc.width, tc.spec)
+			if got != tc.expected {
+				t.Fatalf("unexpected geometry: got %+v want %+v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestVectorRasterSize(t *testing.T) {
+	square := func(side int) models.ResizeSpec {
+		return models.ResizeSpec{Mode: models.ResizeCover, BoxWidth: side, BoxHeight: side}
+	}
+
+	tests := []struct {
+		name       string
+		meta       ThumbnailMeta
+		intrinsic  [2]float64
+		wantWidth  int
+		wantHeight int
+	}{
+		{
+			name:       "largest width",
+			meta:       ThumbnailMeta{ThumbWidths: []int{256, 1024, 512}},
+			intrinsic:  [2]float64{40, 20},
+			wantWidth:  1024,
+			wantHeight: 512,
+		},
+		{
+			name:       "cover box taller than original",
+			meta:       ThumbnailMeta{ThumbWidths: []int{256}, Resize: square(256)},
+			intrinsic:  [2]float64{40, 20},
+			wantWidth:  512,
+			wantHeight: 256,
+		},
+		{
+			name: "fit box taller than original",
+			meta: ThumbnailMeta{
+				ThumbWidths: []int{256},
+				Resize:      models.ResizeSpec{Mode: models.ResizeFitBox, BoxWidth: 256, BoxHeight: 256},
+			},
+			intrinsic:  [2]float64{40, 20},
+			wantWidth:  256,
+			wantHeight: 128,
+		},
+		{
+			name: "box wider than widths",
+			meta: ThumbnailMeta{
+				ThumbWidths: []int{256},
+				Resize:      models.ResizeSpec{Mode: models.ResizeCover, BoxWidth: 1024, BoxHeight: 128},
+			},
+			intrinsic:  [2]float64{40, 20},
+			wantWidth:  1024,
+			wantHeight: 512,
+		},
+		{
+			name: "largest preset",
+			meta: ThumbnailMeta{
+				ThumbWidths: []int{2048},
+				Presets: []models.ThumbPreset{
+					{Name: "grid", Widths: []int{128}, Resize: square(128)},
+					{Name: "full", Widths: []int{640}},
+				},
+			},
+			intrinsic:  [2]float64{40, 20},
+			wantWidth:  640,
+			wantHeight: 320,
+		},
+		{
+			name:       "intrinsic size without widths",
+			intrinsic:  [2]float64{40, 20},
+			wantWidth:  40,
+			wantHeight: 20,
+		},
+		{
+			name: "bounded by max dimension",
+			meta: ThumbnailMeta{
+				ThumbWidths: []int{512},
+				InputLimits: models.InputLimits{MaxDimension: 1000},
+			},
+			intrinsic:  [2]float64{1, 10},
+			wantWidth:  100,
+			wantHeight: 1000,
+		},
+		{
+			name:       "bounded without input limits",
+			meta:       ThumbnailMeta{ThumbWidths: []int{512}},
+			intrinsic:  [2]float64{1, 1000},
+			wantWidth:  16,
+			wantHeight: 16384,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			width, height := vectorRasterSize(tc.meta, tc.intrinsic[0], tc.intrinsic[1])
+			if width != tc.wantWidth || height != tc.wantHeight {
+				t.Fatalf(
+					"raster size = %dx%d, want %dx%d",
+					width,
+					height,
+					tc.wantWidth,
+					tc.wantHeight,
+				)
+			}
+		})
+	}
+}
@@ -8,14 +8,19 @@ import (
 	"time"
 
 	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/metadata"
+	"github.com/giobyte8/thumbnailer/internal/models"
 	"github.com/giobyte8/thumbnailer/internal/telemetry"
 	"github.com/giobyte8/thumbnailer/internal/telemetry/metrics"
+	audioextractor "github.com/giobyte8/thumbnailer/internal/thumbs_gen/audio_extractor"
+	docrenderer "github.com/giobyte8/thumbnailer/internal/thumbs_gen/doc_renderer"
 	frameextractor "github.com/giobyte8/thumbnailer/internal/thumbs_gen/frame_extractor"
 )
 
 type RoutedThumbsGenerator struct {
 	telemetry      *telemetry.TelemetrySvc
 	formatDetector *format.FormatDetector
+	metaExtractor  *metadata.Extractor
 	routes         map[format.Format]ThumbsGenerator
 }
 
@@ -41,20 +46,74 @@ func NewRoutedThumbsGenerator(
 		imageThumbsGenerator,
 	)
 
+	rawThumbsGenerator := NewRawThumbsGenerator(
+		formatDetector,
+		formatConverter,
+		imageThumbsGenerator,
+	)
+
+	documentThumbsGenerator := NewDocumentThumbsGenerator(
+		telemetryService,
+		formatDetector,
+		docrenderer.NewDocRenderer(telemetryService),
+		imageThumbsGenerator,
+	)
+
+	svgThumbsGenerator := NewSvgThumbsGenerator(
+		telemetryService,
+		formatDetector,
+		imageThumbsGenerator,
+	)
+
+	audioThumbsGenerator := NewAudioThumbsGenerator(
+		formatDetector,
+		audioextractor.NewAudioExtractor(telemetryService),
+		imageThumbsGenerator,
+	)
+
+	archiveThumbsGenerator := NewArchiveThumbsGenerator(
+		telemetryService,
+		formatDetector,
+		imageThumbsGenerator,
+	)
+
 	routes := map[format.Format]ThumbsGenerator{
 		format.JPEG: imageThumbsGenerator,
 		format.PNG:  imageThumbsGenerator,
 		format.WEBP: imageThumbsGenerator,
 		format.HEIF: imageThumbsGenerator,
+		format.GIF:  imageThumbsGenerator,
+		format.TIFF: imageThumbsGenerator,
+		format.BMP:  imageThumbsGenerator,
+		format.AVIF: imageThumbsGenerator,
+		format.JXL:  imageThumbsGenerator,
+		format.SVG:  svgThumbsGenerator,
 
-		format.MOV: videoThumbsGenerator,
-		format.MP4: videoThumbsGenerator,
-		format.M4V: videoThumbsGenerator,
+		format.ANIMATED_WEBP: imageThumbsGenerator,
+	}
+	for _, videoFormat := range format.VideoFormats {
+		routes[videoFormat] = videoThumbsGenerator
+	}
+	for _, audioFormat := range format.AudioFormats {
+		routes[audioFormat] = audioThumbsGenerator
+	}
+	for _, archiveFormat := range format.ArchiveFormats {
+		routes[archiveFormat] = archiveThumbsGenerator
+	}
+	for _, rawFormat := range format.RawFormats {
+		routes[rawFormat] = rawThumbsGenerator
+	}
+	for _, documentFormat := range documentThumbsGenerator.SupportedFormats() {
+		routes[documentFormat] = documentThumbsGenerator
+	}
+	if !docrenderer.OfficeSupported() {
+		slog.Info("LibreOffice not found, office documents won't be thumbnailed")
 	}
 
 	return &RoutedThumbsGenerator{
 		telemetry:      telemetryService,
 		formatDetector: formatDetector,
+		metaExtractor:  metadata.NewExtractor(),
 		routes:         routes,
 	}
 }
@@ -64,12 +123,12 @@ func NewRoutedThumbsGenerator(
 func (g *RoutedThumbsGenerator) Generate(
 	ctx context.Context,
 	meta ThumbnailMeta,
-) error {
+) (*models.ThumbGenResult, error) {
 	origFileAbsPath := mkOriginalFileAbsPath(meta)
 
 	format, err := g.formatDetector.Detect(origFileAbsPath)
 	if err != nil {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"failed to detect format for file %s: %w",
 			meta.OrigFileRelPath,
 			err)
@@ -83,7 +142,7 @@ func (g *RoutedThumbsGenerator) GenerateWithoutFormatsCheck(
 	ctx context.Context,
 	meta ThumbnailMeta,
 	origFileFormat format.Format,
-) error {
+) (*models.ThumbGenResult, error) {
 	generator, found := g.routes[origFileFormat]
 	if !found {
 		slog.Warn(
@@ -99,11 +158,11 @@ func (g *RoutedThumbsGenerator) GenerateWithoutFormatsCheck(
 				"generate_successful": strconv.FormatBool(false),
 			},
 		)
-		return nil
+		return nil, nil
 	}
 
 	startTime := time.Now()
-	err := generator.GenerateWithoutFormatsCheck(ctx, meta, origFileFormat)
+	result, err := generator.GenerateWithoutFormatsCheck(ctx, meta, origFileFormat)
 
 	g.telemetry.Metrics().IncrementWAttrs(
 		metrics.ThumbReqGenRouted,
@@ -122,5 +181,97 @@ func (g *RoutedThumbsGenerator) GenerateWithoutFormatsCheck(
 		)
 	}
 
-	return err
+	if err == nil && result != nil {
+		result.Metadata = g.extractMetadata(meta, origFileFormat)
+
+		// Videos of Live Photos are paired while generating thumbnails
+		if result.LivePhoto == nil {
+			result.LivePhoto = g.detectLivePhoto(meta, origFileFormat, result.Metadata)
+		}
+		if result.LivePhoto != nil {
+			g.telemetry.Metrics().IncrementWAttrs(
+				metrics.LivePhotoDetected,
+				map[string]string{
+					"role":         string(result.LivePhoto.Role),
+					"motion_photo": strconv.FormatBool(result.LivePhoto.VideoFileName != ""),
+				},
+			)
+		}
+	}
+
+	return result, err
+}
+
+// extractMetadata reads capture metadata of the original. Failures are
+// logged but don't fail generation, as thumbnails are already created.
+func (g *RoutedThumbsGenerator) extractMetadata(
+	meta ThumbnailMeta,
+	origFileFormat format.Format,
+) *models.MediaMetadata {
+	mediaMeta, err := g.metaExtractor.Extract(
+		mkOriginalFileAbsPath(meta),
+		origFileFormat,
+	)
+	if err != nil {
+		slog.Warn(
+			"Failed to extract original file metadata",
+			"filePath", meta.OrigFileRelPath,
+			"format", origFileFormat,
+			"error", err,
+		)
+		return nil
+	}
+
+	return mediaMeta
+}
+
+// detectLivePhoto describes photo originals captured along with a video:
+// Motion Photos, whose embedded video is extracted next to thumbnails,
+// and photos of Apple Live Photos. Failures are logged but don't fail
+// generation, as thumbnails are already created.
+func (g *RoutedThumbsGenerator) detectLivePhoto(
+	meta ThumbnailMeta,
+	origFileFormat format.Format,
+	mediaMeta *models.MediaMetadata,
+) *models.LivePhoto {
+	if origFileFormat != format.JPEG && origFileFormat != format.HEIF {
+		return nil
+	}
+
+	if origFileFormat == format.JPEG {
+		livePhoto, err := extractMotionPhoto(meta)
+		if err != nil {
+			slog.Warn(
+				"Failed to extract motion photo video",
+				"filePath", meta.OrigFileRelPath,
+				"error", err,
+			)
+		}
+		if livePhoto != nil {
+			return livePhoto
+		}
+	}
+
+	video, videoRelPath, found := findLivePhotoSibling(
+		meta.OrigFilesRootDir,
+		meta.OrigFileRelPath,
+		livePhotoVideoFiles,
+	)
+	if !found {
+		return nil
+	}
+
+	var contentIdentifier string
+	if mediaMeta != nil {
+		contentIdentifier = mediaMeta.ContentIdentifier
+	}
+
+	return pairLivePhoto(
+		g.metaExtractor,
+		meta,
+		models.LivePhotoRolePhoto,
+		contentIdentifier,
+		video,
+		videoRelPath,
+	)
 }
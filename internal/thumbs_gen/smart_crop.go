@@ -0,0 +1,251 @@
+package thumbsgen
+
+import (
+	"image"
+	"math"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// Weights of each signal in the saliency score of a pixel. Edges find
+// detailed subjects, skin tones favor people and saturation favors
+// colorful subjects over dull backgrounds.
+const (
+	saliencyEdgeWeight       = 1.0
+	saliencySkinWeight       = 1.5
+	saliencySaturationWeight = 0.5
+
+	// Score every pixel gets, so that center bias still applies to
+	// images without any salient area.
+	saliencyBaseline = 0.01
+
+	// Max penalty applied to windows far away from the image center, it
+	// breaks ties in favor of centered crops.
+	saliencyCenterBias = 0.15
+)
+
+// chooseCropRect picks the crop window of original file for 'cover'
+// resize mode. Window has the aspect ratio of resize spec and is given in
+// (orientation normalized) original pixel coordinates.
+//
+// An explicit focal point in meta takes precedence over saliency
+// analysis.
+func (g *ImageThumbsGenerator) chooseCropRect(
+	meta ThumbnailMeta,
+	analysisImage func() (image.Image, error),
+	origDimensions *ImgDimensions,
+) (image.Rectangle, error) {
+	aspectWidth := meta.Resize.BoxWidth
+	aspectHeight := meta.Resize.BoxHeight
+
+	if meta.FocalPoint != nil {
+		return focalCropRect(
+			origDimensions.Width,
+			origDimensions.Height,
+			aspectWidth,
+			aspectHeight,
+			*meta.FocalPoint,
+		), nil
+	}
+
+	analysisImg, err := analysisImage()
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+
+	analysisRect := findSalientCrop(analysisImg, aspectWidth, aspectHeight)
+
+	// Map window from analysis image back into original coordinates
+	scale := float64(origDimensions.Width) / float64(analysisImg.Bounds().Dx())
+	cropWidth, cropHeight := cropWindowSize(
+		origDimensions.Width,
+		origDimensions.Height,
+		aspectWidth,
+		aspectHeight,
+	)
+	return clampWindow(
+		int(math.Round(float64(analysisRect.Min.X)*scale)),
+		int(math.Round(float64(analysisRect.Min.Y)*scale)),
+		cropWidth,
+		cropHeight,
+		origDimensions.Width,
+		origDimensions.Height,
+	), nil
+}
+
+// focalCropRect returns the largest window with given aspect ratio
+// centered as close as possible to the focal point.
+func focalCropRect(
+	width int,
+	height int,
+	aspectWidth int,
+	aspectHeight int,
+	focalPoint models.FocalPoint,
+) image.Rectangle {
+	cropWidth, cropHeight := cropWindowSize(width, height, aspectWidth, aspectHeight)
+	centerX := int(math.Round(focalPoint.X * float64(width)))
+	centerY := int(math.Round(focalPoint.Y * float64(height)))
+
+	return clampWindow(
+		centerX-cropWidth/2,
+		centerY-cropHeight/2,
+		cropWidth,
+		cropHeight,
+		width,
+		height,
+	)
+}
+
+// findSalientCrop returns the largest window with given aspect ratio
+// that concentrates the highest saliency score in img.
+func findSalientCrop(
+	img image.Image,
+	aspectWidth int,
+	aspectHeight int,
+) image.Rectangle {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	cropWidth, cropHeight := cropWindowSize(width, height, aspectWidth, aspectHeight)
+
+	integral := saliencyIntegral(img)
+	windowScore := func(x, y int) float64 {
+		return integral[(y+cropHeight)*(width+1)+x+cropWidth] -
+			integral[y*(width+1)+x+cropWidth] -
+			integral[(y+cropHeight)*(width+1)+x] +
+			integral[y*(width+1)+x]
+	}
+
+	maxOffsetX := float64(max(1, width-cropWidth))
+	maxOffsetY := float64(max(1, height-cropHeight))
+
+	bestX, bestY, bestScore := 0, 0, math.Inf(-1)
+	for y := 0; y+cropHeight <= height; y++ {
+		for x := 0; x+cropWidth <= width; x++ {
+
+			// Distance between window and image centers, normalized to [0, 1]
+			distX := math.Abs(float64(x)/maxOffsetX - 0.5)
+			distY := math.Abs(float64(y)/maxOffsetY - 0.5)
+			centerDist := math.Hypot(distX, distY) / math.Hypot(0.5, 0.5)
+			centerPenalty := saliencyCenterBias * centerDist
+
+			score := windowScore(x, y) * (1 - centerPenalty)
+			if score > bestScore {
+				bestX, bestY, bestScore = x, y, score
+			}
+		}
+	}
+
+	return image.Rect(bestX, bestY, bestX+cropWidth, bestY+cropHeight)
+}
+
+// saliencyIntegral computes the saliency score of every pixel in img and
+// returns its summed area table, with an extra leading row and column of
+// zeroes to simplify window sums.
+func saliencyIntegral(img image.Image) []float64 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	luma := make([]float64, width*height)
+	scores := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b := rgb8(img, bounds.Min.X+x, bounds.Min.Y+y)
+			luma[y*width+x] = 0.299*r + 0.587*g + 0.114*b
+
+			maxC := math.Max(r, math.Max(g, b))
+			minC := math.Min(r, math.Min(g, b))
+			saturation := (maxC - minC) / 255
+
+			skin := 0.0
+			if isSkinTone(r, g, b, maxC, minC) {
+				skin = 1
+			}
+
+			scores[y*width+x] = saliencyBaseline +
+				saliencySkinWeight*skin +
+				saliencySaturationWeight*saturation
+		}
+	}
+
+	integral := make([]float64, (width+1)*(height+1))
+	for y := 0; y < height; y++ {
+		rowSum := 0.0
+		for x := 0; x < width; x++ {
+
+			// Central differences of luma approximate edge magnitude
+			gradX := luma[y*width+min(x+1, width-1)] - luma[y*width+max(x-1, 0)]
+			gradY := luma[min(y+1, height-1)*width+x] - luma[max(y-1, 0)*width+x]
+			edge := (math.Abs(gradX) + math.Abs(gradY)) / 255
+
+			rowSum += scores[y*width+x] + saliencyEdgeWeight*edge
+			integral[(y+1)*(width+1)+x+1] = integral[y*(width+1)+x+1] + rowSum
+		}
+	}
+
+	return integral
+}
+
+// isSkinTone applies a classic RGB skin detection rule.
+func isSkinTone(r, g, b, maxC, minC float64) bool {
+	return r > 95 && g > 40 && b > 20 &&
+		maxC-minC > 15 &&
+		math.Abs(r-g) > 15 && r > g && r > b
+}
+
+func rgb8(img image.Image, x int, y int) (float64, float64, float64) {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return float64(r >> 8), float64(g >> 8), float64(b >> 8)
+}
+
+// cropWindowSize returns dimensions of the largest window with given
+// aspect ratio that fits in an image of given dimensions.
+func cropWindowSize(
+	width int,
+	height int,
+	aspectWidth int,
+	aspectHeight int,
+) (int, int) {
+	if width*aspectHeight > height*aspectWidth {
+		return max(1, height*aspectWidth/aspectHeight), height
+	}
+
+	return width, max(1, width*aspectHeight/aspectWidth)
+}
+
+// clampWindow returns a window of given size at (x, y), shifted as needed
+// to lay inside an image of given dimensions.
+func clampWindow(
+	x int,
+	y int,
+	windowWidth int,
+	windowHeight int,
+	width int,
+	height int,
+) image.Rectangle {
+	windowWidth = min(windowWidth, width)
+	windowHeight = min(windowHeight, height)
+	x = max(0, min(x, width-windowWidth))
+	y = max(0, min(y, height-windowHeight))
+
+	return image.Rect(x, y, x+windowWidth, y+windowHeight)
+}
+
+// scaleCropRect maps a crop window in original coordinates into the
+// coordinates of the original scaled to geometry scaled dimensions. The
+// returned window has exactly the dimensions of the thumbnail.
+func scaleCropRect(
+	cropRect image.Rectangle,
+	origDimensions *ImgDimensions,
+	geometry thumbGeometry,
+) image.Rectangle {
+	scale := float64(geometry.ScaledWidth) / float64(origDimensions.Width)
+
+	return clampWindow(
+		int(math.Round(float64(cropRect.Min.X)*scale)),
+		int(math.Round(float64(cropRect.Min.Y)*scale)),
+		geometry.Width,
+		geometry.Height,
+		geometry.ScaledWidth,
+		geometry.ScaledHeight,
+	)
+}
@@ -0,0 +1,80 @@
+package thumbsgen
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+func TestFindSalientCrop_PicksDetailedArea(t *testing.T) {
+
+	// Flat grey landscape with a checkered subject near the right edge
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			img.Set(x, y, color.NRGBA{R: 120, G: 120, B: 120, A: 255})
+			if x >= 220 && x < 280 && y >= 20 && y < 80 && (x/4+y/4)%2 == 0 {
+				img.Set(x, y, color.NRGBA{R: 250, G: 30, B: 30, A: 255})
+			}
+		}
+	}
+
+	got := findSalientCrop(img, 1, 1)
+	if got.Dx() != 100 || got.Dy() != 100 {
+		t.Fatalf("unexpected crop size: %v", got)
+	}
+
+	if got.Min.X > 220 || got.Max.X < 280 {
+		t.Fatalf("expected crop to include subject at x=[220, 280), got %v", got)
+	}
+}
+
+func TestFindSalientCrop_FlatImageIsCentered(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			img.Set(x, y, color.NRGBA{R: 90, G: 90, B: 90, A: 255})
+		}
+	}
+
+	got := findSalientCrop(img, 1, 1)
+	want := image.Rect(100, 0, 200, 100)
+	if got != want {
+		t.Fatalf("unexpected crop: got %v want %v", got, want)
+	}
+}
+
+func TestFocalCropRect(t *testing.T) {
+	tests := []struct {
+		name       string
+		focalPoint models.FocalPoint
+		expected   image.Rectangle
+	}{
+		{
+			name:       "centered on focal point",
+			focalPoint: models.FocalPoint{X: 0.5, Y: 0.5},
+			expected:   image.Rect(150, 0, 450, 300),
+		},
+		{
+			name:       "clamped to left edge",
+			focalPoint: models.FocalPoint{X: 0.05, Y: 0.5},
+			expected:   image.Rect(0, 0, 300, 300),
+		},
+		{
+			name:       "clamped to right edge",
+			focalPoint: models.FocalPoint{X: 1, Y: 0},
+			expected:   image.Rect(300, 0, 600, 300),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := focalCropRect(600, 300, 1, 1, tc.focalPoint)
+			if got != tc.expected {
+				t.Fatalf("unexpected crop: got %v want %v", got, tc.expected)
+			}
+		})
+	}
+}
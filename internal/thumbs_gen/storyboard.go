@@ -0,0 +1,242 @@
+package thumbsgen
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// generateStoryboard extracts evenly spaced frames of the video, tiles
+// them into sprite sheets encoded like other thumbnails and writes the
+// WebVTT file mapping time ranges to frames in sheets.
+func (g *VideoThumbsGenerator) generateStoryboard(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	video *models.VideoInfo,
+) (*models.Storyboard, error) {
+	opts := meta.Storyboard
+
+	// Frames and intermediary sheets never leave this directory
+	workDir, err := os.MkdirTemp(meta.ThumbFileAbsDir, ".storyboard-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storyboard work dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	framePaths, interval, err := g.frameExtractor.ExtractFrames(
+		ctx,
+		mkOriginalFileAbsPath(meta),
+		workDir,
+		video,
+		meta.ToneMap,
+		opts.Frames,
+		opts.TileWidth,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to extract storyboard frames from video %s: %w",
+			meta.OrigFileRelPath,
+			err,
+		)
+	}
+
+	frames, err := loadFrames(framePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	tileBounds := frames[0].Bounds()
+	storyboard := &models.Storyboard{
+		TileWidth:  tileBounds.Dx(),
+		TileHeight: tileBounds.Dy(),
+		Frames:     len(frames),
+		IntervalMs: interval.Milliseconds(),
+	}
+
+	sheetExtension := thumbFormats(meta)[0]
+	for idx, sheet := range tileSheets(frames, opts.Columns, opts.Rows) {
+		sheetThumb, err := g.encodeSheet(ctx, meta, workDir, idx, sheet, sheetExtension)
+		if err != nil {
+			return nil, err
+		}
+
+		storyboard.Sheets = append(storyboard.Sheets, models.StoryboardSheet{
+			FileName: sheetThumb.FileName,
+			Width:    sheetThumb.Width,
+			Height:   sheetThumb.Height,
+		})
+	}
+
+	vttAbsPath := mkStoryboardVttAbsPath(meta)
+	vtt := buildStoryboardVtt(storyboard, interval, opts.Columns, opts.Rows)
+	if err := os.WriteFile(vttAbsPath, []byte(vtt), 0644); err != nil {
+		return nil, fmt.Errorf(
+			"failed to write storyboard file %s: %w",
+			vttAbsPath,
+			err,
+		)
+	}
+
+	storyboard.VttFileName = filepath.Base(vttAbsPath)
+	return storyboard, nil
+}
+
+// encodeSheet writes sheet as an intermediary PNG in workDir and encodes
+// it at its full width through the image thumbnails generator
+func (g *VideoThumbsGenerator) encodeSheet(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	workDir string,
+	sheetIdx int,
+	sheet image.Image,
+	extension string,
+) (*models.Thumb, error) {
+	sheetPng, err := encodePNG(sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	sheetFileName := fmt.Sprintf("%s_storyboard_%d.png", baseNameNoExt(meta), sheetIdx+1)
+	err = os.WriteFile(filepath.Join(workDir, sheetFileName), sheetPng, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write storyboard sheet: %w", err)
+	}
+
+	sheetMeta := ThumbnailMeta{
+		OrigFilesRootDir: workDir,
+		OrigFileRelPath:  sheetFileName,
+		ThumbFileAbsDir:  meta.ThumbFileAbsDir,
+		ThumbWidths:      []int{sheet.Bounds().Dx()},
+		ThumbFormats:     []string{extension},
+		ThumbQuality:     meta.ThumbQuality,
+		ColorProfile:     meta.ColorProfile,
+	}
+
+	result, err := g.imageThumbsGenerator.GenerateWithoutFormatsCheck(
+		ctx,
+		sheetMeta,
+		format.PNG,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to encode storyboard sheet for %s: %w",
+			meta.OrigFileRelPath,
+			err,
+		)
+	}
+	if len(result.Thumbs) != 1 {
+		return nil, fmt.Errorf(
+			"unexpected thumbnails count for storyboard sheet: %d",
+			len(result.Thumbs),
+		)
+	}
+
+	return &result.Thumbs[0], nil
+}
+
+func loadFrames(framePaths []string) ([]image.Image, error) {
+	frames := make([]image.Image, 0, len(framePaths))
+	for _, framePath := range framePaths {
+		file, err := os.Open(framePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open storyboard frame: %w", err)
+		}
+
+		frame, _, err := image.Decode(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode storyboard frame: %w", err)
+		}
+
+		frames = append(frames, frame)
+	}
+
+	return frames, nil
+}
+
+// tileSheets lays frames out left to right, top to bottom, in sheets of
+// up to columns x rows tiles sized after the first frame. Sheets only
+// hold as many rows (and columns) as their frames need.
+func tileSheets(frames []image.Image, columns int, rows int) []*image.NRGBA {
+	tileWidth := frames[0].Bounds().Dx()
+	tileHeight := frames[0].Bounds().Dy()
+	framesPerSheet := columns * rows
+
+	var sheets []*image.NRGBA
+	for first := 0; first < len(frames); first += framesPerSheet {
+		sheetFrames := frames[first:min(first+framesPerSheet, len(frames))]
+		sheetColumns := min(len(sheetFrames), columns)
+		sheetRows := (len(sheetFrames) + columns - 1) / columns
+
+		sheet := image.NewNRGBA(image.Rect(
+			0,
+			0,
+			sheetColumns*tileWidth,
+			sheetRows*tileHeight,
+		))
+		for idx, frame := range sheetFrames {
+			tile := image.Rect(0, 0, tileWidth, tileHeight).Add(image.Pt(
+				idx%columns*tileWidth,
+				idx/columns*tileHeight,
+			))
+			draw.Draw(sheet, tile, frame, frame.Bounds().Min, draw.Src)
+		}
+
+		sheets = append(sheets, sheet)
+	}
+
+	return sheets
+}
+
+// buildStoryboardVtt maps the time range of each frame to its tile in
+// sheets using media fragments (e.g. 'sheet.webp#xywh=160,0,160,90')
+func buildStoryboardVtt(
+	storyboard *models.Storyboard,
+	interval time.Duration,
+	columns int,
+	rows int,
+) string {
+	var vtt strings.Builder
+	vtt.WriteString("WEBVTT\n")
+
+	framesPerSheet := columns * rows
+	for idx := range storyboard.Frames {
+		sheet := storyboard.Sheets[idx/framesPerSheet]
+		tileIdx := idx % framesPerSheet
+
+		fmt.Fprintf(
+			&vtt,
+			"\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			formatVttTime(time.Duration(idx)*interval),
+			formatVttTime(time.Duration(idx+1)*interval),
+			sheet.FileName,
+			tileIdx%columns*storyboard.TileWidth,
+			tileIdx/columns*storyboard.TileHeight,
+			storyboard.TileWidth,
+			storyboard.TileHeight,
+		)
+	}
+
+	return vtt.String()
+}
+
+// formatVttTime formats a WebVTT timestamp (e.g. '00:01:02.500')
+func formatVttTime(timestamp time.Duration) string {
+	millis := timestamp.Milliseconds()
+	return fmt.Sprintf(
+		"%02d:%02d:%02d.%03d",
+		millis/3_600_000,
+		millis/60_000%60,
+		millis/1000%60,
+		millis%1000,
+	)
+}
@@ -0,0 +1,172 @@
+package thumbsgen
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+func TestTileSheets(t *testing.T) {
+	var frames []image.Image
+	for idx := range 5 {
+		frame := image.NewNRGBA(image.Rect(0, 0, 16, 9))
+		fill := color.NRGBA{R: uint8(idx * 50), A: 255}
+		for y := range 9 {
+			for x := range 16 {
+				frame.SetNRGBA(x, y, fill)
+			}
+		}
+		frames = append(frames, frame)
+	}
+
+	sheets := tileSheets(frames, 2, 2)
+	if len(sheets) != 2 {
+		t.Fatalf("sheets = %d, want 2", len(sheets))
+	}
+
+	if got := sheets[0].Bounds(); got != image.Rect(0, 0, 32, 18) {
+		t.Errorf("full sheet bounds = %v", got)
+	}
+	if got := sheets[1].Bounds(); got != image.Rect(0, 0, 16, 9) {
+		t.Errorf("partial sheet bounds = %v", got)
+	}
+
+	// Frames go left to right, top to bottom
+	tiles := []struct {
+		sheet int
+		x, y  int
+		red   uint8
+	}{
+		{0, 0, 0, 0},
+		{0, 16, 0, 50},
+		{0, 0, 9, 100},
+		{0, 31, 17, 150},
+		{1, 0, 0, 200},
+	}
+	for _, tile := range tiles {
+		if got := sheets[tile.sheet].NRGBAAt(tile.x, tile.y).R; got != tile.red {
+			t.Errorf("sheet %d at (%d,%d): red = %d, want %d", tile.sheet, tile.x, tile.y, got, tile.red)
+		}
+	}
+}
+
+func TestBuildStoryboardVtt(t *testing.T) {
+	storyboard := &models.Storyboard{
+		Sheets: []models.StoryboardSheet{
+			{FileName: "clip_storyboard_1_320px.webp"},
+			{FileName: "clip_storyboard_2_160px.webp"},
+		},
+		TileWidth:  160,
+		TileHeight: 90,
+		Frames:     3,
+	}
+
+	got := buildStoryboardVtt(storyboard, 1500*time.Millisecond, 2, 1)
+	want := strings.Join([]string{
+		"WEBVTT",
+		"",
+		"00:00:00.000 --> 00:00:01.500",
+		"clip_storyboard_1_320px.webp#xywh=0,0,160,90",
+		"",
+		"00:00:01.500 --> 00:00:03.000",
+		"clip_storyboard_1_320px.webp#xywh=160,0,160,90",
+		"",
+		"00:00:03.000 --> 00:00:04.500",
+		"clip_storyboard_2_160px.webp#xywh=0,0,160,90",
+		"",
+	}, "\n")
+
+	if got != want {
+		t.Fatalf("unexpected vtt:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatVttTime(t *testing.T) {
+	got := formatVttTime(time.Hour + 2*time.Minute + 3*time.Second + 45*time.Millisecond)
+	if got != "01:02:03.045" {
+		t.Fatalf("formatVttTime = %q", got)
+	}
+}
+
+func TestVideoThumbsGenerator_EncodeSheet(t *testing.T) {
+	meta := ThumbnailMeta{
+		OrigFileRelPath: "clip.mp4",
+		ThumbFileAbsDir: t.TempDir(),
+	}
+
+	sheet := image.NewNRGBA(image.Rect(0, 0, 320, 90))
+	thumb, err := mkVideoGenerator(t).encodeSheet(
+		context.Background(),
+		meta,
+		t.TempDir(),
+		1,
+		sheet,
+		".webp",
+	)
+	if err != nil {
+		t.Fatalf("encode sheet failed: %v", err)
+	}
+
+	if thumb.FileName != "clip_storyboard_2_320px.webp" {
+		t.Errorf("unexpected sheet file name: %s", thumb.FileName)
+	}
+	if thumb.Width != 320 || thumb.Height != 90 {
+		t.Errorf("unexpected sheet dimensions: %dx%d", thumb.Width, thumb.Height)
+	}
+	assertVideoThumbnailCreated(t, filepath.Join(meta.ThumbFileAbsDir, thumb.FileName), 320)
+}
+
+func TestVideoThumbsGenerator_Integration_Storyboard(t *testing.T) {
+	for _, binary := range []string{"ffmpeg", "ffprobe"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("%s not available, skipping integration test", binary)
+		}
+	}
+
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: testutils.TestFilesDir(),
+		OrigFileRelPath:  "11 whatsapp.mp4",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{120},
+		Storyboard: models.StoryboardOptions{
+			Frames:    6,
+			TileWidth: 80,
+			Columns:   2,
+			Rows:      2,
+		},
+	}
+
+	result, err := mkVideoGenerator(t).Generate(context.Background(), meta)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	storyboard := result.Storyboard
+	if storyboard == nil {
+		t.Fatal("expected storyboard in result")
+	}
+	if len(storyboard.Sheets) != 2 || storyboard.TileWidth != 80 {
+		t.Fatalf("unexpected storyboard: %+v", storyboard)
+	}
+
+	for _, fileName := range result.FileNames() {
+		if _, err := os.Stat(filepath.Join(meta.ThumbFileAbsDir, fileName)); err != nil {
+			t.Errorf("expected file %s: %v", fileName, err)
+		}
+	}
+
+	// Work dir with frames is removed
+	entries, _ := filepath.Glob(filepath.Join(meta.ThumbFileAbsDir, ".storyboard-*"))
+	if len(entries) != 0 {
+		t.Errorf("storyboard work dir left behind: %v", entries)
+	}
+}
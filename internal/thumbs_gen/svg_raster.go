@@ -0,0 +1,94 @@
+package thumbsgen
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrSvgEntities is wrapped by errors of SVG originals declaring XML
+// entities, which are used for external entity and expansion attacks.
+var ErrSvgEntities = errors.New("svg declares xml entities")
+
+// Size of SVG originals declaring neither a viewBox nor dimensions, as
+// used by browsers
+const (
+	defaultSvgWidth  = 300
+	defaultSvgHeight = 150
+)
+
+// svgDocument is a parsed SVG original along with its intrinsic size
+type svgDocument struct {
+	icon   *oksvg.SvgIcon
+	width  float64
+	height float64
+}
+
+// parseSvg checks SVG markup for entity declarations, then parses it.
+//
+// The XML decoder resolves no entity besides predefined ones and the
+// renderer implements no resource loading: images, external 'use'
+// references and stylesheets are never fetched, neither from the network
+// nor from local files.
+func parseSvg(markup []byte) (*svgDocument, error) {
+	if err := checkSvgEntities(markup); err != nil {
+		return nil, err
+	}
+
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(markup))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse svg: %w", err)
+	}
+
+	doc := &svgDocument{
+		icon:   icon,
+		width:  icon.ViewBox.W,
+		height: icon.ViewBox.H,
+	}
+	if doc.width <= 0 || doc.height <= 0 {
+		doc.width, doc.height = defaultSvgWidth, defaultSvgHeight
+		icon.ViewBox.W, icon.ViewBox.H = doc.width, doc.height
+	}
+
+	return doc, nil
+}
+
+// checkSvgEntities rejects markup whose DOCTYPE declares entities
+func checkSvgEntities(markup []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(markup))
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse svg: %w", err)
+		}
+
+		directive, isDirective := token.(xml.Directive)
+		if isDirective && strings.Contains(string(directive), "ENTITY") {
+			return ErrSvgEntities
+		}
+	}
+}
+
+// rasterizeSvg draws the SVG over a transparent image of given size
+func rasterizeSvg(doc *svgDocument, width int, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	doc.icon.SetTarget(0, 0, float64(width), float64(height))
+	scanner := rasterx.NewScannerGV(width, height, img, img.Bounds())
+	doc.icon.Draw(rasterx.NewDasher(width, height, scanner), 1)
+
+	return img
+}
@@ -0,0 +1,122 @@
+package thumbsgen
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/telemetry"
+)
+
+// SvgThumbsGenerator produces thumbnails of SVG originals, rasterized in
+// process into an intermediary PNG as large as the largest thumbnail.
+type SvgThumbsGenerator struct {
+	telemetry            *telemetry.TelemetrySvc
+	formatDetector       *format.FormatDetector
+	imageThumbsGenerator ThumbsGenerator
+}
+
+// NewSvgThumbsGenerator builds an SVG thumbnail generator with explicit
+// dependencies.
+func NewSvgThumbsGenerator(
+	telemetrySvc *telemetry.TelemetrySvc,
+	formatDetector *format.FormatDetector,
+	imageThumbsGenerator ThumbsGenerator,
+) *SvgThumbsGenerator {
+	return &SvgThumbsGenerator{
+		telemetry:            telemetrySvc,
+		formatDetector:       formatDetector,
+		imageThumbsGenerator: imageThumbsGenerator,
+	}
+}
+
+// Generate implements ThumbsGenerator.
+func (g *SvgThumbsGenerator) Generate(
+	ctx context.Context,
+	meta ThumbnailMeta,
+) (*models.ThumbGenResult, error) {
+	origFileFormat, err := g.formatDetector.Detect(mkOriginalFileAbsPath(meta))
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to detect file format for %s: %w",
+			meta.OrigFileRelPath,
+			err)
+	}
+
+	if origFileFormat != format.SVG {
+		return nil, fmt.Errorf(
+			"cannot generate thumbnails: unsupported original file format: %v",
+			origFileFormat,
+		)
+	}
+
+	return g.GenerateWithoutFormatsCheck(ctx, meta, origFileFormat)
+}
+
+// GenerateWithoutFormatsCheck implements ThumbsGenerator.
+func (g *SvgThumbsGenerator) GenerateWithoutFormatsCheck(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	origFileFormat format.Format,
+) (*models.ThumbGenResult, error) {
+	rasterAbsPath := mkIntermediaryThumbFileAbsPath(meta, ".png")
+	defer func() {
+		_ = os.Remove(rasterAbsPath)
+	}()
+
+	if err := g.mkRasterFile(meta, rasterAbsPath); err != nil {
+		return nil, fmt.Errorf(
+			"failed to rasterize svg %s: %w",
+			meta.OrigFileRelPath,
+			err,
+		)
+	}
+
+	// Replace original file info with intermediary raster file
+	rasterMeta := meta
+	rasterMeta.OrigFilesRootDir = meta.ThumbFileAbsDir
+	rasterMeta.OrigFileRelPath = filepath.Base(rasterAbsPath)
+
+	result, err := g.imageThumbsGenerator.GenerateWithoutFormatsCheck(
+		ctx,
+		rasterMeta,
+		format.PNG,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to generate svg thumbnails from raster for %s: %w",
+			meta.OrigFileRelPath,
+			err,
+		)
+	}
+
+	return result, nil
+}
+
+// mkRasterFile rasterizes the SVG original into a PNG at 'intoAbsPath'.
+// Originals that can't be parsed fail with a permanent error.
+func (g *SvgThumbsGenerator) mkRasterFile(
+	meta ThumbnailMeta,
+	intoAbsPath string,
+) error {
+	origFileAbsPath := mkOriginalFileAbsPath(meta)
+	if err := checkFileSize(g.telemetry, meta, origFileAbsPath); err != nil {
+		return err
+	}
+
+	markup, err := os.ReadFile(origFileAbsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read svg: %w", err)
+	}
+
+	doc, err := parseSvg(markup)
+	if err != nil {
+		return models.NewPermanentError(err)
+	}
+
+	width, height := vectorRasterSize(meta, doc.width, doc.height)
+	return writePNGFile(rasterizeSvg(doc, width, height), intoAbsPath)
+}
@@ -0,0 +1,165 @@
+package thumbsgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/telemetry"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+// Logo 40x20 user units, red on its left half and blue on its right one
+const testSvg = `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="40" height="20" viewBox="0 0 40 20">
+  <rect x="0" y="0" width="20" height="20" fill="#ff0000"/>
+  <rect x="20" y="0" width="20" height="20" fill="#0000ff"/>
+</svg>`
+
+func TestSvgThumbsGenerator_Integration_Rasterize(t *testing.T) {
+	meta := mkSvgMeta(t, testSvg)
+	meta.ThumbWidths = []int{160, 64}
+
+	result, err := mkSvgGenerator(t).Generate(context.Background(), meta)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if len(result.Thumbs) != 2 {
+		t.Fatalf("expected two thumbnails, got %d", len(result.Thumbs))
+	}
+
+	// Vector originals are rendered at thumbnail size, never upscaled
+	// from their 40px intrinsic width
+	for _, width := range []int{160, 64} {
+		thumb := decodeThumb(t, mkThumbFileAbsPath(meta, width, ThumbsExtension))
+		bounds := thumb.Bounds()
+		if bounds.Dx() != width || bounds.Dy() != width/2 {
+			t.Fatalf("thumbnail is %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), width, width/2)
+		}
+
+		r, _, b, _ := thumb.At(bounds.Dx()/8, bounds.Dy()/2).RGBA()
+		if r <= b {
+			t.Fatalf("expected red on the left, got r=%d b=%d", r>>8, b>>8)
+		}
+		r, _, b, _ = thumb.At(bounds.Dx()-1-bounds.Dx()/8, bounds.Dy()/2).RGBA()
+		if b <= r {
+			t.Fatalf("expected blue on the right, got r=%d b=%d", r>>8, b>>8)
+		}
+	}
+
+	if _, err := os.Stat(mkIntermediaryThumbFileAbsPath(meta, ".png")); !os.IsNotExist(err) {
+		t.Fatalf("expected raster to be removed, stat error: %v", err)
+	}
+}
+
+func TestSvgThumbsGenerator_RejectsEntities(t *testing.T) {
+	tests := map[string]string{
+		"external entity": `<?xml version="1.0"?>
+<!DOCTYPE svg [<!ENTITY secret SYSTEM "file:///etc/passwd">]>
+<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><text>&secret;</text></svg>`,
+		"entity expansion": `<?xml version="1.0"?>
+<!DOCTYPE svg [<!ENTITY a "aaaaaaaaaa"><!ENTITY b "&a;&a;&a;&a;&a;&a;&a;&a;&a;&a;">]>
+<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><desc>&b;</desc></svg>`,
+	}
+
+	for name, svg := range tests {
+		t.Run(name, func(t *testing.T) {
+			meta := mkSvgMeta(t, svg)
+
+			_, err := mkSvgGenerator(t).Generate(context.Background(), meta)
+			if !errors.Is(err, ErrSvgEntities) {
+				t.Fatalf("expected svg entities error, got %v", err)
+			}
+			if category := models.ErrorCategoryOf(err); category != models.ErrCategoryPermanent {
+				t.Fatalf("expected permanent error, got %s", category)
+			}
+		})
+	}
+}
+
+func TestSvgThumbsGenerator_LoadsNoExternalResources(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		},
+	))
+	defer server.Close()
+
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg"
+     xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 40 20">
+  <image href="%s/photo.png" width="40" height="20"/>
+  <image xlink:href="file:///etc/hostname" width="40" height="20"/>
+  <rect x="0" y="0" width="40" height="20" fill="#ff0000"/>
+</svg>`, server.URL)
+	meta := mkSvgMeta(t, svg)
+
+	if _, err := mkSvgGenerator(t).Generate(context.Background(), meta); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if count := requests.Load(); count != 0 {
+		t.Fatalf("expected no external requests, got %d", count)
+	}
+}
+
+func TestSvgThumbsGenerator_UnsupportedMedia(t *testing.T) {
+	meta := ThumbnailMeta{
+		OrigFilesRootDir: testutils.TestFilesDir(),
+		OrigFileRelPath:  "1 house.jpg",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{48},
+	}
+
+	if _, err := mkSvgGenerator(t).Generate(context.Background(), meta); err == nil {
+		t.Fatal("expected unsupported format error for JPEG original")
+	}
+}
+
+// mkSvgMeta writes svg as the original of returned meta
+func mkSvgMeta(t *testing.T, svg string) ThumbnailMeta {
+	t.Helper()
+
+	origDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(origDir, "logo.svg"), []byte(svg), 0o644); err != nil {
+		t.Fatalf("failed to write svg original: %v", err)
+	}
+
+	return ThumbnailMeta{
+		OrigFilesRootDir: origDir,
+		OrigFileRelPath:  "logo.svg",
+		ThumbFileAbsDir:  t.TempDir(),
+		ThumbWidths:      []int{48},
+	}
+}
+
+func mkSvgGenerator(t *testing.T) *SvgThumbsGenerator {
+	t.Helper()
+	t.Setenv("OTEL_ENABLED", "false")
+
+	telemetrySvc, err := telemetry.NewTelemetrySvc(context.Background())
+	if err != nil {
+		t.Fatalf("failed to init telemetry service: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = telemetrySvc.Shutdown(context.Background())
+	})
+
+	fmtDetector := format.NewFormatDetector()
+	fmtConverter := format.NewFormatConverter(telemetrySvc, fmtDetector)
+	imageGenerator := NewImageThumbsGenerator(
+		telemetrySvc,
+		fmtConverter,
+		fmtDetector,
+	)
+
+	return NewSvgThumbsGenerator(telemetrySvc, fmtDetector, imageGenerator)
+}
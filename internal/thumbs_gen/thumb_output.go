@@ -0,0 +1,187 @@
+package thumbsgen
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+
+	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/metadata"
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// thumbOutput holds the colors and metadata handling shared by every
+// thumbnail of an original
+type thumbOutput struct {
+
+	// Converts pixels into sRGB. Nil when pixels are kept as decoded.
+	colorTransform *colorTransform
+
+	// Metadata written into thumbnails
+	metadata outputMetadata
+
+	// Limits of animated thumbnails. Nil when original isn't animated.
+	animation *models.AnimationLimits
+}
+
+// originalInfo holds what is read from the original container before
+// HEIF originals are replaced by their intermediary file
+type originalInfo struct {
+
+	// TIFF structured EXIF with metadata kept in thumbnails, if any
+	keptExif []byte
+
+	// ICC profile stored in the container, for formats converted into
+	// an intermediary file that may not carry it
+	containerProfile []byte
+
+	// Color of HDR HEIF originals to tone map into SDR, nil otherwise
+	hdrColor *metadata.NclxColor
+}
+
+func (g *ImageThumbsGenerator) readOriginalInfo(
+	meta ThumbnailMeta,
+	origFileFormat format.Format,
+) originalInfo {
+	var info originalInfo
+	origFileAbsPath := mkOriginalFileAbsPath(meta)
+
+	if len(meta.KeepMetadata) > 0 {
+		mediaMeta, err := g.metaExtractor.Extract(origFileAbsPath, origFileFormat)
+		if err != nil {
+			slog.Warn(
+				"Failed to read metadata to keep in thumbnails",
+				"filePath", meta.OrigFileRelPath,
+				"error", err,
+			)
+		}
+
+		info.keptExif = metadata.EncodeExif(mediaMeta, meta.KeepMetadata)
+	}
+
+	if origFileFormat == format.HEIF {
+		profile, err := metadata.HeifColorProfile(origFileAbsPath)
+		if err != nil {
+			slog.Warn(
+				"Failed to read HEIF color profile",
+				"filePath", meta.OrigFileRelPath,
+				"error", err,
+			)
+		}
+
+		info.containerProfile = profile
+		info.hdrColor = g.readHdrColor(meta, origFileAbsPath)
+	}
+
+	// Tone mapped pixels are sRGB already
+	if info.hdrColor != nil {
+		info.containerProfile = nil
+	}
+
+	return info
+}
+
+// readHdrColor returns color of HDR HEIF originals when meta.ToneMap is
+// enabled, nil otherwise
+func (g *ImageThumbsGenerator) readHdrColor(
+	meta ThumbnailMeta,
+	origFileAbsPath string,
+) *metadata.NclxColor {
+	if !meta.ToneMap.Enabled() {
+		return nil
+	}
+
+	color, err := metadata.HeifNclxColor(origFileAbsPath)
+	if err != nil {
+		slog.Warn(
+			"Failed to read HEIF color properties",
+			"filePath", meta.OrigFileRelPath,
+			"error", err,
+		)
+		return nil
+	}
+	if color == nil || !color.IsHDR() {
+		return nil
+	}
+
+	return color
+}
+
+// prepareOutput decides how colors of the original are handled based on
+// its ICC profile and meta.ColorProfile policy:
+//
+//   - Originals without profile or with an sRGB one are kept as decoded.
+//   - 'srgb' converts pixels of other profiles into sRGB.
+//   - 'embed' keeps pixels as decoded and embeds their profile.
+//
+// Profiles that can't be converted (e.g. LUT based) are embedded so
+// thumbnails still render with right colors. Same goes for animated
+// originals, whose frames are never edited in memory.
+func (g *ImageThumbsGenerator) prepareOutput(
+	meta ThumbnailMeta,
+	origFileBytes []byte,
+	info originalInfo,
+) (thumbOutput, error) {
+	output := thumbOutput{
+		metadata: outputMetadata{Exif: info.keptExif},
+	}
+
+	decoder, err := g.decode(origFileBytes)
+	if err != nil {
+		return output, err
+	}
+	profile := decoder.ICC()
+	header, err := decoder.Header()
+	decoder.Close()
+	if err != nil {
+		return output, fmt.Errorf("failed to read image header: %w", err)
+	}
+
+	if header.IsAnimated() {
+		output.animation = &meta.AnimationLimits
+	}
+
+	if len(profile) == 0 {
+		profile = info.containerProfile
+	}
+	if len(profile) == 0 {
+		return output, nil
+	}
+
+	if meta.ColorProfile == models.ColorProfileEmbed || output.animation != nil {
+		output.metadata.ICCProfile = profile
+		return output, nil
+	}
+
+	transform, err := newColorTransform(profile)
+	if err != nil {
+		slog.Warn(
+			"Color profile can't be converted to sRGB, embedding it",
+			"filePath", meta.OrigFileRelPath,
+			"error", err,
+		)
+
+		output.metadata.ICCProfile = profile
+		return output, nil
+	}
+
+	if !transform.isIdentity() {
+		output.colorTransform = transform
+	}
+	return output, nil
+}
+
+// withColorTransform composes canvasOp with conversion of its result
+// into sRGB, when output requires it
+func withColorTransform(
+	output thumbOutput,
+	canvasOp func(img image.Image) image.Image,
+) func(img image.Image) image.Image {
+	if output.colorTransform == nil {
+		return canvasOp
+	}
+
+	return func(img image.Image) image.Image {
+		return output.colorTransform.apply(canvasOp(img))
+	}
+}
@@ -0,0 +1,209 @@
+package thumbsgen
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/giobyte8/thumbnailer/internal/metadata"
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// Luminance of SDR white, linear light is normalized to it
+const sdrWhiteNits = 100
+
+// Peak luminance assumed for HDR originals (HLG nominal peak, common
+// mastering peak of PQ content), relative to SDR white
+const hdrPeak = 1000.0 / sdrWhiteNits
+
+// Code points (ISO/IEC 23091-2) of color primaries converted into BT.709
+const (
+	primariesBT2020 = 9
+	primariesP3D65  = 12
+)
+
+// Linear RGB conversions into BT.709 (sRGB) primaries
+var toBT709 = map[uint16][3][3]float64{
+	primariesBT2020: {
+		{1.6605, -0.5876, -0.0728},
+		{-0.1246, 1.1329, -0.0083},
+		{-0.0182, -0.1006, 1.1187},
+	},
+	primariesP3D65: {
+		{1.2249, -0.2247, 0},
+		{-0.0420, 1.0419, 0},
+		{-0.0197, -0.0786, 1.0979},
+	},
+}
+
+// toneMapper converts 16 bits PQ or HLG pixels into 8 bits sRGB ones, the
+// same way the ffmpeg filter chain of videos does: linearize, convert
+// primaries to BT.709, compress highlights of the brightest channel and
+// encode with sRGB curve.
+type toneMapper struct {
+	hlg        bool
+	linearize  [65536]float64
+	matrix     [3][3]float64
+	operator   func(float64) float64
+	srgbEncode [srgbEncodeSteps + 1]uint8
+}
+
+func newToneMapper(
+	color *metadata.NclxColor,
+	operator models.ToneMapOperator,
+) (*toneMapper, error) {
+	curve, err := toneMapCurve(operator, hdrPeak)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper := &toneMapper{
+		hlg:      color.Transfer == metadata.TransferHLG,
+		matrix:   [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		operator: curve,
+	}
+	if matrix, found := toBT709[color.Primaries]; found {
+		mapper.matrix = matrix
+	}
+
+	for value := range 65536 {
+		signal := float64(value) / 65535
+		if mapper.hlg {
+			mapper.linearize[value] = hlgInverseOETF(signal)
+		} else {
+			mapper.linearize[value] = pqEOTF(signal) / sdrWhiteNits
+		}
+	}
+
+	for step := range srgbEncodeSteps + 1 {
+		encoded := srgbEncode(float64(step) / srgbEncodeSteps)
+		mapper.srgbEncode[step] = uint8(math.Round(encoded * 255))
+	}
+
+	return mapper, nil
+}
+
+// apply tone maps img pixels into a new sRGB image
+func (m *toneMapper) apply(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	mapped := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		offset := mapped.PixOffset(0, y-bounds.Min.Y)
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			red, green, blue := m.mapPixel(r, g, b)
+
+			mapped.Pix[offset] = red
+			mapped.Pix[offset+1] = green
+			mapped.Pix[offset+2] = blue
+			mapped.Pix[offset+3] = uint8(a >> 8)
+			offset += 4
+		}
+	}
+
+	return mapped
+}
+
+// mapPixel tone maps 16 bits HDR signal values into 8 bits sRGB ones
+func (m *toneMapper) mapPixel(r, g, b uint32) (uint8, uint8, uint8) {
+	rgb := [3]float64{m.linearize[r], m.linearize[g], m.linearize[b]}
+	if m.hlg {
+		rgb = hlgOOTF(rgb)
+	}
+
+	var linear [3]float64
+	for channel, row := range m.matrix {
+		linear[channel] = max(row[0]*rgb[0]+row[1]*rgb[1]+row[2]*rgb[2], 0)
+	}
+
+	// Scale channels by the compression of the brightest one, so hues
+	// are kept
+	signal := max(linear[0], linear[1], linear[2], 1e-6)
+	scale := m.operator(signal) / signal
+
+	return m.encode(linear[0] * scale),
+		m.encode(linear[1] * scale),
+		m.encode(linear[2] * scale)
+}
+
+func (m *toneMapper) encode(linear float64) uint8 {
+	linear = min(max(linear, 0), 1)
+	return m.srgbEncode[int(math.Round(linear*srgbEncodeSteps))]
+}
+
+// toneMapCurve returns the curve of operator mapping linear light up to
+// peak (SDR white is 1) into SDR range, as ffmpeg 'tonemap' filter does
+// with its default parameters
+func toneMapCurve(
+	operator models.ToneMapOperator,
+	peak float64,
+) (func(float64) float64, error) {
+	switch operator {
+	case models.ToneMapClip:
+		return func(signal float64) float64 {
+			return min(signal, 1)
+		}, nil
+	case models.ToneMapReinhard:
+		return func(signal float64) float64 {
+			return signal / (signal + 1) * (peak + 1) / peak
+		}, nil
+	case models.ToneMapHable:
+		return func(signal float64) float64 {
+			return hable(signal) / hable(peak)
+		}, nil
+	case models.ToneMapMobius:
+		return func(signal float64) float64 {
+			return mobius(signal, 0.3, peak)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported tone map operator: %q", operator)
+	}
+}
+
+// hable is the filmic curve by John Hable (Uncharted 2)
+func hable(signal float64) float64 {
+	const a, b, c, d, e, f = 0.15, 0.50, 0.10, 0.20, 0.02, 0.30
+	return (signal*(signal*a+b*c)+d*e)/(signal*(signal*a+b)+d*f) - e/f
+}
+
+// mobius keeps values up to threshold unchanged and compresses the ones
+// above it towards 1 at peak
+func mobius(signal float64, threshold float64, peak float64) float64 {
+	if signal <= threshold {
+		return signal
+	}
+
+	a := -threshold * threshold * (peak - 1) / (threshold*threshold - 2*threshold + peak)
+	b := (threshold*threshold - 2*threshold*peak + peak) / max(peak-1, 1e-6)
+	return (b*b + 2*b*threshold + threshold*threshold) / (b - a) * (signal + a) / (signal + b)
+}
+
+// pqEOTF converts a PQ (SMPTE ST 2084) signal into luminance in nits
+func pqEOTF(signal float64) float64 {
+	const m1, m2 = 2610.0 / 16384, 2523.0 / 4096 * 128
+	const c1, c2, c3 = 3424.0 / 4096, 2413.0 / 4096 * 32, 2392.0 / 4096 * 32
+
+	power := math.Pow(signal, 1/m2)
+	return 10000 * math.Pow(max(power-c1, 0)/(c2-c3*power), 1/m1)
+}
+
+// hlgInverseOETF converts an HLG (ARIB STD-B67) signal into normalized
+// scene light
+func hlgInverseOETF(signal float64) float64 {
+	const a, b, c = 0.17883277, 0.28466892, 0.55991073
+	if signal <= 0.5 {
+		return signal * signal / 3
+	}
+
+	return (math.Exp((signal-c)/a) + b) / 12
+}
+
+// hlgOOTF converts HLG scene light into display light relative to SDR
+// white, for a display of hdrPeak (system gamma 1.2 at 1000 nits)
+func hlgOOTF(rgb [3]float64) [3]float64 {
+	luminance := 0.2627*rgb[0] + 0.6780*rgb[1] + 0.0593*rgb[2]
+	gain := hdrPeak * math.Pow(luminance, 0.2)
+
+	return [3]float64{rgb[0] * gain, rgb[1] * gain, rgb[2] * gain}
+}
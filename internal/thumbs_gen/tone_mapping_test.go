@@ -0,0 +1,153 @@
+package thumbsgen
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/metadata"
+	"github.com/giobyte8/thumbnailer/internal/models"
+	"github.com/giobyte8/thumbnailer/internal/testutils"
+)
+
+// 16 bits signal of SDR white (100 nits) in PQ
+const pqSdrWhite = 33297
+
+func TestToneMapCurves(t *testing.T) {
+	operators := []models.ToneMapOperator{
+		models.ToneMapClip,
+		models.ToneMapReinhard,
+		models.ToneMapHable,
+		models.ToneMapMobius,
+	}
+
+	for _, operator := range operators {
+		t.Run(string(operator), func(t *testing.T) {
+			curve, err := toneMapCurve(operator, hdrPeak)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := curve(0); got > 1e-6 {
+				t.Errorf("black maps to %f", got)
+			}
+			if got := curve(hdrPeak); got < 0.999 || got > 1.001 {
+				t.Errorf("peak maps to %f, want 1", got)
+			}
+
+			prev := curve(0)
+			for signal := 0.1; signal <= hdrPeak; signal += 0.1 {
+				if mapped := curve(signal); mapped < prev {
+					t.Fatalf("curve decreases at %f", signal)
+				} else {
+					prev = mapped
+				}
+			}
+		})
+	}
+
+	if _, err := toneMapCurve(models.ToneMapNone, hdrPeak); err == nil {
+		t.Error("expected error for disabled operator")
+	}
+}
+
+// Reference pixels of BT.2020 HDR signals tone mapped into sRGB
+func TestToneMapper_ReferencePixels(t *testing.T) {
+	tests := []struct {
+		name     string
+		transfer uint16
+		operator models.ToneMapOperator
+		in       [3]uint32
+		want     [3]uint8
+	}{
+		{"pq black", metadata.TransferPQ, models.ToneMapHable, [3]uint32{0, 0, 0}, [3]uint8{0, 0, 0}},
+		{"pq sdr white hable", metadata.TransferPQ, models.ToneMapHable, [3]uint32{pqSdrWhite, pqSdrWhite, pqSdrWhite}, [3]uint8{152, 152, 152}},
+		{"pq sdr white reinhard", metadata.TransferPQ, models.ToneMapReinhard, [3]uint32{pqSdrWhite, pqSdrWhite, pqSdrWhite}, [3]uint8{196, 196, 196}},
+		{"pq sdr white mobius", metadata.TransferPQ, models.ToneMapMobius, [3]uint32{pqSdrWhite, pqSdrWhite, pqSdrWhite}, [3]uint8{213, 213, 213}},
+		{"pq peak", metadata.TransferPQ, models.ToneMapHable, [3]uint32{65535, 65535, 65535}, [3]uint8{255, 255, 255}},
+		{"hlg skin hable", metadata.TransferHLG, models.ToneMapHable, [3]uint32{47000, 30000, 20000}, [3]uint8{191, 80, 55}},
+		{"hlg sky reinhard", metadata.TransferHLG, models.ToneMapReinhard, [3]uint32{30000, 40000, 50000}, [3]uint8{56, 158, 222}},
+		{"hlg sky mobius", metadata.TransferHLG, models.ToneMapMobius, [3]uint32{30000, 40000, 50000}, [3]uint8{60, 167, 234}},
+		{"hlg skin clip", metadata.TransferHLG, models.ToneMapClip, [3]uint32{47000, 30000, 20000}, [3]uint8{255, 110, 76}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mapper, err := newToneMapper(
+				&metadata.NclxColor{Primaries: primariesBT2020, Transfer: tc.transfer},
+				tc.operator,
+			)
+			if err != nil {
+				t.Fatalf("failed to create tone mapper: %v", err)
+			}
+
+			r, g, b := mapper.mapPixel(tc.in[0], tc.in[1], tc.in[2])
+			if got := [3]uint8{r, g, b}; got != tc.want {
+				t.Fatalf("pixel = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// Tone mapped HLG frame of testdata/hdr_fixtures.go against its
+// reference frames
+func TestToneMapper_ReferenceFrames(t *testing.T) {
+	frame := testutils.LoadImage(t, testutils.TestFilePath("18 hdr_hlg.png"))
+
+	operators := []models.ToneMapOperator{
+		models.ToneMapClip,
+		models.ToneMapReinhard,
+		models.ToneMapHable,
+		models.ToneMapMobius,
+	}
+	for _, operator := range operators {
+		t.Run(string(operator), func(t *testing.T) {
+			mapper, err := newToneMapper(
+				&metadata.NclxColor{Primaries: primariesBT2020, Transfer: metadata.TransferHLG},
+				operator,
+			)
+			if err != nil {
+				t.Fatalf("failed to create tone mapper: %v", err)
+			}
+
+			reference := testutils.LoadImage(
+				t,
+				testutils.TestFilePath(fmt.Sprintf("18 hdr_hlg_srgb_%s.png", operator)),
+			)
+			testutils.AssertPatchesMatch(t, mapper.apply(frame), reference, 16, 0, 2)
+		})
+	}
+}
+
+func TestToneMapper_NotWashedOut(t *testing.T) {
+	mapper, err := newToneMapper(
+		&metadata.NclxColor{Primaries: primariesBT2020, Transfer: metadata.TransferPQ},
+		models.DefaultToneMapOperator,
+	)
+	if err != nil {
+		t.Fatalf("failed to create tone mapper: %v", err)
+	}
+
+	// Gradient from black to 1000 nits
+	frame := image.NewRGBA64(image.Rect(0, 0, 64, 1))
+	for x := range 64 {
+		signal := uint16(x * 48000 / 63)
+		frame.SetRGBA64(x, 0, color.RGBA64{R: signal, G: signal, B: signal, A: 0xffff})
+	}
+
+	mapped := mapper.apply(frame)
+	if got := mapped.NRGBAAt(0, 0).R; got != 0 {
+		t.Errorf("black maps to %d", got)
+	}
+	if got := mapped.NRGBAAt(63, 0).R; got < 250 {
+		t.Errorf("1000 nits maps to %d, want near white", got)
+	}
+
+	// Truncating the signal renders black as 0 but highlights as grey,
+	// tone mapping must spread gradient over the whole range
+	truncatedPeak := uint8(48000 >> 8)
+	if mapped.NRGBAAt(63, 0).R <= truncatedPeak {
+		t.Errorf("tone mapped highlights aren't brighter than truncated ones")
+	}
+}
@@ -0,0 +1,47 @@
+package thumbsgen
+
+import (
+	"slices"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+// resolveTargetWidths applies the upscale policy to the requested widths
+// and returns the widths that should actually be generated for an
+// original file with given width.
+func resolveTargetWidths(
+	widths []int,
+	origWidth int,
+	policy models.UpscalePolicy,
+) []int {
+	switch policy {
+	case models.UpscaleSkip:
+		targetWidths := make([]int, 0, len(widths))
+		for _, width := range widths {
+			if width <= origWidth {
+				targetWidths = append(targetWidths, width)
+			}
+		}
+
+		// Make sure at least an 'original size' thumbnail is produced
+		if len(targetWidths) == 0 && len(widths) > 0 {
+			targetWidths = append(targetWidths, origWidth)
+		}
+
+		return targetWidths
+
+	case models.UpscaleClamp:
+		targetWidths := make([]int, 0, len(widths))
+		for _, width := range widths {
+			width = min(width, origWidth)
+			if !slices.Contains(targetWidths, width) {
+				targetWidths = append(targetWidths, width)
+			}
+		}
+
+		return targetWidths
+
+	default:
+		return slices.Clone(widths)
+	}
+}
@@ -0,0 +1,69 @@
+package thumbsgen
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/giobyte8/thumbnailer/internal/models"
+)
+
+func TestResolveTargetWidths(t *testing.T) {
+	tests := []struct {
+		name      string
+		widths    []int
+		origWidth int
+		policy    models.UpscalePolicy
+		expected  []int
+	}{
+		{
+			name:      "allow keeps every width",
+			widths:    []int{256, 512, 1024},
+			origWidth: 400,
+			policy:    models.UpscaleAllow,
+			expected:  []int{256, 512, 1024},
+		},
+		{
+			name:      "zero value policy keeps every width",
+			widths:    []int{256, 1024},
+			origWidth: 400,
+			expected:  []int{256, 1024},
+		},
+		{
+			name:      "skip drops larger widths",
+			widths:    []int{256, 512, 1024},
+			origWidth: 600,
+			policy:    models.UpscaleSkip,
+			expected:  []int{256, 512},
+		},
+		{
+			name:      "skip falls back to original width",
+			widths:    []int{512, 1024},
+			origWidth: 300,
+			policy:    models.UpscaleSkip,
+			expected:  []int{300},
+		},
+		{
+			name:      "clamp replaces larger widths with original width",
+			widths:    []int{256, 512, 1024},
+			origWidth: 400,
+			policy:    models.UpscaleClamp,
+			expected:  []int{256, 400},
+		},
+		{
+			name:      "clamp keeps widths within original",
+			widths:    []int{256, 400},
+			origWidth: 400,
+			policy:    models.UpscaleClamp,
+			expected:  []int{256, 400},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := resolveTargetWidths(tc.widths, tc.origWidth, tc.policy)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Fatalf("unexpected widths: got %v want %v", got, tc.expected)
+			}
+		})
+	}
+}
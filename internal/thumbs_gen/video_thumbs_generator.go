@@ -3,15 +3,19 @@ package thumbsgen
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 
 	"github.com/giobyte8/thumbnailer/internal/format"
+	"github.com/giobyte8/thumbnailer/internal/metadata"
+	"github.com/giobyte8/thumbnailer/internal/models"
 	frameextractor "github.com/giobyte8/thumbnailer/internal/thumbs_gen/frame_extractor"
 )
 
 type VideoThumbsGenerator struct {
 	frameExtractor       *frameextractor.Extractor
+	metaExtractor        *metadata.Extractor
 	imageThumbsGenerator ThumbsGenerator
 }
 
@@ -23,6 +27,7 @@ func NewVideoThumbsGenerator(
 ) *VideoThumbsGenerator {
 	return &VideoThumbsGenerator{
 		frameExtractor:       frameExtractor,
+		metaExtractor:        metadata.NewExtractor(),
 		imageThumbsGenerator: imageThumbsGenerator,
 	}
 }
@@ -31,7 +36,7 @@ func NewVideoThumbsGenerator(
 func (g *VideoThumbsGenerator) Generate(
 	ctx context.Context,
 	meta ThumbnailMeta,
-) error {
+) (*models.ThumbGenResult, error) {
 	return g.generateThumb(ctx, meta, true)
 }
 
@@ -40,7 +45,7 @@ func (g *VideoThumbsGenerator) GenerateWithoutFormatsCheck(
 	ctx context.Context,
 	meta ThumbnailMeta,
 	origFileFormat format.Format,
-) error {
+) (*models.ThumbGenResult, error) {
 	return g.generateThumb(ctx, meta, false)
 }
 
@@ -48,22 +53,70 @@ func (g *VideoThumbsGenerator) generateThumb(
 	ctx context.Context,
 	meta ThumbnailMeta,
 	withFormatChecks bool,
-) error {
+) (*models.ThumbGenResult, error) {
 	origFileAbsPath := mkOriginalFileAbsPath(meta)
 	vidFrameAbsPath := mkIntermediaryThumbFileAbsPath(meta, ".jpg")
 	defer func() {
 		_ = os.Remove(vidFrameAbsPath)
 	}()
 
-	// Extract a frame from the video
-	var err error
+	// Without stream info frames are still extracted, relying on ffmpeg
+	// autorotation and fixed seek offsets
+	video, err := g.frameExtractor.Probe(ctx, origFileAbsPath)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		slog.Warn(
+			"Failed to probe video",
+			"filePath", meta.OrigFileRelPath,
+			"error", err,
+		)
+	}
+
+	// Stills of Live Photo videos would duplicate the photo, the preview
+	// clip replaces them when configured
+	livePhoto := g.pairLivePhotoVideo(meta)
+	if livePhoto != nil && meta.LivePhotoVideo == models.LivePhotoVideoPreview {
+		result, err := g.generateLivePhotoPreview(ctx, meta, video)
+		if err == nil {
+			result.LivePhoto = livePhoto
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		slog.Warn(
+			"Failed to generate live photo preview, generating still thumbnails",
+			"filePath", meta.OrigFileRelPath,
+			"error", err,
+		)
+	}
+
+	// Extract a representative frame from the video
 	if withFormatChecks {
-		err = g.frameExtractor.Extract(ctx, origFileAbsPath, vidFrameAbsPath)
+		err = g.frameExtractor.Extract(
+			ctx,
+			origFileAbsPath,
+			vidFrameAbsPath,
+			video,
+			meta.ToneMap,
+			meta.FrameSamples,
+		)
 	} else {
-		err = g.frameExtractor.ExtractWithoutFormatsCheck(ctx, origFileAbsPath, vidFrameAbsPath)
+		err = g.frameExtractor.ExtractWithoutFormatsCheck(
+			ctx,
+			origFileAbsPath,
+			vidFrameAbsPath,
+			video,
+			meta.ToneMap,
+			meta.FrameSamples,
+		)
 	}
 	if err != nil {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"failed to extract frame from video %s: %w",
 			meta.OrigFileRelPath,
 			err,
@@ -74,24 +127,168 @@ func (g *VideoThumbsGenerator) generateThumb(
 	frameMeta := meta
 	frameMeta.OrigFilesRootDir = meta.ThumbFileAbsDir
 	frameMeta.OrigFileRelPath = filepath.Base(vidFrameAbsPath)
+	frameMeta.LivePhotoPhoto = ""
 
 	// Generate thumbnails from the extracted frame
+	var result *models.ThumbGenResult
 	if withFormatChecks {
-		err = g.imageThumbsGenerator.Generate(ctx, frameMeta)
+		result, err = g.imageThumbsGenerator.Generate(ctx, frameMeta)
 	} else {
-		err = g.imageThumbsGenerator.GenerateWithoutFormatsCheck(
+		result, err = g.imageThumbsGenerator.GenerateWithoutFormatsCheck(
 			ctx,
 			frameMeta,
 			format.JPEG,
 		)
 	}
 	if err != nil {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"failed to generate video thumbnails from extracted frame for %s: %w",
 			meta.OrigFileRelPath,
 			err,
 		)
 	}
 
+	result.Video = video
+	result.LivePhoto = livePhoto
+
+	if meta.VideoPreview.Enabled() {
+		result.Preview, err = g.generatePreview(ctx, meta, video, result.OrigWidth)
+		if err := optionalOutputErr(ctx, meta, "preview", err); err != nil {
+			return nil, err
+		}
+	}
+
+	if meta.Storyboard.Enabled() {
+		result.Storyboard, err = g.generateStoryboard(ctx, meta, video)
+		if err := optionalOutputErr(ctx, meta, "storyboard", err); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// optionalOutputErr logs failures of outputs produced along with video
+// thumbnails (e.g. preview clip), which are still usable without them.
+// Only cancellation errors are returned.
+func optionalOutputErr(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	output string,
+	err error,
+) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+
+	slog.Warn(
+		"Failed to generate optional video output",
+		"output", output,
+		"filePath", meta.OrigFileRelPath,
+		"error", err,
+	)
 	return nil
 }
+
+// generatePreview produces the looping preview clip of the video, whose
+// frames are videoWidth pixels wide
+func (g *VideoThumbsGenerator) generatePreview(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	video *models.VideoInfo,
+	videoWidth int,
+) (*models.VideoPreview, error) {
+	opts := meta.VideoPreview
+	previewAbsPath := mkPreviewFileAbsPath(meta, opts.Format.Extension())
+
+	clipDuration, err := g.frameExtractor.ExtractPreview(
+		ctx,
+		mkOriginalFileAbsPath(meta),
+		previewAbsPath,
+		video,
+		meta.ToneMap,
+		opts,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to extract preview from video %s: %w",
+			meta.OrigFileRelPath,
+			err,
+		)
+	}
+
+	return &models.VideoPreview{
+		FileName:   filepath.Base(previewAbsPath),
+		Format:     opts.Format,
+		Width:      min(opts.Width, videoWidth),
+		DurationMs: clipDuration.Milliseconds(),
+	}, nil
+}
+
+// pairLivePhotoVideo describes the original as video of an Apple Live
+// Photo, confirming the pairing with content identifiers when both files
+// record them. Returns nil for other videos.
+func (g *VideoThumbsGenerator) pairLivePhotoVideo(
+	meta ThumbnailMeta,
+) *models.LivePhoto {
+	if meta.LivePhotoPhoto == "" {
+		return nil
+	}
+
+	photo, found := livePhotoFileOf(meta.LivePhotoPhoto, livePhotoPhotoFiles)
+	if !found {
+		return nil
+	}
+
+	var contentIdentifier string
+	videoMeta, err := g.metaExtractor.Extract(mkOriginalFileAbsPath(meta), format.MOV)
+	if err == nil && videoMeta != nil {
+		contentIdentifier = videoMeta.ContentIdentifier
+	}
+
+	return pairLivePhoto(
+		g.metaExtractor,
+		meta,
+		models.LivePhotoRoleVideo,
+		contentIdentifier,
+		photo,
+		meta.LivePhotoPhoto,
+	)
+}
+
+// generateLivePhotoPreview produces the preview clip of a Live Photo
+// video in place of its thumbnails. Clip plays the video from its start
+// with the settings of video previews, as webp when these are disabled.
+func (g *VideoThumbsGenerator) generateLivePhotoPreview(
+	ctx context.Context,
+	meta ThumbnailMeta,
+	video *models.VideoInfo,
+) (*models.ThumbGenResult, error) {
+	previewMeta := meta
+	previewMeta.VideoPreview.Segments = 1
+	if !previewMeta.VideoPreview.Enabled() {
+		previewMeta.VideoPreview.Format = models.VideoPreviewWebp
+	}
+
+	result := &models.ThumbGenResult{
+		Thumbs: []models.Thumb{},
+		Video:  video,
+	}
+
+	videoWidth := previewMeta.VideoPreview.Width
+	if video != nil && video.Width > 0 {
+		result.OrigWidth, result.OrigHeight = video.Width, video.Height
+		videoWidth = video.Width
+	}
+
+	preview, err := g.generatePreview(ctx, previewMeta, video, videoWidth)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Preview = preview
+	return result, nil
+}
@@ -49,7 +49,7 @@ func TestVideoThumbsGenerator_Integration_SupportedCases(t *testing.T) {
 				ThumbWidths:      tc.thumbWidths,
 			}
 
-			if err := generator.Generate(context.Background(), meta); err != nil {
+			if _, err := generator.Generate(context.Background(), meta); err != nil {
 				t.Fatalf("generate failed: %v", err)
 			}
 
@@ -98,7 +98,7 @@ func TestVideoThumbsGenerator_Integration_WithoutFormatsCheck(t *testing.T) {
 				ThumbWidths:      tc.thumbWidths,
 			}
 
-			if err := generator.GenerateWithoutFormatsCheck(
+			if _, err := generator.GenerateWithoutFormatsCheck(
 				context.Background(),
 				meta,
 				tc.originalFormat,
@@ -0,0 +1,62 @@
+package thumbsgen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"slices"
+)
+
+// Intrinsic size of waveforms, used when no thumbnail width is known.
+// Waveforms are rendered at the size of the largest thumbnail otherwise.
+const (
+	waveformWidth  = 1024
+	waveformHeight = 512
+)
+
+// Waveform bars fill at most this fraction of image height
+const waveformFill = 0.9
+
+var (
+	waveformBackground = color.RGBA{R: 0x1F, G: 0x23, B: 0x30, A: 0xFF}
+	waveformForeground = color.RGBA{R: 0x4F, G: 0x9D, B: 0xDE, A: 0xFF}
+)
+
+// renderWaveform draws one vertical bar per peak, mirrored around the
+// horizontal center of an opaque image. Peaks are normalized to the
+// loudest one so quiet recordings remain readable.
+func renderWaveform(peaks []float64, width int, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(
+		img,
+		img.Bounds(),
+		image.NewUniform(waveformBackground),
+		image.Point{},
+		draw.Src,
+	)
+
+	loudest := 0.0
+	if len(peaks) > 0 {
+		loudest = slices.Max(peaks)
+	}
+
+	center := height / 2
+	for x := range min(width, len(peaks)) {
+		halfHeight := 0
+		if loudest > 0 {
+			halfHeight = int(peaks[x] / loudest * waveformFill * float64(height) / 2)
+		}
+
+		// Silence still shows as a line across the center
+		bar := image.Rect(x, center-halfHeight, x+1, center+halfHeight+1)
+		draw.Draw(
+			img,
+			bar.Intersect(img.Bounds()),
+			image.NewUniform(waveformForeground),
+			image.Point{},
+			draw.Src,
+		)
+	}
+
+	return img
+}
@@ -0,0 +1,48 @@
+package thumbsgen
+
+import "testing"
+
+func TestRenderWaveform(t *testing.T) {
+	peaks := []float64{0, 0.25, 0.5, 0.25}
+	img := renderWaveform(peaks, 4, 100)
+
+	// Loudest peak is normalized to fill most of the height
+	barHeights := make([]int, len(peaks))
+	for x := range peaks {
+		for y := range 100 {
+			if img.RGBAAt(x, y) == waveformForeground {
+				barHeights[x]++
+			}
+		}
+	}
+
+	want := []int{1, 45, 91, 45}
+	for x := range want {
+		if barHeights[x] != want[x] {
+			t.Fatalf("bar heights = %v, want %v", barHeights, want)
+		}
+	}
+
+	if corner := img.RGBAAt(0, 0); corner != waveformBackground {
+		t.Fatalf("expected opaque background, got %v", corner)
+	}
+	if center := img.RGBAAt(2, 50); center != waveformForeground {
+		t.Fatalf("expected bar across the center, got %v", center)
+	}
+}
+
+func TestRenderWaveform_Silence(t *testing.T) {
+	img := renderWaveform([]float64{0, 0}, 2, 10)
+
+	for x := range 2 {
+		for y := range 10 {
+			want := waveformBackground
+			if y == 5 {
+				want = waveformForeground
+			}
+			if got := img.RGBAAt(x, y); got != want {
+				t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
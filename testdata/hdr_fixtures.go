@@ -0,0 +1,341 @@
+//go:build ignore
+
+// Writes the small HDR fixtures of this directory and their reference
+// SDR frames. The same frame of BT.2020 HLG color patches is stored as
+// an uncompressed 10 bits video (v210 in QuickTime, tagged with 'nclx'
+// color) and as a 16 bits PNG holding the HLG signal of HEIF originals
+// decoded by heif-convert.
+//
+// References tone map the signal in floating point following BT.2100
+// (HLG OOTF for a 1000 nits display, SDR white at 100 nits) and the
+// curves of ffmpeg 'tonemap' filter with its default parameters. Frames
+// extracted from videos are BT.709 encoded, images are sRGB encoded.
+//
+// Usage (from repository root): go run testdata/hdr_fixtures.go
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const (
+	frameWidth  = 64
+	frameHeight = 32
+	patchSize   = 16
+	frameCount  = 4
+)
+
+// HLG signal of color patches, left to right and top to bottom: black,
+// mid grey, bright grey, peak white, skin, sky, red and foliage
+var patches = [8][3]float64{
+	{0, 0, 0},
+	{0.50, 0.50, 0.50},
+	{0.75, 0.75, 0.75},
+	{1, 1, 1},
+	{0.72, 0.46, 0.30},
+	{0.46, 0.61, 0.76},
+	{0.80, 0.20, 0.15},
+	{0.30, 0.70, 0.25},
+}
+
+// Peak luminance of HLG displays relative to SDR white (1000 / 100 nits)
+const hdrPeak = 10
+
+// Linear RGB conversion from BT.2020 into BT.709 primaries
+var bt2020ToBT709 = [3][3]float64{
+	{1.660491, -0.587641, -0.072850},
+	{-0.124550, 1.132900, -0.008349},
+	{-0.018151, -0.100579, 1.118730},
+}
+
+var operators = map[string]func(float64) float64{
+	"clip": func(signal float64) float64 {
+		return min(signal, 1)
+	},
+	"reinhard": func(signal float64) float64 {
+		return signal / (signal + 1) * (hdrPeak + 1) / hdrPeak
+	},
+	"hable": func(signal float64) float64 {
+		return hable(signal) / hable(hdrPeak)
+	},
+	"mobius": func(signal float64) float64 {
+		const threshold = 0.3
+		if signal <= threshold {
+			return signal
+		}
+
+		a := -threshold * threshold * (hdrPeak - 1) / (threshold*threshold - 2*threshold + hdrPeak)
+		b := (threshold*threshold - 2*threshold*hdrPeak + hdrPeak) / (hdrPeak - 1)
+		return (b*b + 2*b*threshold + threshold*threshold) / (b - a) * (signal + a) / (signal + b)
+	},
+}
+
+func hable(signal float64) float64 {
+	const a, b, c, d, e, f = 0.15, 0.50, 0.10, 0.20, 0.02, 0.30
+	return (signal*(signal*a+b*c)+d*e)/(signal*(signal*a+b)+d*f) - e/f
+}
+
+// patchAt returns the HLG signal of pixel x, y
+func patchAt(x int, y int) [3]float64 {
+	return patches[(y/patchSize)*(frameWidth/patchSize)+x/patchSize]
+}
+
+// toneMap converts an HLG signal into linear BT.709 light within SDR
+// range: inverse OETF, OOTF, primaries conversion and compression of the
+// brightest channel, which keeps hues
+func toneMap(signal [3]float64, operator func(float64) float64) [3]float64 {
+	var scene [3]float64
+	for channel, value := range signal {
+		const a, b, c = 0.17883277, 0.28466892, 0.55991073
+		if value <= 0.5 {
+			scene[channel] = value * value / 3
+		} else {
+			scene[channel] = (math.Exp((value-c)/a) + b) / 12
+		}
+	}
+
+	luminance := 0.2627*scene[0] + 0.6780*scene[1] + 0.0593*scene[2]
+	gain := hdrPeak * math.Pow(luminance, 0.2)
+
+	var linear [3]float64
+	for channel, row := range bt2020ToBT709 {
+		value := row[0]*scene[0] + row[1]*scene[1] + row[2]*scene[2]
+		linear[channel] = max(value*gain, 0)
+	}
+
+	brightest := max(linear[0], linear[1], linear[2], 1e-6)
+	scale := operator(brightest) / brightest
+	for channel := range linear {
+		linear[channel] = min(max(linear[channel]*scale, 0), 1)
+	}
+	return linear
+}
+
+func srgbEncode(linear float64) float64 {
+	if linear <= 0.0031308 {
+		return linear * 12.92
+	}
+	return 1.055*math.Pow(linear, 1/2.4) - 0.055
+}
+
+func bt709Encode(linear float64) float64 {
+	if linear < 0.018 {
+		return linear * 4.5
+	}
+	return 1.099*math.Pow(linear, 0.45) - 0.099
+}
+
+// referenceFrame tone maps every patch and encodes it with encode
+func referenceFrame(operator func(float64) float64, encode func(float64) float64) []byte {
+	frame := image.NewNRGBA(image.Rect(0, 0, frameWidth, frameHeight))
+	for y := range frameHeight {
+		for x := range frameWidth {
+			linear := toneMap(patchAt(x, y), operator)
+			frame.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(math.Round(encode(linear[0]) * 255)),
+				G: uint8(math.Round(encode(linear[1]) * 255)),
+				B: uint8(math.Round(encode(linear[2]) * 255)),
+				A: 0xff,
+			})
+		}
+	}
+
+	return encodePng(frame)
+}
+
+// hdrStill stores the HLG signal in 16 bits, as heif-convert decodes
+// HDR HEIF originals
+func hdrStill() []byte {
+	frame := image.NewRGBA64(image.Rect(0, 0, frameWidth, frameHeight))
+	for y := range frameHeight {
+		for x := range frameWidth {
+			signal := patchAt(x, y)
+			frame.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(math.Round(signal[0] * 0xffff)),
+				G: uint16(math.Round(signal[1] * 0xffff)),
+				B: uint16(math.Round(signal[2] * 0xffff)),
+				A: 0xffff,
+			})
+		}
+	}
+
+	return encodePng(frame)
+}
+
+func encodePng(img image.Image) []byte {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		log.Fatal(err)
+	}
+	return encoded.Bytes()
+}
+
+// v210Frame packs the HLG signal as 10 bits limited range BT.2020 Y'CbCr
+// 4:2:2: six pixels per 16 bytes, rows padded to 128 bytes
+func v210Frame() []byte {
+	stride := (frameWidth + 47) / 48 * 128
+	frame := make([]byte, stride*frameHeight)
+
+	for y := range frameHeight {
+		var samples []uint32
+		for x := 0; x < frameWidth; x += 2 {
+			luma, cb, cr := ycbcr(patchAt(x, y))
+			nextLuma, _, _ := ycbcr(patchAt(x+1, y))
+			samples = append(samples, cb, luma, cr, nextLuma)
+		}
+
+		// Three samples per little endian word
+		row := frame[y*stride : (y+1)*stride]
+		for idx := 0; idx < len(samples); idx += 3 {
+			var word uint32
+			for shift, sample := range samples[idx:min(idx+3, len(samples))] {
+				word |= sample << (10 * shift)
+			}
+			binary.LittleEndian.PutUint32(row[idx/3*4:], word)
+		}
+	}
+
+	return frame
+}
+
+// ycbcr converts an HLG R'G'B' signal into 10 bits limited range Y'CbCr
+// with BT.2020 non constant luminance coefficients
+func ycbcr(signal [3]float64) (uint32, uint32, uint32) {
+	luma := 0.2627*signal[0] + 0.6780*signal[1] + 0.0593*signal[2]
+	cb := (signal[2] - luma) / 1.8814
+	cr := (signal[0] - luma) / 1.4746
+
+	return uint32(math.Round(64 + 876*luma)),
+		uint32(math.Round(512 + 896*cb)),
+		uint32(math.Round(512 + 896*cr))
+}
+
+func isoBox(boxType string, payloads ...[]byte) []byte {
+	size := 8
+	for _, payload := range payloads {
+		size += len(payload)
+	}
+
+	data := binary.BigEndian.AppendUint32(nil, uint32(size))
+	data = append(data, boxType...)
+	for _, payload := range payloads {
+		data = append(data, payload...)
+	}
+	return data
+}
+
+func fullBox(boxType string, flags uint32, payloads ...[]byte) []byte {
+	return isoBox(boxType, append([][]byte{u32(flags)}, payloads...)...)
+}
+
+func u16(value uint16) []byte { return binary.BigEndian.AppendUint16(nil, value) }
+func u32(value uint32) []byte { return binary.BigEndian.AppendUint32(nil, value) }
+
+// Identity transformation matrix of movie and track headers
+func identityMatrix() []byte {
+	var matrix []byte
+	for _, value := range []uint32{0x10000, 0, 0, 0, 0x10000, 0, 0, 0, 0x40000000} {
+		matrix = append(matrix, u32(value)...)
+	}
+	return matrix
+}
+
+// hdrVideo: one second of identical v210 frames, tagged as BT.2020
+// primaries and matrix with HLG transfer
+func hdrVideo() []byte {
+	frame := v210Frame()
+	ftyp := isoBox("ftyp", []byte("qt  "), u32(0x200), []byte("qt  "))
+
+	// 'colr' nclx: primaries, transfer and matrix code points, limited
+	// range
+	colr := isoBox("colr", []byte("nclx"), u16(9), u16(18), u16(9), []byte{0})
+
+	compressorName := make([]byte, 32)
+	compressorName[0] = byte(copy(compressorName[1:], "v210"))
+	sampleEntry := isoBox("v210",
+		make([]byte, 6), u16(1), // reserved, data reference index
+		u16(0), u16(0), u32(0), u32(0), u32(0), // version, revision, vendor, quality
+		u16(frameWidth), u16(frameHeight),
+		u32(72<<16), u32(72<<16), u32(0), u16(1),
+		compressorName,
+		u16(24), u16(0xffff), // depth, no color table
+		colr,
+	)
+
+	// Chunk offset depends on 'moov' size, which doesn't depend on it
+	mkMoov := func(chunkOffset uint32) []byte {
+		stbl := isoBox("stbl",
+			fullBox("stsd", 0, u32(1), sampleEntry),
+			fullBox("stts", 0, u32(1), u32(frameCount), u32(1)),
+			fullBox("stsc", 0, u32(1), u32(1), u32(frameCount), u32(1)),
+			fullBox("stsz", 0, u32(uint32(len(frame))), u32(frameCount)),
+			fullBox("stco", 0, u32(1), u32(chunkOffset)),
+		)
+		minf := isoBox("minf",
+			fullBox("vmhd", 1, u16(0), u16(0), u16(0), u16(0)),
+			isoBox("dinf", fullBox("dref", 0, u32(1), fullBox("url ", 1))),
+			stbl,
+		)
+		mdia := isoBox("mdia",
+			fullBox("mdhd", 0, u32(0), u32(0), u32(frameCount), u32(frameCount), u16(0x55c4), u16(0)),
+			fullBox("hdlr", 0, []byte("mhlr"), []byte("vide"), make([]byte, 12), []byte{0}),
+			minf,
+		)
+		tkhd := fullBox("tkhd", 3,
+			u32(0), u32(0), u32(1), u32(0), u32(1000), // times, track ID, duration
+			make([]byte, 8), u16(0), u16(0), u16(0), u16(0), // layer, group, volume
+			identityMatrix(),
+			u32(frameWidth<<16), u32(frameHeight<<16),
+		)
+		mvhd := fullBox("mvhd", 0,
+			u32(0), u32(0), u32(1000), u32(1000), // times, timescale, duration
+			u32(0x10000), u16(0x100), make([]byte, 10), // rate, volume
+			identityMatrix(),
+			make([]byte, 24), u32(2), // next track ID
+		)
+		return isoBox("moov", mvhd, isoBox("trak", tkhd, mdia))
+	}
+
+	moovSize := len(mkMoov(0))
+	mdatOffset := len(ftyp) + moovSize
+	moov := mkMoov(uint32(mdatOffset + 8))
+
+	var samples []byte
+	for range frameCount {
+		samples = append(samples, frame...)
+	}
+
+	data := append([]byte{}, ftyp...)
+	data = append(data, moov...)
+	return append(data, isoBox("mdat", samples)...)
+}
+
+func main() {
+	_, source, _, _ := runtime.Caller(0)
+	dir := filepath.Dir(source)
+
+	fixtures := map[string][]byte{
+		"18 hdr_hlg.mov": hdrVideo(),
+		"18 hdr_hlg.png": hdrStill(),
+	}
+	for name, operator := range operators {
+		fixtures[fmt.Sprintf("18 hdr_hlg_srgb_%s.png", name)] = referenceFrame(operator, srgbEncode)
+		fixtures[fmt.Sprintf("18 hdr_hlg_bt709_%s.png", name)] = referenceFrame(operator, bt709Encode)
+	}
+
+	for name, data := range fixtures {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,502 @@
+//go:build ignore
+
+// Writes the small camera RAW fixtures of this directory. They follow
+// the container structure of real RAW files (IFDs and sub IFDs, CR3
+// boxes) with tiny embedded previews and fake sensor data.
+//
+// Usage (from repository root): go run testdata/raw_fixtures.go
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// TIFF field types
+const (
+	typeByte      = 1
+	typeASCII     = 2
+	typeShort     = 3
+	typeLong      = 4
+	typeSRational = 10
+)
+
+// TIFF tags
+const (
+	tagNewSubfileType    = 0x00FE
+	tagImageWidth        = 0x0100
+	tagImageLength       = 0x0101
+	tagBitsPerSample     = 0x0102
+	tagCompression       = 0x0103
+	tagPhotometric       = 0x0106
+	tagMake              = 0x010F
+	tagModel             = 0x0110
+	tagStripOffsets      = 0x0111
+	tagOrientation       = 0x0112
+	tagSamplesPerPixel   = 0x0115
+	tagRowsPerStrip      = 0x0116
+	tagStripByteCounts   = 0x0117
+	tagSubIFDs           = 0x014A
+	tagJPEGInterchange   = 0x0201
+	tagJPEGInterchangeL  = 0x0202
+	tagCFARepeatPattern  = 0x828D
+	tagCFAPattern        = 0x828E
+	tagDNGVersion        = 0xC612
+	tagUniqueCameraModel = 0xC614
+	tagWhiteLevel        = 0xC61D
+	tagColorMatrix1      = 0xC621
+	tagAsShotNeutral     = 0xC628
+)
+
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+type tiffEntry struct {
+	tag       uint16
+	fieldType uint16
+	count     uint32
+	value     []byte
+}
+
+// tiffBuilder lays out TIFF data bottom up: blobs and IFDs are appended
+// once the offsets they point to are known
+type tiffBuilder struct {
+	order byteOrder
+	data  []byte
+}
+
+func newTiffBuilder(order byteOrder, header []byte) *tiffBuilder {
+	return &tiffBuilder{order: order, data: append([]byte{}, header...)}
+}
+
+func (b *tiffBuilder) blob(data []byte) uint32 {
+	if len(b.data)%2 == 1 {
+		b.data = append(b.data, 0)
+	}
+
+	offset := uint32(len(b.data))
+	b.data = append(b.data, data...)
+	return offset
+}
+
+func (b *tiffBuilder) ifd(next uint32, entries ...tiffEntry) uint32 {
+	values := make(map[int]uint32)
+	for idx, entry := range entries {
+		if len(entry.value) > 4 {
+			values[idx] = b.blob(entry.value)
+		}
+	}
+
+	offset := b.blob(nil)
+	b.data = b.order.AppendUint16(b.data, uint16(len(entries)))
+	for idx, entry := range entries {
+		b.data = b.order.AppendUint16(b.data, entry.tag)
+		b.data = b.order.AppendUint16(b.data, entry.fieldType)
+		b.data = b.order.AppendUint32(b.data, entry.count)
+
+		if valueOffset, found := values[idx]; found {
+			b.data = b.order.AppendUint32(b.data, valueOffset)
+		} else {
+			inline := make([]byte, 4)
+			copy(inline, entry.value)
+			b.data = append(b.data, inline...)
+		}
+	}
+
+	b.data = b.order.AppendUint32(b.data, next)
+	return offset
+}
+
+func (b *tiffBuilder) setIFD0(offset uint32) {
+	b.order.PutUint32(b.data[4:8], offset)
+}
+
+func (b *tiffBuilder) short(tag uint16, values ...uint16) tiffEntry {
+	var value []byte
+	for _, v := range values {
+		value = b.order.AppendUint16(value, v)
+	}
+	return tiffEntry{tag, typeShort, uint32(len(values)), value}
+}
+
+func (b *tiffBuilder) long(tag uint16, values ...uint32) tiffEntry {
+	var value []byte
+	for _, v := range values {
+		value = b.order.AppendUint32(value, v)
+	}
+	return tiffEntry{tag, typeLong, uint32(len(values)), value}
+}
+
+func (b *tiffBuilder) ascii(tag uint16, value string) tiffEntry {
+	return tiffEntry{tag, typeASCII, uint32(len(value) + 1), append([]byte(value), 0)}
+}
+
+func (b *tiffBuilder) bytes(tag uint16, values ...byte) tiffEntry {
+	return tiffEntry{tag, typeByte, uint32(len(values)), values}
+}
+
+func (b *tiffBuilder) srational(tag uint16, den int32, values ...int32) tiffEntry {
+	var value []byte
+	for _, v := range values {
+		value = b.order.AppendUint32(value, uint32(v))
+		value = b.order.AppendUint32(value, uint32(den))
+	}
+	return tiffEntry{tag, typeSRational, uint32(len(values)), value}
+}
+
+// previewJpeg encodes a width x height picture, red on its left half and
+// blue on its right one, so orientation shows in thumbnails
+func previewJpeg(width int, height int) []byte {
+	picture := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			pixel := color.RGBA{R: 220, G: 30, B: 30, A: 255}
+			if x >= width/2 {
+				pixel = color.RGBA{R: 30, G: 30, B: 220, A: 255}
+			}
+			picture.SetRGBA(x, y, pixel)
+		}
+	}
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, picture, &jpeg.Options{Quality: 90}); err != nil {
+		log.Fatal(err)
+	}
+	return encoded.Bytes()
+}
+
+// withExifApp1 inserts an APP1 EXIF segment claiming an upright
+// orientation, as some cameras write into previews
+func withExifApp1(preview []byte) []byte {
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1}
+	tiff = append(tiff, 0x01, 0x12, 0, typeShort, 0, 0, 0, 1, 0, 1, 0, 0)
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	withExif := append([]byte{}, preview[:2]...)
+	withExif = append(withExif, segment...)
+	return append(withExif, preview[2:]...)
+}
+
+// losslessJpeg returns the start of a lossless (SOF3) JPEG stream, as
+// Canon and DNG files store sensor data, followed by fake data
+func losslessJpeg(width int, height int) []byte {
+	data := []byte{0xFF, 0xD8, 0xFF, 0xC3, 0x00, 0x0E, 0x0E}
+	data = binary.BigEndian.AppendUint16(data, uint16(height))
+	data = binary.BigEndian.AppendUint16(data, uint16(width))
+	data = append(data, 0x02, 0x01, 0x11, 0x00, 0x02, 0x11, 0x00)
+	data = append(data, make([]byte, 256)...)
+	return append(data, 0xFF, 0xD9)
+}
+
+func sensorData(size int) []byte {
+	data := make([]byte, size)
+	for idx := range data {
+		data[idx] = byte(idx * 7)
+	}
+	return data
+}
+
+// nef: big endian, JPEG preview carrying its own EXIF in a sub IFD and
+// sensor data in a second one
+func nef() []byte {
+	b := newTiffBuilder(binary.BigEndian, []byte{'M', 'M', 0, 42, 0, 0, 0, 0})
+	preview := b.blob(withExifApp1(previewJpeg(96, 64)))
+	previewLength := uint32(len(b.data)) - preview
+	raw := b.blob(sensorData(512))
+
+	previewIFD := b.ifd(0,
+		b.long(tagNewSubfileType, 1),
+		b.short(tagCompression, 6),
+		b.long(tagJPEGInterchange, preview),
+		b.long(tagJPEGInterchangeL, previewLength),
+	)
+	rawIFD := b.ifd(0,
+		b.long(tagNewSubfileType, 0),
+		b.long(tagImageWidth, 16),
+		b.long(tagImageLength, 16),
+		b.short(tagCompression, 34713),
+		b.long(tagStripOffsets, raw),
+		b.long(tagStripByteCounts, 512),
+	)
+	b.setIFD0(b.ifd(0,
+		b.long(tagNewSubfileType, 1),
+		b.ascii(tagMake, "NIKON CORPORATION"),
+		b.ascii(tagModel, "NIKON Z 6"),
+		b.short(tagOrientation, 6),
+		b.long(tagSubIFDs, previewIFD, rawIFD),
+	))
+
+	return b.data
+}
+
+// arw: little endian, preview in IFD0, smaller thumbnail in IFD1
+func arw() []byte {
+	b := newTiffBuilder(binary.LittleEndian, []byte{'I', 'I', 42, 0, 0, 0, 0, 0})
+	thumbnailJpeg := previewJpeg(32, 24)
+	thumbnail := b.blob(thumbnailJpeg)
+	previewJpegData := previewJpeg(96, 64)
+	preview := b.blob(previewJpegData)
+	raw := b.blob(sensorData(512))
+
+	rawIFD := b.ifd(0,
+		b.long(tagImageWidth, 16),
+		b.long(tagImageLength, 16),
+		b.short(tagCompression, 32767),
+		b.long(tagStripOffsets, raw),
+		b.long(tagStripByteCounts, 512),
+	)
+	ifd1 := b.ifd(0,
+		b.long(tagJPEGInterchange, thumbnail),
+		b.long(tagJPEGInterchangeL, uint32(len(thumbnailJpeg))),
+	)
+	b.setIFD0(b.ifd(ifd1,
+		b.ascii(tagMake, "SONY"),
+		b.ascii(tagModel, "ILCE-7M3"),
+		b.short(tagOrientation, 8),
+		b.long(tagSubIFDs, rawIFD),
+		b.long(tagJPEGInterchange, preview),
+		b.long(tagJPEGInterchangeL, uint32(len(previewJpegData))),
+	))
+
+	return b.data
+}
+
+// cr2: CR2 header, preview strip in IFD0, thumbnail in IFD1 and
+// lossless sensor data in IFD3
+func cr2() []byte {
+	header := []byte{'I', 'I', 42, 0, 0, 0, 0, 0, 'C', 'R', 2, 0, 0, 0, 0, 0}
+	b := newTiffBuilder(binary.LittleEndian, header)
+	previewJpegData := previewJpeg(96, 64)
+	preview := b.blob(previewJpegData)
+	thumbnailJpeg := previewJpeg(32, 24)
+	thumbnail := b.blob(thumbnailJpeg)
+	rawJpeg := losslessJpeg(512, 512)
+	raw := b.blob(rawJpeg)
+
+	ifd3 := b.ifd(0,
+		b.short(tagCompression, 6),
+		b.long(tagStripOffsets, raw),
+		b.long(tagStripByteCounts, uint32(len(rawJpeg))),
+	)
+	binary.LittleEndian.PutUint32(b.data[12:16], ifd3)
+
+	ifd2 := b.ifd(ifd3,
+		b.long(tagImageWidth, 8),
+		b.long(tagImageLength, 8),
+		b.short(tagCompression, 1),
+	)
+	ifd1 := b.ifd(ifd2,
+		b.long(tagJPEGInterchange, thumbnail),
+		b.long(tagJPEGInterchangeL, uint32(len(thumbnailJpeg))),
+	)
+	b.setIFD0(b.ifd(ifd1,
+		b.long(tagImageWidth, 96),
+		b.long(tagImageLength, 64),
+		b.short(tagCompression, 6),
+		b.ascii(tagMake, "Canon"),
+		b.ascii(tagModel, "Canon EOS 5D Mark IV"),
+		b.long(tagStripOffsets, preview),
+		b.short(tagOrientation, 3),
+		b.long(tagStripByteCounts, uint32(len(previewJpegData))),
+	))
+
+	return b.data
+}
+
+// dng: RGB thumbnail in IFD0, lossless sensor data and JPEG preview in
+// sub IFDs
+func dng() []byte {
+	b := newTiffBuilder(binary.LittleEndian, []byte{'I', 'I', 42, 0, 0, 0, 0, 0})
+	thumbnail := b.blob(sensorData(8 * 8 * 3))
+	rawJpeg := losslessJpeg(512, 512)
+	raw := b.blob(rawJpeg)
+	previewJpegData := previewJpeg(96, 64)
+	preview := b.blob(previewJpegData)
+
+	rawIFD := b.ifd(0,
+		b.long(tagNewSubfileType, 0),
+		b.long(tagImageWidth, 512),
+		b.long(tagImageLength, 512),
+		b.short(tagCompression, 7),
+		b.long(tagStripOffsets, raw),
+		b.long(tagStripByteCounts, uint32(len(rawJpeg))),
+	)
+	previewIFD := b.ifd(0,
+		b.long(tagNewSubfileType, 1),
+		b.long(tagImageWidth, 96),
+		b.long(tagImageLength, 64),
+		b.short(tagCompression, 7),
+		b.long(tagStripOffsets, preview),
+		b.long(tagStripByteCounts, uint32(len(previewJpegData))),
+	)
+	b.setIFD0(b.ifd(0,
+		b.long(tagNewSubfileType, 1),
+		b.long(tagImageWidth, 8),
+		b.long(tagImageLength, 8),
+		b.short(tagCompression, 1),
+		b.ascii(tagMake, "Apple"),
+		b.long(tagStripOffsets, thumbnail),
+		b.short(tagOrientation, 6),
+		b.long(tagStripByteCounts, 8*8*3),
+		b.long(tagSubIFDs, rawIFD, previewIFD),
+		b.bytes(tagDNGVersion, 1, 4, 0, 0),
+	))
+
+	return b.data
+}
+
+// dngWithoutPreview: uncompressed 16x16 RGGB sensor data only, for the
+// demosaic fallback
+func dngWithoutPreview() []byte {
+	b := newTiffBuilder(binary.LittleEndian, []byte{'I', 'I', 42, 0, 0, 0, 0, 0})
+
+	const size = 16
+	var cfa []byte
+	for y := range size {
+		for x := range size {
+			level := uint16(600 + x*150 + y*100)
+			cfa = binary.LittleEndian.AppendUint16(cfa, level)
+		}
+	}
+	raw := b.blob(cfa)
+
+	b.setIFD0(b.ifd(0,
+		b.long(tagNewSubfileType, 0),
+		b.long(tagImageWidth, size),
+		b.long(tagImageLength, size),
+		b.short(tagBitsPerSample, 16),
+		b.short(tagCompression, 1),
+		b.short(tagPhotometric, 32803),
+		b.ascii(tagMake, "Thumbnailer"),
+		b.ascii(tagModel, "Fixture"),
+		b.long(tagStripOffsets, raw),
+		b.short(tagOrientation, 1),
+		b.short(tagSamplesPerPixel, 1),
+		b.long(tagRowsPerStrip, size),
+		b.long(tagStripByteCounts, uint32(len(cfa))),
+		b.short(tagCFARepeatPattern, 2, 2),
+		b.bytes(tagCFAPattern, 0, 1, 1, 2),
+		b.bytes(tagDNGVersion, 1, 4, 0, 0),
+		b.ascii(tagUniqueCameraModel, "Thumbnailer Fixture"),
+		b.long(tagWhiteLevel, 4095),
+		b.srational(tagColorMatrix1, 10000,
+			10000, 0, 0,
+			0, 10000, 0,
+			0, 0, 10000,
+		),
+		b.srational(tagAsShotNeutral, 10000, 5000, 10000, 7000),
+	))
+
+	return b.data
+}
+
+func isoBox(boxType string, payloads ...[]byte) []byte {
+	size := 8
+	for _, payload := range payloads {
+		size += len(payload)
+	}
+
+	data := binary.BigEndian.AppendUint32(nil, uint32(size))
+	data = append(data, boxType...)
+	for _, payload := range payloads {
+		data = append(data, payload...)
+	}
+	return data
+}
+
+// cr3: full size JPEG as first track sample, 'PRVW' preview and 'CMT1'
+// orientation
+func cr3() []byte {
+	cr3MetadataUUID := []byte{
+		0x85, 0xc0, 0xb6, 0x87, 0x82, 0x0f, 0x11, 0xe0,
+		0x81, 0x11, 0xf4, 0xce, 0x46, 0x2b, 0x6a, 0x48,
+	}
+	cr3PreviewUUID := []byte{
+		0xea, 0xf4, 0x2b, 0x5e, 0x1c, 0x98, 0x4b, 0x88,
+		0xb9, 0xfb, 0xb7, 0xdc, 0x40, 0x6e, 0x4d, 0x16,
+	}
+
+	cmt1 := newTiffBuilder(binary.LittleEndian, []byte{'I', 'I', 42, 0, 0, 0, 0, 0})
+	cmt1.setIFD0(cmt1.ifd(0,
+		cmt1.ascii(tagMake, "Canon"),
+		cmt1.ascii(tagModel, "Canon EOS R5"),
+		cmt1.short(tagOrientation, 6),
+	))
+
+	prvwJpeg := previewJpeg(48, 32)
+	prvwHeader := make([]byte, 12)
+	binary.BigEndian.PutUint16(prvwHeader[4:], 1)
+	binary.BigEndian.PutUint16(prvwHeader[6:], 48)
+	binary.BigEndian.PutUint16(prvwHeader[8:], 32)
+	binary.BigEndian.PutUint16(prvwHeader[10:], 1)
+	prvw := isoBox(
+		"PRVW",
+		prvwHeader,
+		binary.BigEndian.AppendUint32(nil, uint32(len(prvwJpeg))),
+		prvwJpeg,
+	)
+	previewBox := isoBox("uuid", cr3PreviewUUID, make([]byte, 8), prvw)
+
+	fullJpeg := previewJpeg(96, 64)
+	ftyp := isoBox("ftyp", []byte("crx "), []byte{0, 0, 0, 1}, []byte("crx isom"))
+
+	// Chunk offset depends on 'moov' size, which doesn't depend on it
+	mkMoov := func(chunkOffset uint64) []byte {
+		stsz := isoBox("stsz",
+			[]byte{0, 0, 0, 0},
+			binary.BigEndian.AppendUint32(nil, uint32(len(fullJpeg))),
+			binary.BigEndian.AppendUint32(nil, 1),
+		)
+		co64 := isoBox("co64",
+			[]byte{0, 0, 0, 0},
+			binary.BigEndian.AppendUint32(nil, 1),
+			binary.BigEndian.AppendUint64(nil, chunkOffset),
+		)
+		trak := isoBox("trak", isoBox("mdia", isoBox("minf", isoBox("stbl", stsz, co64))))
+		metadata := isoBox("uuid", cr3MetadataUUID, isoBox("CMT1", cmt1.data))
+		return isoBox("moov", metadata, trak)
+	}
+
+	moovSize := len(mkMoov(0))
+	mdatOffset := len(ftyp) + moovSize + len(previewBox)
+	moov := mkMoov(uint64(mdatOffset + 8))
+
+	data := append([]byte{}, ftyp...)
+	data = append(data, moov...)
+	data = append(data, previewBox...)
+	return append(data, isoBox("mdat", fullJpeg)...)
+}
+
+func main() {
+	_, source, _, _ := runtime.Caller(0)
+	dir := filepath.Dir(source)
+
+	fixtures := map[string][]byte{
+		"12 raw_nikon.nef":      nef(),
+		"13 raw_sony.arw":       arw(),
+		"14 raw_canon.cr2":      cr2(),
+		"15 raw_adobe.dng":      dng(),
+		"16 raw_canon.cr3":      cr3(),
+		"17 raw_no_preview.dng": dngWithoutPreview(),
+	}
+
+	for name, data := range fixtures {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}